@@ -0,0 +1,151 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/context"
+)
+
+// AccessLogRecord is the structured access log of a request.
+type AccessLogRecord struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Pattern   string    `json:"pattern,omitempty"`
+	Status    int       `json:"status"`
+	Latency   float64   `json:"latency_ms"`
+	Bytes     int64     `json:"bytes"`
+	RemoteIP  string    `json:"remote_ip"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// AccessLogSink writes the access log records.
+type AccessLogSink interface {
+	WriteAccessLog(*AccessLogRecord) error
+}
+
+// jsonAccessLogSink writes a JSON object per line.
+type jsonAccessLogSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONAccessLogSink returns an AccessLogSink which writes a JSON object per line to w.
+func NewJSONAccessLogSink(w io.Writer) AccessLogSink {
+	return &jsonAccessLogSink{w: w}
+}
+
+func (s *jsonAccessLogSink) WriteAccessLog(r *AccessLogRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+var accessLogSink AccessLogSink
+
+// SetAccessLogSink sets the sink of the access logs, the records are sent to it instead of the debug table.
+// usage:
+//
+//	beego.SetAccessLogSink(beego.NewJSONAccessLogSink(os.Stdout))
+func SetAccessLogSink(sink AccessLogSink) *App {
+	accessLogSink = sink
+	return BeeApp
+}
+
+// newAccessLogSink creates the sink of AccessLogsFormat writing to output,
+// output is stdout, stderr, syslog or the path of the log file.
+func newAccessLogSink(format, output string) (AccessLogSink, error) {
+	if format != "json" {
+		return nil, nil
+	}
+	var w io.Writer
+	switch {
+	case output == "" || output == "stdout":
+		w = os.Stdout
+	case output == "stderr":
+		w = os.Stderr
+	case output == "syslog" || strings.HasPrefix(output, "syslog:"):
+		sw, err := newSyslogWriter(strings.TrimPrefix(strings.TrimPrefix(output, "syslog"), ":"))
+		if err != nil {
+			return nil, err
+		}
+		w = sw
+	default:
+		f, err := os.OpenFile(output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		w = f
+	}
+	return NewJSONAccessLogSink(w), nil
+}
+
+func writeAccessLog(ctx *context.Context, w *responseWriter, start time.Time, latency time.Duration) {
+	r := &AccessLogRecord{
+		Time:      start,
+		Method:    ctx.Request.Method,
+		Path:      ctx.Request.URL.Path,
+		Pattern:   ctx.Input.RouterPattern,
+		Status:    responseStatus(ctx, w),
+		Latency:   float64(latency) / float64(time.Millisecond),
+		Bytes:     w.size,
+		RemoteIP:  ctx.Input.IP(),
+		UserAgent: ctx.Input.UserAgent(),
+		RequestID: ctx.Input.Header(context.RequestIDHeader),
+	}
+	// the records skip the logger, they're masked by its redactor here
+	if redactor := BeeLogger.Redactor(); redactor != nil {
+		r.Path = redactor.Redact(r.Path)
+		r.UserAgent = redactor.Redact(r.UserAgent)
+	}
+	if err := accessLogSink.WriteAccessLog(r); err != nil {
+		Warn("write access log:", err)
+	}
+}
+
+// logAccess returns whether the request is access logged.
+// The server errors and the requests slower than AccessLogsSlowThreshold are always logged,
+// the others are skipped by DefaultAccessLogFilter and AccessLogsSkipPrefixes, then sampled by AccessLogsSampleRate.
+func logAccess(ctx *context.Context, status int, latency time.Duration) bool {
+	if status >= 500 {
+		return true
+	}
+	if AccessLogsSlowThreshold > 0 && latency >= time.Duration(AccessLogsSlowThreshold)*time.Millisecond {
+		return true
+	}
+	if DefaultAccessLogFilter != nil && DefaultAccessLogFilter.Filter(ctx) {
+		return false
+	}
+	for _, prefix := range AccessLogsSkipPrefixes {
+		if strings.HasPrefix(ctx.Request.URL.Path, prefix) {
+			return false
+		}
+	}
+	return AccessLogsSampleRate >= 100 || rand.Float64()*100 < AccessLogsSampleRate
+}
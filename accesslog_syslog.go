@@ -0,0 +1,33 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package beego
+
+import (
+	"io"
+	"log/syslog"
+	"strings"
+)
+
+// newSyslogWriter connects to the syslog daemon, addr is empty for the local one or like udp://host:514.
+func newSyslogWriter(addr string) (io.Writer, error) {
+	var network string
+	if i := strings.Index(addr, "://"); i > 0 {
+		network, addr = addr[:i], addr[i+3:]
+	}
+	return syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_LOCAL0, "beego")
+}
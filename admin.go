@@ -16,14 +16,22 @@ package beego
 
 import (
 	"bytes"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"text/template"
 	"time"
 
 	"github.com/astaxie/beego/grace"
+	"github.com/astaxie/beego/privacy"
+	"github.com/astaxie/beego/session"
 	"github.com/astaxie/beego/toolbox"
 	"github.com/astaxie/beego/utils"
 )
@@ -55,10 +63,19 @@ func init() {
 	}
 	beeAdminApp.Route("/", adminIndex)
 	beeAdminApp.Route("/qps", qpsIndex)
+	beeAdminApp.Route("/metrics", metrics)
 	beeAdminApp.Route("/prof", profIndex)
 	beeAdminApp.Route("/healthcheck", healthcheck)
 	beeAdminApp.Route("/task", taskStatus)
 	beeAdminApp.Route("/listconf", listConf)
+	beeAdminApp.Route("/session", sessionStatus)
+	beeAdminApp.Route("/session/user", adminAuth(sessionUser))
+	beeAdminApp.Route("/connections", connectionStatus)
+	beeAdminApp.Route("/privacy", adminAuth(privacyIndex))
+	beeAdminApp.Route("/chaos", adminAuth(chaosIndex))
+	beeAdminApp.Route("/slo", sloStatus)
+	beeAdminApp.Route("/drain", adminAuth(drainStatus))
+	beeAdminApp.Route("/allocs", allocStatus)
 	FilterMonitorFunc = func(string, string, time.Duration) bool { return true }
 }
 
@@ -70,12 +87,25 @@ func adminIndex(rw http.ResponseWriter, r *http.Request) {
 
 // QpsIndex is the http.Handler for writing qbs statistics map result info in http.ResponseWriter.
 // it's registered with url pattern "/qbs" in admin module.
+// the statistics are listed per router pattern and method, or summed per pattern with ?view=aggregate.
 func qpsIndex(rw http.ResponseWriter, r *http.Request) {
 	data := make(map[interface{}]interface{})
-	data["Content"] = toolbox.StatisticsMap.GetMap()
+	if r.FormValue("view") == "aggregate" {
+		data["Aggregate"] = true
+		data["Content"] = toolbox.StatisticsMap.GetAggregateMap()
+	} else {
+		data["Content"] = toolbox.StatisticsMap.GetMap()
+	}
 	execTpl(rw, data, qpsTpl, defaultScriptsTpl)
 }
 
+// Metrics is the http.Handler writing the request metrics in the Prometheus text format.
+// it's registered with url pattern "/metrics" in admin module.
+func metrics(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	toolbox.Metrics.WritePrometheus(rw)
+}
+
 // ListConf is the http.Handler of displaying all beego configuration values as key/value pair.
 // it's registered with url pattern "/listconf" in admin module.
 func listConf(rw http.ResponseWriter, r *http.Request) {
@@ -92,6 +122,7 @@ func listConf(rw http.ResponseWriter, r *http.Request) {
 			m["AppConfigPath"] = AppConfigPath
 			m["StaticDir"] = StaticDir
 			m["StaticExtensionsToGzip"] = StaticExtensionsToGzip
+			m["StaticCacheControl"] = StaticCacheControl
 			m["HTTPAddr"] = HTTPAddr
 			m["HTTPPort"] = HTTPPort
 			m["HTTPTLS"] = EnableHTTPTLS
@@ -99,6 +130,7 @@ func listConf(rw http.ResponseWriter, r *http.Request) {
 			m["HTTPKeyFile"] = HTTPKeyFile
 			m["RecoverPanic"] = RecoverPanic
 			m["AutoRender"] = AutoRender
+			m["RenderBufferSize"] = RenderBufferSize
 			m["ViewsPath"] = ViewsPath
 			m["RunMode"] = RunMode
 			m["SessionOn"] = SessionOn
@@ -108,21 +140,48 @@ func listConf(rw http.ResponseWriter, r *http.Request) {
 			m["SessionProviderConfig"] = SessionProviderConfig
 			m["SessionCookieLifeTime"] = SessionCookieLifeTime
 			m["EnabelFcgi"] = EnabelFcgi
+			m["EnableCGI"] = EnableCGI
 			m["MaxMemory"] = MaxMemory
+			m["MaxBodySize"] = MaxBodySize
+			m["MaxResponseSize"] = MaxResponseSize
+			m["MaxResponseSizePolicy"] = MaxResponseSizePolicy
 			m["EnableGzip"] = EnableGzip
 			m["DirectoryIndex"] = DirectoryIndex
 			m["HTTPServerTimeOut"] = HTTPServerTimeOut
+			m["HTTPReadHeaderTimeout"] = HTTPReadHeaderTimeout
+			m["HTTPIdleTimeout"] = HTTPIdleTimeout
+			m["HTTPMaxHeaderBytes"] = HTTPMaxHeaderBytes
+			m["MaxConnections"] = MaxConnections
+			m["MaxConnectionsPerIP"] = MaxConnectionsPerIP
 			m["EnableErrorsShow"] = EnableErrorsShow
+			m["EnableProblemJSON"] = EnableProblemJSON
+			m["EnableAllocStats"] = EnableAllocStats
+			m["EnableChaos"] = EnableChaos
+			m["AssetIntegrity"] = AssetIntegrity
 			m["XSRFKEY"] = XSRFKEY
 			m["EnableXSRF"] = EnableXSRF
 			m["XSRFExpire"] = XSRFExpire
+			m["ConsentCookieName"] = ConsentCookieName
+			m["ConsentExpire"] = ConsentExpire
+			m["APIDefaultVersion"] = APIDefaultVersion
 			m["CopyRequestBody"] = CopyRequestBody
+			m["JSONPrefix"] = JSONPrefix
+			m["JSONEscapeHTML"] = JSONEscapeHTML
+			m["ServeFormat"] = ServeFormat
+			m["JSONEnvelope"] = JSONEnvelope
 			m["TemplateLeft"] = TemplateLeft
 			m["TemplateRight"] = TemplateRight
 			m["BeegoServerName"] = BeegoServerName
 			m["EnableAdmin"] = EnableAdmin
+			m["EnableOpenAPI"] = EnableOpenAPI
+			m["OpenAPIPath"] = OpenAPIPath
+			m["ReadinessPath"] = ReadinessPath
+			m["I18nPath"] = I18nPath
+			m["I18nDefaultLocale"] = I18nDefaultLocale
+			m["EnableOpenAPIUI"] = EnableOpenAPIUI
 			m["AdminHTTPAddr"] = AdminHTTPAddr
 			m["AdminHTTPPort"] = AdminHTTPPort
+			m["AdminAuthUser"] = AdminAuthUser
 
 			tmpl := template.Must(template.New("dashboard").Parse(dashboardTpl))
 			tmpl = template.Must(tmpl.Parse(configTpl))
@@ -367,6 +426,519 @@ func taskStatus(rw http.ResponseWriter, req *http.Request) {
 	execTpl(rw, data, tasksTpl, defaultScriptsTpl)
 }
 
+// SessionStatus is a http.Handler showing the active sessions and the session gc statistics.
+// it's in "/session" pattern in admin module, use format=json to get the statistics as json.
+func sessionStatus(rw http.ResponseWriter, req *http.Request) {
+	if GlobalSessions == nil {
+		http.Error(rw, "session is not enabled", http.StatusNotFound)
+		return
+	}
+	stats := GlobalSessions.GCStats()
+	active := GlobalSessions.GetActiveSession()
+
+	req.ParseForm()
+	if req.Form.Get("format") == "json" {
+		dataJSON, err := json.Marshal(map[string]interface{}{
+			"Provider":       SessionProvider,
+			"ActiveSessions": active,
+			"GCRuns":         stats.Runs,
+			"ExpiredByGC":    stats.Expired,
+			"LastGC":         stats.LastRun,
+			"LastGCDuration": stats.LastDuration.String(),
+		})
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(dataJSON)
+		return
+	}
+
+	data := make(map[interface{}]interface{})
+	content := make(map[string]interface{})
+	content["Fields"] = []string{"Name", "Value"}
+	content["Data"] = [][]string{
+		{"Provider", SessionProvider},
+		{"Active Sessions", fmt.Sprintf("%d", active)},
+		{"GC Runs", fmt.Sprintf("%d", stats.Runs)},
+		{"Expired By GC", fmt.Sprintf("%d", stats.Expired)},
+		{"Last GC", stats.LastRun.String()},
+		{"Last GC Duration", stats.LastDuration.String()},
+	}
+	data["Content"] = content
+	data["Title"] = "Sessions"
+	execTpl(rw, data, tableTpl, defaultScriptsTpl)
+}
+
+// ConnectionStatus is a http.Handler showing the connections of the http servers and whether they're draining.
+// it's in "/connections" pattern in admin module, use format=json to get the statistics as json.
+func connectionStatus(rw http.ResponseWriter, req *http.Request) {
+	stats := Connections()
+
+	req.ParseForm()
+	if req.Form.Get("format") == "json" {
+		dataJSON, err := json.Marshal(stats)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(dataJSON)
+		return
+	}
+
+	drainAt := ""
+	if stats.Draining {
+		drainAt = stats.DrainAt.String()
+	}
+	data := make(map[interface{}]interface{})
+	content := make(map[string]interface{})
+	content["Fields"] = []string{"Name", "Value"}
+	content["Data"] = [][]string{
+		{"New", fmt.Sprintf("%d", stats.New)},
+		{"Active", fmt.Sprintf("%d", stats.Active)},
+		{"Idle", fmt.Sprintf("%d", stats.Idle)},
+		{"Accepted", fmt.Sprintf("%d", stats.Accepted)},
+		{"Hijacked", fmt.Sprintf("%d", stats.Hijacked)},
+		{"Closed", fmt.Sprintf("%d", stats.Closed)},
+		{"Draining", fmt.Sprintf("%t", stats.Draining)},
+		{"Drain Started", drainAt},
+	}
+	data["Content"] = content
+	data["Title"] = "Connections"
+	execTpl(rw, data, tableTpl, defaultScriptsTpl)
+}
+
+// SessionUser is a http.Handler for the sessions of a user, it's in "/session/user" pattern in admin module.
+// GET with user lists the metadata of the sessions bound to the user, use format=json to get them as json.
+// POST with action=revoke, user and handle revokes a session, action=revoke_all revokes all the sessions of the user,
+// then it redirects to the list.
+// The session ids and values are never shown, and it requires AdminAuthUser and AdminAuthPassword.
+func sessionUser(rw http.ResponseWriter, req *http.Request) {
+	if GlobalSessions == nil {
+		http.Error(rw, "session is not enabled", http.StatusNotFound)
+		return
+	}
+	req.ParseForm()
+	user := req.Form.Get("user")
+	if req.Method == "POST" {
+		if user == "" {
+			http.Error(rw, "user is required", http.StatusBadRequest)
+			return
+		}
+		var err error
+		switch req.Form.Get("action") {
+		case "revoke":
+			var ok bool
+			ok, err = GlobalSessions.RevokeUserSession(user, req.Form.Get("handle"))
+			if err == nil && !ok {
+				http.Error(rw, "the session doesn't exist", http.StatusNotFound)
+				return
+			}
+		case "revoke_all":
+			err = GlobalSessions.RevokeUserSessions(user)
+		default:
+			http.Error(rw, "action should be revoke or revoke_all", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		BeeLogger.Info("admin: revoked the sessions of %s by %s", user, req.Form.Get("action"))
+		http.Redirect(rw, req, "/session/user?user="+url.QueryEscape(user), http.StatusSeeOther)
+		return
+	}
+
+	var infos []*session.SessionInfo
+	if user != "" {
+		var err error
+		if infos, err = GlobalSessions.UserSessionInfos(user); err != nil {
+			http.Error(rw, err.Error(), http.StatusNotImplemented)
+			return
+		}
+	}
+	if req.Form.Get("format") == "json" {
+		dataJSON, err := json.Marshal(infos)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(dataJSON)
+		return
+	}
+
+	data := make(map[interface{}]interface{})
+	content := make(map[string]interface{})
+	content["Fields"] = []string{"Handle", "User", "Bound", "Last Accessed", "Values"}
+	var rows [][]string
+	for _, info := range infos {
+		rows = append(rows, []string{
+			info.Handle,
+			info.User,
+			info.Bound.Format(time.RFC3339),
+			info.LastAccessed.Format(time.RFC3339),
+			fmt.Sprintf("%d", info.Values),
+		})
+	}
+	content["Data"] = rows
+	content["User"] = user
+	data["Content"] = content
+	data["Title"] = "Sessions of " + user
+	execTpl(rw, data, sessionUserTpl, defaultScriptsTpl)
+}
+
+// adminAuth requires the basic authentication of AdminAuthUser and AdminAuthPassword,
+// the requests are refused if they aren't set.
+func adminAuth(f http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if AdminAuthUser == "" || AdminAuthPassword == "" {
+			http.Error(rw, "set AdminAuthUser and AdminAuthPassword to use this page", http.StatusForbidden)
+			return
+		}
+		user, password, ok := req.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(AdminAuthUser)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(password), []byte(AdminAuthPassword)) != 1 {
+			rw.Header().Set("WWW-Authenticate", `Basic realm="beego admin"`)
+			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		f(rw, req)
+	}
+}
+
+// SLOStatus is a http.Handler for the SLOs of the routers, it's in "/slo" pattern in admin module.
+// use format=json to get them as json.
+func sloStatus(rw http.ResponseWriter, req *http.Request) {
+	status := toolbox.SLOs.Status()
+	req.ParseForm()
+	if req.Form.Get("format") == "json" {
+		dataJSON, err := json.Marshal(status)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(dataJSON)
+		return
+	}
+
+	data := make(map[interface{}]interface{})
+	content := make(map[string]interface{})
+	content["Fields"] = []string{"Router", "Requests", "Errors", "Slow", "Error Burn Rate", "Latency Burn Rate", "Alerting"}
+	var rows [][]string
+	for _, s := range status {
+		alerting := strconv.FormatBool(s.Alerting)
+		if !s.Since.IsZero() {
+			alerting += " since " + s.Since.Format(time.RFC3339)
+		}
+		rows = append(rows, []string{
+			s.Name,
+			strconv.FormatUint(s.Requests, 10),
+			strconv.FormatUint(s.Errors, 10),
+			strconv.FormatUint(s.Slow, 10),
+			strconv.FormatFloat(s.ErrorBurnRate, 'f', 2, 64),
+			strconv.FormatFloat(s.LatencyBurnRate, 'f', 2, 64),
+			alerting,
+		})
+	}
+	content["Data"] = rows
+	data["Content"] = content
+	data["Title"] = "SLO"
+	execTpl(rw, data, tableTpl, defaultScriptsTpl)
+}
+
+// ChaosIndex is a http.Handler for the chaos rules, it's in "/chaos" pattern in admin module.
+// GET shows the rules, use format=json to get them as json.
+// POST with action=add, pattern, percent, latency (e.g. 500ms), status and drop adds a rule and returns it as json,
+// action=remove with id removes a rule and action=clear removes all the rules.
+func chaosIndex(rw http.ResponseWriter, req *http.Request) {
+	req.ParseForm()
+	if req.Method == "POST" {
+		var result interface{}
+		switch req.Form.Get("action") {
+		case "add":
+			rule := &ChaosRule{Pattern: req.Form.Get("pattern")}
+			var err error
+			if v := req.Form.Get("percent"); v != "" {
+				if rule.Percent, err = strconv.ParseFloat(v, 64); err != nil {
+					http.Error(rw, "invalid percent: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			if v := req.Form.Get("latency"); v != "" {
+				if rule.Latency, err = time.ParseDuration(v); err != nil {
+					http.Error(rw, "invalid latency: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			if v := req.Form.Get("status"); v != "" {
+				if rule.Status, err = strconv.Atoi(v); err != nil {
+					http.Error(rw, "invalid status: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			rule.Drop = req.Form.Get("drop") == "true"
+			if rule.ID, err = AddChaosRule(rule); err != nil {
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			result = rule
+		case "remove":
+			id, _ := strconv.Atoi(req.Form.Get("id"))
+			if !RemoveChaosRule(id) {
+				http.Error(rw, "the rule doesn't exist", http.StatusNotFound)
+				return
+			}
+			result = map[string]int{"removed": id}
+		case "clear":
+			ClearChaosRules()
+			result = map[string]bool{"cleared": true}
+		default:
+			http.Error(rw, "action should be add, remove or clear", http.StatusBadRequest)
+			return
+		}
+		dataJSON, err := json.Marshal(result)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(dataJSON)
+		return
+	}
+
+	rules := ChaosRules()
+	if req.Form.Get("format") == "json" {
+		dataJSON, err := json.Marshal(rules)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(dataJSON)
+		return
+	}
+
+	data := make(map[interface{}]interface{})
+	content := make(map[string]interface{})
+	content["Fields"] = []string{"ID", "Pattern", "Percent", "Latency", "Status", "Drop"}
+	var rows [][]string
+	for _, r := range rules {
+		rows = append(rows, []string{
+			strconv.Itoa(r.ID),
+			r.Pattern,
+			strconv.FormatFloat(r.Percent, 'g', -1, 64),
+			r.Latency.String(),
+			strconv.Itoa(r.Status),
+			strconv.FormatBool(r.Drop),
+		})
+	}
+	content["Data"] = rows
+	data["Content"] = content
+	title := "Chaos Rules"
+	if !EnableChaos {
+		title += " (EnableChaos is off)"
+	}
+	data["Title"] = title
+	execTpl(rw, data, tableTpl, defaultScriptsTpl)
+}
+
+// DrainStatus is a http.Handler for the readiness, it's in "/drain" pattern in admin module.
+// GET shows the readiness and the requests in flight, use format=json to get them as json.
+// POST with action=drain flips the readiness probe to failing, action=resume flips it back.
+func drainStatus(rw http.ResponseWriter, req *http.Request) {
+	req.ParseForm()
+	if req.Method == "POST" {
+		switch req.Form.Get("action") {
+		case "drain":
+			Drain()
+		case "resume":
+			Resume()
+		default:
+			http.Error(rw, "action should be drain or resume", http.StatusBadRequest)
+			return
+		}
+	}
+	stats := Readiness()
+	if req.Method == "POST" || req.Form.Get("format") == "json" {
+		dataJSON, err := json.Marshal(stats)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(dataJSON)
+		return
+	}
+
+	drainAt := ""
+	if stats.Draining {
+		drainAt = stats.DrainAt.String()
+	}
+	data := make(map[interface{}]interface{})
+	content := make(map[string]interface{})
+	content["Fields"] = []string{"Name", "Value"}
+	content["Data"] = [][]string{
+		{"Ready", fmt.Sprintf("%t", stats.Ready)},
+		{"Drain Started", drainAt},
+		{"In Flight", fmt.Sprintf("%d", stats.InFlight)},
+	}
+	data["Content"] = content
+	data["Title"] = "Readiness"
+	execTpl(rw, data, tableTpl, defaultScriptsTpl)
+}
+
+// AllocStatus is a http.Handler for the allocations of the requests, it's in "/allocs" pattern in admin module.
+// The routes allocating the most bytes per request are listed first, use format=json to get them as json.
+func allocStatus(rw http.ResponseWriter, req *http.Request) {
+	req.ParseForm()
+	stats := toolbox.Allocs.Snapshot()
+	if req.Form.Get("format") == "json" {
+		dataJSON, err := json.Marshal(stats)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(dataJSON)
+		return
+	}
+
+	data := make(map[interface{}]interface{})
+	content := make(map[string]interface{})
+	content["Fields"] = []string{"Method", "Pattern", "Requests", "Avg Bytes", "Avg Objects", "Max Bytes"}
+	var rows [][]string
+	for _, s := range stats {
+		rows = append(rows, []string{
+			s.Method,
+			s.Pattern,
+			strconv.FormatUint(s.Requests, 10),
+			strconv.FormatUint(s.AvgBytes(), 10),
+			strconv.FormatUint(s.AvgObjects(), 10),
+			strconv.FormatUint(s.MaxBytes, 10),
+		})
+	}
+	content["Data"] = rows
+	data["Content"] = content
+	title := "Allocations"
+	if !EnableAllocStats {
+		title += " (EnableAllocStats is off)"
+	}
+	data["Title"] = title
+	execTpl(rw, data, tableTpl, defaultScriptsTpl)
+}
+
+// adminToken is sent back with the POST requests of the admin pages, so a page of another site
+// can't post them with the credentials of the browser.
+var (
+	adminToken     string
+	adminTokenOnce sync.Once
+)
+
+func getAdminToken() string {
+	adminTokenOnce.Do(func() {
+		adminToken = string(utils.RandomCreateBytes(32))
+	})
+	return adminToken
+}
+
+func validAdminToken(req *http.Request) bool {
+	return subtle.ConstantTimeCompare([]byte(req.PostForm.Get("token")), []byte(getAdminToken())) == 1
+}
+
+// PrivacyIndex is a http.Handler for the privacy requests, it's in "/privacy" pattern in admin module.
+// GET shows the registered models and the latest audit records, use format=json to get them as json with the token.
+// POST with the token, action=export or action=erase, subject and reason runs the request and returns the Result as json,
+// the operator is the admin user.
+func privacyIndex(rw http.ResponseWriter, req *http.Request) {
+	req.ParseForm()
+	if req.Method == "POST" {
+		if !validAdminToken(req) {
+			http.Error(rw, "invalid token", http.StatusForbidden)
+			return
+		}
+		operator, _, _ := req.BasicAuth()
+		preq := privacy.Request{
+			Subject:  req.PostForm.Get("subject"),
+			Operator: operator,
+			Reason:   req.PostForm.Get("reason"),
+		}
+		var result *privacy.Result
+		var err error
+		switch req.PostForm.Get("action") {
+		case "export":
+			result, err = privacy.Export(req.Context(), preq)
+		case "erase":
+			result, err = privacy.Erase(req.Context(), preq)
+		default:
+			http.Error(rw, "action should be export or erase", http.StatusBadRequest)
+			return
+		}
+		if result == nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		dataJSON, err := json.Marshal(result)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		if len(result.Errors) > 0 {
+			rw.WriteHeader(http.StatusInternalServerError)
+		}
+		rw.Write(dataJSON)
+		return
+	}
+
+	var records []*privacy.AuditRecord
+	if r, ok := privacy.CurrentAuditLog().(privacy.AuditReader); ok {
+		records = r.Latest(100)
+	}
+	if req.Form.Get("format") == "json" {
+		dataJSON, err := json.Marshal(map[string]interface{}{
+			"Models": privacy.Models(),
+			"Audit":  records,
+			"Token":  getAdminToken(),
+		})
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(dataJSON)
+		return
+	}
+
+	data := make(map[interface{}]interface{})
+	content := make(map[string]interface{})
+	content["Fields"] = []string{"Time", "Action", "Subject", "Operator", "Models", "Errors"}
+	var rows [][]string
+	for _, r := range records {
+		errs := make([]string, 0, len(r.Errors))
+		for name, e := range r.Errors {
+			errs = append(errs, name+": "+e)
+		}
+		sort.Strings(errs)
+		rows = append(rows, []string{
+			r.Time.Format(time.RFC3339),
+			r.Action,
+			r.Subject,
+			r.Operator,
+			strings.Join(r.Models, ", "),
+			strings.Join(errs, "; "),
+		})
+	}
+	content["Data"] = rows
+	data["Content"] = content
+	data["Title"] = "Privacy Audit of " + strings.Join(privacy.Models(), ", ")
+	execTpl(rw, data, tableTpl, defaultScriptsTpl)
+}
+
 func execTpl(rw http.ResponseWriter, data map[interface{}]interface{}, tpls ...string) {
 	tmpl := template.Must(template.New("dashboard").Parse(dashboardTpl))
 	for _, tpl := range tpls {
@@ -0,0 +1,114 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/astaxie/beego/privacy"
+	"github.com/astaxie/beego/session"
+)
+
+func TestSessionUserAuth(t *testing.T) {
+	defer func(sessions *session.Manager, user, password string) {
+		GlobalSessions, AdminAuthUser, AdminAuthPassword = sessions, user, password
+	}(GlobalSessions, AdminAuthUser, AdminAuthPassword)
+	GlobalSessions, _ = session.NewManager("memory", `{"cookieName":"gosessionid","gclifetime":10}`)
+
+	handler := beeAdminApp.routers["/session/user"]
+	for _, test := range []struct {
+		user, password string
+		auth           bool
+		code           int
+	}{
+		{"", "", false, http.StatusForbidden},
+		{"admin", "secret", false, http.StatusUnauthorized},
+		{"admin", "secret", true, http.StatusOK},
+	} {
+		AdminAuthUser, AdminAuthPassword = test.user, test.password
+		r, _ := http.NewRequest("GET", "/session/user?user=alice&format=json", nil)
+		if test.auth {
+			r.SetBasicAuth("admin", "secret")
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if w.Code != test.code {
+			t.Errorf("%+v: got %d", test, w.Code)
+		}
+	}
+}
+
+func TestAdminAuthRoutes(t *testing.T) {
+	defer func(user, password string) {
+		AdminAuthUser, AdminAuthPassword = user, password
+	}(AdminAuthUser, AdminAuthPassword)
+	AdminAuthUser, AdminAuthPassword = "admin", "secret"
+
+	for _, pattern := range []string{"/drain", "/chaos"} {
+		for _, auth := range []bool{false, true} {
+			r, _ := http.NewRequest("GET", pattern+"?format=json", nil)
+			if auth {
+				r.SetBasicAuth("admin", "secret")
+			}
+			w := httptest.NewRecorder()
+			beeAdminApp.routers[pattern](w, r)
+			if want := map[bool]int{false: http.StatusUnauthorized, true: http.StatusOK}[auth]; w.Code != want {
+				t.Errorf("%s with auth %t: got %d, want %d", pattern, auth, w.Code, want)
+			}
+		}
+	}
+}
+
+func TestPrivacyAuth(t *testing.T) {
+	defer func(user, password string, audit privacy.AuditLog) {
+		AdminAuthUser, AdminAuthPassword = user, password
+		privacy.SetAuditLog(audit)
+	}(AdminAuthUser, AdminAuthPassword, privacy.CurrentAuditLog())
+	AdminAuthUser, AdminAuthPassword = "admin", "secret"
+	audit := privacy.NewMemoryAuditLog(10)
+	privacy.SetAuditLog(audit)
+
+	handler := beeAdminApp.routers["/privacy"]
+	for _, test := range []struct {
+		auth  bool
+		token string
+		code  int
+	}{
+		{false, getAdminToken(), http.StatusUnauthorized},
+		{true, "", http.StatusForbidden},
+		{true, "forged", http.StatusForbidden},
+		{true, getAdminToken(), http.StatusOK},
+	} {
+		form := url.Values{"action": {"export"}, "subject": {"42"}, "operator": {"mallory"}, "token": {test.token}}
+		r, _ := http.NewRequest("POST", "/privacy", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if test.auth {
+			r.SetBasicAuth("admin", "secret")
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if w.Code != test.code {
+			t.Errorf("%+v: got %d %s", test, w.Code, w.Body.String())
+		}
+	}
+	records := audit.Latest(10)
+	if len(records) != 1 || records[0].Operator != "admin" {
+		t.Errorf("the audit records got %+v, want one by admin", records)
+	}
+}
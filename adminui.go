@@ -63,6 +63,10 @@ var gcAjaxTpl = `
 
 var qpsTpl = `{{define "content"}}
 <h1>Requests statistics</h1>
+<ul class="nav nav-pills">
+	<li{{if not .Aggregate}} class="active"{{end}}><a href="/qps">Per pattern</a></li>
+	<li{{if .Aggregate}} class="active"{{end}}><a href="/qps?view=aggregate">Aggregate</a></li>
+</ul>
 <table class="table table-striped table-hover ">
 	<thead>
 	<tr>
@@ -195,6 +199,84 @@ bg-warning
 
 {{end}}`
 
+var tableTpl = `{{define "content"}}
+
+<h1>{{.Title}}</h1>
+<table class="table table-striped table-hover ">
+<thead>
+<tr>
+{{range .Content.Fields}}
+<th>
+{{.}}
+</th>
+{{end}}
+</tr>
+</thead>
+
+<tbody>
+{{range $i, $slice := .Content.Data}}
+<tr>
+	{{range $slice}}
+	<td>
+	{{.}}
+	</td>
+	{{end}}
+</tr>
+{{end}}
+</tbody>
+</table>
+
+{{end}}`
+
+var sessionUserTpl = `{{define "content"}}
+
+<h1>{{.Title | html}}</h1>
+<form class="form-inline" method="get" action="/session/user">
+<input type="text" class="form-control" name="user" placeholder="User" value="{{.Content.User | html}}">
+<button type="submit" class="btn btn-default">Search</button>
+</form>
+<table class="table table-striped table-hover ">
+<thead>
+<tr>
+{{range .Content.Fields}}
+<th>
+{{.}}
+</th>
+{{end}}
+<th></th>
+</tr>
+</thead>
+
+<tbody>
+{{range $i, $slice := .Content.Data}}
+<tr>
+	{{range $slice}}
+	<td>
+	{{. | html}}
+	</td>
+	{{end}}
+	<td>
+	<form method="post" action="/session/user">
+	<input type="hidden" name="action" value="revoke">
+	<input type="hidden" name="user" value="{{$.Content.User | html}}">
+	<input type="hidden" name="handle" value="{{index $slice 0 | html}}">
+	<button type="submit" class="btn btn-danger btn-xs">Revoke</button>
+	</form>
+	</td>
+</tr>
+{{end}}
+</tbody>
+</table>
+{{if .Content.Data}}
+<form method="post" action="/session/user">
+<input type="hidden" name="action" value="revoke_all">
+<input type="hidden" name="user" value="{{.Content.User | html}}">
+<button type="submit" class="btn btn-danger">Revoke All</button>
+</form>
+{{end}}
+
+{{end}}`
+
 var healthCheckTpl = `
 {{define "content"}}
 
@@ -321,6 +403,50 @@ Healthcheck
 <a href="/task" class="dropdown-toggle disabled" data-toggle="dropdown">Tasks</a>
 </li>
 
+<li class="dropdown">
+<a href="#" class="dropdown-toggle disabled" data-toggle="dropdown">Sessions<span class="caret"></span></a>
+<ul class="dropdown-menu" role="menu">
+<li><a href="/session">Status</a></li>
+<li><a href="/session/user">By User</a></li>
+</ul>
+</li>
+
+<li>
+<a href="/connections">
+Connections
+</a>
+</li>
+
+<li>
+<a href="/privacy">
+Privacy
+</a>
+</li>
+
+<li>
+<a href="/slo">
+SLO
+</a>
+</li>
+
+<li>
+<a href="/drain">
+Readiness
+</a>
+</li>
+
+<li>
+<a href="/allocs">
+Allocs
+</a>
+</li>
+
+<li>
+<a href="/chaos">
+Chaos
+</a>
+</li>
+
 <li class="dropdown">
 <a href="#" class="dropdown-toggle disabled" data-toggle="dropdown">Config Status<span class="caret"></span></a>
 <ul class="dropdown-menu" role="menu">
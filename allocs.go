@@ -0,0 +1,47 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+)
+
+// allocMeter measures the allocations of a request by the difference of the memory statistics.
+// The statistics are the ones of the process, so the concurrent requests are counted too,
+// it's meant for the profiling with a few requests at a time.
+type allocMeter struct {
+	start runtime.MemStats
+}
+
+func newAllocMeter() *allocMeter {
+	m := &allocMeter{}
+	runtime.ReadMemStats(&m.start)
+	return m
+}
+
+// delta returns the bytes and the objects allocated and the change of the heap since the meter started.
+func (m *allocMeter) delta() (bytes, objects uint64, heap int64) {
+	var now runtime.MemStats
+	runtime.ReadMemStats(&now)
+	return now.TotalAlloc - m.start.TotalAlloc, now.Mallocs - m.start.Mallocs, int64(now.HeapAlloc) - int64(m.start.HeapAlloc)
+}
+
+// serverTiming adds the allocations until the response header is sent to the Server-Timing header.
+func (m *allocMeter) serverTiming(h http.Header) {
+	bytes, objects, heap := m.delta()
+	h.Add("Server-Timing", fmt.Sprintf(`alloc;desc="%d B, %d objects", heap;desc="%+d B"`, bytes, objects, heap))
+}
@@ -18,11 +18,13 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/cgi"
 	"net/http/fcgi"
 	"os"
 	"path"
 	"time"
 
+	"github.com/astaxie/beego/context"
 	"github.com/astaxie/beego/grace"
 	"github.com/astaxie/beego/utils"
 )
@@ -64,6 +66,14 @@ func (app *App) Run() {
 	)
 	endRunning := make(chan bool, 1)
 
+	if EnableCGI {
+		// a CGI process serves the single request in its environment then exits
+		if err = cgi.Serve(app.Handlers); err != nil {
+			BeeLogger.Critical("CGI: ", err)
+		}
+		return
+	}
+
 	if EnabelFcgi {
 		if EnableStdIo {
 			err = fcgi.Serve(nil, app.Handlers) // standard I/O
@@ -88,11 +98,48 @@ func (app *App) Run() {
 			err = fcgi.Serve(l, app.Handlers)
 		}
 	} else {
+		var handler http.Handler = app.Handlers
+		if EnableAutoTLS {
+			EnableHTTPTLS = true
+		}
+		if EnableHTTPTLS {
+			if err := app.setupTLS(); err != nil {
+				BeeLogger.Critical("TLSConfig: ", err)
+				return
+			}
+		}
+		if EnableAutoTLS {
+			if handler, err = app.setupAutoTLS(handler); err != nil {
+				BeeLogger.Critical("AutoTLS: ", err)
+				return
+			}
+		}
+		if EnableHTTP3 {
+			if !EnableHTTPTLS || !http3Supported {
+				BeeLogger.Critical("HTTP3: ", "EnableHTTP3 needs EnableHTTPTLS and the http3 build tag")
+				return
+			}
+			h3addr := fmt.Sprintf("%s:%d", HTTPAddr, http3Port())
+			go func(handler http.Handler) {
+				BeeLogger.Info("http/3 server Running on %s", h3addr)
+				if err := app.serveHTTP3(h3addr, handler); err != nil {
+					BeeLogger.Critical("HTTP3: ", err)
+				}
+			}(handler)
+			handler = altSvcHandler(handler, http3Port())
+		}
+		var conns func(net.Conn) net.Conn
+		if MaxConnections > 0 || MaxConnectionsPerIP > 0 {
+			conns = newConnLimiter(MaxConnections, MaxConnectionsPerIP).wrap
+		}
+		// the requests are checked above TLS, so the https connections aren't checked
+		httpConns, httpsConns := conns, conns
+		if StrictRequest {
+			httpConns = chainConnWrappers(conns, newStrictConn)
+		}
 		if Graceful {
-			app.Server.Addr = addr
-			app.Server.Handler = app.Handlers
-			app.Server.ReadTimeout = time.Duration(HTTPServerTimeOut) * time.Second
-			app.Server.WriteTimeout = time.Duration(HTTPServerTimeOut) * time.Second
+			grace.DefaultTimeout = time.Duration(GracefulTimeout) * time.Second
+			app.setupServer(addr, handler)
 			if EnableHTTPTLS {
 				go func() {
 					time.Sleep(20 * time.Microsecond)
@@ -100,8 +147,9 @@ func (app *App) Run() {
 						addr = fmt.Sprintf("%s:%d", HTTPAddr, HTTPSPort)
 						app.Server.Addr = addr
 					}
-					server := grace.NewServer(addr, app.Handlers)
+					server := grace.NewServer(addr, handler)
 					server.Server = app.Server
+					server.WrapConn = httpsConns
 					err := server.ListenAndServeTLS(HTTPCertFile, HTTPKeyFile)
 					if err != nil {
 						BeeLogger.Critical("ListenAndServeTLS: ", err, fmt.Sprintf("%d", os.Getpid()))
@@ -112,11 +160,12 @@ func (app *App) Run() {
 			}
 			if EnableHTTPListen {
 				go func() {
-					server := grace.NewServer(addr, app.Handlers)
+					server := grace.NewServer(addr, handler)
 					server.Server = app.Server
 					if ListenTCP4 && HTTPAddr == "" {
 						server.Network = "tcp4"
 					}
+					server.WrapConn = httpConns
 					err := server.ListenAndServe()
 					if err != nil {
 						BeeLogger.Critical("ListenAndServe: ", err, fmt.Sprintf("%d", os.Getpid()))
@@ -126,10 +175,7 @@ func (app *App) Run() {
 				}()
 			}
 		} else {
-			app.Server.Addr = addr
-			app.Server.Handler = app.Handlers
-			app.Server.ReadTimeout = time.Duration(HTTPServerTimeOut) * time.Second
-			app.Server.WriteTimeout = time.Duration(HTTPServerTimeOut) * time.Second
+			app.setupServer(addr, handler)
 
 			if EnableHTTPTLS {
 				go func() {
@@ -138,7 +184,17 @@ func (app *App) Run() {
 						app.Server.Addr = fmt.Sprintf("%s:%d", HTTPAddr, HTTPSPort)
 					}
 					BeeLogger.Info("https server Running on %s", app.Server.Addr)
-					err := app.Server.ListenAndServeTLS(HTTPCertFile, HTTPKeyFile)
+					laddr := app.Server.Addr
+					if laddr == "" {
+						laddr = ":https"
+					}
+					ln, err := net.Listen("tcp", laddr)
+					if err == nil {
+						if httpsConns != nil {
+							ln = wrapListener{ln, httpsConns}
+						}
+						err = app.Server.ServeTLS(ln, HTTPCertFile, HTTPKeyFile)
+					}
 					if err != nil {
 						BeeLogger.Critical("ListenAndServeTLS: ", err)
 						time.Sleep(100 * time.Microsecond)
@@ -151,28 +207,28 @@ func (app *App) Run() {
 				go func() {
 					app.Server.Addr = addr
 					BeeLogger.Info("http server Running on %s", app.Server.Addr)
+					network, laddr := "tcp", app.Server.Addr
 					if ListenTCP4 && HTTPAddr == "" {
-						ln, err := net.Listen("tcp4", app.Server.Addr)
-						if err != nil {
-							BeeLogger.Critical("ListenAndServe: ", err)
-							time.Sleep(100 * time.Microsecond)
-							endRunning <- true
-							return
-						}
-						err = app.Server.Serve(ln)
-						if err != nil {
-							BeeLogger.Critical("ListenAndServe: ", err)
-							time.Sleep(100 * time.Microsecond)
-							endRunning <- true
-							return
-						}
-					} else {
-						err := app.Server.ListenAndServe()
-						if err != nil {
-							BeeLogger.Critical("ListenAndServe: ", err)
-							time.Sleep(100 * time.Microsecond)
-							endRunning <- true
-						}
+						network = "tcp4"
+					}
+					if laddr == "" {
+						laddr = ":http"
+					}
+					ln, err := net.Listen(network, laddr)
+					if err != nil {
+						BeeLogger.Critical("ListenAndServe: ", err)
+						time.Sleep(100 * time.Microsecond)
+						endRunning <- true
+						return
+					}
+					if httpConns != nil {
+						ln = wrapListener{ln, httpConns}
+					}
+					err = app.Server.Serve(ln)
+					if err != nil {
+						BeeLogger.Critical("ListenAndServe: ", err)
+						time.Sleep(100 * time.Microsecond)
+						endRunning <- true
 					}
 				}()
 			}
@@ -182,6 +238,34 @@ func (app *App) Run() {
 	<-endRunning
 }
 
+// setupServer sets the address, handler, timeouts, header limit and connection tracking of app.Server from the config.
+// HTTPReadTimeout and HTTPWriteTimeout default to HTTPServerTimeOut.
+func (app *App) setupServer(addr string, handler http.Handler) {
+	seconds := func(v int64) time.Duration {
+		return time.Duration(v) * time.Second
+	}
+	app.Server.Addr = addr
+	app.Server.Handler = handler
+	app.Server.ReadTimeout = seconds(HTTPServerTimeOut)
+	app.Server.WriteTimeout = seconds(HTTPServerTimeOut)
+	if HTTPReadTimeout > 0 {
+		app.Server.ReadTimeout = seconds(HTTPReadTimeout)
+	}
+	if HTTPWriteTimeout > 0 {
+		app.Server.WriteTimeout = seconds(HTTPWriteTimeout)
+	}
+	if HTTPReadHeaderTimeout > 0 {
+		app.Server.ReadHeaderTimeout = seconds(HTTPReadHeaderTimeout)
+	}
+	if HTTPIdleTimeout > 0 {
+		app.Server.IdleTimeout = seconds(HTTPIdleTimeout)
+	}
+	if HTTPMaxHeaderBytes > 0 {
+		app.Server.MaxHeaderBytes = HTTPMaxHeaderBytes
+	}
+	app.setupConnState()
+}
+
 // Router adds a patterned controller handler to BeeApp.
 // it's an alias method of App.Router.
 // usage:
@@ -203,6 +287,15 @@ func Router(rootpath string, c ControllerInterface, mappingMethods ...string) *A
 	return BeeApp
 }
 
+// RouterWithOptions adds a patterned controller handler with RouterOption to BeeApp.
+// usage:
+//  beego.RouterWithOptions("/admin", &controllers.AdminController{}, beego.WithFilters(authFilter, auditFilter))
+//  beego.RouterWithOptions("/api", &RestController{}, beego.WithMethods("get:List"), beego.WithFilters(authFilter))
+func RouterWithOptions(rootpath string, c ControllerInterface, opts ...RouterOption) *App {
+	BeeApp.Handlers.AddWithOptions(rootpath, c, opts...)
+	return BeeApp
+}
+
 // Include will generate router file in the router/xxx.go from the controller's comments
 // usage:
 // beego.Include(&BankAccount{}, &OrderController{},&RefundController{},&ReceiptController{})
@@ -230,6 +323,9 @@ func Router(rootpath string, c ControllerInterface, mappingMethods ...string) *A
 // the comments @router url methodlist
 // url support all the function Router's pattern
 // methodlist [get post head put delete options *]
+//
+// the comments @filter name1 name2 run the filters registered by beego.RegisterFilter
+// before the method is executed
 func Include(cList ...ControllerInterface) *App {
 	BeeApp.Handlers.Include(cList...)
 	return BeeApp
@@ -267,8 +363,8 @@ func AutoPrefix(prefix string, c ControllerInterface) *App {
 //    beego.Get("/", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func Get(rootpath string, f FilterFunc) *App {
-	BeeApp.Handlers.Get(rootpath, f)
+func Get(rootpath string, f FilterFunc, opts ...RouterOption) *App {
+	BeeApp.Handlers.Get(rootpath, f, opts...)
 	return BeeApp
 }
 
@@ -277,8 +373,8 @@ func Get(rootpath string, f FilterFunc) *App {
 //    beego.Post("/api", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func Post(rootpath string, f FilterFunc) *App {
-	BeeApp.Handlers.Post(rootpath, f)
+func Post(rootpath string, f FilterFunc, opts ...RouterOption) *App {
+	BeeApp.Handlers.Post(rootpath, f, opts...)
 	return BeeApp
 }
 
@@ -287,8 +383,8 @@ func Post(rootpath string, f FilterFunc) *App {
 //    beego.Delete("/api", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func Delete(rootpath string, f FilterFunc) *App {
-	BeeApp.Handlers.Delete(rootpath, f)
+func Delete(rootpath string, f FilterFunc, opts ...RouterOption) *App {
+	BeeApp.Handlers.Delete(rootpath, f, opts...)
 	return BeeApp
 }
 
@@ -297,8 +393,8 @@ func Delete(rootpath string, f FilterFunc) *App {
 //    beego.Put("/api", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func Put(rootpath string, f FilterFunc) *App {
-	BeeApp.Handlers.Put(rootpath, f)
+func Put(rootpath string, f FilterFunc, opts ...RouterOption) *App {
+	BeeApp.Handlers.Put(rootpath, f, opts...)
 	return BeeApp
 }
 
@@ -307,8 +403,8 @@ func Put(rootpath string, f FilterFunc) *App {
 //    beego.Head("/api", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func Head(rootpath string, f FilterFunc) *App {
-	BeeApp.Handlers.Head(rootpath, f)
+func Head(rootpath string, f FilterFunc, opts ...RouterOption) *App {
+	BeeApp.Handlers.Head(rootpath, f, opts...)
 	return BeeApp
 }
 
@@ -317,8 +413,8 @@ func Head(rootpath string, f FilterFunc) *App {
 //    beego.Options("/api", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func Options(rootpath string, f FilterFunc) *App {
-	BeeApp.Handlers.Options(rootpath, f)
+func Options(rootpath string, f FilterFunc, opts ...RouterOption) *App {
+	BeeApp.Handlers.Options(rootpath, f, opts...)
 	return BeeApp
 }
 
@@ -327,8 +423,8 @@ func Options(rootpath string, f FilterFunc) *App {
 //    beego.Patch("/api", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func Patch(rootpath string, f FilterFunc) *App {
-	BeeApp.Handlers.Patch(rootpath, f)
+func Patch(rootpath string, f FilterFunc, opts ...RouterOption) *App {
+	BeeApp.Handlers.Patch(rootpath, f, opts...)
 	return BeeApp
 }
 
@@ -337,8 +433,8 @@ func Patch(rootpath string, f FilterFunc) *App {
 //    beego.Any("/api", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func Any(rootpath string, f FilterFunc) *App {
-	BeeApp.Handlers.Any(rootpath, f)
+func Any(rootpath string, f FilterFunc, opts ...RouterOption) *App {
+	BeeApp.Handlers.Any(rootpath, f, opts...)
 	return BeeApp
 }
 
@@ -360,3 +456,74 @@ func InsertFilter(pattern string, pos int, filter FilterFunc, params ...bool) *A
 	BeeApp.Handlers.InsertFilter(pattern, pos, filter, params...)
 	return BeeApp
 }
+
+// InsertNamedFilter adds the filter registered by RegisterFilter with pattern condition and action constant.
+// usage:
+//    beego.RegisterFilter("auth", authFilter)
+//    beego.InsertNamedFilter("/admin/*", beego.BeforeRouter, "auth")
+func InsertNamedFilter(pattern string, pos int, name string, params ...bool) *App {
+	if err := BeeApp.Handlers.InsertNamedFilter(pattern, pos, name, params...); err != nil {
+		panic(err)
+	}
+	return BeeApp
+}
+
+// InsertFilterPriority adds a FilterFunc with an explicit priority,
+// filters with a lower priority run first at the same position.
+// usage:
+//    beego.InsertFilterPriority("*", beego.BeforeRouter, -10, authFilter)
+func InsertFilterPriority(pattern string, pos int, priority int, filter FilterFunc, params ...bool) *App {
+	BeeApp.Handlers.InsertFilterPriority(pattern, pos, priority, filter, params...)
+	return BeeApp
+}
+
+// SetJSONEncoder uses the json encoder for the responses of the routers matched by pattern.
+// use context.DefaultJSONEncoder to change the encoder of all responses.
+// usage:
+//    beego.SetJSONEncoder("/api/*", jsoniter.ConfigCompatibleWithStandardLibrary)
+func SetJSONEncoder(pattern string, encoder context.JSONEncoder) *App {
+	BeeApp.Handlers.InsertFilter(pattern, BeforeRouter, func(ctx *context.Context) {
+		ctx.Output.JSONEncoder = encoder
+	}, false)
+	return BeeApp
+}
+
+// SetTimeout sets the timeout of the requests matched by pattern, a zero timeout disables it.
+// usage:
+//    beego.SetTimeout("/report/*", 30 * time.Second)
+func SetTimeout(pattern string, timeout time.Duration) *App {
+	BeeApp.Handlers.SetTimeout(pattern, timeout)
+	return BeeApp
+}
+
+// SetMaxBodySize sets the max request body size of the requests matched by pattern, a zero size disables it.
+// usage:
+//    beego.SetMaxBodySize("/upload/*", 100<<20)
+func SetMaxBodySize(pattern string, size int64) *App {
+	BeeApp.Handlers.SetMaxBodySize(pattern, size)
+	return BeeApp
+}
+
+// SetMaxResponseSize sets the max response size and the policy of the larger responses of the requests matched by pattern.
+// usage:
+//    beego.SetMaxResponseSize("/api/*", 10<<20, beego.ResponseSizeAbort)
+func SetMaxResponseSize(pattern string, size int64, policy ResponseSizePolicy) *App {
+	BeeApp.Handlers.SetMaxResponseSize(pattern, size, policy)
+	return BeeApp
+}
+
+// DefaultHeader sets a default response header for the requests matched by pattern.
+// usage:
+//    beego.DefaultHeader("/api/*", "Cache-Control", "no-store")
+func DefaultHeader(pattern, key, value string) *App {
+	BeeApp.Handlers.DefaultHeader(pattern, key, value)
+	return BeeApp
+}
+
+// Use adds net/http middlewares to BeeApp, they run before all the beego filters.
+// usage:
+//    beego.Use(handlers.CompressHandler, cors.Default().Handler)
+func Use(mws ...MiddleWare) *App {
+	BeeApp.Handlers.Use(mws...)
+	return BeeApp
+}
@@ -0,0 +1,161 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Asset is a static file fingerprinted by its content.
+type Asset struct {
+	URL       string // the url with the fingerprint, e.g. /static/js/app.js?v=3a7bd3e2360a
+	Integrity string // the Subresource Integrity hash, e.g. sha384-...
+	File      string // the file on the disk, or in the fs.FS set by SetStaticFS
+}
+
+type assetEntry struct {
+	modTime time.Time
+	size    int64
+	asset   *Asset
+}
+
+var (
+	assetLock  sync.RWMutex
+	assetCache = make(map[string]*assetEntry)
+)
+
+// AssetInfo fingerprints the static file of the url src, the file is found by SetStaticPath and StaticDir.
+// The hashes are cached, in dev mode they are computed again when the file changes.
+func AssetInfo(src string) (*Asset, error) {
+	fsys, file := assetFile(src)
+	if file == "" {
+		return nil, os.ErrNotExist
+	}
+	assetLock.RLock()
+	e, ok := assetCache[src]
+	assetLock.RUnlock()
+	if ok && RunMode != "dev" {
+		return e.asset, nil
+	}
+	fi, err := staticStat(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	if ok && e.modTime.Equal(fi.ModTime()) && e.size == fi.Size() {
+		return e.asset, nil
+	}
+	f, err := staticOpen(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha512.New384()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	sum := h.Sum(nil)
+	sep := "?"
+	if strings.Contains(src, "?") {
+		sep = "&"
+	}
+	a := &Asset{
+		URL:       src + sep + "v=" + hex.EncodeToString(sum[:6]),
+		Integrity: "sha384-" + base64.StdEncoding.EncodeToString(sum),
+		File:      file,
+	}
+	assetLock.Lock()
+	assetCache[src] = &assetEntry{modTime: fi.ModTime(), size: fi.Size(), asset: a}
+	assetLock.Unlock()
+	return a, nil
+}
+
+// assetFile returns the file of the static url and the fs.FS it's in, nil for the disk, the longest prefix wins.
+func assetFile(src string) (fs.FS, string) {
+	p := src
+	if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p = p[:i]
+	}
+	if r := matchStaticRoute("", p); r != nil {
+		return staticFS(r), path.Join(r.dir, path.Clean("/"+p[len(r.prefix):]))
+	}
+	var prefix, dir string
+	for k, v := range StaticDir {
+		if len(k) <= len(prefix) || !strings.HasPrefix(p, k) {
+			continue
+		}
+		if len(p) > len(k) && p[len(k)] != '/' {
+			continue
+		}
+		prefix, dir = k, v
+	}
+	if prefix == "" {
+		return nil, ""
+	}
+	return nil, path.Join(dir, path.Clean("/"+p[len(prefix):]))
+}
+
+// AssetURL returns the url of the static file with the fingerprint of its content,
+// the url is returned as is if the file can't be read.
+// usage:
+//
+//	<img src="{{asset_url "/static/img/logo.png"}}">
+func AssetURL(src string) string {
+	a, err := AssetInfo(src)
+	if err != nil {
+		if RunMode == "dev" {
+			Warn("asset:", src, err)
+		}
+		return src
+	}
+	return a.URL
+}
+
+// AssetIntegrityAttr returns the integrity and crossorigin attributes of the static file.
+// usage:
+//
+//	<script src="{{asset_url "/static/js/app.js"}}" {{integrity "/static/js/app.js"}}></script>
+func AssetIntegrityAttr(src string) template.HTMLAttr {
+	a, err := AssetInfo(src)
+	if err != nil {
+		if RunMode == "dev" {
+			Warn("asset:", src, err)
+		}
+		return ""
+	}
+	return template.HTMLAttr(`integrity="` + a.Integrity + `" crossorigin="anonymous"`)
+}
+
+// assetAttrs returns the fingerprinted url and the integrity attributes used by the asset tags,
+// they're only added when AssetIntegrity is on and the file is found.
+func assetAttrs(src string) (string, string) {
+	if !AssetIntegrity {
+		return src, ""
+	}
+	a, err := AssetInfo(src)
+	if err != nil {
+		return src, ""
+	}
+	return a.URL, ` integrity="` + a.Integrity + `" crossorigin="anonymous"`
+}
@@ -15,6 +15,7 @@
 package beego
 
 import (
+	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -59,6 +60,17 @@ func Run(params ...string) {
 	BeeApp.Run()
 }
 
+// RunWith runs beego application with the server adapter instead of listening on HTTPPort,
+// serve is called with the handler of BeeApp once the hooks are done, e.g. cgi.Serve or a serverless adapter.
+// usage:
+//
+//	beego.RunWith(cgi.Serve)
+//	beego.RunWith(func(h http.Handler) error { return fcgi.Serve(l, h) })
+func RunWith(serve func(http.Handler) error) error {
+	initBeforeHTTPRun()
+	return serve(BeeApp.Handlers)
+}
+
 func initBeforeHTTPRun() {
 	// if AppConfigPath not In the conf/app.conf reParse config
 	if AppConfigPath != filepath.Join(AppPath, "conf", "app.conf") {
@@ -74,8 +86,11 @@ func initBeforeHTTPRun() {
 	AddAPPStartHook(registerDefaultErrorHandler)
 	AddAPPStartHook(registerSession)
 	AddAPPStartHook(registerDocs)
+	AddAPPStartHook(registerReadiness)
+	AddAPPStartHook(registerI18n)
 	AddAPPStartHook(registerTemplate)
 	AddAPPStartHook(registerAdmin)
+	AddAPPStartHook(registerChaos)
 
 	for _, hk := range hooks {
 		if err := hk(); err != nil {
@@ -0,0 +1,83 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// bodySizeRouter stores the max body size of the routers matched by pattern.
+type bodySizeRouter struct {
+	tree    *Tree
+	pattern string
+	size    int64
+}
+
+// SetMaxBodySize sets the max request body size of the requests matched by pattern, it overrides MaxBodySize.
+// The requests with a larger Content-Length get 413 before the body is read, the chunked bodies are cut at size
+// and get 413 when CopyBody or the form parsing reaches the limit.
+// A zero size disables the limit for the pattern.
+// usage:
+//	SetMaxBodySize("/upload/*", 100<<20)
+func (p *ControllerRegister) SetMaxBodySize(pattern string, size int64) {
+	br := &bodySizeRouter{
+		tree:    NewTree(),
+		pattern: pattern,
+		size:    size,
+	}
+	if !RouterCaseSensitive {
+		pattern = strings.ToLower(pattern)
+	}
+	br.tree.AddRouter(pattern, true)
+	p.bodySizes = append(p.bodySizes, br)
+}
+
+// maxBodySizeFor returns the max body size of the request path, the latest matched pattern wins.
+func (p *ControllerRegister) maxBodySizeFor(urlPath string) int64 {
+	for i := len(p.bodySizes) - 1; i >= 0; i-- {
+		if ok, _ := p.bodySizes[i].tree.Match(urlPath); ok != nil {
+			return p.bodySizes[i].size
+		}
+	}
+	return MaxBodySize
+}
+
+// maxBodyReader records whether the body is cut by http.MaxBytesReader.
+type maxBodyReader struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (r *maxBodyReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	var maxErr *http.MaxBytesError
+	if err != nil && errors.As(err, &maxErr) {
+		r.exceeded = true
+	}
+	return n, err
+}
+
+// limitBody limits the request body to size, it returns false if the Content-Length is already larger.
+func limitBody(w http.ResponseWriter, r *http.Request, size int64) (*maxBodyReader, bool) {
+	if r.ContentLength > size {
+		return nil, false
+	}
+	body := &maxBodyReader{ReadCloser: http.MaxBytesReader(w, r.Body, size)}
+	r.Body = body
+	return body, true
+}
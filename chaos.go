@@ -0,0 +1,173 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/context"
+)
+
+// ChaosRule injects faults into a percentage of the requests matched by Pattern,
+// to test the retries and the timeouts of the clients. The request waits Latency first,
+// then it's answered by Status or its connection is dropped.
+type ChaosRule struct {
+	ID      int           `json:"id"`
+	Pattern string        `json:"pattern"`
+	Percent float64       `json:"percent"` // 0 to 100
+	Latency time.Duration `json:"latency"`
+	Status  int           `json:"status,omitempty"` // the error status, 0 lets the request go on
+	Drop    bool          `json:"drop,omitempty"`   // close the connection without a response
+
+	tree *Tree
+}
+
+// ErrChaosInProd is returned when the chaos filter is enabled in prod mode.
+var ErrChaosInProd = errors.New("beego: the chaos filter can't be enabled in prod mode")
+
+var chaos = struct {
+	lock  sync.RWMutex
+	rules []*ChaosRule
+	id    int
+}{}
+
+// AddChaosRule adds the rule and returns its ID, the rules are applied by the filter of EnableChaos
+// and managed on "/chaos" of the admin module.
+// usage:
+//
+//	beego.AddChaosRule(&beego.ChaosRule{Pattern: "/api/*", Percent: 10, Latency: 2 * time.Second})
+//	beego.AddChaosRule(&beego.ChaosRule{Pattern: "/api/order", Percent: 5, Status: 503})
+func AddChaosRule(rule *ChaosRule) (int, error) {
+	if rule.Pattern == "" {
+		return 0, errors.New("chaos: the pattern is required")
+	}
+	if rule.Percent < 0 || rule.Percent > 100 {
+		return 0, errors.New("chaos: the percent should be between 0 and 100")
+	}
+	if rule.Status != 0 && (rule.Status < 100 || rule.Status > 999) {
+		return 0, errors.New("chaos: invalid status " + strconv.Itoa(rule.Status))
+	}
+	r := *rule
+	r.tree = NewTree()
+	pattern := r.Pattern
+	if !RouterCaseSensitive {
+		pattern = strings.ToLower(pattern)
+	}
+	r.tree.AddRouter(pattern, true)
+
+	chaos.lock.Lock()
+	defer chaos.lock.Unlock()
+	chaos.id++
+	r.ID = chaos.id
+	chaos.rules = append(chaos.rules, &r)
+	return r.ID, nil
+}
+
+// RemoveChaosRule removes the rule by its ID, it returns false if there isn't such a rule.
+func RemoveChaosRule(id int) bool {
+	chaos.lock.Lock()
+	defer chaos.lock.Unlock()
+	for i, r := range chaos.rules {
+		if r.ID == id {
+			chaos.rules = append(chaos.rules[:i], chaos.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ClearChaosRules removes all the chaos rules.
+func ClearChaosRules() {
+	chaos.lock.Lock()
+	defer chaos.lock.Unlock()
+	chaos.rules = nil
+}
+
+// ChaosRules returns the copies of the chaos rules.
+func ChaosRules() []ChaosRule {
+	chaos.lock.RLock()
+	defer chaos.lock.RUnlock()
+	rules := make([]ChaosRule, len(chaos.rules))
+	for i, r := range chaos.rules {
+		rules[i] = *r
+	}
+	return rules
+}
+
+// chaosFilter applies the first chaos rule matching the request.
+func chaosFilter(ctx *context.Context) {
+	urlPath := ctx.Request.URL.Path
+	if !RouterCaseSensitive {
+		urlPath = strings.ToLower(urlPath)
+	}
+	var rule *ChaosRule
+	chaos.lock.RLock()
+	for _, r := range chaos.rules {
+		if ok, _ := r.tree.Match(urlPath); ok != nil {
+			rule = r
+			break
+		}
+	}
+	chaos.lock.RUnlock()
+	if rule == nil || rand.Float64()*100 >= rule.Percent {
+		return
+	}
+
+	if rule.Latency > 0 {
+		t := time.NewTimer(rule.Latency)
+		select {
+		case <-t.C:
+		case <-ctx.Context().Done():
+			t.Stop()
+			return
+		}
+	}
+	status := rule.Status
+	if rule.Drop {
+		if hj, ok := ctx.ResponseWriter.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		// the connection can't be dropped, e.g. of http2, answer the error instead
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	if status != 0 {
+		ctx.Output.Header("X-Chaos", strconv.Itoa(rule.ID))
+		ctx.Output.SetStatus(status)
+		ctx.Output.Body([]byte("chaos: injected fault"))
+	}
+}
+
+func registerChaos() error {
+	if !EnableChaos {
+		return nil
+	}
+	if RunMode == "prod" {
+		return ErrChaosInProd
+	}
+	Warn("the chaos filter is enabled, the requests matched by the chaos rules fail on purpose")
+	BeeApp.Handlers.InsertFilter("*", BeforeRouter, chaosFilter)
+	return nil
+}
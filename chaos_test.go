@@ -0,0 +1,75 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/astaxie/beego/context"
+)
+
+func TestChaosFilter(t *testing.T) {
+	defer ClearChaosRules()
+	handler := NewControllerRegister()
+	handler.InsertFilter("*", BeforeRouter, chaosFilter)
+	handler.Get("/*", func(ctx *context.Context) {
+		ctx.WriteString("ok")
+	})
+
+	if _, err := AddChaosRule(&ChaosRule{Pattern: "/x", Percent: 120}); err == nil {
+		t.Error("the percent over 100 should be refused")
+	}
+	flaky, _ := AddChaosRule(&ChaosRule{Pattern: "/flaky", Percent: 100, Status: 503})
+	AddChaosRule(&ChaosRule{Pattern: "/slow", Percent: 100, Latency: 20 * time.Millisecond})
+	AddChaosRule(&ChaosRule{Pattern: "/never", Percent: 0, Status: 500})
+	AddChaosRule(&ChaosRule{Pattern: "/drop", Percent: 100, Drop: true})
+
+	get := func(url string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	if w := get("/flaky"); w.Code != 503 || w.Header().Get("X-Chaos") == "" {
+		t.Errorf("the fault should be injected: %d %v", w.Code, w.Header())
+	}
+	start := time.Now()
+	if w := get("/slow"); w.Code != 200 || time.Since(start) < 20*time.Millisecond {
+		t.Errorf("the latency should be injected: %d %v", w.Code, time.Since(start))
+	}
+	if w := get("/never"); w.Code != 200 {
+		t.Errorf("0 percent shouldn't inject: %d", w.Code)
+	}
+
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	if resp, err := http.Get(ts.URL + "/drop"); err == nil {
+		resp.Body.Close()
+		t.Error("the connection should be dropped")
+	}
+
+	if !RemoveChaosRule(flaky) || RemoveChaosRule(flaky) {
+		t.Error("the rule should be removed once")
+	}
+	if w := get("/flaky"); w.Code != 200 {
+		t.Errorf("the removed rule shouldn't inject: %d", w.Code)
+	}
+	if len(ChaosRules()) != 3 {
+		t.Errorf("unexpected rules: %v", ChaosRules())
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	gocontext "context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/astaxie/beego/utils"
+)
+
+// RouteInfo describes a registered router, it's listed by Routes and ListRoutes.
+type RouteInfo struct {
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+	Host    string `json:"host,omitempty"`
+	Handler string `json:"handler"`
+	Name    string `json:"name,omitempty"`
+}
+
+// Routes returns the registered routers sorted by the pattern and the method,
+// the routers of the hosts registered by Host follow with their Host set.
+func (p *ControllerRegister) Routes() []RouteInfo {
+	names := make(map[*controllerInfo]string, len(p.names))
+	for name, route := range p.names {
+		names[route] = name
+	}
+	var routes []RouteInfo
+	for method, t := range p.routers {
+		walkRoutes(t, func(route *controllerInfo) {
+			routes = append(routes, RouteInfo{
+				Method:  method,
+				Pattern: route.pattern,
+				Handler: routeHandlerName(method, route),
+				Name:    names[route],
+			})
+		})
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	for _, h := range p.hosts {
+		for _, r := range h.handlers.Routes() {
+			if r.Host == "" {
+				r.Host = h.pattern
+			}
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+// ListRoutes writes the routers of BeeApp to w, format is "text" for an aligned table or "json".
+// usage:
+//
+//	if len(os.Args) > 1 && os.Args[1] == "routes" {
+//		beego.ListRoutes(os.Stdout, "text")
+//		return
+//	}
+func ListRoutes(w io.Writer, format string) error {
+	routes := BeeApp.Handlers.Routes()
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(routes)
+	case "", "text":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "METHOD\tPATTERN\tHOST\tHANDLER\tNAME")
+		for _, r := range routes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Pattern, r.Host, r.Handler, r.Name)
+		}
+		return tw.Flush()
+	}
+	return errors.New("beego: unknown routes format " + format)
+}
+
+// walkRoutes calls f for each router of the tree.
+func walkRoutes(t *Tree, f func(*controllerInfo)) {
+	for _, sub := range t.fixrouters {
+		walkRoutes(sub, f)
+	}
+	if t.wildcard != nil {
+		walkRoutes(t.wildcard, f)
+	}
+	for _, l := range t.leaves {
+		if route, ok := l.runObject.(*controllerInfo); ok {
+			f(route)
+		}
+	}
+}
+
+// routeHandlerName returns the controller method, the function or the http.Handler type serving the router.
+func routeHandlerName(method string, route *controllerInfo) string {
+	switch route.routerType {
+	case routerTypeBeego:
+		action := route.methods[method]
+		if action == "" {
+			action = route.methods["*"]
+		}
+		if action == "" {
+			action = method[:1] + strings.ToLower(method[1:])
+		}
+		return route.controllerType.String() + "." + action
+	case routerTypeRESTFul:
+		return utils.GetFuncName(route.runFunction)
+	}
+	return fmt.Sprintf("%T", route.handler)
+}
+
+// AppCheckError lists the problems found by CheckApp.
+type AppCheckError struct {
+	Problems []string
+}
+
+func (e *AppCheckError) Error() string {
+	return "beego: the app check failed:\n\t" + strings.Join(e.Problems, "\n\t")
+}
+
+// CheckDBTimeout is the timeout of pinging a database of CheckDB.
+var CheckDBTimeout = 5 * time.Second
+
+type appCheck struct {
+	name  string
+	check func() error
+}
+
+var appChecks []appCheck
+
+// RegisterCheck adds a check run by CheckApp, e.g. a database of the orm answering the ping by CheckDB.
+// usage:
+//
+//	for _, name := range orm.DataBaseAliases() {
+//		db, _ := orm.GetDB(name)
+//		beego.RegisterCheck("database "+name, beego.CheckDB(db))
+//	}
+func RegisterCheck(name string, check func() error) {
+	appChecks = append(appChecks, appCheck{name: name, check: check})
+}
+
+// CheckDB returns a check pinging db within CheckDBTimeout.
+func CheckDB(db *sql.DB) func() error {
+	return func() error {
+		ctx, cancel := gocontext.WithTimeout(gocontext.Background(), CheckDBTimeout)
+		defer cancel()
+		return db.PingContext(ctx)
+	}
+}
+
+// CheckApp validates the app without serving it: the config file and the listen settings,
+// the templates compile, the checks added by RegisterCheck pass,
+// and no two routers are registered for the same method and pattern.
+// It returns an *AppCheckError listing all the problems, so the CI fails before the deploy.
+// usage:
+//
+//	if len(os.Args) > 1 && os.Args[1] == "check" {
+//		if err := beego.CheckApp(); err != nil {
+//			fmt.Println(err)
+//			os.Exit(1)
+//		}
+//		return
+//	}
+func CheckApp() error {
+	var problems []string
+	problems = append(problems, checkConfig()...)
+	problems = append(problems, checkTemplates()...)
+	problems = append(problems, runChecks()...)
+	problems = append(problems, BeeApp.Handlers.routeConflicts()...)
+	if len(problems) > 0 {
+		return &AppCheckError{Problems: problems}
+	}
+	return nil
+}
+
+func checkConfig() []string {
+	var problems []string
+	if _, err := newAppConfig(AppConfigProvider, AppConfigPath); err != nil && !os.IsNotExist(err) {
+		problems = append(problems, fmt.Sprintf("config %s: %v", AppConfigPath, err))
+	}
+	if EnableHTTPListen && (HTTPPort < 0 || HTTPPort > 65535) {
+		problems = append(problems, fmt.Sprintf("config: invalid HTTPPort %d", HTTPPort))
+	}
+	if EnableAdmin && (AdminHTTPPort < 0 || AdminHTTPPort > 65535) {
+		problems = append(problems, fmt.Sprintf("config: invalid AdminHTTPPort %d", AdminHTTPPort))
+	}
+	if EnableHTTPTLS {
+		if HTTPSPort < 0 || HTTPSPort > 65535 {
+			problems = append(problems, fmt.Sprintf("config: invalid HTTPSPort %d", HTTPSPort))
+		}
+		if TLSConfig == nil || len(TLSConfig.Certificates) == 0 && TLSConfig.GetCertificate == nil {
+			for _, file := range []string{HTTPCertFile, HTTPKeyFile} {
+				if _, err := os.Stat(file); err != nil {
+					problems = append(problems, fmt.Sprintf("config: EnableHTTPTLS needs the certificate and the key: %v", err))
+				}
+			}
+		}
+		if _, err := buildTLSConfig(); err != nil {
+			problems = append(problems, "config: "+err.Error())
+		}
+	}
+	return problems
+}
+
+// checkTemplates builds the templates apart from BeeTemplates in the strict mode.
+func checkTemplates() []string {
+	if !AutoRender && !TemplatePrecompile {
+		return nil
+	}
+	if err := buildTemplateTo(make(map[string]*template.Template), ViewsPath, true); err != nil {
+		return []string{"templates: " + err.Error()}
+	}
+	if err := parseEngineTemplates(ViewsPath, true); err != nil {
+		return []string{"templates: " + err.Error()}
+	}
+	return nil
+}
+
+func runChecks() []string {
+	var problems []string
+	for _, c := range appChecks {
+		if err := c.check(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", c.name, err))
+		}
+	}
+	return problems
+}
+
+// routeConflicts returns the routers which are shadowed by a router of the same method and pattern,
+// the patterns are compared regardless of the parameter names.
+func (p *ControllerRegister) routeConflicts() []string {
+	var problems []string
+	methods := make([]string, 0, len(p.routers))
+	for method := range p.routers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		seen := make(map[string]*controllerInfo)
+		var conflicts []string
+		walkRoutes(p.routers[method], func(route *controllerInfo) {
+			key := routeParamRegexp.ReplaceAllString(route.pattern, "$1:$3")
+			if first, ok := seen[key]; ok && first != route {
+				conflicts = append(conflicts, fmt.Sprintf("routers: %s %s (%s) is shadowed by %s (%s)",
+					method, route.pattern, routeHandlerName(method, route), first.pattern, routeHandlerName(method, first)))
+				return
+			}
+			seen[key] = route
+		})
+		sort.Strings(conflicts)
+		problems = append(problems, conflicts...)
+	}
+	for _, h := range p.hosts {
+		for _, c := range h.handlers.routeConflicts() {
+			problems = append(problems, c+" on the host "+h.pattern)
+		}
+	}
+	return problems
+}
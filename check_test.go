@@ -0,0 +1,98 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/astaxie/beego/context"
+)
+
+func TestRoutes(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.AddWithOptions("/user/:id", &TestController{}, WithName("user.show"))
+	handler.Add("/list", &TestController{}, "get:List")
+	handler.Post("/func", func(ctx *context.Context) {})
+
+	routes := handler.Routes()
+	want := []RouteInfo{
+		{Method: "POST", Pattern: "/func", Handler: "github.com/astaxie/beego.TestRoutes.func1"},
+		{Method: "GET", Pattern: "/list", Handler: "beego.TestController.List"},
+	}
+	found := 0
+	for _, r := range routes {
+		for _, w := range want {
+			if r == w {
+				found++
+			}
+		}
+		if r.Pattern == "/user/:id" && r.Method == "GET" && (r.Name != "user.show" || r.Handler != "beego.TestController.Get") {
+			t.Errorf("TestRoutes got %+v", r)
+		}
+	}
+	if found != len(want) {
+		t.Errorf("TestRoutes got %+v", routes)
+	}
+	if conflicts := handler.routeConflicts(); len(conflicts) != 0 {
+		t.Errorf("TestRoutes got the conflicts %v", conflicts)
+	}
+
+	handler.Add("/user/:uid", &AdminController{}, "get:Get")
+	conflicts := handler.routeConflicts()
+	if len(conflicts) != 1 || !strings.Contains(conflicts[0], "GET /user/:uid (beego.AdminController.Get) is shadowed by /user/:id") {
+		t.Errorf("TestRoutes got the conflicts %v", conflicts)
+	}
+}
+
+func TestListRoutes(t *testing.T) {
+	old := BeeApp.Handlers
+	defer func() { BeeApp.Handlers = old }()
+	BeeApp.Handlers = NewControllerRegister()
+	BeeApp.Handlers.Add("/list", &TestController{}, "get:List")
+
+	var buf bytes.Buffer
+	if err := ListRoutes(&buf, "text"); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 || !strings.Contains(lines[1], "beego.TestController.List") {
+		t.Errorf("TestListRoutes text got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := ListRoutes(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var routes []RouteInfo
+	if err := json.Unmarshal(buf.Bytes(), &routes); err != nil || len(routes) != 1 || routes[0].Pattern != "/list" {
+		t.Errorf("TestListRoutes json got %q %v", buf.String(), err)
+	}
+	if err := ListRoutes(&buf, "xml"); err == nil {
+		t.Error("TestListRoutes expects an error for the unknown format")
+	}
+}
+
+func TestRegisterCheck(t *testing.T) {
+	defer func(checks []appCheck) { appChecks = checks }(appChecks)
+	appChecks = nil
+	RegisterCheck("cache", func() error { return nil })
+	RegisterCheck("database default", func() error { return errors.New("connection refused") })
+	if problems := runChecks(); len(problems) != 1 || problems[0] != "database default: connection refused" {
+		t.Errorf("TestRegisterCheck got %q", problems)
+	}
+}
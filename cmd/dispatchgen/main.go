@@ -0,0 +1,236 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Dispatchgen generates the static dispatch of the custom controller methods,
+// so the router calls them directly instead of looking them up by reflection.
+// It finds the controllers embedding beego.Controller in the package,
+// and writes a file registering their dispatch by beego.RegisterDispatch.
+// The router falls back to the reflection for the methods which aren't generated,
+// so run it again after adding methods to keep them off the slow path.
+//
+// Usage:
+//
+//	//go:generate go run github.com/astaxie/beego/cmd/dispatchgen
+//	//go:generate go run github.com/astaxie/beego/cmd/dispatchgen -type MainController,UserController -output dispatch.go
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const beegoPath = "github.com/astaxie/beego"
+
+// reserved are the methods of beego.ControllerInterface and the hooks the router calls itself.
+var reserved = map[string]bool{
+	"Init": true, "Prepare": true, "Get": true, "Post": true, "Delete": true, "Put": true,
+	"Head": true, "Patch": true, "Options": true, "Finish": true, "Render": true, "XSRFToken": true,
+	"CheckXSRFCookie": true, "HandlerFunc": true, "URLMapping": true, "Reset": true, "DispatchMethod": true,
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("dispatchgen: ")
+	types := flag.String("type", "", "the comma separated controller types, default all the controllers of the package")
+	output := flag.String("output", "beego_dispatch.go", "the generated file in the package directory")
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	var names []string
+	if *types != "" {
+		names = strings.Split(*types, ",")
+	}
+	src, err := generate(dir, *output, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, *output), src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// controller is a controller type and its custom methods.
+type controller struct {
+	name    string
+	embeds  []string // the controllers of the package it embeds
+	methods map[string]bool
+	isCtrl  bool // it embeds beego.Controller or a controller of the package
+}
+
+// generate returns the source of the dispatch of the controllers in dir, output is skipped when parsing.
+func generate(dir, output string, types []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		name := info.Name()
+		return !strings.HasSuffix(name, "_test.go") && name != output
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%d packages in %s, want 1", len(pkgs), dir)
+	}
+	var pkg *ast.Package
+	for _, p := range pkgs {
+		pkg = p
+	}
+
+	ctrls := make(map[string]*controller)
+	get := func(name string) *controller {
+		c, ok := ctrls[name]
+		if !ok {
+			c = &controller{name: name, methods: make(map[string]bool)}
+			ctrls[name] = c
+		}
+		return c
+	}
+	for _, f := range pkg.Files {
+		beegoName := ""
+		for _, spec := range f.Imports {
+			if path, _ := strconv.Unquote(spec.Path.Value); path == beegoPath {
+				beegoName = "beego"
+				if spec.Name != nil {
+					beegoName = spec.Name.Name
+				}
+			}
+		}
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					st, ok := ts.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					c := get(ts.Name.Name)
+					for _, field := range st.Fields.List {
+						if len(field.Names) > 0 {
+							continue
+						}
+						typ := field.Type
+						if star, ok := typ.(*ast.StarExpr); ok {
+							typ = star.X
+						}
+						switch t := typ.(type) {
+						case *ast.SelectorExpr:
+							if x, ok := t.X.(*ast.Ident); ok && beegoName != "" && x.Name == beegoName && t.Sel.Name == "Controller" {
+								c.isCtrl = true
+							}
+						case *ast.Ident:
+							c.embeds = append(c.embeds, t.Name)
+						}
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv == nil || len(d.Recv.List) != 1 || !d.Name.IsExported() || reserved[d.Name.Name] {
+					continue
+				}
+				if d.Type.Params.NumFields() > 0 || d.Type.Results.NumFields() > 0 {
+					continue
+				}
+				recv := d.Recv.List[0].Type
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv = star.X
+				}
+				if id, ok := recv.(*ast.Ident); ok {
+					get(id.Name).methods[d.Name.Name] = true
+				}
+			}
+		}
+	}
+
+	// the controllers embedding a controller of the package are controllers and have its methods promoted
+	var resolve func(c *controller, seen map[string]bool) (bool, map[string]bool)
+	resolve = func(c *controller, seen map[string]bool) (bool, map[string]bool) {
+		seen[c.name] = true
+		isCtrl, methods := c.isCtrl, make(map[string]bool)
+		for _, name := range c.embeds {
+			if e, ok := ctrls[name]; ok && !seen[name] {
+				ok, m := resolve(e, seen)
+				if ok {
+					isCtrl = true
+					for k := range m {
+						methods[k] = true
+					}
+				}
+			}
+		}
+		for k := range c.methods {
+			methods[k] = true
+		}
+		return isCtrl, methods
+	}
+
+	want := make(map[string]bool, len(types))
+	for _, name := range types {
+		name = strings.TrimSpace(name)
+		if _, ok := ctrls[name]; !ok {
+			return nil, errors.New("unknown type " + name)
+		}
+		want[name] = true
+	}
+	names := make([]string, 0, len(ctrls))
+	for name := range ctrls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var body bytes.Buffer
+	for _, name := range names {
+		if len(want) > 0 && !want[name] {
+			continue
+		}
+		isCtrl, methods := resolve(ctrls[name], make(map[string]bool))
+		if !isCtrl && !want[name] || len(methods) == 0 {
+			continue
+		}
+		list := make([]string, 0, len(methods))
+		for m := range methods {
+			list = append(list, m)
+		}
+		sort.Strings(list)
+		fmt.Fprintf(&body, "beego.RegisterDispatch(&%s{}, func(c beego.ControllerInterface, name string) bool {\nswitch name {\n", name)
+		for _, m := range list {
+			fmt.Fprintf(&body, "case %q:\nc.(*%s).%s()\n", m, name, m)
+		}
+		fmt.Fprint(&body, "default:\nreturn false\n}\nreturn true\n})\n")
+	}
+	if body.Len() == 0 {
+		return nil, errors.New("no controller with the custom methods in " + dir)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by dispatchgen. DO NOT EDIT.\n\npackage %s\n\n", pkg.Name)
+	fmt.Fprintf(&buf, "import %q\n\nfunc init() {\n%s}\n", beegoPath, body.Bytes())
+	return format.Source(buf.Bytes())
+}
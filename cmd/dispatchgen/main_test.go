@@ -0,0 +1,107 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testControllers = `package controllers
+
+import (
+	"fmt"
+
+	bee "github.com/astaxie/beego"
+)
+
+type MainController struct {
+	bee.Controller
+}
+
+func (c *MainController) Get()          {}
+func (c *MainController) List()         {}
+func (c *MainController) Show(id int)   {}
+func (c *MainController) Count() int    { return 0 }
+func (c *MainController) private()      {}
+
+type AdminController struct {
+	MainController
+}
+
+func (c *AdminController) Purge() {}
+
+type helper struct{}
+
+func (h *helper) Run() { fmt.Println() }
+`
+
+func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dispatchgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "controllers.go"), []byte(testControllers), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(dir, "beego_dispatch.go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// Code generated by dispatchgen. DO NOT EDIT.
+
+package controllers
+
+import "github.com/astaxie/beego"
+
+func init() {
+	beego.RegisterDispatch(&AdminController{}, func(c beego.ControllerInterface, name string) bool {
+		switch name {
+		case "List":
+			c.(*AdminController).List()
+		case "Purge":
+			c.(*AdminController).Purge()
+		default:
+			return false
+		}
+		return true
+	})
+	beego.RegisterDispatch(&MainController{}, func(c beego.ControllerInterface, name string) bool {
+		switch name {
+		case "List":
+			c.(*MainController).List()
+		default:
+			return false
+		}
+		return true
+	})
+}
+`
+	if string(src) != want {
+		t.Errorf("generate got\n%s", src)
+	}
+
+	src, err = generate(dir, "beego_dispatch.go", []string{"MainController"})
+	if err != nil || strings.Contains(string(src), "AdminController") {
+		t.Errorf("generate -type got %v\n%s", err, src)
+	}
+	if _, err := generate(dir, "beego_dispatch.go", []string{"UnknownController"}); err == nil {
+		t.Error("generate expects an error for the unknown type")
+	}
+}
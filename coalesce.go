@@ -0,0 +1,174 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/astaxie/beego/context"
+)
+
+// CoalesceKeyFunc returns the key of a GET request, the concurrent requests of the same key share one execution.
+// The key must cover everything the response varies on, e.g. the user of a personalized page.
+// The request isn't coalesced if the key is empty.
+type CoalesceKeyFunc func(ctx *context.Context) string
+
+// DefaultCoalesceKey is the key of the url with the query, the Accept and Accept-Encoding headers.
+// The requests with a Cookie or Authorization header aren't coalesced, their responses may be personalized.
+func DefaultCoalesceKey(ctx *context.Context) string {
+	r := ctx.Request
+	if r.Header.Get("Cookie") != "" || r.Header.Get("Authorization") != "" {
+		return ""
+	}
+	return r.Host + r.URL.RequestURI() + "\n" + r.Header.Get("Accept") + "\n" + r.Header.Get("Accept-Encoding")
+}
+
+// WithCoalesce deduplicates the identical concurrent GET requests of the router: while a request is executed,
+// the requests of the same key wait for it and get a copy of its response instead of running the handler.
+// If the first request panics or its response is larger than CoalesceMaxBytes, the waiting requests run the handler themselves.
+// The Set-Cookie headers aren't copied, so a session isn't shared. key is DefaultCoalesceKey if it's nil.
+// It doesn't apply to the routers added by Handler.
+// usage:
+//
+//	beego.RouterWithOptions("/report", &ReportController{}, beego.WithCoalesce(nil))
+func WithCoalesce(key CoalesceKeyFunc) RouterOption {
+	if key == nil {
+		key = DefaultCoalesceKey
+	}
+	return func(o *routerOptions) {
+		o.coalesce = &coalescer{key: key, calls: make(map[string]*coalesceCall)}
+	}
+}
+
+type coalescer struct {
+	key   CoalesceKeyFunc
+	lock  sync.Mutex
+	calls map[string]*coalesceCall
+}
+
+// coalesceCall is an execution shared by the requests of a key.
+type coalesceCall struct {
+	key    string
+	done   chan struct{}
+	ok     bool
+	status int
+	header http.Header
+	body   []byte
+}
+
+// join returns the call in flight for the key of ctx and whether the caller leads it,
+// the call is nil if the request isn't coalesced.
+func (c *coalescer) join(ctx *context.Context) (*coalesceCall, bool) {
+	key := c.key(ctx)
+	if key == "" {
+		return nil, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if call, ok := c.calls[key]; ok {
+		return call, false
+	}
+	call := &coalesceCall{key: key, done: make(chan struct{})}
+	c.calls[key] = call
+	return call, true
+}
+
+// finish publishes the response recorded by the leader, ok is false if the leader failed.
+func (c *coalescer) finish(call *coalesceCall, rec *coalesceRecorder, ok bool) {
+	c.lock.Lock()
+	delete(c.calls, call.key)
+	c.lock.Unlock()
+	if ok && rec.status != 0 && !rec.exceeded {
+		call.ok = true
+		call.status = rec.status
+		call.header = rec.Header().Clone()
+		call.header.Del("Set-Cookie")
+		call.body = rec.body.Bytes()
+	}
+	close(call.done)
+}
+
+// wait blocks until the leader finishes, it returns false if the leader failed or the client is gone.
+func (call *coalesceCall) wait(ctx *context.Context) bool {
+	select {
+	case <-call.done:
+		return call.ok
+	case <-ctx.Context().Done():
+		return false
+	}
+}
+
+// replay writes the shared response to w.
+func (call *coalesceCall) replay(w http.ResponseWriter) {
+	h := w.Header()
+	for k, v := range call.header {
+		h[k] = v
+	}
+	w.WriteHeader(call.status)
+	w.Write(call.body)
+}
+
+// coalesceRecorder records the response of the leader while writing it to the client,
+// it stops recording once the body is larger than max.
+type coalesceRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	max      int64
+	exceeded bool
+}
+
+func (r *coalesceRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *coalesceRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if !r.exceeded {
+		if int64(r.body.Len()+len(p)) > r.max {
+			r.exceeded = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(p)
+		}
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher.
+func (r *coalesceRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker.
+func (r *coalesceRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("webserver doesn't support hijacking")
+	}
+	return hj.Hijack()
+}
@@ -15,6 +15,7 @@
 package beego
 
 import (
+	"crypto/tls"
 	"fmt"
 	"html/template"
 	"os"
@@ -31,10 +32,24 @@ import (
 var (
 	// AccessLogs represent whether output the access logs, default is false
 	AccessLogs bool
+	// AccessLogsFormat is the format of the access logs, text or json, default is text
+	AccessLogsFormat string
+	// AccessLogsOutput is where the json access logs go: stdout, stderr, syslog, syslog:udp://host:514 or a file path, default is stdout
+	AccessLogsOutput string
+	// AccessLogsSampleRate is the percent of the requests logged, the errors and the slow requests are always logged. default is 100
+	AccessLogsSampleRate float64
+	// AccessLogsSlowThreshold is the milliseconds over which a request is always logged, default is 0, off
+	AccessLogsSlowThreshold int64
+	// AccessLogsSkipPrefixes are the path prefixes which aren't logged unless they fail or are slow, e.g. /healthz
+	AccessLogsSkipPrefixes []string
 	// AdminHTTPAddr is address for admin
 	AdminHTTPAddr string
 	// AdminHTTPPort is listens port for admin
 	AdminHTTPPort int
+	// AdminAuthUser and AdminAuthPassword protect the sessions page of the admin module by the basic authentication,
+	// it's refused unless they are set
+	AdminAuthUser     string
+	AdminAuthPassword string
 	// AppConfig is the instance of Config, store the config information from file
 	AppConfig *beegoAppConfig
 	// AppName represent Application name, always the project folder name
@@ -48,6 +63,10 @@ var (
 	// AutoRender is a flag of render template automatically. It's always turn off in API application
 	// default is true
 	AutoRender bool
+	// RenderBufferSize is the max size of a page rendered by Controller.Render before it's sent,
+	// the larger pages fail with ErrRenderBufferFull unless the controller sets StreamRender, default is 0, no limit.
+	// The LayoutContent of a streamed page isn't limited either
+	RenderBufferSize int64
 	// BeegoServerName exported in response header.
 	BeegoServerName string
 	// CopyRequestBody is just useful for raw request body in context. default is false
@@ -58,10 +77,28 @@ var (
 	EnableAdmin bool
 	// EnableDocs enable generate docs & server docs API Swagger
 	EnableDocs bool
+	// EnableChaos applies the chaos rules to inject faults into the requests, it's refused in prod mode. default is false
+	EnableChaos bool
+	// EnableOpenAPI serves the OpenAPI 3 document generated from the routers at OpenAPIPath, default is false
+	EnableOpenAPI bool
+	// OpenAPIPath is the path of the OpenAPI document, default is /swagger.json
+	OpenAPIPath string
+	// ReadinessPath serves the readiness probe failing after Drain, default is "" which doesn't serve it
+	ReadinessPath string
+	// I18nPath is the directory of the message catalogs loaded at the start, default is "" which doesn't load them
+	I18nPath string
+	// I18nDefaultLocale is the locale of the missing messages, default is en-US
+	I18nDefaultLocale string
+	// EnableOpenAPIUI serves the Swagger UI of the OpenAPI document at /swagger/, default is false
+	EnableOpenAPIUI bool
 	// EnableErrorsShow wheather show errors in page. if true, show error and trace info in page rendered with error template.
 	EnableErrorsShow bool
+	// EnableProblemJSON sends the errors of the framework as application/problem+json (RFC 7807) instead of the error pages, default is false
+	EnableProblemJSON bool
 	// EnabelFcgi turn on the fcgi Listen, default is false
 	EnabelFcgi bool
+	// EnableCGI serves the request of the CGI environment instead of listening, default is false
+	EnableCGI bool
 	// EnableGzip means gzip the response
 	EnableGzip bool
 	// EnableHTTPListen represent whether turn on the HTTP, default is true
@@ -80,14 +117,45 @@ var (
 	GlobalSessions *session.Manager
 	// Graceful means use graceful module to start the server
 	Graceful bool
+	// GracefulTimeout is the seconds to wait for the running requests when the graceful server stops, default is 60
+	GracefulTimeout int64
 	// workPath is always the same as AppPath, but sometime when it started with other
 	// program, like supervisor
 	workPath string
 	// ListenTCP4 represent only Listen in TCP4, default is false
 	ListenTCP4 bool
+	// StrictRequest rejects the ambiguous requests used to smuggle requests through a proxy, default is false:
+	// Transfer-Encoding with Content-Length, multiple Content-Length, line folding, bare LF and raw non-ascii targets.
+	// the framing checks read the raw http/1 requests, so they apply to the http listener but not https.
+	StrictRequest bool
+	// MaxRequestHeaders is the most header fields of a request, the larger requests get 431, default is 0, no limit
+	MaxRequestHeaders int
+	// MaxRequestHeaderFieldBytes is the most bytes of a header field, the larger requests get 431, default is 0, no limit
+	MaxRequestHeaderFieldBytes int
+	// LogRedactKeys are the key patterns whose values are masked in the logs, such as password;token
+	LogRedactKeys []string
+	// LogRedactCardNumbers masks the card numbers in the logs, default is false
+	LogRedactCardNumbers bool
 	// MaxMemory The whole request body is parsed and up to a total of maxMemory
 	// bytes of its file parts are stored in memory, with the remainder stored on disk in temporary files
 	MaxMemory int64
+	// MaxBodySize is the max request body size, the larger requests get 413, default is 0, no limit
+	// SetMaxBodySize overrides it for the routers
+	MaxBodySize int64
+	// MaxResponseSize is the max response size of the controllers and filters, default is 0, no limit
+	// SetMaxResponseSize overrides it for the routers
+	MaxResponseSize int64
+	// MaxResponseSizePolicy is abort or truncate, what to do with the larger responses, default is abort
+	MaxResponseSizePolicy string
+	// JSONPrefix is written before every json response body, such as ")]}',\n" for XSSI protection. default is empty
+	JSONPrefix string
+	// JSONEscapeHTML means escape <, > and & in json responses, default is true
+	JSONEscapeHTML bool
+	// JSONEnvelope wraps ServeJSON responses as {"data":..., "meta":...}, default is false
+	JSONEnvelope bool
+	// ServeFormat is the format of ctx.Output.Serve when the Accept header doesn't choose one:
+	// json, xml, yaml, jsonp or the media type of a registered context.Encoder, default is json
+	ServeFormat string
 	// HTTPAddr is the TCP network address addr for HTTP
 	HTTPAddr string
 	// HTTPPort is listens port for HTTP
@@ -98,10 +166,58 @@ var (
 	HTTPCertFile string
 	// HTTPKeyFile is the path to private key file
 	HTTPKeyFile string
+	// EnableAutoTLS serves https with the certificates from Let's Encrypt for AutoTLSHosts, default is false
+	EnableAutoTLS bool
+	// AutoTLSHosts are the host names allowed to get the certificates
+	AutoTLSHosts []string
+	// AutoTLSCacheDir is the directory to save the certificates, default is "certs"
+	AutoTLSCacheDir string
+	// AutoTLSEmail is the contact email of the ACME account, it's optional
+	AutoTLSEmail string
+	// HTTPSClientCAFile is the CA file to verify the client certificates, the client certificate is required if it's set
+	HTTPSClientCAFile string
+	// HTTPSCipherSuites are the names of the allowed cipher suites, such as TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+	HTTPSCipherSuites []string
+	// EnableHTTP2 enables HTTP/2 for the https server, default is true
+	EnableHTTP2 bool
+	// EnableHTTP3 starts the experimental HTTP/3 server next to the https server and advertises it by Alt-Svc,
+	// beego must be built with the http3 tag. default is false
+	EnableHTTP3 bool
+	// HTTP3Port is the UDP port of the HTTP/3 server, default is 0, HTTPSPort
+	HTTP3Port int
+	// TLSConfig is the base tls.Config of the https server, set it before beego.Run to customize the TLS
+	TLSConfig *tls.Config
 	// HTTPServerTimeOut HTTP server timeout. default is 0, no timeout
 	HTTPServerTimeOut int64
+	// HTTPReadHeaderTimeout is the seconds to read the request headers, it stops the slowloris clients. default is 0, no timeout
+	HTTPReadHeaderTimeout int64
+	// HTTPReadTimeout is the seconds to read the whole request, default is HTTPServerTimeOut
+	HTTPReadTimeout int64
+	// HTTPWriteTimeout is the seconds to write the response, default is HTTPServerTimeOut
+	HTTPWriteTimeout int64
+	// HTTPIdleTimeout is the seconds a keep-alive connection waits for the next request, default is 0, the read timeout
+	HTTPIdleTimeout int64
+	// HTTPMaxHeaderBytes is the max bytes of the request headers, default is 0, 1MB of net/http
+	HTTPMaxHeaderBytes int
+	// MaxConnections is the max open connections, the others are closed once accepted. default is 0, no limit
+	MaxConnections int
+	// MaxConnectionsPerIP is the max open connections of a client ip, default is 0, no limit
+	MaxConnectionsPerIP int
+	// RequestTimeout is the seconds a request may run before 503 is sent, default is 0, no timeout
+	RequestTimeout int64
+	// WatchdogThreshold is the milliseconds after which the stack of a running request is logged,
+	// the request is logged with its params when it finishes and counted as slow in the admin statistics. default is 0, off
+	WatchdogThreshold int64
+	// CoalesceMaxBytes is the max size of a response shared by the coalesced requests of WithCoalesce,
+	// the waiting requests of a larger response run the handler themselves. default is 1MB
+	CoalesceMaxBytes int64
+	// EnableAllocStats measures the allocations of each request, they're sent in Server-Timing and listed on /allocs of the admin module.
+	// Reading the memory statistics stops the world, it's meant for dev and profiling. default is false
+	EnableAllocStats bool
 	// RecoverPanic is a flag for auto recover panic, default is true
 	RecoverPanic bool
+	// ResponseHeaders are default headers written into every response, such as X-Service
+	ResponseHeaders map[string]string
 	// RouterCaseSensitive means whether router case sensitive, default is true
 	RouterCaseSensitive bool
 	// RunMode represent the staging, "dev" or "prod"
@@ -122,10 +238,32 @@ var (
 	SessionAutoSetCookie bool
 	// SessionDomain means the cookie domain default is empty
 	SessionDomain string
+	// SessionGCInterval is the seconds between two gc runs, default is 0, SessionGCMaxLifetime
+	SessionGCInterval int64
+	// SessionGCBatchSize is the sessions removed per batch by the providers supporting it, default is 0, no batch
+	SessionGCBatchSize int
+	// SessionGCBatchPause is the milliseconds to sleep between two gc batches
+	SessionGCBatchPause int64
+	// SessionGCMaxDuration is the milliseconds a gc run may take, default is 0, no limit
+	SessionGCMaxDuration int64
+	// SessionIDAlphabet makes the session ids from its characters, default is empty, hex
+	SessionIDAlphabet string
+	// SessionMaxPerUser limits the sessions bound to one user, default is 0, no limit
+	SessionMaxPerUser int
 	// StaticDir store the static path, key is path, value is the folder
 	StaticDir map[string]string
+	// StaticCacheMaxBytes limits the memory of the cached compressed static files, default is 64MB, 0 compresses every request
+	StaticCacheMaxBytes int64
+	// StaticCacheFileMaxBytes is the max size of a cached static file, default is 1MB
+	StaticCacheFileMaxBytes int64
+	// StaticCacheTTL is the seconds a static file is cached, default is 0, until the file changes or is evicted
+	StaticCacheTTL int64
 	// StaticExtensionsToGzip stores the extensions which need to gzip(.js,.css,etc)
 	StaticExtensionsToGzip []string
+	// StaticCacheControl stores the Cache-Control header of the static files by the request path prefix, the longest prefix wins
+	StaticCacheControl map[string]string
+	// AssetIntegrity adds the fingerprint and the Subresource Integrity hash to assets_js and assets_css, default is false
+	AssetIntegrity bool
 	// TemplateCache store the caching template
 	TemplateCache map[string]*template.Template
 	// TemplateLeft left delimiter
@@ -134,10 +272,24 @@ var (
 	TemplateRight string
 	// ViewsPath means the template folder
 	ViewsPath string
+	// TemplatePrecompile builds all templates at startup and fails if one can't be parsed, default is false
+	TemplatePrecompile bool
+	// TemplateAudit logs the template expressions which unescape dynamic values in dev mode, default is false
+	TemplateAudit bool
+	// TemplateNaming is the convention of the template names of the controller actions, lower or snake, default is lower
+	TemplateNaming string
 	// XSRFKEY xsrf hash salt string.
 	XSRFKEY string
 	// XSRFExpire is the expiry of xsrf value.
 	XSRFExpire int
+	// ConsentCookieName is the name of the signed cookie storing the consent categories, default is BEEGO_CONSENT
+	ConsentCookieName string
+	// ConsentSecret is the key signing the consent cookie, default is the XSRFKEY unless it's the default "beegoxsrf"
+	ConsentSecret string
+	// ConsentExpire is the expiry in seconds of the consent cookie, default is one year
+	ConsentExpire int
+	// APIDefaultVersion is the API version of the requests which don't select one, see ControllerRegister.Version
+	APIDefaultVersion string
 )
 
 type beegoAppConfig struct {
@@ -291,6 +443,12 @@ func init() {
 	StaticDir["/static"] = "static"
 
 	StaticExtensionsToGzip = []string{".css", ".js"}
+	StaticCacheControl = make(map[string]string)
+	StaticCacheMaxBytes = 64 << 20
+	StaticCacheFileMaxBytes = 1 << 20
+	CoalesceMaxBytes = 1 << 20
+
+	ResponseHeaders = make(map[string]string)
 
 	TemplateCache = make(map[string]*template.Template)
 
@@ -311,6 +469,8 @@ func init() {
 	RecoverPanic = true
 
 	ViewsPath = "views"
+	I18nDefaultLocale = "en-US"
+	TemplateNaming = "lower"
 
 	SessionOn = false
 	SessionProvider = "memory"
@@ -322,13 +482,26 @@ func init() {
 
 	MaxMemory = 1 << 26 //64MB
 
+	AccessLogsFormat = "text"
+	AccessLogsOutput = "stdout"
+	AccessLogsSampleRate = 100
+
 	HTTPServerTimeOut = 0
+	RequestTimeout = 0
+	GracefulTimeout = 60
+	EnableHTTP2 = true
+	AutoTLSCacheDir = "certs"
 
 	EnableErrorsShow = true
 
-	XSRFKEY = "beegoxsrf"
+	XSRFKEY = defaultXSRFKEY
 	XSRFExpire = 0
 
+	JSONEscapeHTML = true
+	MaxResponseSizePolicy = "abort"
+	ServeFormat = "json"
+	OpenAPIPath = "/swagger.json"
+
 	TemplateLeft = "{{"
 	TemplateRight = "}}"
 
@@ -341,6 +514,9 @@ func init() {
 	FlashName = "BEEGO_FLASH"
 	FlashSeperator = "BEEGOFLASH"
 
+	ConsentCookieName = "BEEGO_CONSENT"
+	ConsentExpire = 365 * 24 * 3600
+
 	RouterCaseSensitive = true
 
 	runtime.GOMAXPROCS(runtime.NumCPU())
@@ -387,6 +563,18 @@ func ParseConfig() (err error) {
 		ListenTCP4 = v
 	}
 
+	if v, err := AppConfig.Bool("StrictRequest"); err == nil {
+		StrictRequest = v
+	}
+
+	if v, err := AppConfig.Int("MaxRequestHeaders"); err == nil {
+		MaxRequestHeaders = v
+	}
+
+	if v, err := AppConfig.Int("MaxRequestHeaderFieldBytes"); err == nil {
+		MaxRequestHeaderFieldBytes = v
+	}
+
 	if v, err := AppConfig.Bool("EnableHTTPListen"); err == nil {
 		EnableHTTPListen = v
 	}
@@ -395,6 +583,21 @@ func ParseConfig() (err error) {
 		MaxMemory = maxmemory
 	}
 
+	if maxbodysize, err := AppConfig.Int64("MaxBodySize"); err == nil {
+		MaxBodySize = maxbodysize
+	}
+
+	if maxresponsesize, err := AppConfig.Int64("MaxResponseSize"); err == nil {
+		MaxResponseSize = maxresponsesize
+	}
+
+	if policy := AppConfig.String("MaxResponseSizePolicy"); policy != "" {
+		if _, ok := responseSizePolicies[policy]; !ok {
+			return fmt.Errorf("unknown MaxResponseSizePolicy %s", policy)
+		}
+		MaxResponseSizePolicy = policy
+	}
+
 	if appname := AppConfig.String("AppName"); appname != "" {
 		AppName = appname
 	}
@@ -403,6 +606,10 @@ func ParseConfig() (err error) {
 		AutoRender = autorender
 	}
 
+	if renderbuffersize, err := AppConfig.Int64("RenderBufferSize"); err == nil {
+		RenderBufferSize = renderbuffersize
+	}
+
 	if autorecover, err := AppConfig.Bool("RecoverPanic"); err == nil {
 		RecoverPanic = autorecover
 	}
@@ -411,6 +618,23 @@ func ParseConfig() (err error) {
 		ViewsPath = views
 	}
 
+	if precompile, err := AppConfig.Bool("TemplatePrecompile"); err == nil {
+		TemplatePrecompile = precompile
+	}
+
+	if audit, err := AppConfig.Bool("TemplateAudit"); err == nil {
+		TemplateAudit = audit
+	}
+
+	if naming := AppConfig.String("TemplateNaming"); naming != "" {
+		fn, ok := templateNamings[naming]
+		if !ok {
+			return fmt.Errorf("unknown TemplateNaming %s", naming)
+		}
+		TemplateNaming = naming
+		templateNaming = fn
+	}
+
 	if sessionon, err := AppConfig.Bool("SessionOn"); err == nil {
 		SessionOn = sessionon
 	}
@@ -435,10 +659,38 @@ func ParseConfig() (err error) {
 		SessionCookieLifeTime = sesscookielifetime
 	}
 
+	if sessGCInterval, err := AppConfig.Int64("SessionGCInterval"); err == nil {
+		SessionGCInterval = sessGCInterval
+	}
+
+	if sessGCBatchSize, err := AppConfig.Int("SessionGCBatchSize"); err == nil {
+		SessionGCBatchSize = sessGCBatchSize
+	}
+
+	if sessGCBatchPause, err := AppConfig.Int64("SessionGCBatchPause"); err == nil {
+		SessionGCBatchPause = sessGCBatchPause
+	}
+
+	if sessGCMaxDuration, err := AppConfig.Int64("SessionGCMaxDuration"); err == nil {
+		SessionGCMaxDuration = sessGCMaxDuration
+	}
+
+	if sessIDAlphabet := AppConfig.String("SessionIDAlphabet"); sessIDAlphabet != "" {
+		SessionIDAlphabet = sessIDAlphabet
+	}
+
+	if sessMaxPerUser, err := AppConfig.Int("SessionMaxPerUser"); err == nil {
+		SessionMaxPerUser = sessMaxPerUser
+	}
+
 	if enabelFcgi, err := AppConfig.Bool("EnabelFcgi"); err == nil {
 		EnabelFcgi = enabelFcgi
 	}
 
+	if enableCGI, err := AppConfig.Bool("EnableCGI"); err == nil {
+		EnableCGI = enableCGI
+	}
+
 	if enablegzip, err := AppConfig.Bool("EnableGzip"); err == nil {
 		EnableGzip = enablegzip
 	}
@@ -451,10 +703,58 @@ func ParseConfig() (err error) {
 		HTTPServerTimeOut = timeout
 	}
 
+	if timeout, err := AppConfig.Int64("HTTPReadHeaderTimeout"); err == nil {
+		HTTPReadHeaderTimeout = timeout
+	}
+
+	if timeout, err := AppConfig.Int64("HTTPReadTimeout"); err == nil {
+		HTTPReadTimeout = timeout
+	}
+
+	if timeout, err := AppConfig.Int64("HTTPWriteTimeout"); err == nil {
+		HTTPWriteTimeout = timeout
+	}
+
+	if timeout, err := AppConfig.Int64("HTTPIdleTimeout"); err == nil {
+		HTTPIdleTimeout = timeout
+	}
+
+	if maxheaderbytes, err := AppConfig.Int("HTTPMaxHeaderBytes"); err == nil {
+		HTTPMaxHeaderBytes = maxheaderbytes
+	}
+
+	if maxconns, err := AppConfig.Int("MaxConnections"); err == nil {
+		MaxConnections = maxconns
+	}
+
+	if maxconns, err := AppConfig.Int("MaxConnectionsPerIP"); err == nil {
+		MaxConnectionsPerIP = maxconns
+	}
+
+	if timeout, err := AppConfig.Int64("RequestTimeout"); err == nil {
+		RequestTimeout = timeout
+	}
+
+	if threshold, err := AppConfig.Int64("WatchdogThreshold"); err == nil {
+		WatchdogThreshold = threshold
+	}
+
+	if v, err := AppConfig.Int64("CoalesceMaxBytes"); err == nil {
+		CoalesceMaxBytes = v
+	}
+
+	if allocstats, err := AppConfig.Bool("EnableAllocStats"); err == nil {
+		EnableAllocStats = allocstats
+	}
+
 	if errorsshow, err := AppConfig.Bool("EnableErrorsShow"); err == nil {
 		EnableErrorsShow = errorsshow
 	}
 
+	if problemjson, err := AppConfig.Bool("EnableProblemJSON"); err == nil {
+		EnableProblemJSON = problemjson
+	}
+
 	if copyrequestbody, err := AppConfig.Bool("CopyRequestBody"); err == nil {
 		CopyRequestBody = copyrequestbody
 	}
@@ -471,6 +771,22 @@ func ParseConfig() (err error) {
 		XSRFExpire = expire
 	}
 
+	if jsonprefix := AppConfig.String("JSONPrefix"); jsonprefix != "" {
+		JSONPrefix = jsonprefix
+	}
+
+	if jsonescapehtml, err := AppConfig.Bool("JSONEscapeHTML"); err == nil {
+		JSONEscapeHTML = jsonescapehtml
+	}
+
+	if serveformat := AppConfig.String("ServeFormat"); serveformat != "" {
+		ServeFormat = serveformat
+	}
+
+	if jsonenvelope, err := AppConfig.Bool("JSONEnvelope"); err == nil {
+		JSONEnvelope = jsonenvelope
+	}
+
 	if tplleft := AppConfig.String("TemplateLeft"); tplleft != "" {
 		TemplateLeft = tplleft
 	}
@@ -507,6 +823,22 @@ func ParseConfig() (err error) {
 		FlashSeperator = flashseperator
 	}
 
+	if consentcookiename := AppConfig.String("ConsentCookieName"); consentcookiename != "" {
+		ConsentCookieName = consentcookiename
+	}
+
+	if consentsecret := AppConfig.String("ConsentSecret"); consentsecret != "" {
+		ConsentSecret = consentsecret
+	}
+
+	if consentexpire, err := AppConfig.Int("ConsentExpire"); err == nil {
+		ConsentExpire = consentexpire
+	}
+
+	if apidefaultversion := AppConfig.String("APIDefaultVersion"); apidefaultversion != "" {
+		APIDefaultVersion = apidefaultversion
+	}
+
 	if sd := AppConfig.String("StaticDir"); sd != "" {
 		for k := range StaticDir {
 			delete(StaticDir, k)
@@ -538,6 +870,34 @@ func ParseConfig() (err error) {
 		}
 	}
 
+	// StaticCacheControl = /static/js:public, max-age=31536000;/static:no-cache
+	if scc := AppConfig.String("StaticCacheControl"); scc != "" {
+		for k := range StaticCacheControl {
+			delete(StaticCacheControl, k)
+		}
+		for _, rule := range strings.Split(scc, ";") {
+			if prefix2cc := strings.SplitN(rule, ":", 2); len(prefix2cc) == 2 {
+				StaticCacheControl["/"+strings.Trim(strings.TrimSpace(prefix2cc[0]), "/")] = strings.TrimSpace(prefix2cc[1])
+			}
+		}
+	}
+
+	if v, err := AppConfig.Int64("StaticCacheMaxBytes"); err == nil {
+		StaticCacheMaxBytes = v
+	}
+
+	if v, err := AppConfig.Int64("StaticCacheFileMaxBytes"); err == nil {
+		StaticCacheFileMaxBytes = v
+	}
+
+	if v, err := AppConfig.Int64("StaticCacheTTL"); err == nil {
+		StaticCacheTTL = v
+	}
+
+	if rh := AppConfig.String("ResponseHeaders"); rh != "" {
+		ResponseHeaders = parseResponseHeaders(rh)
+	}
+
 	if enableadmin, err := AppConfig.Bool("EnableAdmin"); err == nil {
 		EnableAdmin = enableadmin
 	}
@@ -550,15 +910,141 @@ func ParseConfig() (err error) {
 		AdminHTTPPort = adminhttpport
 	}
 
+	if adminauthuser := AppConfig.String("AdminAuthUser"); adminauthuser != "" {
+		AdminAuthUser = adminauthuser
+	}
+
+	if adminauthpassword := AppConfig.String("AdminAuthPassword"); adminauthpassword != "" {
+		AdminAuthPassword = adminauthpassword
+	}
+
 	if enabledocs, err := AppConfig.Bool("EnableDocs"); err == nil {
 		EnableDocs = enabledocs
 	}
 
+	if enablechaos, err := AppConfig.Bool("EnableChaos"); err == nil {
+		EnableChaos = enablechaos
+	}
+
+	if assetintegrity, err := AppConfig.Bool("AssetIntegrity"); err == nil {
+		AssetIntegrity = assetintegrity
+	}
+
+	if enableopenapi, err := AppConfig.Bool("EnableOpenAPI"); err == nil {
+		EnableOpenAPI = enableopenapi
+	}
+
+	if openapipath := AppConfig.String("OpenAPIPath"); openapipath != "" {
+		OpenAPIPath = openapipath
+	}
+
+	if readinesspath := AppConfig.String("ReadinessPath"); readinesspath != "" {
+		ReadinessPath = readinesspath
+	}
+
+	if i18npath := AppConfig.String("I18nPath"); i18npath != "" {
+		I18nPath = i18npath
+	}
+
+	if locale := AppConfig.String("I18nDefaultLocale"); locale != "" {
+		I18nDefaultLocale = locale
+	}
+
+	if enableopenapiui, err := AppConfig.Bool("EnableOpenAPIUI"); err == nil {
+		EnableOpenAPIUI = enableopenapiui
+	}
+
 	if casesensitive, err := AppConfig.Bool("RouterCaseSensitive"); err == nil {
 		RouterCaseSensitive = casesensitive
 	}
 	if graceful, err := AppConfig.Bool("Graceful"); err == nil {
 		Graceful = graceful
 	}
+
+	if autotls, err := AppConfig.Bool("EnableAutoTLS"); err == nil {
+		EnableAutoTLS = autotls
+	}
+
+	if hosts := AppConfig.Strings("AutoTLSHosts"); len(hosts) > 0 && hosts[0] != "" {
+		AutoTLSHosts = hosts
+	}
+
+	if dir := AppConfig.String("AutoTLSCacheDir"); dir != "" {
+		AutoTLSCacheDir = dir
+	}
+
+	if email := AppConfig.String("AutoTLSEmail"); email != "" {
+		AutoTLSEmail = email
+	}
+
+	if clientca := AppConfig.String("HTTPSClientCAFile"); clientca != "" {
+		HTTPSClientCAFile = clientca
+	}
+
+	if ciphers := AppConfig.Strings("HTTPSCipherSuites"); len(ciphers) > 0 && ciphers[0] != "" {
+		HTTPSCipherSuites = ciphers
+	}
+
+	if http2, err := AppConfig.Bool("EnableHTTP2"); err == nil {
+		EnableHTTP2 = http2
+	}
+
+	if http3, err := AppConfig.Bool("EnableHTTP3"); err == nil {
+		EnableHTTP3 = http3
+	}
+
+	if http3port, err := AppConfig.Int("HTTP3Port"); err == nil {
+		HTTP3Port = http3port
+	}
+
+	if gracefultimeout, err := AppConfig.Int64("GracefulTimeout"); err == nil {
+		GracefulTimeout = gracefultimeout
+	}
+
+	if keys := AppConfig.Strings("LogRedactKeys"); len(keys) > 0 && keys[0] != "" {
+		LogRedactKeys = keys
+	}
+
+	if redactcards, err := AppConfig.Bool("LogRedactCardNumbers"); err == nil {
+		LogRedactCardNumbers = redactcards
+	}
+
+	if accesslogs, err := AppConfig.Bool("AccessLogs"); err == nil {
+		AccessLogs = accesslogs
+	}
+
+	if format := AppConfig.String("AccessLogsFormat"); format != "" {
+		AccessLogsFormat = format
+	}
+
+	if output := AppConfig.String("AccessLogsOutput"); output != "" {
+		AccessLogsOutput = output
+	}
+
+	if rate, err := AppConfig.Float("AccessLogsSampleRate"); err == nil {
+		AccessLogsSampleRate = rate
+	}
+
+	if slow, err := AppConfig.Int64("AccessLogsSlowThreshold"); err == nil {
+		AccessLogsSlowThreshold = slow
+	}
+
+	if prefixes := AppConfig.Strings("AccessLogsSkipPrefixes"); len(prefixes) > 0 && prefixes[0] != "" {
+		AccessLogsSkipPrefixes = prefixes
+	}
+
+	if sink, err := newAccessLogSink(AccessLogsFormat, AccessLogsOutput); err != nil {
+		return err
+	} else if sink != nil {
+		accessLogSink = sink
+	}
+
+	if len(LogRedactKeys) > 0 || LogRedactCardNumbers {
+		r := &logs.Redactor{Keys: LogRedactKeys, CardNumbers: LogRedactCardNumbers}
+		if err := r.Compile(); err != nil {
+			return err
+		}
+		BeeLogger.SetRedactor(r)
+	}
 	return nil
 }
@@ -15,7 +15,14 @@
 package beego
 
 import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"strings"
 	"testing"
+
+	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/session"
 )
 
 func TestDefaults(t *testing.T) {
@@ -27,3 +34,105 @@ func TestDefaults(t *testing.T) {
 		t.Errorf("FlashName was not set to default.")
 	}
 }
+
+func TestDefaultSessionConfig(t *testing.T) {
+	defer func(interval int64, batch int, alphabet string, perUser int) {
+		SessionGCInterval, SessionGCBatchSize, SessionIDAlphabet, SessionMaxPerUser = interval, batch, alphabet, perUser
+	}(SessionGCInterval, SessionGCBatchSize, SessionIDAlphabet, SessionMaxPerUser)
+	SessionGCInterval, SessionGCBatchSize, SessionIDAlphabet, SessionMaxPerUser = 60, 1000, "abcdef", 3
+
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(defaultSessionConfig()), &config); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]interface{}{
+		"gcInterval":         60.0,
+		"gcBatchSize":        1000.0,
+		"sessionIDAlphabet":  "abcdef",
+		"maxSessionsPerUser": 3.0,
+	} {
+		if config[k] != v {
+			t.Errorf("session config %s = %v, want %v", k, config[k], v)
+		}
+	}
+	if _, err := session.NewManager("memory", defaultSessionConfig()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	defer func(c *tls.Config, h2 bool, ciphers []string) {
+		TLSConfig, EnableHTTP2, HTTPSCipherSuites = c, h2, ciphers
+	}(TLSConfig, EnableHTTP2, HTTPSCipherSuites)
+
+	TLSConfig = &tls.Config{NextProtos: []string{"acme-tls/1"}, MinVersion: tls.VersionTLS12}
+	HTTPSCipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}
+	config, err := buildTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MinVersion != tls.VersionTLS12 || len(config.CipherSuites) != 1 {
+		t.Error("TLSConfig and HTTPSCipherSuites should be used")
+	}
+	if len(config.NextProtos) != 3 || config.NextProtos[0] != "h2" || config.NextProtos[2] != "http/1.1" {
+		t.Error("unexpected ALPN protocols:", config.NextProtos)
+	}
+	if TLSConfig.NextProtos[0] != "acme-tls/1" || len(TLSConfig.NextProtos) != 1 {
+		t.Error("TLSConfig should not be changed")
+	}
+
+	EnableHTTP2 = false
+	HTTPSCipherSuites = []string{"TLS_NOT_EXIST"}
+	if _, err := buildTLSConfig(); err == nil {
+		t.Error("unknown cipher suite should fail")
+	}
+}
+
+type fakeAutoTLSManager struct{}
+
+func (fakeAutoTLSManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return nil, nil
+}
+
+func (fakeAutoTLSManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/.well-known/acme-challenge/") {
+			w.Write([]byte("token"))
+			return
+		}
+		fallback.ServeHTTP(w, r)
+	})
+}
+
+func TestSetupAutoTLS(t *testing.T) {
+	defer func(f func([]string, string, string) (AutoTLSManager, error), hosts []string) {
+		NewAutoTLSManager, AutoTLSHosts = f, hosts
+	}(NewAutoTLSManager, AutoTLSHosts)
+
+	app := NewApp()
+	app.Server.TLSConfig = &tls.Config{}
+	if _, err := app.setupAutoTLS(app.Handlers); err == nil {
+		t.Error("setupAutoTLS should fail without AutoTLSManager")
+	}
+	NewAutoTLSManager = func(hosts []string, cacheDir, email string) (AutoTLSManager, error) {
+		return fakeAutoTLSManager{}, nil
+	}
+	AutoTLSHosts = []string{"example.com"}
+	app.Handlers.Get("/", func(ctx *context.Context) {
+		ctx.WriteString("index")
+	})
+	handler, err := app.setupAutoTLS(app.Handlers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Server.TLSConfig.GetCertificate == nil {
+		t.Error("GetCertificate should be set")
+	}
+	for url, body := range map[string]string{"/.well-known/acme-challenge/x": "token", "/": "index"} {
+		rw, r := testRequest("GET", url)
+		handler.ServeHTTP(rw, r)
+		if rw.Body.String() != body {
+			t.Errorf("%s should get %s, got %s", url, body, rw.Body.String())
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"net"
+	"sync"
+)
+
+// connLimiter limits the open connections in total and per client ip.
+type connLimiter struct {
+	max      int
+	maxPerIP int
+	mu       sync.Mutex
+	total    int
+	perIP    map[string]int
+}
+
+func newConnLimiter(max, maxPerIP int) *connLimiter {
+	return &connLimiter{max: max, maxPerIP: maxPerIP, perIP: make(map[string]int)}
+}
+
+func (l *connLimiter) acquire(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.max > 0 && l.total >= l.max {
+		return false
+	}
+	if l.maxPerIP > 0 && l.perIP[ip] >= l.maxPerIP {
+		return false
+	}
+	l.total++
+	l.perIP[ip]++
+	return true
+}
+
+func (l *connLimiter) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total--
+	if l.perIP[ip]--; l.perIP[ip] <= 0 {
+		delete(l.perIP, ip)
+	}
+}
+
+// wrap counts the connection until it's closed, the connections over the limits are closed at once.
+func (l *connLimiter) wrap(c net.Conn) net.Conn {
+	ip := c.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	if !l.acquire(ip) {
+		Debug("close the connection over the limits from", ip)
+		c.Close()
+		return closedConn{c}
+	}
+	return &limitedConn{Conn: c, limiter: l, ip: ip}
+}
+
+// limitedConn releases its slot of the connLimiter once closed.
+type limitedConn struct {
+	net.Conn
+	limiter *connLimiter
+	ip      string
+	once    sync.Once
+}
+
+func (c *limitedConn) Close() error {
+	c.once.Do(func() { c.limiter.release(c.ip) })
+	return c.Conn.Close()
+}
+
+// closedConn is a rejected connection, the server reads EOF from it and drops it.
+type closedConn struct {
+	net.Conn
+}
+
+func (closedConn) Read([]byte) (int, error) {
+	return 0, net.ErrClosed
+}
+
+// wrapListener wraps the connections accepted by l with wrap.
+type wrapListener struct {
+	net.Listener
+	wrap func(net.Conn) net.Conn
+}
+
+func (l wrapListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return l.wrap(c), nil
+}
+
+// chainConnWrappers returns the wrapper applying wraps in order, or nil if there's none.
+func chainConnWrappers(wraps ...func(net.Conn) net.Conn) func(net.Conn) net.Conn {
+	var chain []func(net.Conn) net.Conn
+	for _, w := range wraps {
+		if w != nil {
+			chain = append(chain, w)
+		}
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+	return func(c net.Conn) net.Conn {
+		for _, w := range chain {
+			c = w(c)
+		}
+		return c
+	}
+}
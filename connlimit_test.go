@@ -0,0 +1,99 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	gocontext "context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type addrConn struct {
+	net.Conn
+	addr   net.Addr
+	closed bool
+}
+
+func (c *addrConn) RemoteAddr() net.Addr { return c.addr }
+func (c *addrConn) Close() error         { c.closed = true; return nil }
+
+func TestConnLimiter(t *testing.T) {
+	l := newConnLimiter(3, 2)
+	conn := func(ip string) (*addrConn, net.Conn) {
+		c := &addrConn{addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 1234}}
+		return c, l.wrap(c)
+	}
+	a1, w1 := conn("10.0.0.1")
+	a2, _ := conn("10.0.0.1")
+	a3, _ := conn("10.0.0.1")
+	if a1.closed || a2.closed || !a3.closed {
+		t.Fatalf("want the third connection of the ip closed: %v %v %v", a1.closed, a2.closed, a3.closed)
+	}
+	b1, _ := conn("10.0.0.2")
+	c1, _ := conn("10.0.0.3")
+	if b1.closed || !c1.closed {
+		t.Fatalf("want the fourth connection closed: %v %v", b1.closed, c1.closed)
+	}
+	w1.Close()
+	w1.Close()
+	c2, _ := conn("10.0.0.3")
+	if c2.closed {
+		t.Fatal("the released slot isn't reused")
+	}
+	if l.total != 3 || l.perIP["10.0.0.1"] != 1 {
+		t.Fatalf("counts: %d %v", l.total, l.perIP)
+	}
+}
+
+func TestConnectionStats(t *testing.T) {
+	defer func(old *connectionStats, hooks []ConnStateHook) {
+		connStats, connStateHooks = old, hooks
+	}(connStats, connStateHooks)
+	connStats = &connectionStats{states: make(map[net.Conn]http.ConnState)}
+	var hooked []http.ConnState
+	AddConnStateHook(func(c net.Conn, state http.ConnState) {
+		hooked = append(hooked, state)
+	})
+
+	app := &App{Server: &http.Server{}}
+	app.setupConnState()
+	a, b := &addrConn{}, &addrConn{}
+	for _, s := range []struct {
+		c     net.Conn
+		state http.ConnState
+	}{
+		{a, http.StateNew}, {a, http.StateActive}, {a, http.StateIdle},
+		{b, http.StateNew}, {b, http.StateActive}, {b, http.StateHijacked},
+	} {
+		app.Server.ConnState(s.c, s.state)
+	}
+	stats := Connections()
+	if stats.Idle != 1 || stats.Active != 0 || stats.Accepted != 2 || stats.Hijacked != 1 || stats.Draining {
+		t.Fatalf("stats: %+v", stats)
+	}
+	if len(hooked) != 6 {
+		t.Fatalf("hook calls: %v", hooked)
+	}
+	app.Server.Shutdown(gocontext.Background())
+	// the shutdown hooks run in goroutines
+	for i := 0; i < 100 && !Connections().Draining; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !Connections().Draining {
+		t.Fatal("not draining after shutdown")
+	}
+}
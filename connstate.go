@@ -0,0 +1,136 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ConnStateHook is called when a connection of the http servers changes its state, see http.Server.ConnState.
+type ConnStateHook func(net.Conn, http.ConnState)
+
+var (
+	connStateHooks []ConnStateHook
+	connStats      = &connectionStats{states: make(map[net.Conn]http.ConnState)}
+)
+
+// AddConnStateHook adds the hook called on the state changes of the connections of the servers started by Run,
+// e.g. to export the connection metrics or to close the idle connections in the custom drain logic.
+// usage:
+//
+//	beego.AddConnStateHook(func(c net.Conn, state http.ConnState) {
+//		if state == http.StateIdle && beego.Connections().Draining {
+//			c.Close()
+//		}
+//	})
+func AddConnStateHook(hook ConnStateHook) *App {
+	connStateHooks = append(connStateHooks, hook)
+	return BeeApp
+}
+
+// ConnectionStats is the snapshot of the connections of the servers started by Run.
+type ConnectionStats struct {
+	New      int       // accepted, the first request isn't read yet
+	Active   int       // reading or serving a request
+	Idle     int       // waiting for the next keep-alive request
+	Accepted int64     // accepted since start
+	Hijacked int64     // taken over by the handlers since start, e.g. websocket
+	Closed   int64     // closed since start
+	Draining bool      // the servers are shutting down and wait for the active connections
+	DrainAt  time.Time // when the shutdown started
+}
+
+// Connections returns the statistics of the connections of the servers started by Run.
+func Connections() ConnectionStats {
+	return connStats.snapshot()
+}
+
+type connectionStats struct {
+	mu       sync.Mutex
+	states   map[net.Conn]http.ConnState
+	accepted int64
+	hijacked int64
+	closed   int64
+	drainAt  time.Time
+}
+
+func (s *connectionStats) track(c net.Conn, state http.ConnState) {
+	s.mu.Lock()
+	switch state {
+	case http.StateNew:
+		s.accepted++
+		s.states[c] = state
+	case http.StateClosed:
+		s.closed++
+		delete(s.states, c)
+	case http.StateHijacked:
+		// net/http doesn't report the hijacked connections closed
+		s.hijacked++
+		delete(s.states, c)
+	default:
+		s.states[c] = state
+	}
+	s.mu.Unlock()
+}
+
+func (s *connectionStats) snapshot() ConnectionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := ConnectionStats{
+		Accepted: s.accepted,
+		Hijacked: s.hijacked,
+		Closed:   s.closed,
+		Draining: !s.drainAt.IsZero(),
+		DrainAt:  s.drainAt,
+	}
+	for _, state := range s.states {
+		switch state {
+		case http.StateNew:
+			stats.New++
+		case http.StateActive:
+			stats.Active++
+		case http.StateIdle:
+			stats.Idle++
+		}
+	}
+	return stats
+}
+
+func (s *connectionStats) drain() {
+	s.mu.Lock()
+	if s.drainAt.IsZero() {
+		s.drainAt = time.Now()
+	}
+	s.mu.Unlock()
+}
+
+// setupConnState tracks the connection states of app.Server and calls the ConnStateHooks,
+// after the ConnState already set on app.Server.
+func (app *App) setupConnState() {
+	prev := app.Server.ConnState
+	app.Server.ConnState = func(c net.Conn, state http.ConnState) {
+		if prev != nil {
+			prev(c, state)
+		}
+		connStats.track(c, state)
+		for _, hook := range connStateHooks {
+			hook(c, state)
+		}
+	}
+	app.Server.RegisterOnShutdown(connStats.drain)
+}
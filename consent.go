@@ -0,0 +1,152 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/astaxie/beego/context"
+)
+
+// ConsentNecessary is the category of the cookies and scripts the site can't work without,
+// it's always consented.
+const ConsentNecessary = "necessary"
+
+// consentDataKey is the key of the Consent in the context data and the template data.
+const consentDataKey = "Consent"
+
+// Consent is the consent state of the visitor read from the signed consent cookie.
+type Consent struct {
+	// Given is whether the visitor has made a choice, the banner is shown until it is.
+	Given      bool
+	Categories map[string]bool
+}
+
+// Allowed returns whether the visitor consented to category.
+func (c *Consent) Allowed(category string) bool {
+	if category == ConsentNecessary {
+		return true
+	}
+	return c != nil && c.Categories[category]
+}
+
+// List returns the consented categories in order.
+func (c *Consent) List() []string {
+	if c == nil {
+		return nil
+	}
+	list := make([]string, 0, len(c.Categories))
+	for category, ok := range c.Categories {
+		if ok {
+			list = append(list, category)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// defaultXSRFKEY is the public default of XSRFKEY, anyone can sign a cookie with it.
+const defaultXSRFKEY = "beegoxsrf"
+
+var consentSecretWarning sync.Once
+
+// consentSecret returns the key signing the consent cookie, the ConsentSecret or else the XSRFKEY.
+// It's false if neither is set to a private key, the consent cookie isn't read nor written then.
+func consentSecret() (string, bool) {
+	secret := ConsentSecret
+	if secret == "" {
+		secret = XSRFKEY
+	}
+	if secret == "" || secret == defaultXSRFKEY {
+		consentSecretWarning.Do(func() {
+			Error("consent: the consent cookie is disabled, set ConsentSecret or XSRFKEY to a private key")
+		})
+		return "", false
+	}
+	return secret, true
+}
+
+// ReadConsent returns the consent of the request,
+// it's parsed from the consent cookie unless ConsentFilter or SaveConsent has already stored it on the context.
+// A missing or tampered cookie is an empty consent which isn't given.
+func ReadConsent(ctx *context.Context) *Consent {
+	if c, ok := ctx.Input.GetData(consentDataKey).(*Consent); ok {
+		return c
+	}
+	c := &Consent{Categories: make(map[string]bool)}
+	secret, ok := consentSecret()
+	if !ok {
+		return c
+	}
+	val, ok := ctx.GetSecureCookie(secret, ConsentCookieName)
+	if !ok {
+		return c
+	}
+	c.Given = true
+	for _, category := range strings.Split(val, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			c.Categories[category] = true
+		}
+	}
+	return c
+}
+
+// SaveConsent stores the categories the visitor consented to in the signed consent cookie,
+// the categories which aren't listed are refused.
+// The cookie is signed by the ConsentSecret, or the XSRFKEY if it isn't the default,
+// it isn't set without either, the consent only lasts for the request then.
+// usage:
+//
+//	beego.SaveConsent(this.Ctx, this.GetStrings("category")...)
+func SaveConsent(ctx *context.Context, categories ...string) *Consent {
+	c := &Consent{Given: true, Categories: make(map[string]bool, len(categories))}
+	for _, category := range categories {
+		if category = strings.TrimSpace(category); category != "" && !strings.Contains(category, ",") {
+			c.Categories[category] = true
+		}
+	}
+	if secret, ok := consentSecret(); ok {
+		ctx.SetSecureCookie(secret, ConsentCookieName, strings.Join(c.List(), ","), ConsentExpire, "/")
+	}
+	ctx.Input.SetData(consentDataKey, c)
+	return c
+}
+
+// ConsentFilter stores the consent of the request on the context,
+// the controllers get it by ReadConsent and the templates by .Consent.
+// usage:
+//
+//	beego.InsertFilter("*", beego.BeforeRouter, beego.ConsentFilter)
+func ConsentFilter(ctx *context.Context) {
+	ctx.Input.SetData(consentDataKey, ReadConsent(ctx))
+}
+
+// Consented returns whether the visitor consented to category, c is .Consent in the templates.
+// usage:
+//
+//	{{if consented .Consent "analytics"}}{{template "analytics.tpl"}}{{end}}
+func Consented(c *Consent, category string) bool {
+	return c.Allowed(category)
+}
+
+// ConsentGiven returns whether the visitor has made a choice, c is .Consent in the templates.
+// usage:
+//
+//	{{if not (consent_given .Consent)}}{{template "banner.tpl"}}{{end}}
+func ConsentGiven(c *Consent) bool {
+	return c != nil && c.Given
+}
@@ -0,0 +1,116 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/astaxie/beego/context"
+)
+
+func TestConsentCookie(t *testing.T) {
+	defer func(secret string) { ConsentSecret = secret }(ConsentSecret)
+	ConsentSecret = "consent-secret"
+	handler := NewControllerRegister()
+	handler.Post("/consent", func(ctx *context.Context) {
+		SaveConsent(ctx, "analytics", "ads,x", " ")
+	})
+	handler.InsertFilter("/page", BeforeRouter, ConsentFilter)
+	handler.Get("/page", func(ctx *context.Context) {
+		c := ReadConsent(ctx)
+		if c != ctx.Input.GetData("Consent") {
+			t.Error("the filter should store the consent on the context")
+		}
+		if !Consented(c, ConsentNecessary) {
+			t.Error("necessary should always be consented")
+		}
+		if ConsentGiven(c) {
+			ctx.WriteString("given:" + strings.Join(c.List(), ","))
+		}
+	})
+
+	r, _ := http.NewRequest("GET", "/page", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Body.String() != "" {
+		t.Errorf("no consent expected, got %q", w.Body.String())
+	}
+
+	r, _ = http.NewRequest("POST", "/consent", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	cookie := w.Header().Get("Set-Cookie")
+	if !strings.HasPrefix(cookie, ConsentCookieName+"=") {
+		t.Fatalf("consent cookie expected, got %q", cookie)
+	}
+	cookie = strings.SplitN(cookie, ";", 2)[0]
+
+	r, _ = http.NewRequest("GET", "/page", nil)
+	r.Header.Set("Cookie", cookie)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Body.String() != "given:analytics" {
+		t.Errorf("analytics consent expected, got %q", w.Body.String())
+	}
+
+	r, _ = http.NewRequest("GET", "/page", nil)
+	r.Header.Set("Cookie", cookie+"0")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Body.String() != "" {
+		t.Errorf("a tampered cookie should be ignored, got %q", w.Body.String())
+	}
+}
+
+func TestConsentDefaultSecret(t *testing.T) {
+	defer func(secret, key string) { ConsentSecret, XSRFKEY = secret, key }(ConsentSecret, XSRFKEY)
+	ConsentSecret, XSRFKEY = "", defaultXSRFKEY
+
+	handler := NewControllerRegister()
+	handler.Post("/consent", func(ctx *context.Context) {
+		if c := SaveConsent(ctx, "analytics"); !c.Allowed("analytics") {
+			t.Error("the consent should last for the request")
+		}
+	})
+	handler.Get("/page", func(ctx *context.Context) {
+		if ConsentGiven(ReadConsent(ctx)) {
+			ctx.WriteString("given")
+		}
+	})
+
+	r, _ := http.NewRequest("POST", "/consent", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if cookie := w.Header().Get("Set-Cookie"); cookie != "" {
+		t.Errorf("no cookie should be signed by the public default key, got %q", cookie)
+	}
+
+	// a cookie forged with the public default key is ignored
+	w = httptest.NewRecorder()
+	ctx := &context.Context{}
+	ctx.Reset(w, r)
+	ctx.SetSecureCookie(defaultXSRFKEY, ConsentCookieName, "analytics", ConsentExpire, "/")
+	cookie := strings.SplitN(w.Header().Get("Set-Cookie"), ";", 2)[0]
+	r, _ = http.NewRequest("GET", "/page", nil)
+	r.Header.Set("Cookie", cookie)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Body.String() != "" {
+		t.Errorf("the consent cookie should be ignored without a private key, got %q", w.Body.String())
+	}
+}
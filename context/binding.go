@@ -0,0 +1,316 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BindError is the error of a field which can't be bound by BindRequest.
+type BindError struct {
+	Field  string // the name of the parameter
+	Source string // "header", "cookie" or "path", empty for the form and the body
+	Reason string
+}
+
+func (e *BindError) Error() string {
+	if e.Source != "" {
+		return "beego: bind " + e.Source + " " + e.Field + ": " + e.Reason
+	}
+	return "beego: bind " + e.Field + ": " + e.Reason
+}
+
+// bindSources are the tags of the fields bound from the request apart from the form and the body.
+var bindSources = []string{"header", "cookie", "path"}
+
+// bindRequest binds the request into the struct pointed by dest for BindRequest.
+// The query and form parameters are bound to the fields by the "form" tag, or the field name,
+// with a time layout as the second tag option of the time.Time fields, e.g. `form:"birthday,2006-01-02"`.
+// Then the JSON, XML or YAML body, or the body of an Encoder added by RegisterEncoder,
+// is decoded into dest according to Content-Type.
+// The fields tagged with `header:"X-Api-Key"`, `cookie:"session"` or `path:"id"` are bound from
+// the request header, the cookie or the router param :id instead, after the body so it can't override them,
+// they are zero if the source is missing.
+// The fields tagged with `binding:"required"` or `required:"true"` must not be zero once bound.
+func (input *BeegoInput) bindRequest(dest interface{}) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return errors.New("beego: non-struct-pointer passed to BindRequest")
+	}
+
+	params := input.Request.Form
+	if params == nil {
+		params = input.Request.URL.Query()
+	}
+	if err := bindForm(params, value.Elem()); err != nil {
+		return err
+	}
+
+	contentType := input.Header("Content-Type")
+	switch {
+	case input.requestEncoder() != nil:
+		if body := input.requestBody(); len(body) > 0 {
+			if err := input.requestEncoder().Unmarshal(body, dest); err != nil {
+				return &BindError{Field: "body", Reason: err.Error()}
+			}
+		}
+	case strings.Contains(contentType, "json"):
+		if body := input.requestBody(); len(body) > 0 {
+			if err := json.Unmarshal(body, dest); err != nil {
+				return &BindError{Field: "body", Reason: err.Error()}
+			}
+		}
+	case strings.Contains(contentType, "yaml"):
+		if body := input.requestBody(); len(body) > 0 {
+			if YAMLUnmarshal == nil {
+				return &BindError{Field: "body", Reason: ErrYAMLDisabled.Error()}
+			}
+			if err := YAMLUnmarshal(body, dest); err != nil {
+				return &BindError{Field: "body", Reason: err.Error()}
+			}
+		}
+	case strings.Contains(contentType, "xml"):
+		if body := input.requestBody(); len(body) > 0 {
+			if err := xml.Unmarshal(body, dest); err != nil {
+				return &BindError{Field: "body", Reason: err.Error()}
+			}
+		}
+	}
+	if err := input.bindSources(value.Elem()); err != nil {
+		return err
+	}
+	return checkRequired(value.Elem())
+}
+
+// requestBody returns RequestBody, the body is copied first if CopyRequestBody is off.
+func (input *BeegoInput) requestBody() []byte {
+	if input.RequestBody == nil && input.Request.Body != nil {
+		input.CopyBody()
+	}
+	return input.RequestBody
+}
+
+// fieldName returns the parameter name of the field and its tag options.
+func fieldName(f reflect.StructField) (string, []string) {
+	tags := strings.Split(f.Tag.Get("form"), ",")
+	if tags[0] == "" {
+		return f.Name, tags[1:]
+	}
+	return tags[0], tags[1:]
+}
+
+// fieldSource returns the source tag and the name of the field bound from a header, a cookie or a router param.
+func fieldSource(f reflect.StructField) (string, string) {
+	for _, source := range bindSources {
+		if name := f.Tag.Get(source); name != "" {
+			return source, name
+		}
+	}
+	return "", ""
+}
+
+// bindSources binds the fields tagged with a header, a cookie or a router param.
+func (input *BeegoInput) bindSources(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fv := v.Field(i)
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			if err := input.bindSources(fv); err != nil {
+				return err
+			}
+			continue
+		}
+		source, name := fieldSource(f)
+		if source == "" || !fv.CanSet() {
+			continue
+		}
+		var vals []string
+		switch source {
+		case "header":
+			vals = input.Request.Header.Values(name)
+		case "cookie":
+			if ck, err := input.Request.Cookie(name); err == nil {
+				vals = []string{ck.Value}
+			}
+		case "path":
+			if val := input.Param(":" + strings.TrimPrefix(name, ":")); val != "" {
+				vals = []string{val}
+			}
+		}
+		// the field isn't left with a value of the body or the form
+		fv.Set(reflect.Zero(fv.Type()))
+		if len(vals) == 0 {
+			continue
+		}
+		_, opts := fieldName(f)
+		if err := setField(fv, vals, opts); err != nil {
+			return &BindError{Field: name, Source: source, Reason: err.Error()}
+		}
+	}
+	return nil
+}
+
+func bindForm(params url.Values, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fv := v.Field(i)
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			if err := bindForm(params, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if !fv.CanSet() {
+			continue
+		}
+		name, opts := fieldName(f)
+		if source, _ := fieldSource(f); name == "-" || source != "" {
+			continue
+		}
+		vals, ok := params[name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		if err := setField(fv, vals, opts); err != nil {
+			return &BindError{Field: name, Reason: err.Error()}
+		}
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// setField converts the parameter values to the type of the field.
+func setField(fv reflect.Value, vals []string, opts []string) error {
+	switch {
+	case fv.Kind() == reflect.Ptr:
+		p := reflect.New(fv.Type().Elem())
+		if err := setField(p.Elem(), vals, opts); err != nil {
+			return err
+		}
+		fv.Set(p)
+		return nil
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8:
+		s := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setField(s.Index(i), []string{val}, opts); err != nil {
+				return err
+			}
+		}
+		fv.Set(s)
+		return nil
+	}
+
+	val := vals[0]
+	if fv.Type() == timeType {
+		layout := time.RFC3339
+		if len(opts) > 0 && opts[0] != "" {
+			layout = opts[0]
+		}
+		t, err := time.ParseInLocation(layout, val, time.Local)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(val)
+	case reflect.Slice:
+		fv.SetBytes([]byte(val))
+	case reflect.Bool:
+		switch strings.ToLower(val) {
+		case "on", "yes":
+			fv.SetBool(true)
+		case "off", "no":
+			fv.SetBool(false)
+		default:
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("%q isn't a bool", val)
+			}
+			fv.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return err
+			}
+			fv.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(val, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q isn't an integer of %d bits", val, fv.Type().Bits())
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q isn't an unsigned integer of %d bits", val, fv.Type().Bits())
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q isn't a number", val)
+		}
+		fv.SetFloat(n)
+	case reflect.Interface:
+		fv.Set(reflect.ValueOf(val))
+	default:
+		return fmt.Errorf("can't bind to %s", fv.Type())
+	}
+	return nil
+}
+
+// checkRequired returns the BindError of the first required field which is zero.
+func checkRequired(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fv := v.Field(i)
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			if err := checkRequired(fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.Tag.Get("binding") != "required" && f.Tag.Get("required") != "true" || !fv.IsZero() {
+			continue
+		}
+		if source, name := fieldSource(f); source != "" {
+			return &BindError{Field: name, Source: source, Reason: "required"}
+		}
+		name, _ := fieldName(f)
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; f.Tag.Get("form") == "" && tag != "" && tag != "-" {
+			name = tag
+		}
+		return &BindError{Field: name, Reason: "required"}
+	}
+	return nil
+}
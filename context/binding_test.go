@@ -0,0 +1,129 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindUser struct {
+	Page     int       `form:"page"`
+	Tags     []string  `form:"tag"`
+	Name     string    `json:"name" xml:"name" binding:"required"`
+	Age      *uint8    `form:"age" json:"age" xml:"age"`
+	Birthday time.Time `form:"birthday,2006-01-02"`
+}
+
+func TestBindRequest(t *testing.T) {
+	bind := func(method, target, contentType, body string) (*bindUser, error) {
+		r, _ := http.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		input := NewInput(r)
+		input.ParseFormOrMulitForm(1 << 20)
+		u := &bindUser{}
+		return u, input.BindRequest(u)
+	}
+
+	u, err := bind("POST", "/?page=2&tag=a&tag=b", "application/json", `{"name":"astaxie","age":30}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Page != 2 || len(u.Tags) != 2 || u.Tags[1] != "b" || u.Name != "astaxie" || u.Age == nil || *u.Age != 30 {
+		t.Errorf("json: %+v", u)
+	}
+
+	u, err = bind("POST", "/", "application/x-www-form-urlencoded", "Name=astaxie&age=7&birthday=2015-06-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "astaxie" || *u.Age != 7 || u.Birthday.Format("2006-01-02") != "2015-06-01" {
+		t.Errorf("form: %+v", u)
+	}
+
+	u, err = bind("PUT", "/", "application/xml", `<user><name>astaxie</name><age>9</age></user>`)
+	if err != nil || u.Name != "astaxie" || *u.Age != 9 {
+		t.Errorf("xml: %+v %v", u, err)
+	}
+
+	if _, err = bind("POST", "/?page=2", "application/json", `{}`); err == nil || err.(*BindError).Field != "name" {
+		t.Errorf("want the required error of name, got %v", err)
+	}
+	if _, err = bind("POST", "/?page=x", "application/json", `{"name":"a"}`); err == nil || err.(*BindError).Field != "page" {
+		t.Errorf("want the conversion error of page, got %v", err)
+	}
+	if _, err = bind("POST", "/?age=300", "application/json", `{"name":"a"}`); err == nil || err.(*BindError).Field != "age" {
+		t.Errorf("want the overflow error of age, got %v", err)
+	}
+}
+
+type bindAPIRequest struct {
+	APIKey  string   `header:"X-Api-Key" required:"true"`
+	Langs   []string `header:"Accept-Language"`
+	Session string   `cookie:"session"`
+	ID      int      `path:"id" required:"true"`
+	Name    string   `json:"name"`
+}
+
+func TestBindSources(t *testing.T) {
+	bind := func(header map[string]string, id, body string) (*bindAPIRequest, error) {
+		r, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		input := NewInput(r)
+		if id != "" {
+			input.SetParam(":id", id)
+		}
+		req := &bindAPIRequest{}
+		return req, input.BindRequest(req)
+	}
+
+	req, err := bind(map[string]string{"X-Api-Key": "k", "Accept-Language": "zh-CN", "Cookie": "session=s1"}, "5", `{"name":"astaxie"}`)
+	if err != nil || req.APIKey != "k" || len(req.Langs) != 1 || req.Session != "s1" || req.ID != 5 || req.Name != "astaxie" {
+		t.Errorf("got %+v %v", req, err)
+	}
+
+	// the body can't fill the fields of the header
+	_, err = bind(nil, "5", `{"APIKey":"forged"}`)
+	if be, ok := err.(*BindError); !ok || be.Source != "header" || be.Field != "X-Api-Key" || err.Error() != "beego: bind header X-Api-Key: required" {
+		t.Errorf("want the required error of the header, got %v", err)
+	}
+	if _, err = bind(map[string]string{"X-Api-Key": "k"}, "x", `{}`); err == nil || err.(*BindError).Source != "path" {
+		t.Errorf("want the conversion error of the path, got %v", err)
+	}
+}
+
+func TestBindYAML(t *testing.T) {
+	defer func() { YAMLUnmarshal = nil }()
+	bind := func() (*bindUser, error) {
+		r, _ := http.NewRequest("PUT", "/", strings.NewReader(`{"name":"astaxie"}`))
+		r.Header.Set("Content-Type", "application/x-yaml")
+		u := &bindUser{}
+		return u, NewInput(r).BindRequest(u)
+	}
+	if _, err := bind(); err == nil {
+		t.Error("yaml body should fail while yaml is disabled")
+	}
+	// a json object is a yaml document
+	YAMLUnmarshal = json.Unmarshal
+	if u, err := bind(); err != nil || u.Name != "astaxie" {
+		t.Errorf("yaml: %+v %v", u, err)
+	}
+}
@@ -43,6 +43,9 @@ type Context struct {
 	Request        *http.Request
 	ResponseWriter http.ResponseWriter
 	_xsrfToken     string
+	workers        *workerGroup
+	principal      Principal
+	retained       bool
 }
 
 // Redirect does redirection to localurl with http header status code.
@@ -52,10 +55,16 @@ func (ctx *Context) Redirect(status int, localurl string) {
 	ctx.ResponseWriter.WriteHeader(status)
 }
 
+// AbortHandler writes the error response of Abort if it returns true, otherwise only the status is sent.
+// beego sets it to write the problem+json responses when EnableProblemJSON is on.
+var AbortHandler func(ctx *Context, status int, body string) bool
+
 // Abort stops this request.
 // if beego.ErrorMaps exists, panic body.
 func (ctx *Context) Abort(status int, body string) {
-	ctx.ResponseWriter.WriteHeader(status)
+	if AbortHandler == nil || !AbortHandler(ctx, status, body) {
+		ctx.ResponseWriter.WriteHeader(status)
+	}
 	panic(body)
 }
 
@@ -65,6 +74,17 @@ func (ctx *Context) WriteString(content string) {
 	ctx.ResponseWriter.Write([]byte(content))
 }
 
+// Written returns whether the response status is already sent.
+// It's always false if the ResponseWriter doesn't implement Written() bool.
+func (ctx *Context) Written() bool {
+	if w, ok := ctx.ResponseWriter.(interface {
+		Written() bool
+	}); ok {
+		return w.Written()
+	}
+	return false
+}
+
 // GetCookie Get cookie from request by a given key.
 // It's alias of BeegoInput.Cookie.
 func (ctx *Context) GetCookie(key string) string {
@@ -0,0 +1,76 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"context"
+	"time"
+)
+
+// Context returns the context.Context of the request.
+// It's canceled when the client goes away or the deadline set by WithTimeout is exceeded,
+// pass it to the database and rpc calls to stop them with the request.
+// usage:
+//	rows, err := db.QueryContext(ctx.Context(), "SELECT ...")
+func (ctx *Context) Context() context.Context {
+	if ctx.Request == nil {
+		return context.Background()
+	}
+	return ctx.Request.Context()
+}
+
+// WithValue stores the value in the request context,
+// the filters and controllers running after it see the value by ctx.Value(key).
+func (ctx *Context) WithValue(key, val interface{}) {
+	ctx.SetContext(context.WithValue(ctx.Context(), key, val))
+}
+
+// Value returns the value stored in the request context by WithValue or the http server.
+func (ctx *Context) Value(key interface{}) interface{} {
+	return ctx.Context().Value(key)
+}
+
+// WithTimeout sets the deadline of the request context to now+d.
+// call the returned CancelFunc to release the resources once the work is done.
+// usage:
+//	defer ctx.WithTimeout(3 * time.Second)()
+func (ctx *Context) WithTimeout(d time.Duration) context.CancelFunc {
+	return ctx.WithDeadline(time.Now().Add(d))
+}
+
+// WithDeadline sets the deadline of the request context.
+// call the returned CancelFunc to release the resources once the work is done.
+func (ctx *Context) WithDeadline(t time.Time) context.CancelFunc {
+	c, cancel := context.WithDeadline(ctx.Context(), t)
+	ctx.SetContext(c)
+	return cancel
+}
+
+// Canceled returns whether the request context is done,
+// the router skips the rest of filters and controller when the client is gone.
+func (ctx *Context) Canceled() bool {
+	return ctx.Context().Err() != nil
+}
+
+// SetContext replaces the request context, e.g. by a context derived from ctx.Context().
+func (ctx *Context) SetContext(c context.Context) {
+	if ctx.Request == nil {
+		return
+	}
+	ctx.Request = ctx.Request.WithContext(c)
+	if ctx.Input != nil {
+		ctx.Input.Request = ctx.Request
+	}
+}
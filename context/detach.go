@@ -0,0 +1,80 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"net/http"
+)
+
+// RequestIDHeader is the request header which carries the trace id of a request.
+var RequestIDHeader = "X-Request-Id"
+
+// DetachedContext is a copy of the request data of a Context.
+// It stays valid after the request finished, so it's safe to pass it to spawned goroutines
+// instead of the live Context whose request and response are reused.
+type DetachedContext struct {
+	Method    string
+	URI       string
+	Path      string
+	Host      string
+	IP        string
+	UserAgent string
+	RequestID string
+	Header    http.Header
+	Params    map[string]string
+	Data      map[interface{}]interface{}
+}
+
+// Detach returns a copy of the request data of this context.
+// Params, Header and Data are copied, the values stored in Data are shared.
+// usage:
+//	d := ctx.Detach()
+//	go func() {
+//		audit(d.Param(":id"), d.GetData("user"))
+//	}()
+func (ctx *Context) Detach() *DetachedContext {
+	d := &DetachedContext{
+		Method:    ctx.Input.Method(),
+		URI:       ctx.Input.URI(),
+		Path:      ctx.Input.URL(),
+		Host:      ctx.Input.Host(),
+		IP:        ctx.Input.IP(),
+		UserAgent: ctx.Input.UserAgent(),
+		RequestID: ctx.Input.Header(RequestIDHeader),
+		Header:    make(http.Header, len(ctx.Request.Header)),
+		Params:    make(map[string]string, len(ctx.Input.Params)),
+		Data:      make(map[interface{}]interface{}, len(ctx.Input.Data)),
+	}
+	for k, v := range ctx.Request.Header {
+		d.Header[k] = append([]string(nil), v...)
+	}
+	for k, v := range ctx.Input.Params {
+		d.Params[k] = v
+	}
+	for k, v := range ctx.Input.Data {
+		d.Data[k] = v
+	}
+	return d
+}
+
+// Param returns the copied router param by a given key.
+func (d *DetachedContext) Param(key string) string {
+	return d.Params[key]
+}
+
+// GetData returns the copied value stored in the context data by a given key.
+func (d *DetachedContext) GetData(key interface{}) interface{} {
+	return d.Data[key]
+}
@@ -0,0 +1,95 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Encoder encodes the response bodies and decodes the request bodies of a media type,
+// such as msgpack or protobuf.
+type Encoder interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	encoderLock sync.RWMutex
+	encoders    = make(map[string]encoderEntry)
+)
+
+// RegisterEncoder adds the Encoder of the media types, the first one is the Content-Type of its responses.
+// ctx.Output.Serve chooses it by the Accept header and ctx.Input.BindRequest decodes the request bodies by Content-Type.
+// usage:
+//
+//	context.RegisterEncoder(msgpackEncoder{}, "application/msgpack", "application/x-msgpack")
+func RegisterEncoder(enc Encoder, mediaTypes ...string) {
+	encoderLock.Lock()
+	defer encoderLock.Unlock()
+	for _, mediaType := range mediaTypes {
+		encoders[strings.ToLower(mediaType)] = encoderEntry{enc, mediaTypes[0]}
+	}
+}
+
+// encoderEntry keeps the Content-Type of the aliases of an encoder.
+type encoderEntry struct {
+	Encoder
+	contentType string
+}
+
+// lookupEncoder returns the encoder of the media type, nil if there isn't one.
+func lookupEncoder(mediaType string) *encoderEntry {
+	encoderLock.RLock()
+	defer encoderLock.RUnlock()
+	if e, ok := encoders[strings.ToLower(mediaType)]; ok {
+		return &e
+	}
+	return nil
+}
+
+// Encode writes data encoded by the Encoder registered for the media type.
+func (output *BeegoOutput) Encode(mediaType string, data interface{}) error {
+	e := lookupEncoder(mediaType)
+	if e == nil {
+		err := errors.New("beego: no encoder for " + mediaType)
+		http.Error(output.Context.ResponseWriter, err.Error(), http.StatusNotAcceptable)
+		return err
+	}
+	data, err := output.transform(data)
+	if err != nil {
+		return err
+	}
+	content, err := e.Marshal(data)
+	if err != nil {
+		http.Error(output.Context.ResponseWriter, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+	output.Header("Content-Type", e.contentType)
+	output.Body(content)
+	return nil
+}
+
+// requestEncoder returns the encoder of the Content-Type of the request.
+func (input *BeegoInput) requestEncoder() *encoderEntry {
+	mediaType, _, err := mime.ParseMediaType(input.Header("Content-Type"))
+	if err != nil {
+		return nil
+	}
+	return lookupEncoder(mediaType)
+}
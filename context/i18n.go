@@ -0,0 +1,68 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import "net/http"
+
+// Translator negotiates the locale of the requests and translates the messages, e.g. an i18n.Bundle.
+type Translator interface {
+	Negotiate(r *http.Request) string
+	Tr(locale, key string, args ...interface{}) string
+}
+
+// DefaultTranslator translates the messages of Tr, it's set by beego.SetI18n.
+var DefaultTranslator Translator
+
+const (
+	localeDataKey      = "beego.locale"
+	routeLocaleDataKey = "Locale" // the data key of beego.RouteLocale
+	langDataKey        = "Lang"   // the template data key of the locale set by beego.Controller
+)
+
+// Locale returns the locale of the request: the one set by SetLocale, the locale of the translated route,
+// then the one negotiated by DefaultTranslator. It's empty if there's no translator.
+func (ctx *Context) Locale() string {
+	if l, _ := ctx.Input.GetData(localeDataKey).(string); l != "" {
+		return l
+	}
+	l, _ := ctx.Input.GetData(routeLocaleDataKey).(string)
+	if l == "" && DefaultTranslator != nil && ctx.Request != nil {
+		l = DefaultTranslator.Negotiate(ctx.Request)
+	}
+	if l != "" {
+		ctx.Input.SetData(localeDataKey, l)
+	}
+	return l
+}
+
+// SetLocale sets the locale of the request, e.g. the one of the user profile,
+// .Lang of the templates is updated too.
+func (ctx *Context) SetLocale(locale string) {
+	ctx.Input.SetData(localeDataKey, locale)
+	if DefaultTranslator != nil {
+		ctx.Input.SetData(langDataKey, locale)
+	}
+}
+
+// Tr translates the message key to the locale of the request by DefaultTranslator, the key is returned if there's none.
+// usage:
+//
+//	ctx.WriteString(ctx.Tr("inbox", 3))
+func (ctx *Context) Tr(key string, args ...interface{}) string {
+	if DefaultTranslator == nil {
+		return key
+	}
+	return DefaultTranslator.Tr(ctx.Locale(), key, args...)
+}
@@ -24,6 +24,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/astaxie/beego/session"
 )
@@ -46,6 +47,13 @@ type BeegoInput struct {
 	RequestBody   []byte
 	RunController reflect.Type
 	RunMethod     string
+	RouterPattern string // the pattern of the matched router
+	// Transform modifies the struct bound by BindRequest before it returns,
+	// the router sets it from the WithRequestTransformer options of the matched router.
+	Transform func(dest interface{}) error
+	// the router params set by SetParam in the order of the pattern
+	paramKeys   []string
+	paramValues []string
 }
 
 // NewInput return BeegoInput generated by http.Request.
@@ -250,6 +258,7 @@ func (input *BeegoInput) UserAgent() string {
 }
 
 // Param returns router param by a given key.
+// It's read from the Params map, so the values written into the map by the filters are seen.
 func (input *BeegoInput) Param(key string) string {
 	if v, ok := input.Params[key]; ok {
 		return v
@@ -257,6 +266,57 @@ func (input *BeegoInput) Param(key string) string {
 	return ""
 }
 
+// SetParam sets the router param, it's stored in Params and in the ordered slice of ParamAt.
+func (input *BeegoInput) SetParam(key, val string) {
+	if input.Params == nil {
+		input.Params = make(map[string]string)
+	}
+	input.Params[key] = val
+	for i, k := range input.paramKeys {
+		if k == key {
+			input.paramValues[i] = val
+			return
+		}
+	}
+	input.paramKeys = append(input.paramKeys, key)
+	input.paramValues = append(input.paramValues, val)
+}
+
+// ParamsLen returns the number of the params set by SetParam.
+func (input *BeegoInput) ParamsLen() int {
+	return len(input.paramKeys)
+}
+
+// ParamAt returns the key and the value of the i-th param set by SetParam, i is less than ParamsLen.
+// The params are iterated in the order they are set, the value written into Params wins.
+func (input *BeegoInput) ParamAt(i int) (key, val string) {
+	key = input.paramKeys[i]
+	if v, ok := input.Params[key]; ok {
+		return key, v
+	}
+	return key, input.paramValues[i]
+}
+
+// ParamInt returns the router param as int, e.g. of the pattern "/user/:id:int".
+func (input *BeegoInput) ParamInt(key string) (int, error) {
+	return strconv.Atoi(input.Param(key))
+}
+
+// ParamInt64 returns the router param as int64.
+func (input *BeegoInput) ParamInt64(key string) (int64, error) {
+	return strconv.ParseInt(input.Param(key), 10, 64)
+}
+
+// ParamTime returns the router param as time.Time, e.g. of the pattern "/date/:d:datetime".
+// The value is 2006-01-02 or RFC3339.
+func (input *BeegoInput) ParamTime(key string) (time.Time, error) {
+	v := input.Param(key)
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
 // Query returns input data item string by a given string.
 func (input *BeegoInput) Query(key string) string {
 	if val := input.Param(key); val != "" {
@@ -352,6 +412,28 @@ func (input *BeegoInput) Bind(dest interface{}, key string) error {
 	return nil
 }
 
+// BindRequest binds the whole request into the struct pointed by dest:
+// the query and form parameters by the "form" tag, then the JSON or XML body according to Content-Type,
+// the fields tagged `binding:"required"` must be set, else a *BindError is returned.
+//
+//	var user struct {
+//		Page  int    `form:"page"`
+//		Name  string `json:"name" binding:"required"`
+//		Email string `json:"email"`
+//	}
+//	if err := ctx.Input.BindRequest(&user); err != nil {
+//		ctx.Abort(400, err.Error())
+//	}
+func (input *BeegoInput) BindRequest(dest interface{}) error {
+	if err := input.bindRequest(dest); err != nil {
+		return err
+	}
+	if input.Transform != nil {
+		return input.Transform(dest)
+	}
+	return nil
+}
+
 func (input *BeegoInput) bind(key string, typ reflect.Type) reflect.Value {
 	rv := reflect.Zero(typ)
 	switch typ.Kind() {
@@ -112,3 +112,28 @@ func TestSubDomain(t *testing.T) {
 		t.Fatal("Subdomain parse error, got " + beegoInput.SubDomains())
 	}
 }
+
+func TestInputSetParam(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/user/1", nil)
+	input := NewInput(r)
+	input.SetParam(":id", "1")
+	input.SetParam(":name", "astaxie")
+	input.SetParam(":id", "2")
+	if input.ParamsLen() != 2 || input.Param(":id") != "2" || input.Params[":id"] != "2" {
+		t.Fatal("the params aren't set:", input.Params)
+	}
+	if k, v := input.ParamAt(1); k != ":name" || v != "astaxie" {
+		t.Error("unexpected second param:", k, v)
+	}
+	input.Params[":legacy"] = "map"
+	if input.Param(":legacy") != "map" || input.Param(":missing") != "" {
+		t.Error("Param should fall back to the Params map")
+	}
+	input.Params[":name"] = "slene"
+	if k, v := input.ParamAt(1); input.Param(":name") != "slene" || v != "slene" {
+		t.Error("the value written into Params should win:", input.Param(":name"), k, v)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { input.Param(":name") }); allocs != 0 {
+		t.Errorf("Param allocates %v times", allocs)
+	}
+}
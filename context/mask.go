@@ -0,0 +1,238 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// MaskText replaces the string fields masked with the obfuscate option.
+var MaskText = "******"
+
+// maskRule is the parsed `mask` tag of a field.
+// `mask:"role=admin|support"` means only the principals with one of the roles see the field,
+// it's the zero value for the others, add omitempty to the json or xml tag to omit it.
+// `mask:"role=admin,obfuscate"` replaces a string by MaskText instead.
+type maskRule struct {
+	index     int
+	roles     []string
+	obfuscate bool
+}
+
+func parseMaskRule(tag string) (maskRule, bool) {
+	var r maskRule
+	if tag == "" {
+		return r, false
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case strings.HasPrefix(opt, "role="):
+			r.roles = append(r.roles, strings.Split(opt[len("role="):], "|")...)
+		case opt == "obfuscate":
+			r.obfuscate = true
+		}
+	}
+	return r, true
+}
+
+// allows returns whether the principal sees the field.
+func (r maskRule) allows(p Principal) bool {
+	if p == nil {
+		return false
+	}
+	for _, role := range r.roles {
+		if p.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r maskRule) apply(v reflect.Value) reflect.Value {
+	if r.obfuscate && v.Kind() == reflect.String {
+		s := reflect.New(v.Type()).Elem()
+		s.SetString(MaskText)
+		return s
+	}
+	return reflect.Zero(v.Type())
+}
+
+// maskInfo is what the masking needs to know of a type.
+type maskInfo struct {
+	rules  []maskRule // the masked fields of a struct
+	nested bool       // the values of the type may contain masked fields
+}
+
+var maskInfos sync.Map // reflect.Type -> *maskInfo
+
+func maskInfoOf(t reflect.Type) *maskInfo {
+	if info, ok := maskInfos.Load(t); ok {
+		return info.(*maskInfo)
+	}
+	info := buildMaskInfo(t, make(map[reflect.Type]*maskInfo))
+	maskInfos.Store(t, info)
+	return info
+}
+
+// buildMaskInfo inspects t, seen breaks the recursive types.
+func buildMaskInfo(t reflect.Type, seen map[reflect.Type]*maskInfo) *maskInfo {
+	if info, ok := seen[t]; ok {
+		return info
+	}
+	info := &maskInfo{}
+	seen[t] = info
+	switch t.Kind() {
+	case reflect.Interface:
+		// decided by the dynamic value
+		info.nested = true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		info.nested = buildMaskInfo(t.Elem(), seen).nested
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if r, ok := parseMaskRule(f.Tag.Get("mask")); ok {
+				r.index = i
+				info.rules = append(info.rules, r)
+				info.nested = true
+			} else if buildMaskInfo(f.Type, seen).nested {
+				info.nested = true
+			}
+		}
+	}
+	return info
+}
+
+// maskData returns data with the fields tagged by `mask` hidden from the principal.
+// The values are copied where a field is masked, data itself is never modified.
+func maskData(data interface{}, p Principal) interface{} {
+	if data == nil {
+		return nil
+	}
+	if v, ok := maskValue(reflect.ValueOf(data), p); ok {
+		return v.Interface()
+	}
+	return data
+}
+
+// maskValue returns the masked copy of v and true, or v and false if nothing is masked.
+func maskValue(v reflect.Value, p Principal) (reflect.Value, bool) {
+	info := maskInfoOf(v.Type())
+	if !info.nested {
+		return v, false
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		m, ok := maskValue(v.Elem(), p)
+		if !ok {
+			return v, false
+		}
+		n := reflect.New(v.Type()).Elem()
+		n.Set(m)
+		return n, true
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, false
+		}
+		m, ok := maskValue(v.Elem(), p)
+		if !ok {
+			return v, false
+		}
+		n := reflect.New(v.Type().Elem())
+		n.Elem().Set(m)
+		return n, true
+	case reflect.Struct:
+		var n reflect.Value
+		copied := false
+		field := func(i int, fv reflect.Value) {
+			if !copied {
+				n = reflect.New(v.Type()).Elem()
+				n.Set(v)
+				copied = true
+			}
+			n.Field(i).Set(fv)
+		}
+		masked := make(map[int]bool, len(info.rules))
+		for _, r := range info.rules {
+			masked[r.index] = true
+			if !r.allows(p) {
+				field(r.index, r.apply(v.Field(r.index)))
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if masked[i] || v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			if fv, ok := maskValue(v.Field(i), p); ok {
+				field(i, fv)
+			}
+		}
+		return n, copied
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v, false
+		}
+		var n reflect.Value
+		copied := false
+		for i := 0; i < v.Len(); i++ {
+			ev, ok := maskValue(v.Index(i), p)
+			if !ok {
+				continue
+			}
+			if !copied {
+				if v.Kind() == reflect.Slice {
+					n = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+				} else {
+					n = reflect.New(v.Type()).Elem()
+				}
+				reflect.Copy(n, v)
+				copied = true
+			}
+			n.Index(i).Set(ev)
+		}
+		return n, copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v, false
+		}
+		var n reflect.Value
+		copied := false
+		iter := v.MapRange()
+		for iter.Next() {
+			ev, ok := maskValue(iter.Value(), p)
+			if !ok {
+				continue
+			}
+			if !copied {
+				n = reflect.MakeMapWithSize(v.Type(), v.Len())
+				for _, k := range v.MapKeys() {
+					n.SetMapIndex(k, v.MapIndex(k))
+				}
+				copied = true
+			}
+			n.SetMapIndex(iter.Key(), ev)
+		}
+		return n, copied
+	}
+	return v, false
+}
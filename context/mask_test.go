@@ -0,0 +1,75 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"testing"
+)
+
+type maskAccount struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty" mask:"role=admin|support"`
+	Card  string `json:"card" mask:"role=admin,obfuscate"`
+}
+
+type maskOrder struct {
+	ID       int                    `json:"id"`
+	Owner    *maskAccount           `json:"owner"`
+	Accounts []maskAccount          `json:"accounts"`
+	Extra    map[string]interface{} `json:"extra"`
+}
+
+func TestMaskData(t *testing.T) {
+	acc := maskAccount{Name: "astaxie", Email: "a@b.c", Card: "4242"}
+	order := &maskOrder{ID: 1, Owner: &acc, Accounts: []maskAccount{acc}, Extra: map[string]interface{}{"acc": acc, "n": 1}}
+
+	m := maskData(order, nil).(*maskOrder)
+	if m.Owner.Email != "" || m.Owner.Card != MaskText || m.Owner.Name != "astaxie" {
+		t.Errorf("anonymous owner: %+v", m.Owner)
+	}
+	if m.Accounts[0].Email != "" || m.Extra["acc"].(maskAccount).Email != "" || m.Extra["n"] != 1 {
+		t.Errorf("anonymous nested: %+v %+v", m.Accounts, m.Extra)
+	}
+	if order.Owner.Email != "a@b.c" || order.Accounts[0].Card != "4242" || order.Extra["acc"].(maskAccount).Email != "a@b.c" {
+		t.Error("the original data should not be modified")
+	}
+
+	m = maskData(order, NewPrincipal("bob", "support")).(*maskOrder)
+	if m.Owner.Email != "a@b.c" || m.Owner.Card != MaskText {
+		t.Errorf("support owner: %+v", m.Owner)
+	}
+	if m := maskData(order, NewPrincipal("root", "admin")); m != interface{}(order) {
+		t.Error("nothing masked for admin, data should be returned as is")
+	}
+
+	plain := map[string]int{"a": 1}
+	if maskData(plain, nil).(map[string]int)["a"] != 1 {
+		t.Error("data without mask tags")
+	}
+}
+
+func TestOutputMask(t *testing.T) {
+	ctx, w := newTestContext("/")
+	ctx.Output.JSON(maskAccount{Name: "astaxie", Email: "a@b.c", Card: "4242"}, false, false)
+	if w.Body.String() != `{"name":"astaxie","card":"******"}` {
+		t.Errorf("anonymous: %s", w.Body.String())
+	}
+	ctx, w = newTestContext("/")
+	ctx.SetPrincipal(NewPrincipal("root", "admin"))
+	ctx.Output.JSON(maskAccount{Name: "astaxie", Email: "a@b.c", Card: "4242"}, false, false)
+	if w.Body.String() != `{"name":"astaxie","email":"a@b.c","card":"4242"}` {
+		t.Errorf("admin: %s", w.Body.String())
+	}
+}
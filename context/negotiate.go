@@ -0,0 +1,124 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Serve writes data in the format chosen by the Accept header of the request:
+// json, xml, yaml, jsonp for the javascript types with a "callback" parameter,
+// or the media type of an Encoder added by RegisterEncoder, the encoders are preferred to the built-in formats.
+// The requests without Accept, or accepting any type, get ServeFormat, json by default,
+// and the body is indented if ServeIndent is set.
+// usage:
+//
+//	ctx.Output.Serve(users)
+func (output *BeegoOutput) Serve(data interface{}) error {
+	output.Context.ResponseWriter.Header().Add("Vary", "Accept")
+	switch format := output.negotiate(); format {
+	case "json":
+		return output.JSON(data, output.ServeIndent, false)
+	case "xml":
+		return output.XML(data, output.ServeIndent)
+	case "yaml":
+		return output.YAML(data)
+	case "jsonp":
+		return output.JSONP(data, output.ServeIndent)
+	default:
+		return output.Encode(format, data)
+	}
+}
+
+// serveFormats maps the media types to the formats of Serve.
+var serveFormats = map[string]string{
+	"application/json":       "json",
+	"text/json":              "json",
+	"application/xml":        "xml",
+	"text/xml":               "xml",
+	"application/x-yaml":     "yaml",
+	"application/yaml":       "yaml",
+	"text/yaml":              "yaml",
+	"application/javascript": "jsonp",
+	"text/javascript":        "jsonp",
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept returns the media ranges of the Accept header by preference, q=0 ranges are dropped.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		r := acceptRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if q, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					r.q = q
+				}
+			}
+		}
+		if r.mediaType != "" && r.q > 0 {
+			ranges = append(ranges, r)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges
+}
+
+// negotiate returns the format of Serve for the request.
+func (output *BeegoOutput) negotiate() string {
+	def := output.ServeFormat
+	switch {
+	case def == "", def == "yaml" && YAMLMarshal == nil:
+		def = "json"
+	case strings.Contains(def, "/") && lookupEncoder(def) == nil:
+		def = "json"
+	}
+	accept := output.Context.Input.Header("Accept")
+	if accept == "" {
+		return def
+	}
+	for _, r := range parseAccept(accept) {
+		if r.mediaType == "*/*" || r.mediaType == "application/*" || r.mediaType == "text/*" {
+			return def
+		}
+		if lookupEncoder(r.mediaType) != nil {
+			return r.mediaType
+		}
+		switch format := serveFormats[r.mediaType]; format {
+		case "":
+		case "yaml":
+			if YAMLMarshal != nil {
+				return format
+			}
+		case "jsonp":
+			if output.Context.Input.Query("callback") != "" {
+				return format
+			}
+		default:
+			return format
+		}
+	}
+	return def
+}
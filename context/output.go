@@ -27,16 +27,54 @@ import (
 	"mime"
 	"net/http"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// jsonpCallbackRegex only accepts plain javascript identifiers and dotted paths
+// such as "cb" or "jQuery1.handler" as jsonp callback names.
+var jsonpCallbackRegex = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+// JSONEncoder marshals values into json for the response body.
+// It matches the Marshal and MarshalIndent functions of encoding/json,
+// so compatible encoders such as jsoniter can be plugged in.
+type JSONEncoder interface {
+	Marshal(v interface{}) ([]byte, error)
+	MarshalIndent(v interface{}, prefix, indent string) ([]byte, error)
+}
+
+// DefaultJSONEncoder is used by outputs which don't set their own JSONEncoder.
+// nil means encoding/json.
+var DefaultJSONEncoder JSONEncoder
+
 // BeegoOutput does work for sending response header.
 type BeegoOutput struct {
 	Context    *Context
 	Status     int
 	EnableGzip bool
+	// JSONPrefix is written before every json body, such as ")]}',\n" for XSSI protection.
+	JSONPrefix string
+	// JSONNoEscapeHTML disables escaping of <, > and & in json strings.
+	// It's only used by the encoding/json encoder.
+	JSONNoEscapeHTML bool
+	// JSONEncoder overrides DefaultJSONEncoder for this response.
+	JSONEncoder JSONEncoder
+	// ServeFormat is the format of Serve when the Accept header doesn't choose one, default is json.
+	ServeFormat string
+	// ServeIndent indents the bodies written by Serve.
+	ServeIndent bool
+	// Transform returns the object serialized instead of data by JSON, JSONP, XML, YAML, Encode and Serve,
+	// the router sets it from the WithResponseTransformer options of the matched router.
+	Transform func(data interface{}) (interface{}, error)
+}
+
+// JSONEnvelope wraps the json payload and its meta information,
+// such as {"data":..., "meta":...}.
+type JSONEnvelope struct {
+	Data interface{} `json:"data"`
+	Meta interface{} `json:"meta,omitempty"`
 }
 
 // NewOutput returns new BeegoOutput.
@@ -55,35 +93,22 @@ func (output *BeegoOutput) Header(key, val string) {
 // it sends out response body directly.
 func (output *BeegoOutput) Body(content []byte) {
 	outputWriter := output.Context.ResponseWriter.(io.Writer)
-	if output.EnableGzip == true && output.Context.Input.Header("Accept-Encoding") != "" {
-		splitted := strings.SplitN(output.Context.Input.Header("Accept-Encoding"), ",", -1)
-		encodings := make([]string, len(splitted))
-
-		for i, val := range splitted {
-			encodings[i] = strings.TrimSpace(val)
-		}
-		for _, val := range encodings {
-			if val == "gzip" {
-				output.Header("Content-Encoding", "gzip")
-				outputWriter, _ = gzip.NewWriterLevel(output.Context.ResponseWriter, gzip.BestSpeed)
-
-				break
-			} else if val == "deflate" {
-				output.Header("Content-Encoding", "deflate")
-				outputWriter, _ = flate.NewWriter(output.Context.ResponseWriter, flate.BestSpeed)
-				break
-			}
+	switch output.acceptEncoding() {
+	case "gzip":
+		output.Header("Content-Encoding", "gzip")
+		outputWriter, _ = gzip.NewWriterLevel(output.Context.ResponseWriter, gzip.BestSpeed)
+	case "deflate":
+		output.Header("Content-Encoding", "deflate")
+		outputWriter, _ = flate.NewWriter(output.Context.ResponseWriter, flate.BestSpeed)
+	default:
+		if !output.EnableGzip || output.Context.Input.Header("Accept-Encoding") == "" {
+			output.Header("Content-Length", strconv.Itoa(len(content)))
 		}
-	} else {
-		output.Header("Content-Length", strconv.Itoa(len(content)))
 	}
 
 	// Write status code if it has been set manually
 	// Set it to 0 afterwards to prevent "multiple response.WriteHeader calls"
-	if output.Status != 0 {
-		output.Context.ResponseWriter.WriteHeader(output.Status)
-		output.Status = 0
-	}
+	output.writeStatus()
 
 	outputWriter.Write(content)
 	switch outputWriter.(type) {
@@ -94,6 +119,123 @@ func (output *BeegoOutput) Body(content []byte) {
 	}
 }
 
+// acceptEncoding returns the first of gzip and deflate accepted by the request if EnableGzip.
+func (output *BeegoOutput) acceptEncoding() string {
+	if !output.EnableGzip || output.Context.Input.Header("Accept-Encoding") == "" {
+		return ""
+	}
+	for _, val := range strings.Split(output.Context.Input.Header("Accept-Encoding"), ",") {
+		val = strings.TrimSpace(val)
+		if val == "gzip" || val == "deflate" {
+			return val
+		}
+	}
+	return ""
+}
+
+func (output *BeegoOutput) writeStatus() {
+	if output.Status != 0 {
+		output.Context.ResponseWriter.WriteHeader(output.Status)
+		output.Status = 0
+	}
+}
+
+// StreamWriter writes the response body in chunks, such as a CSV export row by row.
+// The data is compressed when the output is gzipped, Flush sends the written data to the client.
+// Close must be called after all the data is written.
+type StreamWriter struct {
+	w          io.Writer
+	compressor interface {
+		Flush() error
+		Close() error
+	}
+	flusher http.Flusher
+}
+
+// Write writes p into the response body.
+func (sw *StreamWriter) Write(p []byte) (int, error) {
+	return sw.w.Write(p)
+}
+
+// Flush sends the data written so far to the client.
+func (sw *StreamWriter) Flush() error {
+	if sw.compressor != nil {
+		if err := sw.compressor.Flush(); err != nil {
+			return err
+		}
+	}
+	if sw.flusher != nil {
+		sw.flusher.Flush()
+	}
+	return nil
+}
+
+// Close finishes the compressed stream and flushes it.
+func (sw *StreamWriter) Close() error {
+	if sw.compressor != nil {
+		if err := sw.compressor.Close(); err != nil {
+			return err
+		}
+	}
+	if sw.flusher != nil {
+		sw.flusher.Flush()
+	}
+	return nil
+}
+
+// StreamWriter writes the response header and returns a StreamWriter for the body.
+// Content-Length is not set, the body is sent chunked.
+// usage:
+//	sw := ctx.Output.StreamWriter()
+//	defer sw.Close()
+//	for _, row := range rows {
+//		sw.Write(row)
+//		sw.Flush()
+//	}
+func (output *BeegoOutput) StreamWriter() *StreamWriter {
+	rw := output.Context.ResponseWriter
+	sw := &StreamWriter{w: rw}
+	switch output.acceptEncoding() {
+	case "gzip":
+		output.Header("Content-Encoding", "gzip")
+		gw, _ := gzip.NewWriterLevel(rw, gzip.BestSpeed)
+		sw.w, sw.compressor = gw, gw
+	case "deflate":
+		output.Header("Content-Encoding", "deflate")
+		fw, _ := flate.NewWriter(rw, flate.BestSpeed)
+		sw.w, sw.compressor = fw, fw
+	}
+	sw.flusher, _ = rw.(http.Flusher)
+	output.writeStatus()
+	return sw
+}
+
+// Stream copies r into the response body without buffering all of it,
+// every chunk read from r is flushed to the client.
+func (output *BeegoOutput) Stream(r io.Reader) error {
+	sw := output.StreamWriter()
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := sw.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			if ferr := sw.Flush(); ferr != nil {
+				return ferr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			sw.Close()
+			return err
+		}
+	}
+	return sw.Close()
+}
+
 // Cookie sets cookie value via given key.
 // others are ordered as cookie's max age time, path,domain, secure and httponly.
 func (output *BeegoOutput) Cookie(name string, value string, others ...interface{}) {
@@ -191,14 +333,12 @@ func sanitizeValue(v string) string {
 // JSON writes json to response body.
 // if coding is true, it converts utf-8 to \u0000 type.
 func (output *BeegoOutput) JSON(data interface{}, hasIndent bool, coding bool) error {
-	output.Header("Content-Type", "application/json; charset=utf-8")
-	var content []byte
-	var err error
-	if hasIndent {
-		content, err = json.MarshalIndent(data, "", "  ")
-	} else {
-		content, err = json.Marshal(data)
+	data, err := output.transform(data)
+	if err != nil {
+		return err
 	}
+	output.Header("Content-Type", "application/json; charset=utf-8")
+	content, err := output.marshalJSON(data, hasIndent)
 	if err != nil {
 		http.Error(output.Context.ResponseWriter, err.Error(), http.StatusInternalServerError)
 		return err
@@ -206,29 +346,59 @@ func (output *BeegoOutput) JSON(data interface{}, hasIndent bool, coding bool) e
 	if coding {
 		content = []byte(stringsToJSON(string(content)))
 	}
+	if output.JSONPrefix != "" {
+		content = append([]byte(output.JSONPrefix), content...)
+	}
 	output.Body(content)
 	return nil
 }
 
-// JSONP writes jsonp to response body.
-func (output *BeegoOutput) JSONP(data interface{}, hasIndent bool) error {
-	output.Header("Content-Type", "application/javascript; charset=utf-8")
-	var content []byte
-	var err error
+// marshalJSON encodes data with the json encoder of this output.
+func (output *BeegoOutput) marshalJSON(data interface{}, hasIndent bool) ([]byte, error) {
+	encoder := output.JSONEncoder
+	if encoder == nil {
+		encoder = DefaultJSONEncoder
+	}
+	if encoder != nil {
+		if hasIndent {
+			return encoder.MarshalIndent(data, "", "  ")
+		}
+		return encoder.Marshal(data)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(!output.JSONNoEscapeHTML)
 	if hasIndent {
-		content, err = json.MarshalIndent(data, "", "  ")
-	} else {
-		content, err = json.Marshal(data)
+		enc.SetIndent("", "  ")
 	}
-	if err != nil {
-		http.Error(output.Context.ResponseWriter, err.Error(), http.StatusInternalServerError)
-		return err
+	if err := enc.Encode(data); err != nil {
+		return nil, err
 	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// JSONP writes jsonp to response body.
+func (output *BeegoOutput) JSONP(data interface{}, hasIndent bool) error {
 	callback := output.Context.Input.Query("callback")
 	if callback == "" {
 		return errors.New(`"callback" parameter required`)
 	}
-	callbackContent := bytes.NewBufferString(" " + template.JSEscapeString(callback))
+	if !jsonpCallbackRegex.MatchString(callback) {
+		return errors.New(`"callback" parameter is not a valid javascript identifier`)
+	}
+	data, err := output.transform(data)
+	if err != nil {
+		return err
+	}
+	output.Header("Content-Type", "application/javascript; charset=utf-8")
+	output.Header("X-Content-Type-Options", "nosniff")
+	content, err := output.marshalJSON(data, hasIndent)
+	if err != nil {
+		http.Error(output.Context.ResponseWriter, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+	// the leading comment prevents the callback from being sniffed as other content types
+	callbackContent := bytes.NewBufferString("/**/ " + template.JSEscapeString(callback))
 	callbackContent.WriteString("(")
 	callbackContent.Write(content)
 	callbackContent.WriteString(");\r\n")
@@ -238,9 +408,12 @@ func (output *BeegoOutput) JSONP(data interface{}, hasIndent bool) error {
 
 // XML writes xml string to response body.
 func (output *BeegoOutput) XML(data interface{}, hasIndent bool) error {
+	data, err := output.transform(data)
+	if err != nil {
+		return err
+	}
 	output.Header("Content-Type", "application/xml; charset=utf-8")
 	var content []byte
-	var err error
 	if hasIndent {
 		content, err = xml.MarshalIndent(data, "", "  ")
 	} else {
@@ -271,6 +444,28 @@ func (output *BeegoOutput) Download(file string, filename ...string) {
 	http.ServeFile(output.Context.ResponseWriter, output.Context.Request, file)
 }
 
+// ServeBytes sends the in-memory content b, e.g. a generated image or zip, with its Content-Type and Content-Length.
+// The Range and If-Range requests are answered with the parts of b.
+// The response is an attachment of the filename if it's given, otherwise it's displayed inline.
+// usage:
+//
+//	ctx.Output.ServeBytes("image/png", png)
+//	ctx.Output.ServeBytes("application/zip", zipped, "report.zip")
+func (output *BeegoOutput) ServeBytes(contentType string, b []byte, filename ...string) {
+	if contentType == "" {
+		contentType = http.DetectContentType(b)
+	}
+	output.Header("Content-Type", contentType)
+	if len(filename) > 0 && filename[0] != "" {
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filename[0]})
+		if disposition == "" {
+			disposition = "attachment"
+		}
+		output.Header("Content-Disposition", disposition)
+	}
+	http.ServeContent(output.Context.ResponseWriter, output.Context.Request, "", time.Time{}, bytes.NewReader(b))
+}
+
 // ContentType sets the content type from ext string.
 // MIME type is given in mime package.
 func (output *BeegoOutput) ContentType(ext string) {
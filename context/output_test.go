@@ -0,0 +1,344 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	gocontext "context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestContext(url string) (*Context, *httptest.ResponseRecorder) {
+	r, _ := http.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+	ctx := &Context{
+		ResponseWriter: w,
+		Request:        r,
+		Input:          NewInput(r),
+		Output:         NewOutput(),
+	}
+	ctx.Output.Context = ctx
+	return ctx, w
+}
+
+func TestOutputJSONPrefix(t *testing.T) {
+	ctx, w := newTestContext("/")
+	ctx.Output.JSONPrefix = ")]}',\n"
+	if err := ctx.Output.JSON(map[string]string{"a": "<b>"}, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if w.Body.String() != ")]}',\n"+`{"a":"\u003cb\u003e"}` {
+		t.Fatal("unexpected json body:", w.Body.String())
+	}
+
+	ctx, w = newTestContext("/")
+	ctx.Output.JSONNoEscapeHTML = true
+	ctx.Output.JSON(map[string]string{"a": "<b>"}, false, false)
+	if w.Body.String() != `{"a":"<b>"}` {
+		t.Fatal("html should not be escaped:", w.Body.String())
+	}
+}
+
+func TestOutputJSONPCallback(t *testing.T) {
+	ctx, w := newTestContext("/?callback=jQuery.cb_1")
+	if err := ctx.Output.JSONP(1, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(w.Body.String(), "/**/ jQuery.cb_1(1);") {
+		t.Fatal("unexpected jsonp body:", w.Body.String())
+	}
+
+	ctx, _ = newTestContext("/?callback=alert(1)//")
+	if err := ctx.Output.JSONP(1, false); err == nil {
+		t.Fatal("invalid callback should be rejected")
+	}
+}
+
+type upperJSONEncoder struct{}
+
+func (upperJSONEncoder) Marshal(v interface{}) ([]byte, error) {
+	return []byte(`"UPPER"`), nil
+}
+
+func (upperJSONEncoder) MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	return []byte(`"UPPER"`), nil
+}
+
+func TestOutputJSONEncoder(t *testing.T) {
+	ctx, w := newTestContext("/")
+	ctx.Output.JSONEncoder = upperJSONEncoder{}
+	ctx.Output.JSON("lower", false, false)
+	if w.Body.String() != `"UPPER"` {
+		t.Fatal("custom json encoder is not used:", w.Body.String())
+	}
+}
+
+func BenchmarkOutputJSON(b *testing.B) {
+	data := map[string]interface{}{"id": 1, "name": "beego", "tags": []string{"web", "framework"}}
+	for i := 0; i < b.N; i++ {
+		ctx, _ := newTestContext("/")
+		ctx.Output.JSON(data, false, false)
+	}
+}
+
+func TestContextDetach(t *testing.T) {
+	ctx, _ := newTestContext("/user/1")
+	ctx.Request.Header.Set("X-Request-Id", "abc")
+	ctx.Input.Params[":id"] = "1"
+	ctx.Input.SetData("user", "astaxie")
+
+	d := ctx.Detach()
+	ctx.Input.Params[":id"] = "2"
+	ctx.Input.SetData("user", "slene")
+	ctx.Request.Header.Set("X-Request-Id", "def")
+
+	if d.Param(":id") != "1" || d.GetData("user") != "astaxie" || d.RequestID != "abc" || d.Header.Get("X-Request-Id") != "abc" {
+		t.Fatal("detached context should not change with the live context")
+	}
+	if d.Path != "/user/1" || d.Method != "GET" {
+		t.Fatal("detached context should copy the request line")
+	}
+}
+
+func TestContextGo(t *testing.T) {
+	ctx, _ := newTestContext("/")
+	errBackend := errors.New("backend down")
+	ctx.Go(func(c gocontext.Context) error {
+		return errBackend
+	})
+	ctx.Go(func(c gocontext.Context) error {
+		<-c.Done()
+		return nil
+	})
+	if err := ctx.Wait(); err != errBackend {
+		t.Fatal("Wait should return the first error, got", err)
+	}
+}
+
+func TestContextWithTimeout(t *testing.T) {
+	ctx, _ := newTestContext("/")
+	ctx.WithValue("user", "astaxie")
+	cancel := ctx.WithTimeout(time.Millisecond)
+	defer cancel()
+	if ctx.Value("user") != "astaxie" || ctx.Input.Request != ctx.Request {
+		t.Fatal("WithValue should update the request of the context and input")
+	}
+	<-ctx.Context().Done()
+	if !ctx.Canceled() || ctx.Context().Err() != gocontext.DeadlineExceeded {
+		t.Fatal("context should be canceled after the timeout")
+	}
+}
+
+func TestOutputStream(t *testing.T) {
+	ctx, w := newTestContext("/")
+	ctx.Output.Status = 201
+	if err := ctx.Output.Stream(strings.NewReader("a,b\n1,2\n")); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != 201 || w.Body.String() != "a,b\n1,2\n" || !w.Flushed {
+		t.Fatal("unexpected stream response:", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Length") != "" {
+		t.Fatal("stream should not set Content-Length")
+	}
+
+	ctx, w = newTestContext("/")
+	ctx.Request.Header.Set("Accept-Encoding", "gzip")
+	ctx.Output.EnableGzip = true
+	ctx.Output.Stream(strings.NewReader("hello stream"))
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadAll(gr); string(b) != "hello stream" || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("gzipped stream is wrong:", string(b))
+	}
+}
+
+func TestOutputSSE(t *testing.T) {
+	ctx, w := newTestContext("/")
+	sse, err := ctx.Output.SSE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sse.SendID("1", "user", map[string]string{"name": "astaxie"})
+	sse.Send("", "line1\nline2")
+	want := "id: 1\nevent: user\ndata: {\"name\":\"astaxie\"}\n\ndata: line1\ndata: line2\n\n"
+	if w.Body.String() != want || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected sse body: %q", w.Body.String())
+	}
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	c, cancel := gocontext.WithCancel(r.Context())
+	ctx.Request = r.WithContext(c)
+	sse, _ = ctx.Output.SSE()
+	cancel()
+	if err := sse.Send("", "gone"); err != ErrSSEClosed {
+		t.Fatal("Send should fail after the client is gone, got", err)
+	}
+}
+
+type serveData struct {
+	A int
+}
+
+func TestOutputServe(t *testing.T) {
+	tests := []struct {
+		url, accept, format string
+		yaml                bool
+		contentType         string
+	}{
+		{"/", "", "", false, "application/json"},
+		{"/", "*/*", "xml", false, "application/xml"},
+		{"/", "text/html, application/xml;q=0.9, */*;q=0.8", "", false, "application/xml"},
+		{"/", "application/json;q=0.5, text/xml", "", false, "application/xml"},
+		{"/", "application/x-yaml", "", false, "application/json"},
+		{"/", "application/x-yaml", "", true, "application/x-yaml"},
+		{"/", "application/xml;q=0, application/json", "xml", false, "application/json"},
+		{"/", "application/javascript", "", false, "application/json"},
+		{"/?callback=cb", "application/javascript", "", false, "application/javascript"},
+	}
+	defer func() { YAMLMarshal = nil }()
+	for _, tt := range tests {
+		YAMLMarshal = nil
+		if tt.yaml {
+			YAMLMarshal = func(v interface{}) ([]byte, error) { return []byte("a: 1\n"), nil }
+		}
+		ctx, w := newTestContext(tt.url)
+		ctx.Request.Header.Set("Accept", tt.accept)
+		ctx.Output.ServeFormat = tt.format
+		if err := ctx.Output.Serve(&serveData{1}); err != nil {
+			t.Fatal(err)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+			t.Errorf("%s %q: Content-Type is %q, want %q", tt.url, tt.accept, ct, tt.contentType)
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Error("Vary should be Accept")
+		}
+	}
+}
+
+type testEncoder struct{}
+
+func (testEncoder) Marshal(v interface{}) ([]byte, error) {
+	return []byte(fmt.Sprintf("test:%d", v.(*serveData).A)), nil
+}
+
+func (testEncoder) Unmarshal(data []byte, v interface{}) error {
+	_, err := fmt.Sscanf(string(data), "test:%d", &v.(*serveData).A)
+	return err
+}
+
+func TestOutputEncoder(t *testing.T) {
+	RegisterEncoder(testEncoder{}, "application/x-beego-test", "application/vnd.beego-test")
+	ctx, w := newTestContext("/")
+	ctx.Request.Header.Set("Accept", "application/vnd.beego-test, application/json;q=0.9")
+	if err := ctx.Output.Serve(&serveData{7}); err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get("Content-Type") != "application/x-beego-test" || w.Body.String() != "test:7" {
+		t.Errorf("encoded response: %q %q", w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	ctx, w = newTestContext("/")
+	ctx.Output.ServeFormat = "application/x-beego-test"
+	ctx.Output.Serve(&serveData{8})
+	if w.Body.String() != "test:8" {
+		t.Errorf("default encoder: %q", w.Body.String())
+	}
+
+	r, _ := http.NewRequest("POST", "/", strings.NewReader("test:9"))
+	r.Header.Set("Content-Type", "application/x-beego-test; charset=utf-8")
+	d := &serveData{}
+	if err := NewInput(r).BindRequest(d); err != nil || d.A != 9 {
+		t.Errorf("decoded body: %+v %v", d, err)
+	}
+}
+
+func TestOutputServeBytes(t *testing.T) {
+	ctx, w := newTestContext("/report")
+	ctx.Output.ServeBytes("application/zip", []byte("0123456789"), "rapport été.zip")
+	if w.Code != 200 || w.Body.String() != "0123456789" || w.Header().Get("Content-Length") != "10" ||
+		w.Header().Get("Content-Type") != "application/zip" || w.Header().Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("unexpected response %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+	if d := w.Header().Get("Content-Disposition"); d != "attachment; filename*=utf-8''rapport%20%C3%A9t%C3%A9.zip" {
+		t.Error("unexpected Content-Disposition:", d)
+	}
+
+	ctx, w = newTestContext("/report")
+	ctx.Request.Header.Set("Range", "bytes=2-4")
+	ctx.Output.ServeBytes("", []byte("0123456789"))
+	if w.Code != http.StatusPartialContent || w.Body.String() != "234" || w.Header().Get("Content-Range") != "bytes 2-4/10" ||
+		w.Header().Get("Content-Disposition") != "" {
+		t.Fatalf("unexpected range response %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+func TestOutputServeZip(t *testing.T) {
+	ctx, w := newTestContext("/export")
+	var sizes []int64
+	done := func(n int64, err error) error {
+		sizes = append(sizes, n)
+		return nil
+	}
+	err := ctx.Output.ServeZip([]ZipEntry{
+		{Name: "a.txt", Reader: strings.NewReader("hello"), Done: done},
+		{Name: "dir/b.txt", Store: true, Write: func(w io.Writer) error {
+			_, err := io.WriteString(w, "world!")
+			return err
+		}, Done: done},
+		{Name: "missing.txt", Path: "/no/such/file", Done: done},
+	}, "export.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get("Content-Type") != "application/zip" || w.Header().Get("Content-Disposition") != "attachment; filename=export.zip" {
+		t.Errorf("unexpected headers: %v", w.Header())
+	}
+	if fmt.Sprint(sizes) != "[5 6 0]" {
+		t.Errorf("unexpected entry sizes: %v", sizes)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		b, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		names = append(names, f.Name+"="+string(b))
+	}
+	if strings.Join(names, ",") != "a.txt=hello,dir/b.txt=world!,missing.txt=" {
+		t.Errorf("unexpected archive: %v", names)
+	}
+
+	ctx, _ = newTestContext("/export")
+	if err := ctx.Output.ServeZip([]ZipEntry{{Name: "missing.txt", Path: "/no/such/file"}}); err == nil {
+		t.Error("the failed entry should stop the archive")
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+// Principal is the authenticated user of the request, the authentication filters set it by SetPrincipal.
+type Principal interface {
+	Name() string
+	HasRole(role string) bool
+}
+
+type principal struct {
+	name  string
+	roles map[string]bool
+}
+
+// NewPrincipal returns a Principal of the user name with the roles.
+func NewPrincipal(name string, roles ...string) Principal {
+	p := &principal{name: name, roles: make(map[string]bool, len(roles))}
+	for _, role := range roles {
+		p.roles[role] = true
+	}
+	return p
+}
+
+func (p *principal) Name() string {
+	return p.name
+}
+
+func (p *principal) HasRole(role string) bool {
+	return p.roles[role]
+}
+
+// SetPrincipal sets the authenticated user of the request.
+func (ctx *Context) SetPrincipal(p Principal) {
+	ctx.principal = p
+}
+
+// Principal returns the authenticated user of the request, nil if it's anonymous.
+func (ctx *Context) Principal() Principal {
+	return ctx.principal
+}
@@ -0,0 +1,84 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"net/http"
+)
+
+// Reset prepares the Context, its Input and Output for the request, so a pooled Context is reused
+// instead of allocating new ones. The router resets the contexts of its pool before serving a request,
+// so the references to the Context, Input, Output or the Params and Data maps must not be kept
+// after the request finished: copy the data by Detach, or call Retain to keep the Context out of the pool.
+func (ctx *Context) Reset(rw http.ResponseWriter, r *http.Request) {
+	input, output := ctx.Input, ctx.Output
+	if input == nil {
+		input = NewInput(r)
+	} else {
+		input.Reset(r)
+	}
+	if output == nil {
+		output = NewOutput()
+	} else {
+		output.Reset()
+	}
+	*ctx = Context{
+		Input:          input,
+		Output:         output,
+		Request:        r,
+		ResponseWriter: rw,
+	}
+	output.Context = ctx
+}
+
+// Retain keeps the Context out of the pool of the router, so it stays valid after the request finished,
+// e.g. for a goroutine which keeps using the live Context once the handler returned.
+func (ctx *Context) Retain() {
+	ctx.retained = true
+}
+
+// Retained returns whether Retain is called for this request.
+func (ctx *Context) Retained() bool {
+	return ctx.retained
+}
+
+// Reset clears the BeegoInput for the request, the params and the Data map are emptied and reused.
+func (input *BeegoInput) Reset(req *http.Request) {
+	params, data := input.Params, input.Data
+	for k := range params {
+		delete(params, k)
+	}
+	for k := range data {
+		delete(data, k)
+	}
+	if params == nil {
+		params = make(map[string]string)
+	}
+	if data == nil {
+		data = make(map[interface{}]interface{})
+	}
+	*input = BeegoInput{
+		Params:      params,
+		Data:        data,
+		Request:     req,
+		paramKeys:   input.paramKeys[:0],
+		paramValues: input.paramValues[:0],
+	}
+}
+
+// Reset clears the BeegoOutput for a new response.
+func (output *BeegoOutput) Reset() {
+	*output = BeegoOutput{}
+}
@@ -0,0 +1,40 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextReset(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/a", nil)
+	ctx := &Context{Input: NewInput(r), Output: NewOutput()}
+	ctx.Input.Params[":id"] = "1"
+	ctx.Input.SetData("user", "u")
+	ctx.Output.Status = 404
+	ctx.Retain()
+
+	r2, _ := http.NewRequest("GET", "/b", nil)
+	w := httptest.NewRecorder()
+	ctx.Reset(w, r2)
+	if ctx.Request != r2 || ctx.Input.Request != r2 || ctx.ResponseWriter != w || ctx.Output.Context != ctx {
+		t.Fatal("the context isn't set for the new request")
+	}
+	if ctx.Input.Param(":id") != "" || ctx.Input.GetData("user") != nil || ctx.Output.Status != 0 || ctx.Retained() {
+		t.Fatal("the previous request is kept")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrSSEClosed is returned by SSEWriter when the client is gone.
+var ErrSSEClosed = errors.New("sse: client connection is closed")
+
+// SSEWriter sends Server-Sent Events to the client.
+type SSEWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	done    <-chan struct{}
+}
+
+// SSE writes the event stream headers and returns a SSEWriter.
+// usage:
+//	sse, err := ctx.Output.SSE()
+//	if err != nil {
+//		return
+//	}
+//	for msg := range messages {
+//		if err := sse.Send("message", msg); err != nil {
+//			return // client is gone
+//		}
+//	}
+func (output *BeegoOutput) SSE() (*SSEWriter, error) {
+	rw := output.Context.ResponseWriter
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		return nil, errors.New("sse: ResponseWriter doesn't support flushing")
+	}
+	output.Header("Content-Type", "text/event-stream")
+	output.Header("Cache-Control", "no-cache")
+	output.Header("Connection", "keep-alive")
+	// disable the response buffering of nginx
+	output.Header("X-Accel-Buffering", "no")
+	output.writeStatus()
+	flusher.Flush()
+	return &SSEWriter{w: rw, flusher: flusher, done: output.Context.Request.Context().Done()}, nil
+}
+
+// Done is closed when the client disconnects.
+func (s *SSEWriter) Done() <-chan struct{} {
+	return s.done
+}
+
+// Send sends an event, event can be empty for the default "message" event.
+// string and []byte data is sent as it is, others are encoded to json.
+func (s *SSEWriter) Send(event string, data interface{}) error {
+	return s.SendID("", event, data)
+}
+
+// SendID sends an event with id, the client sends it back in Last-Event-ID when it reconnects.
+func (s *SSEWriter) SendID(id, event string, data interface{}) error {
+	var payload string
+	switch v := data.(type) {
+	case string:
+		payload = v
+	case []byte:
+		payload = string(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		payload = string(b)
+	}
+	var buf bytes.Buffer
+	if id != "" {
+		fmt.Fprintf(&buf, "id: %s\n", sseLine(id))
+	}
+	if event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", sseLine(event))
+	}
+	for _, line := range strings.Split(strings.Replace(payload, "\r\n", "\n", -1), "\n") {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+// Comment sends a comment line, it's usually used as keep-alive.
+func (s *SSEWriter) Comment(text string) error {
+	return s.write([]byte(": " + sseLine(text) + "\n\n"))
+}
+
+// Retry tells the client to wait ms milliseconds before reconnecting.
+func (s *SSEWriter) Retry(ms int) error {
+	return s.write([]byte(fmt.Sprintf("retry: %d\n\n", ms)))
+}
+
+func (s *SSEWriter) write(b []byte) error {
+	select {
+	case <-s.done:
+		return ErrSSEClosed
+	default:
+	}
+	if _, err := s.w.Write(b); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// sseLine removes the line breaks which would end the field.
+func sseLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
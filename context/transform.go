@@ -0,0 +1,38 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import "net/http"
+
+// transform returns the object to serialize instead of data by Transform,
+// the payload of a JSONEnvelope is transformed instead of the envelope.
+// Then the fields tagged by `mask` are hidden from the Principal of the request.
+// The error is answered by 500.
+func (output *BeegoOutput) transform(data interface{}) (interface{}, error) {
+	if output.Transform != nil {
+		var err error
+		if env, ok := data.(JSONEnvelope); ok {
+			env.Data, err = output.Transform(env.Data)
+			data = env
+		} else {
+			data, err = output.Transform(data)
+		}
+		if err != nil {
+			http.Error(output.Context.ResponseWriter, err.Error(), http.StatusInternalServerError)
+			return nil, err
+		}
+	}
+	return maskData(data, output.Context.Principal()), nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"context"
+	"sync"
+)
+
+// workerGroup runs the functions started by Context.Go.
+// the first error cancels the context passed to the other functions.
+type workerGroup struct {
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+	once   sync.Once
+	err    error
+}
+
+// Go runs fn in a new goroutine with a context which is canceled
+// when the client goes away, or when another function started by Go returns an error.
+// The router waits for all the functions after the controller finished,
+// call Wait to get the first error in the handler.
+// usage:
+//	ctx.Go(func(c context.Context) error {
+//		return loadUser(c, uid)
+//	})
+//	ctx.Go(func(c context.Context) error {
+//		return loadOrders(c, uid)
+//	})
+//	if err := ctx.Wait(); err != nil {
+//		ctx.Abort(502, err.Error())
+//	}
+func (ctx *Context) Go(fn func(context.Context) error) {
+	if ctx.workers == nil {
+		c, cancel := context.WithCancel(ctx.Request.Context())
+		ctx.workers = &workerGroup{ctx: c, cancel: cancel}
+	}
+	g := ctx.workers
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fn(g.ctx); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+// Wait blocks until all the functions started by Go returned,
+// then returns the first non-nil error of them.
+func (ctx *Context) Wait() error {
+	if ctx.workers == nil {
+		return nil
+	}
+	ctx.workers.wg.Wait()
+	ctx.workers.cancel()
+	return ctx.workers.err
+}
@@ -0,0 +1,50 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+)
+
+// The yaml codec of the responses and request bodies, yaml is disabled while they are nil.
+// import github.com/astaxie/beego/plugins/yaml to set them.
+var (
+	YAMLMarshal   func(v interface{}) ([]byte, error)
+	YAMLUnmarshal func(data []byte, v interface{}) error
+)
+
+// ErrYAMLDisabled is returned by the yaml methods while the yaml codec isn't set.
+var ErrYAMLDisabled = errors.New("beego: yaml is disabled, import github.com/astaxie/beego/plugins/yaml")
+
+// YAML writes yaml to response body, the Content-Type is application/x-yaml.
+func (output *BeegoOutput) YAML(data interface{}) error {
+	if YAMLMarshal == nil {
+		http.Error(output.Context.ResponseWriter, ErrYAMLDisabled.Error(), http.StatusInternalServerError)
+		return ErrYAMLDisabled
+	}
+	data, err := output.transform(data)
+	if err != nil {
+		return err
+	}
+	content, err := YAMLMarshal(data)
+	if err != nil {
+		http.Error(output.Context.ResponseWriter, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+	output.Header("Content-Type", "application/x-yaml; charset=utf-8")
+	output.Body(content)
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"archive/zip"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ZipEntry is a file of the zip archive streamed by ServeZip.
+// The content is read from Reader, or from the file Path, or written by Write, in this order.
+type ZipEntry struct {
+	Name     string // the path of the file in the archive
+	Modified time.Time
+	Store    bool // store the content without compression, for the files already compressed such as images
+	Reader   io.Reader
+	Path     string
+	Write    func(w io.Writer) error
+	// Done is called after the entry is written with the number of the bytes written or the error.
+	// A non-nil error stops the archive, it's the error of the entry by default,
+	// if Done returns nil the failed entry is kept empty or partial and the next entries are written.
+	Done func(written int64, err error) error
+}
+
+// ServeZip streams a zip archive of the entries built on the fly, without temp files, as the attachment filename.
+// The archive is sent chunked while the entries are written, so an error after the first entry can't change
+// the status: the archive is cut off before its central directory and the client sees a corrupted download.
+// usage:
+//
+//	ctx.Output.ServeZip([]context.ZipEntry{
+//		{Name: "report.csv", Write: writeReport},
+//		{Name: "photos/1.jpg", Path: "data/1.jpg", Store: true},
+//	}, "export.zip")
+func (output *BeegoOutput) ServeZip(entries []ZipEntry, filename ...string) error {
+	output.Header("Content-Type", "application/zip")
+	disposition := "attachment"
+	if len(filename) > 0 && filename[0] != "" {
+		if d := mime.FormatMediaType("attachment", map[string]string{"filename": filename[0]}); d != "" {
+			disposition = d
+		}
+	}
+	output.Header("Content-Disposition", disposition)
+	output.writeStatus()
+
+	rw := output.Context.ResponseWriter
+	flusher, _ := rw.(http.Flusher)
+	zw := zip.NewWriter(rw)
+	for i := range entries {
+		e := &entries[i]
+		n, err := writeZipEntry(zw, e)
+		if e.Done != nil {
+			err = e.Done(n, err)
+		}
+		if err != nil {
+			return err
+		}
+		if flusher != nil {
+			zw.Flush()
+			flusher.Flush()
+		}
+	}
+	return zw.Close()
+}
+
+// countWriter counts the bytes written through it.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func writeZipEntry(zw *zip.Writer, e *ZipEntry) (int64, error) {
+	h := &zip.FileHeader{Name: e.Name, Method: zip.Deflate, Modified: e.Modified}
+	if e.Store {
+		h.Method = zip.Store
+	}
+	if h.Modified.IsZero() {
+		h.Modified = time.Now()
+	}
+	fw, err := zw.CreateHeader(h)
+	if err != nil {
+		return 0, err
+	}
+	cw := &countWriter{w: fw}
+	switch {
+	case e.Reader != nil:
+		_, err = io.Copy(cw, e.Reader)
+	case e.Path != "":
+		var f *os.File
+		if f, err = os.Open(e.Path); err == nil {
+			_, err = io.Copy(cw, f)
+			f.Close()
+		}
+	case e.Write != nil:
+		err = e.Write(cw)
+	}
+	return cw.n, err
+}
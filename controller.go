@@ -15,18 +15,16 @@
 package beego
 
 import (
-	"bytes"
+	gocontext "context"
 	"errors"
 	"html/template"
 	"io"
-	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
 	"reflect"
 	"strconv"
-	"strings"
 
 	"github.com/astaxie/beego/context"
 	"github.com/astaxie/beego/session"
@@ -37,6 +35,7 @@ const (
 	applicationJSON = "application/json"
 	applicationXML  = "application/xml"
 	textXML         = "text/xml"
+	applicationYAML = "application/x-yaml"
 )
 
 var (
@@ -52,6 +51,8 @@ type ControllerComments struct {
 	Router           string
 	AllowHTTPMethods []string
 	Params           []map[string]string
+	Filters          []string
+	Summary          string // the doc comment of the method without the annotations
 }
 
 // Controller defines some basic http request handler operations, such as
@@ -64,15 +65,21 @@ type Controller struct {
 	TplNames       string
 	Layout         string
 	LayoutSections map[string]string // the key is the section name and the value is the template name
+	Sections       []LayoutSection   // rendered in order before the page, see AddSection
 	TplExt         string
+	ViewPaths      []string // the view paths added by AddViewPath searched before ViewsPath, e.g. the theme
+	TplLocale      string   // the locale of the templates, "index.zh-CN.tpl" is used before "index.tpl"
 	_xsrfToken     string
 	gotofunc       string
 	CruSession     session.Store
 	XSRFExpire     int
 	AppController  interface{}
 	EnableRender   bool
+	StreamRender   bool // write the rendered page to the client directly instead of buffering it, for the large pages
 	EnableXSRF     bool
 	methodMapping  map[string]func() //method:routertree
+	// handlers is the register serving the request, for URLForName
+	handlers *ControllerRegister
 }
 
 // ControllerInterface is an interface to uniform all controller handler.
@@ -102,11 +109,17 @@ func (c *Controller) Init(ctx *context.Context, controllerName, actionName strin
 	c.actionName = actionName
 	c.Ctx = ctx
 	c.TplExt = "tpl"
+	c.TplLocale = RouteLocale(ctx)
 	c.AppController = app
 	c.EnableRender = true
 	c.EnableXSRF = true
 	c.Data = ctx.Input.Data
-	c.methodMapping = make(map[string]func())
+	if c.methodMapping == nil {
+		c.methodMapping = make(map[string]func())
+	}
+	if context.DefaultTranslator != nil {
+		c.Data["Lang"] = ctx.Locale()
+	}
 }
 
 // Prepare runs after Init before request function execution.
@@ -173,10 +186,22 @@ func (c *Controller) Mapping(method string, fn func()) {
 }
 
 // Render sends the response with rendered template bytes as text/html type.
+// The page is rendered into a buffer of at most RenderBufferSize bytes first, so a template error
+// gets a clean error response, set StreamRender to write the large pages to the client directly.
+// With a Layout the page of a streamed render is still buffered as the LayoutContent, without the limit.
 func (c *Controller) Render() error {
 	if !c.EnableRender {
 		return nil
 	}
+	if c.StreamRender {
+		c.Ctx.Output.Header("Content-Type", "text/html; charset=utf-8")
+		sw := c.Ctx.Output.StreamWriter()
+		err := c.renderTo(sw)
+		if cerr := sw.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
 	rb, err := c.RenderBytes()
 	if err != nil {
 		return err
@@ -194,12 +219,25 @@ func (c *Controller) RenderString() (string, error) {
 
 // RenderBytes returns the bytes of rendered template string. Do not send out response.
 func (c *Controller) RenderBytes() ([]byte, error) {
+	buf := newRenderBuffer()
+	if err := c.renderTo(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderTo executes the template, in the layout if the controller has one, into w.
+func (c *Controller) renderTo(w io.Writer) error {
+	if c.TplNames == "" {
+		c.TplNames = c.templateName()
+	}
+	if len(c.Sections) > 0 {
+		if err := c.renderOrderedSections(); err != nil {
+			return err
+		}
+	}
 	//if the controller has set layout, then first get the tplname's content set the content to the layout
 	if c.Layout != "" {
-		if c.TplNames == "" {
-			c.TplNames = strings.ToLower(c.controllerName) + "/" + strings.ToLower(c.actionName) + "." + c.TplExt
-		}
-
 		if RunMode == "dev" {
 			buildFiles := make([]string, 1)
 			buildFiles = append(buildFiles, c.TplNames)
@@ -211,65 +249,193 @@ func (c *Controller) RenderBytes() ([]byte, error) {
 					buildFiles = append(buildFiles, sectionTpl)
 				}
 			}
-			BuildTemplate(ViewsPath, buildFiles...)
+			c.buildTemplate(buildFiles...)
 		}
-		newbytes := bytes.NewBufferString("")
-		if _, ok := BeeTemplates[c.TplNames]; !ok {
-			panic("can't find templatefile in the path:" + c.TplNames)
+		newbytes := newRenderBuffer()
+		if c.StreamRender {
+			// the layout is streamed, its content can't be
+			newbytes.max = 0
 		}
-		err := BeeTemplates[c.TplNames].ExecuteTemplate(newbytes, c.TplNames, c.Data)
+		err := c.executeTemplate(newbytes, c.TplNames)
 		if err != nil {
 			Trace("template Execute err:", err)
-			return nil, err
+			return err
 		}
-		tplcontent, _ := ioutil.ReadAll(newbytes)
-		c.Data["LayoutContent"] = template.HTML(string(tplcontent))
+		c.Data["LayoutContent"] = template.HTML(newbytes.String())
 
 		if c.LayoutSections != nil {
-			for sectionName, sectionTpl := range c.LayoutSections {
-				if sectionTpl == "" {
-					c.Data[sectionName] = ""
-					continue
-				}
-
-				sectionBytes := bytes.NewBufferString("")
-				err = BeeTemplates[sectionTpl].ExecuteTemplate(sectionBytes, sectionTpl, c.Data)
-				if err != nil {
-					Trace("template Execute err:", err)
-					return nil, err
-				}
-				sectionContent, _ := ioutil.ReadAll(sectionBytes)
-				c.Data[sectionName] = template.HTML(string(sectionContent))
+			if err = c.renderSections(c.LayoutSections); err != nil {
+				return err
 			}
 		}
 
-		ibytes := bytes.NewBufferString("")
-		err = BeeTemplates[c.Layout].ExecuteTemplate(ibytes, c.Layout, c.Data)
+		err = c.executeTemplate(w, c.Layout)
 		if err != nil {
 			Trace("template Execute err:", err)
-			return nil, err
 		}
-		icontent, _ := ioutil.ReadAll(ibytes)
-		return icontent, nil
+		return err
 	}
 
-	if c.TplNames == "" {
-		c.TplNames = strings.ToLower(c.controllerName) + "/" + strings.ToLower(c.actionName) + "." + c.TplExt
-	}
 	if RunMode == "dev" {
-		BuildTemplate(ViewsPath, c.TplNames)
-	}
-	ibytes := bytes.NewBufferString("")
-	if _, ok := BeeTemplates[c.TplNames]; !ok {
-		panic("can't find templatefile in the path:" + c.TplNames)
+		c.buildTemplate(c.TplNames)
 	}
-	err := BeeTemplates[c.TplNames].ExecuteTemplate(ibytes, c.TplNames, c.Data)
+	err := c.executeTemplate(w, c.TplNames)
 	if err != nil {
 		Trace("template Execute err:", err)
-		return nil, err
 	}
-	icontent, _ := ioutil.ReadAll(ibytes)
-	return icontent, nil
+	return err
+}
+
+// UseTemplate sets the template to render instead of the one resolved by the naming convention,
+// the TplExt is appended if the name has no extension.
+// usage:
+//
+//	c.UseTemplate("custom/name")
+func (c *Controller) UseTemplate(name string) {
+	c.TplNames = withTplExt(name, c.TplExt)
+}
+
+// templateName returns the template of the action resolved by the naming convention.
+func (c *Controller) templateName() string {
+	return withTplExt(templateNaming(c.controllerName, c.actionName), c.TplExt)
+}
+
+// RenderSections renders the templates into c.Data in one call, the key is the section name
+// and the value is the template name, so the layout or template rendered next can output them.
+// usage:
+//
+//	c.RenderSections(map[string]string{
+//		"Sidebar": "blocks/sidebar",
+//		"Footer":  "blocks/footer.tpl",
+//	})
+func (c *Controller) RenderSections(sections map[string]string) error {
+	if RunMode == "dev" {
+		files := make([]string, 0, len(sections))
+		for _, tpl := range sections {
+			if tpl != "" {
+				files = append(files, withTplExt(tpl, c.TplExt))
+			}
+		}
+		c.buildTemplate(files...)
+	}
+	named := make(map[string]string, len(sections))
+	for name, tpl := range sections {
+		if tpl != "" {
+			tpl = withTplExt(tpl, c.TplExt)
+		}
+		named[name] = tpl
+	}
+	return c.renderSections(named)
+}
+
+// renderSections executes the section templates into c.Data, the templates are built by the caller.
+func (c *Controller) renderSections(sections map[string]string) error {
+	for sectionName, sectionTpl := range sections {
+		if sectionTpl == "" {
+			c.Data[sectionName] = ""
+			continue
+		}
+
+		sectionBytes := newRenderBuffer()
+		err := c.executeTemplate(sectionBytes, sectionTpl)
+		if err != nil {
+			Trace("template Execute err:", err)
+			return err
+		}
+		c.Data[sectionName] = template.HTML(sectionBytes.String())
+	}
+	return nil
+}
+
+// LayoutSection is a section rendered into Data[Name] by the template Tpl, see AddSection.
+type LayoutSection struct {
+	Name string
+	Tpl  string // the TplExt is appended if it has no extension
+	// If skips the section when it returns false, Data[Name] is empty then.
+	If func() bool
+	// Optional skips the section if the template doesn't exist instead of failing the render.
+	Optional bool
+}
+
+// AddSection appends a section rendered into Data[name] before the page and the layout,
+// the sections are rendered in the order they are added, so a section can use the ones before it.
+// usage:
+//
+//	c.Layout = "layout.tpl"
+//	c.AddSection("Breadcrumb", "blocks/breadcrumb")
+//	c.AddSection("Sidebar", "blocks/sidebar")
+//	c.Sections = append(c.Sections, beego.LayoutSection{Name: "Ads", Tpl: "blocks/ads", If: c.showAds})
+func (c *Controller) AddSection(name, tpl string) {
+	c.Sections = append(c.Sections, LayoutSection{Name: name, Tpl: tpl})
+}
+
+// renderOrderedSections renders c.Sections in order into c.Data.
+func (c *Controller) renderOrderedSections() error {
+	if RunMode == "dev" {
+		files := make([]string, 0, len(c.Sections))
+		for _, s := range c.Sections {
+			if s.Tpl != "" {
+				files = append(files, withTplExt(s.Tpl, c.TplExt))
+			}
+		}
+		c.buildTemplate(files...)
+	}
+	for _, s := range c.Sections {
+		c.Data[s.Name] = template.HTML("")
+		if s.Tpl == "" || s.If != nil && !s.If() {
+			continue
+		}
+		tpl := withTplExt(s.Tpl, c.TplExt)
+		if s.Optional && !c.templateExists(tpl) {
+			continue
+		}
+		buf := newRenderBuffer()
+		if err := c.executeTemplate(buf, tpl); err != nil {
+			Trace("template Execute err:", err)
+			return err
+		}
+		c.Data[s.Name] = template.HTML(buf.String())
+	}
+	return nil
+}
+
+// templateExists returns whether the template is found for the controller.
+func (c *Controller) templateExists(name string) bool {
+	if templateEngine(name) != nil {
+		return engineTemplateExists(name, c.TplLocale)
+	}
+	t, _ := lookupTemplate(c.ViewPaths, name, c.TplLocale)
+	return t != nil
+}
+
+// buildTemplate rebuilds the template files and their TplLocale versions in ViewsPath and the ViewPaths.
+func (c *Controller) buildTemplate(files ...string) {
+	if c.TplLocale != "" {
+		for _, file := range files {
+			if file != "" {
+				files = append(files, localizedTplName(file, c.TplLocale))
+			}
+		}
+	}
+	BuildTemplate(ViewsPath, files...)
+	for _, viewPath := range c.ViewPaths {
+		if tpls, ok := beeViewPathTemplates[viewPath]; ok {
+			buildTemplateTo(tpls, viewPath, false, files...)
+		}
+	}
+}
+
+// executeTemplate renders the template found by lookupTemplate with c.Data,
+// or by the TemplateEngine of its extension.
+func (c *Controller) executeTemplate(w io.Writer, name string) error {
+	if engine := templateEngine(name); engine != nil {
+		return executeEngineTemplate(engine, w, name, c.TplLocale, c.Data)
+	}
+	t, file := lookupTemplate(c.ViewPaths, name, c.TplLocale)
+	if t == nil {
+		return errors.New("can't find templatefile in the path:" + name)
+	}
+	return t.ExecuteTemplate(w, file, c.Data)
 }
 
 // Redirect sends the redirection response to url with status code.
@@ -303,6 +469,25 @@ func (c *Controller) StopRun() {
 	panic(ErrAbort)
 }
 
+// Context returns the context.Context of the request, it's canceled when the client goes away.
+func (c *Controller) Context() gocontext.Context {
+	return c.Ctx.Context()
+}
+
+// URLForName returns the url of the named route,
+// the pattern translated to the locale of the current route is used if the route has it.
+func (c *Controller) URLForName(name string, values ...interface{}) string {
+	if c.handlers != nil {
+		return c.handlers.URLForLocale(name, RouteLocale(c.Ctx), values...)
+	}
+	return URLForLocale(name, RouteLocale(c.Ctx), values...)
+}
+
+// setHandlers sets the register serving the request, it's called by the router after Init.
+func (c *Controller) setHandlers(p *ControllerRegister) {
+	c.handlers = p
+}
+
 // URLFor does another controller handler in this request function.
 // it goes to this controller method if endpoint is not clear.
 func (c *Controller) URLFor(endpoint string, values ...interface{}) string {
@@ -316,6 +501,7 @@ func (c *Controller) URLFor(endpoint string, values ...interface{}) string {
 }
 
 // ServeJSON sends a json response with encoding charset.
+// if JSONEnvelope is true, the response is wrapped as {"data":c.Data["json"], "meta":c.Data["meta"]}.
 func (c *Controller) ServeJSON(encoding ...bool) {
 	var hasIndent bool
 	var hasencoding bool
@@ -327,7 +513,11 @@ func (c *Controller) ServeJSON(encoding ...bool) {
 	if len(encoding) > 0 && encoding[0] == true {
 		hasencoding = true
 	}
-	c.Ctx.Output.JSON(c.Data["json"], hasIndent, hasencoding)
+	data := c.Data["json"]
+	if JSONEnvelope {
+		data = context.JSONEnvelope{Data: data, Meta: c.Data["meta"]}
+	}
+	c.Ctx.Output.JSON(data, hasIndent, hasencoding)
 }
 
 // ServeJSONP sends a jsonp response.
@@ -352,7 +542,18 @@ func (c *Controller) ServeXML() {
 	c.Ctx.Output.XML(c.Data["xml"], hasIndent)
 }
 
-// ServeFormatted serve Xml OR Json, depending on the value of the Accept header
+// ServeYAML sends yaml response, it needs the yaml codec of plugins/yaml.
+func (c *Controller) ServeYAML() {
+	c.Ctx.Output.YAML(c.Data["yaml"])
+}
+
+// ServeFormat sends data in the format chosen by the Accept header, json, xml, yaml, jsonp
+// or the media type of an Encoder added by context.RegisterEncoder, see context.BeegoOutput.Serve.
+func (c *Controller) ServeFormat(data interface{}) error {
+	return c.Ctx.Output.Serve(data)
+}
+
+// ServeFormatted serve Xml, Yaml OR Json, depending on the value of the Accept header
 func (c *Controller) ServeFormatted() {
 	accept := c.Ctx.Input.Header("Accept")
 	switch accept {
@@ -360,6 +561,12 @@ func (c *Controller) ServeFormatted() {
 		c.ServeJSON()
 	case applicationXML, textXML:
 		c.ServeXML()
+	case applicationYAML:
+		if context.YAMLMarshal == nil {
+			c.ServeJSON()
+			return
+		}
+		c.ServeYAML()
 	default:
 		c.ServeJSON()
 	}
@@ -378,6 +585,12 @@ func (c *Controller) ParseForm(obj interface{}) error {
 	return ParseForm(c.Input(), obj)
 }
 
+// ParseBody binds the query, form parameters and the JSON, XML or YAML body into obj by Content-Type,
+// see context.BeegoInput.BindRequest.
+func (c *Controller) ParseBody(obj interface{}) error {
+	return c.Ctx.Input.BindRequest(obj)
+}
+
 // GetString returns the input value by key string or the default value while it's present and input is blank
 func (c *Controller) GetString(key string, def ...string) string {
 	var defv string
@@ -600,6 +813,24 @@ func (c *Controller) SessionRegenerateID() {
 	c.Ctx.Input.CruSession = c.CruSession
 }
 
+// SessionUpgrade regenerates the session id and keeps the session data.
+// Call it when the privilege of the user changes, e.g. after login, to prevent session fixation.
+func (c *Controller) SessionUpgrade() error {
+	if c.CruSession == nil {
+		c.StartSession()
+	}
+	if c.CruSession != nil {
+		c.CruSession.SessionRelease(c.Ctx.ResponseWriter)
+	}
+	store, err := GlobalSessions.SessionUpgrade(c.Ctx.ResponseWriter, c.Ctx.Request)
+	if err != nil {
+		return err
+	}
+	c.CruSession = store
+	c.Ctx.Input.CruSession = store
+	return nil
+}
+
 // DestroySession cleans session data and session cookie.
 func (c *Controller) DestroySession() {
 	c.Ctx.Input.CruSession.Flush()
@@ -0,0 +1,88 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"reflect"
+	"sync"
+)
+
+// ResettableController is a controller reused by the requests instead of allocating a new one by reflect.New per request.
+// Reset is called when the request and the functions started by ctx.Go finished, it must clear the fields
+// of the controller itself, the embedded Controller is cleared by the router. The controller mustn't be used
+// after the request, e.g. by a goroutine started in the handler. A controller which panics isn't reused.
+// Pooled, a simple JSON handler allocates 1568 instead of 1872 bytes per request and runs 3-5% faster, within the noise,
+// see BenchmarkControllerJSONPooled.
+// usage:
+//
+//	type UserController struct {
+//		beego.Controller
+//		user *models.User
+//	}
+//
+//	func (c *UserController) Reset() {
+//		c.user = nil
+//	}
+type ResettableController interface {
+	ControllerInterface
+	Reset()
+}
+
+var (
+	resettableControllerType = reflect.TypeOf((*ResettableController)(nil)).Elem()
+	// controllerPools are the pools of the ResettableController types, the pool is nil for the other types
+	controllerPools sync.Map
+)
+
+// newController returns a new controller of t, it's taken from the pool of t if it's a ResettableController.
+func newController(t reflect.Type) (reflect.Value, *sync.Pool) {
+	pool := controllerPool(t)
+	if pool == nil {
+		return reflect.New(t), nil
+	}
+	return reflect.ValueOf(pool.Get()), pool
+}
+
+func controllerPool(t reflect.Type) *sync.Pool {
+	if v, ok := controllerPools.Load(t); ok {
+		return v.(*sync.Pool)
+	}
+	var pool *sync.Pool
+	if reflect.PtrTo(t).Implements(resettableControllerType) {
+		pool = &sync.Pool{New: func() interface{} {
+			return reflect.New(t).Interface()
+		}}
+	}
+	v, _ := controllerPools.LoadOrStore(t, pool)
+	return v.(*sync.Pool)
+}
+
+// releaseController resets the controller and its embedded Controller, then puts it back into its pool.
+func releaseController(pool *sync.Pool, c ControllerInterface) {
+	c.(ResettableController).Reset()
+	if r, ok := c.(interface{ resetController() }); ok {
+		r.resetController()
+	}
+	pool.Put(c)
+}
+
+// resetController clears the Controller of a pooled controller, the method mapping is emptied and reused.
+func (c *Controller) resetController() {
+	methodMapping := c.methodMapping
+	for k := range methodMapping {
+		delete(methodMapping, k)
+	}
+	*c = Controller{methodMapping: methodMapping}
+}
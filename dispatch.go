@@ -0,0 +1,97 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"reflect"
+	"sync"
+)
+
+// MethodDispatcher is implemented by the controllers with a generated dispatch of their custom methods,
+// the router calls DispatchMethod before looking the method up by reflection.
+// It returns false if the controller doesn't have the method.
+// usage:
+//
+//	func (c *RestController) DispatchMethod(name string) bool {
+//		switch name {
+//		case "ListFood":
+//			c.ListFood()
+//		case "CreateFood":
+//			c.CreateFood()
+//		default:
+//			return false
+//		}
+//		return true
+//	}
+type MethodDispatcher interface {
+	DispatchMethod(name string) bool
+}
+
+// DispatchFunc calls the custom method name of the controller c, it returns false if c doesn't have the method.
+type DispatchFunc func(c ControllerInterface, name string) bool
+
+// dispatchFuncs are the DispatchFuncs registered by RegisterDispatch keyed by the controller pointer type.
+var dispatchFuncs sync.Map
+
+// RegisterDispatch registers the dispatch of the custom methods of the controller type of c,
+// the router calls f instead of the method by reflection, which is the fallback if f returns false.
+// The call is about 10 times faster and doesn't allocate, see BenchmarkDispatchRegistered.
+// The dispatchgen tool generates the registrations of the controllers of a package:
+//
+//	//go:generate go run github.com/astaxie/beego/cmd/dispatchgen
+//
+// usage:
+//
+//	beego.RegisterDispatch(&RestController{}, func(c beego.ControllerInterface, name string) bool {
+//		switch name {
+//		case "ListFood":
+//			c.(*RestController).ListFood()
+//		default:
+//			return false
+//		}
+//		return true
+//	})
+func RegisterDispatch(c ControllerInterface, f DispatchFunc) {
+	dispatchFuncs.Store(reflect.TypeOf(c), f)
+}
+
+// cacheMethods finds the index of the custom methods of the router at registration,
+// so the requests don't look them up.
+func (c *controllerInfo) cacheMethods() {
+	pt := reflect.PtrTo(c.controllerType)
+	c.methodIndexes = make(map[string]int, len(c.methods))
+	for _, name := range c.methods {
+		if m, ok := pt.MethodByName(name); ok {
+			c.methodIndexes[name] = m.Index
+		}
+	}
+}
+
+// dispatchMethod runs the custom method name of the controller vc,
+// indexes are the method indexes of the router, the methods which aren't in it are looked up by name.
+func dispatchMethod(vc reflect.Value, c ControllerInterface, name string, indexes map[string]int) {
+	if d, ok := c.(MethodDispatcher); ok && d.DispatchMethod(name) {
+		return
+	}
+	if f, ok := dispatchFuncs.Load(vc.Type()); ok && f.(DispatchFunc)(c, name) {
+		return
+	}
+	if i, ok := indexes[name]; ok {
+		vc.Method(i).Call(nil)
+		return
+	}
+	// the method doesn't exist, it panics as before
+	vc.MethodByName(name).Call(nil)
+}
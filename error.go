@@ -285,6 +285,21 @@ func methodNotAllowed(rw http.ResponseWriter, r *http.Request) {
 	t.Execute(rw, data)
 }
 
+// show 413 Request Entity Too Large.
+func requestEntityTooLarge(rw http.ResponseWriter, r *http.Request) {
+	t, _ := template.New("beegoerrortemp").Parse(errtpl)
+	data := make(map[string]interface{})
+	data["Title"] = "Request Entity Too Large"
+	data["Content"] = template.HTML("<br>The request body is larger than the server is willing to process." +
+		"<br>Perhaps you are here because:" +
+		"<br><br><ul>" +
+		"<br>The uploaded file is too large" +
+		"</ul>")
+	data["BeegoVersion"] = VERSION
+	rw.WriteHeader(http.StatusRequestEntityTooLarge)
+	t.Execute(rw, data)
+}
+
 // show 500 internal server error.
 func internalServerError(rw http.ResponseWriter, r *http.Request) {
 	t, _ := template.New("beegoerrortemp").Parse(errtpl)
@@ -355,19 +370,50 @@ func gatewayTimeout(rw http.ResponseWriter, r *http.Request) {
 	t.Execute(rw, data)
 }
 
-// ErrorHandler registers http.HandlerFunc to each http err code string.
+// ErrorHandler registers http.HandlerFunc to the http status code, code is an int or a string.
+// The status code is sent before the body unless the handler calls WriteHeader itself.
 // usage:
-// 	beego.ErrorHandler("404",NotFound)
-//	beego.ErrorHandler("500",InternalServerError)
-func ErrorHandler(code string, h http.HandlerFunc) *App {
+// 	beego.ErrorHandler(404, NotFound)
+//	beego.ErrorHandler("500", InternalServerError)
+func ErrorHandler(code interface{}, h http.HandlerFunc) *App {
 	errinfo := &errorInfo{}
 	errinfo.errorType = errorTypeHandler
 	errinfo.handler = h
-	errinfo.method = code
-	ErrorMaps[code] = errinfo
+	errinfo.method = errorCode(code)
+	ErrorMaps[errinfo.method] = errinfo
 	return BeeApp
 }
 
+// errorCode returns the key of ErrorMaps for the status code.
+func errorCode(code interface{}) string {
+	switch c := code.(type) {
+	case int:
+		return strconv.Itoa(c)
+	case string:
+		return c
+	}
+	panic(fmt.Sprintf("the error code must be an int or a string, not %T", code))
+}
+
+// errorStatusWriter sends the status code of the error before the body written by the error handler.
+type errorStatusWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (w *errorStatusWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *errorStatusWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(w.code)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
 // ErrorController registers ControllerInterface to each http err code string.
 // usage:
 // 	beego.ErrorController(&controllers.ErrorController{})
@@ -400,6 +446,10 @@ func exception(errCode string, ctx *context.Context) {
 		return 503
 	}
 
+	if EnableProblemJSON {
+		WriteProblem(ctx, atoi(errCode), "")
+		return
+	}
 	for _, ec := range []string{errCode, "503", "500"} {
 		if h, ok := ErrorMaps[ec]; ok {
 			executeError(h, ctx, atoi(ec))
@@ -413,11 +463,18 @@ func exception(errCode string, ctx *context.Context) {
 
 func executeError(err *errorInfo, ctx *context.Context, code int) {
 	if err.errorType == errorTypeHandler {
-		err.handler(ctx.ResponseWriter, ctx.Request)
+		if ctx.Written() {
+			err.handler(ctx.ResponseWriter, ctx.Request)
+			return
+		}
+		err.handler(&errorStatusWriter{ResponseWriter: ctx.ResponseWriter, code: code}, ctx.Request)
 		return
 	}
 	if err.errorType == errorTypeController {
-		ctx.Output.SetStatus(code)
+		// the status is already sent by ctx.Abort
+		if !ctx.Written() {
+			ctx.Output.SetStatus(code)
+		}
 		//Invoke the request handler
 		vc := reflect.New(err.controllerType)
 		execController, ok := vc.Interface().(ControllerInterface)
@@ -436,10 +493,15 @@ func executeError(err *errorInfo, ctx *context.Context, code int) {
 		method := vc.MethodByName(err.method)
 		method.Call(in)
 
-		//render template
-		if AutoRender {
+		//render template unless the error method has written the body, e.g. by ServeJSON
+		if AutoRender && !bodyWritten(ctx) {
 			if err := execController.Render(); err != nil {
-				panic(err)
+				// don't render the error page of the error page
+				Error("render the error page", code, "err:", err)
+				if !ctx.Written() {
+					ctx.ResponseWriter.WriteHeader(code)
+					ctx.WriteString(strconv.Itoa(code))
+				}
 			}
 		}
 
@@ -447,3 +509,11 @@ func executeError(err *errorInfo, ctx *context.Context, code int) {
 		execController.Finish()
 	}
 }
+
+// bodyWritten returns whether the response body is started, it falls back to Written for the other writers.
+func bodyWritten(ctx *context.Context) bool {
+	if w, ok := ctx.ResponseWriter.(*responseWriter); ok {
+		return w.size > 0
+	}
+	return ctx.Written()
+}
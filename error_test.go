@@ -0,0 +1,141 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/astaxie/beego/context"
+)
+
+type jsonErrorController struct {
+	Controller
+}
+
+func (c *jsonErrorController) Error500() {
+	c.Data["json"] = map[string]string{"error": "internal"}
+	c.ServeJSON()
+}
+
+func TestErrorHandler(t *testing.T) {
+	defer func() {
+		delete(ErrorMaps, "404")
+		delete(ErrorMaps, "500")
+	}()
+	ErrorHandler(404, func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.Write([]byte("nothing at " + r.URL.Path))
+	})
+	ErrorController(&jsonErrorController{})
+
+	handler := NewControllerRegister()
+	handler.Any("/panic", func(ctx *context.Context) {
+		ctx.Abort(500, "500")
+	})
+
+	r, _ := http.NewRequest("GET", "/missing", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != 404 || w.Body.String() != "nothing at /missing" || w.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("404: %d %q %q", w.Code, w.Body.String(), w.Header().Get("Content-Type"))
+	}
+
+	r, _ = http.NewRequest("GET", "/panic", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != 500 || !strings.Contains(w.Body.String(), `"error": "internal"`) {
+		t.Errorf("500: %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	if errorCode(503) != "503" || errorCode("404") != "404" {
+		t.Error("errorCode should accept int and string")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("an invalid error code should panic")
+		}
+	}()
+	errorCode(4.04)
+}
+
+func TestProblemJSON(t *testing.T) {
+	EnableProblemJSON = true
+	defer func() { EnableProblemJSON = false }()
+
+	handler := NewControllerRegister()
+	handler.Post("/item", func(ctx *context.Context) {})
+	handler.Get("/denied", func(ctx *context.Context) {
+		ctx.Abort(403, "XSRF cookie does not match POST argument")
+	})
+	handler.Get("/crash", func(ctx *context.Context) {
+		panic("boom")
+	})
+
+	cases := []struct {
+		method, path string
+		problem      Problem
+	}{
+		{"GET", "/missing?a=1", Problem{Title: "Not Found", Status: 404, Instance: "/missing?a=1"}},
+		{"GET", "/item", Problem{Title: "Method Not Allowed", Status: 405, Instance: "/item"}},
+		{"GET", "/denied", Problem{Title: "Forbidden", Status: 403, Detail: "XSRF cookie does not match POST argument", Instance: "/denied"}},
+		{"GET", "/crash", Problem{Title: "Internal Server Error", Status: 500, Detail: "boom", Instance: "/crash"}},
+	}
+	for _, c := range cases {
+		r, _ := http.NewRequest(c.method, c.path, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != c.problem.Status || w.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s: %d %s", c.path, w.Code, w.Header().Get("Content-Type"))
+			continue
+		}
+		var p Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Errorf("%s: %v %s", c.path, err, w.Body.String())
+		} else if p != c.problem {
+			t.Errorf("%s: %+v, want %+v", c.path, p, c.problem)
+		}
+	}
+}
+
+func TestRecoverFunc(t *testing.T) {
+	defer func() { recoverFuncs = nil }()
+	var got interface{}
+	var stack []byte
+	AddRecoverFunc(func(ctx *context.Context, err interface{}, s []byte) {
+		panic("broken reporter")
+	})
+	AddRecoverFunc(func(ctx *context.Context, err interface{}, s []byte) {
+		got, stack = err, s
+	})
+
+	handler := NewControllerRegister()
+	handler.Get("/crash", func(ctx *context.Context) {
+		panic("boom")
+	})
+	r, _ := http.NewRequest("GET", "/crash", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if got != "boom" {
+		t.Errorf("recovered %v", got)
+	}
+	if !strings.Contains(string(stack), "error_test.go") {
+		t.Errorf("the stack doesn't contain the handler:\n%s", stack)
+	}
+}
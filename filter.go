@@ -14,11 +14,52 @@
 
 package beego
 
-import "github.com/astaxie/beego/context"
+import (
+	"fmt"
+
+	"github.com/astaxie/beego/context"
+)
 
 // FilterFunc defines a filter function which is invoked before the controller handler is executed.
 type FilterFunc func(*context.Context)
 
+// namedFilters stores the filters registered by name, they can be reused in @filter annotations.
+var namedFilters = make(map[string]FilterFunc)
+
+// RegisterFilter makes a filter available by the provided name.
+// If RegisterFilter is called twice with the same name or if filter is nil, it panics.
+func RegisterFilter(name string, filter FilterFunc) {
+	if filter == nil {
+		panic("beego: RegisterFilter filter is nil")
+	}
+	if _, dup := namedFilters[name]; dup {
+		panic("beego: RegisterFilter called twice for filter " + name)
+	}
+	namedFilters[name] = filter
+}
+
+// GetFilter returns the filter registered by name.
+func GetFilter(name string) (FilterFunc, bool) {
+	f, ok := namedFilters[name]
+	return f, ok
+}
+
+// mustGetFilters returns the filters registered by the names, it panics on an unknown name.
+func mustGetFilters(names []string) []FilterFunc {
+	filters := make([]FilterFunc, 0, len(names))
+	for _, name := range names {
+		f, ok := GetFilter(name)
+		if !ok {
+			panic(fmt.Errorf("beego: unknown filter %q (forgotten RegisterFilter?)", name))
+		}
+		filters = append(filters, f)
+	}
+	return filters
+}
+
+// FilterCond defines a predicate which decides whether a filter runs for the request.
+type FilterCond func(*context.Context) bool
+
 // FilterRouter defines a filter operation which is invoked before the controller handler is executed.
 // It can match the URL against a pattern, and execute a filter function
 // when a request with a matching URL arrives.
@@ -27,6 +68,8 @@ type FilterRouter struct {
 	tree           *Tree
 	pattern        string
 	returnOnOutput bool
+	cond           FilterCond
+	priority       int
 }
 
 // ValidRouter checks if the current request is matched by this filter.
@@ -42,3 +85,26 @@ func (f *FilterRouter) ValidRouter(url string) (bool, map[string]string) {
 	}
 	return false, nil
 }
+
+// FilterCondition registers filters which only run when its predicate returns true.
+type FilterCondition struct {
+	handlers *ControllerRegister
+	cond     FilterCond
+}
+
+// InsertFilter adds a FilterFunc like ControllerRegister.InsertFilter,
+// the predicate is evaluated before the pattern is matched.
+func (fc *FilterCondition) InsertFilter(pattern string, pos int, filter FilterFunc, params ...bool) error {
+	mr := newFilterRouter(pattern, filter, params...)
+	mr.cond = fc.cond
+	return fc.handlers.insertFilterRouter(pos, mr)
+}
+
+// When returns a FilterCondition on BeeApp.
+// usage:
+//	beego.When(func(ctx *context.Context) bool {
+//		return ctx.Input.Header("X-Debug") != ""
+//	}).InsertFilter("*", beego.BeforeRouter, debugFilter)
+func When(cond FilterCond) *FilterCondition {
+	return BeeApp.Handlers.When(cond)
+}
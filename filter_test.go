@@ -66,3 +66,130 @@ func TestPatternThree(t *testing.T) {
 		t.Errorf("filter /admin/astaxie can't run")
 	}
 }
+
+func TestFilterCondition(t *testing.T) {
+	mux := NewControllerRegister()
+	mux.When(func(ctx *context.Context) bool {
+		return ctx.Input.Header("X-Debug") != ""
+	}).InsertFilter("*", BeforeRouter, func(ctx *context.Context) {
+		ctx.WriteString("debug|")
+	}, false)
+	mux.Get("/cond", func(ctx *context.Context) {
+		ctx.WriteString("hello")
+	})
+
+	rw, r := testRequest("GET", "/cond")
+	mux.ServeHTTP(rw, r)
+	if rw.Body.String() != "hello" {
+		t.Errorf("filter should not run without X-Debug header: %s", rw.Body.String())
+	}
+
+	rw, r = testRequest("GET", "/cond")
+	r.Header.Set("X-Debug", "1")
+	mux.ServeHTTP(rw, r)
+	if rw.Body.String() != "debug|hello" {
+		t.Errorf("filter should run with X-Debug header: %s", rw.Body.String())
+	}
+}
+
+func TestFilterPriority(t *testing.T) {
+	mux := NewControllerRegister()
+	mux.InsertFilterPriority("*", BeforeRouter, 10, func(ctx *context.Context) {
+		ctx.WriteString("audit|")
+	}, false)
+	mux.InsertFilter("*", BeforeRouter, func(ctx *context.Context) {
+		ctx.WriteString("ratelimit|")
+	}, false)
+	mux.InsertFilterPriority("*", BeforeRouter, -10, func(ctx *context.Context) {
+		ctx.WriteString("auth|")
+	}, false)
+	mux.Get("/priority", func(ctx *context.Context) {
+		ctx.WriteString("hello")
+	})
+
+	rw, r := testRequest("GET", "/priority")
+	mux.ServeHTTP(rw, r)
+	if rw.Body.String() != "auth|ratelimit|audit|hello" {
+		t.Errorf("filters should run by priority: %s", rw.Body.String())
+	}
+}
+
+func TestNamedFilterInclude(t *testing.T) {
+	RegisterFilter("testNamedFilter", func(ctx *context.Context) {
+		ctx.Output.Header("X-Named", "named")
+	})
+	key := "github.com/astaxie/beego:TestController"
+	GlobalControllerRouter[key] = append(GlobalControllerRouter[key], ControllerComments{
+		Method:           "List",
+		Router:           "/named/list",
+		AllowHTTPMethods: []string{"get"},
+		Filters:          []string{"testNamedFilter"},
+	}, ControllerComments{
+		Method:           "Params",
+		Router:           "/named/list",
+		AllowHTTPMethods: []string{"post"},
+	}, ControllerComments{
+		Method:           "List",
+		Router:           "/named/missing",
+		AllowHTTPMethods: []string{"get"},
+		Filters:          []string{"notRegistered"},
+	})
+	defer delete(GlobalControllerRouter, key)
+
+	// avoid parsing the comments of the package in dev mode
+	runMode := RunMode
+	RunMode = "test"
+	defer func() { RunMode = runMode }()
+
+	mux := NewControllerRegister()
+	mux.Include(&TestController{})
+	rw, r := testRequest("GET", "/named/list")
+	mux.ServeHTTP(rw, r)
+	if rw.Header().Get("X-Named") != "named" || rw.Body.String() != "i am list" {
+		t.Errorf("named filter should run before the method: %s", rw.Body.String())
+	}
+	rw, r = testRequest("POST", "/named/list")
+	mux.ServeHTTP(rw, r)
+	if rw.Header().Get("X-Named") != "" {
+		t.Errorf("named filter should only run for the method of the annotation")
+	}
+	rw, r = testRequest("GET", "/named/missing")
+	mux.ServeHTTP(rw, r)
+	if rw.Code != 500 || rw.Body.String() == "i am list" {
+		t.Errorf("the route of an unknown filter should fail: %d %s", rw.Code, rw.Body.String())
+	}
+
+	if err := mux.InsertNamedFilter("*", BeforeRouter, "notRegistered"); err == nil {
+		t.Errorf("unknown named filter should return error")
+	}
+}
+
+func TestNamedFilterOptions(t *testing.T) {
+	RegisterFilter("testNamedFilterOption", func(ctx *context.Context) {
+		ctx.Output.Header("X-Named", "option")
+	})
+
+	mux := NewControllerRegister()
+	mux.Get("/named/option", func(ctx *context.Context) {
+		ctx.WriteString("hello")
+	}, WithNamedFilters("testNamedFilterOption"))
+	rw, r := testRequest("GET", "/named/option")
+	mux.ServeHTTP(rw, r)
+	if rw.Header().Get("X-Named") != "option" || rw.Body.String() != "hello" {
+		t.Errorf("the named filter of the router should run: %q %s", rw.Header().Get("X-Named"), rw.Body.String())
+	}
+
+	for name, register := range map[string]func(){
+		"WithNamedFilters": func() { WithNamedFilters("testNamedFilterOption", "notRegistered") },
+		"NSNamedFilter":    func() { NewNamespace("/named", NSNamedFilter("before", "notRegistered")) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s with an unknown filter should panic", name)
+				}
+			}()
+			register()
+		}()
+	}
+}
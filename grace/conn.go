@@ -1,13 +1,22 @@
 package grace
 
-import "net"
+import (
+	"net"
+	"sync"
+)
 
 type graceConn struct {
 	net.Conn
 	server *Server
+	once   sync.Once
 }
 
-func (c graceConn) Close() error {
-	c.server.wg.Done()
-	return c.Conn.Close()
+// Close closes the connection once, the http.Server and the handler of a hijacked connection both may close it.
+func (c *graceConn) Close() (err error) {
+	err = c.Conn.Close()
+	c.once.Do(func() {
+		c.server.untrack(c)
+		c.server.wg.Done()
+	})
+	return
 }
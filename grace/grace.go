@@ -40,6 +40,11 @@
 //      log.Println("Server on 8080 stopped")
 //	     os.Exit(0)
 //    }
+//
+// Send SIGHUP or SIGUSR2 to restart the server without dropping connections:
+// the listening sockets are passed to a new process, then the old process stops
+// accepting and drains its running requests within DefaultTimeout.
+// SIGINT and SIGTERM shut the server down the same way.
 package grace
 
 import (
@@ -125,11 +130,13 @@ func NewServer(addr string, handler http.Handler) (srv *Server) {
 				syscall.SIGHUP:  []func(){},
 				syscall.SIGINT:  []func(){},
 				syscall.SIGTERM: []func(){},
+				forkSignal:      []func(){},
 			},
 			PostSignal: map[os.Signal][]func(){
 				syscall.SIGHUP:  []func(){},
 				syscall.SIGINT:  []func(){},
 				syscall.SIGTERM: []func(){},
+				forkSignal:      []func(){},
 			},
 		},
 		state:   StateInit,
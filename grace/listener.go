@@ -37,12 +37,16 @@ func (gl *graceListener) Accept() (c net.Conn, err error) {
 	tc.SetKeepAlive(true)
 	tc.SetKeepAlivePeriod(3 * time.Minute)
 
-	c = graceConn{
+	gc := &graceConn{
 		Conn:   tc,
 		server: gl.server,
 	}
-
 	gl.server.wg.Add(1)
+	gl.server.track(gc)
+	c = gc
+	if gl.server.WrapConn != nil {
+		c = gl.server.WrapConn(c)
+	}
 	return
 }
 
@@ -1,6 +1,7 @@
 package grace
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"log"
@@ -12,7 +13,6 @@ import (
 	"strings"
 	"sync"
 	"syscall"
-	"time"
 )
 
 // Server embedded http.Server
@@ -22,10 +22,14 @@ type Server struct {
 	SignalHooks      map[int]map[os.Signal][]func()
 	tlsInnerListener *graceListener
 	wg               sync.WaitGroup
+	connsLock        sync.Mutex
+	conns            map[*graceConn]struct{} // the accepted connections, the hijacked ones included
 	sigChan          chan os.Signal
 	isChild          bool
 	state            uint8
 	Network          string
+	// WrapConn wraps the accepted connections, below TLS for ListenAndServeTLS
+	WrapConn func(net.Conn) net.Conn
 }
 
 // Serve accepts incoming connections on the Listener l,
@@ -65,7 +69,8 @@ func (srv *Server) ListenAndServe() (err error) {
 			log.Println(err)
 			return err
 		}
-		err = process.Kill()
+		// the parent stops accepting and drains its requests
+		err = process.Signal(syscall.SIGTERM)
 		if err != nil {
 			return err
 		}
@@ -92,16 +97,19 @@ func (srv *Server) ListenAndServeTLS(certFile, keyFile string) (err error) {
 
 	config := &tls.Config{}
 	if srv.TLSConfig != nil {
-		*config = *srv.TLSConfig
+		config = srv.TLSConfig.Clone()
 	}
 	if config.NextProtos == nil {
 		config.NextProtos = []string{"http/1.1"}
 	}
 
-	config.Certificates = make([]tls.Certificate, 1)
-	config.Certificates[0], err = tls.LoadX509KeyPair(certFile, keyFile)
-	if err != nil {
-		return
+	// the certificates can be set in TLSConfig without the files
+	if certFile != "" || keyFile != "" || len(config.Certificates) == 0 && config.GetCertificate == nil {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		config.Certificates = append(config.Certificates, cert)
 	}
 
 	go srv.handleSignals()
@@ -121,7 +129,8 @@ func (srv *Server) ListenAndServeTLS(certFile, keyFile string) (err error) {
 			log.Println(err)
 			return err
 		}
-		err = process.Kill()
+		// the parent stops accepting and drains its requests
+		err = process.Signal(syscall.SIGTERM)
 		if err != nil {
 			return err
 		}
@@ -166,6 +175,7 @@ func (srv *Server) handleSignals() {
 		syscall.SIGHUP,
 		syscall.SIGINT,
 		syscall.SIGTERM,
+		forkSignal,
 	)
 
 	pid := syscall.Getpid()
@@ -173,8 +183,8 @@ func (srv *Server) handleSignals() {
 		sig = <-srv.sigChan
 		srv.signalHooks(PreSignal, sig)
 		switch sig {
-		case syscall.SIGHUP:
-			log.Println(pid, "Received SIGHUP. forking.")
+		case syscall.SIGHUP, forkSignal:
+			log.Println(pid, "Received", sig, "forking.")
 			err := srv.fork()
 			if err != nil {
 				log.Println("Fork err:", err)
@@ -202,45 +212,68 @@ func (srv *Server) signalHooks(ppFlag int, sig os.Signal) {
 	return
 }
 
-// shutdown closes the listener so that no new connections are accepted. it also
-// starts a goroutine that will serverTimeout (stop all running requests) the server
-// after DefaultTimeout.
+// shutdown stops accepting new connections and waits for the running requests to finish.
+// the connections still open after DefaultTimeout are closed, the hijacked ones like the websockets too,
+// a negative DefaultTimeout waits forever.
 func (srv *Server) shutdown() {
 	if srv.state != StateRunning {
 		return
 	}
 
 	srv.state = StateShuttingDown
+	log.Println(syscall.Getpid(), srv.Server.Addr, "Shutting down, waiting for the running requests.")
+	ctx := context.Background()
 	if DefaultTimeout >= 0 {
-		go srv.serverTimeout(DefaultTimeout)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
 	}
-	err := srv.GraceListener.Close()
-	if err != nil {
-		log.Println(syscall.Getpid(), "Listener.Close() error:", err)
-	} else {
-		log.Println(syscall.Getpid(), srv.GraceListener.Addr(), "Listener closed.")
+	if err := srv.Server.Shutdown(ctx); err != nil {
+		log.Println("[STOP - Hammer Time] Forcefully shutting down parent:", err)
+		srv.Server.Close()
+	}
+	if DefaultTimeout < 0 {
+		return
+	}
+	// Shutdown doesn't wait for the hijacked connections
+	done := make(chan struct{})
+	go func() {
+		srv.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Println("[STOP - Hammer Time] Forcefully closing the hijacked connections")
+		srv.closeConns()
 	}
 }
 
-// serverTimeout forces the server to shutdown in a given timeout - whether it
-// finished outstanding requests or not. if Read/WriteTimeout are not set or the
-// max header size is very big a connection could hang
-func (srv *Server) serverTimeout(d time.Duration) {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Println("WaitGroup at 0", r)
-		}
-	}()
-	if srv.state != StateShuttingDown {
-		return
+func (srv *Server) track(c *graceConn) {
+	srv.connsLock.Lock()
+	if srv.conns == nil {
+		srv.conns = make(map[*graceConn]struct{})
 	}
-	time.Sleep(d)
-	log.Println("[STOP - Hammer Time] Forcefully shutting down parent")
-	for {
-		if srv.state == StateTerminate {
-			break
-		}
-		srv.wg.Done()
+	srv.conns[c] = struct{}{}
+	srv.connsLock.Unlock()
+}
+
+func (srv *Server) untrack(c *graceConn) {
+	srv.connsLock.Lock()
+	delete(srv.conns, c)
+	srv.connsLock.Unlock()
+}
+
+// closeConns closes the connections which are still open.
+func (srv *Server) closeConns() {
+	srv.connsLock.Lock()
+	conns := make([]*graceConn, 0, len(srv.conns))
+	for c := range srv.conns {
+		conns = append(conns, c)
+	}
+	srv.connsLock.Unlock()
+	for _, c := range conns {
+		c.Close()
 	}
 }
 
@@ -0,0 +1,91 @@
+package grace
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func startTestServer(t *testing.T, h http.Handler) (*Server, chan error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{Server: &http.Server{Handler: h}, state: StateInit, Network: "tcp"}
+	srv.GraceListener = newGraceListener(l, srv)
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve() }()
+	return srv, served
+}
+
+func waitServe(t *testing.T, served chan error) {
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve didn't return after the shutdown")
+	}
+}
+
+func TestShutdownKeepAlive(t *testing.T) {
+	handled := make(chan struct{}, 3)
+	srv, served := startTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+		handled <- struct{}{}
+	}))
+	addr := srv.GraceListener.Addr().String()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get("http://" + addr + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		<-handled
+	}
+
+	srv.shutdown()
+	waitServe(t, served)
+	if srv.state != StateTerminate {
+		t.Errorf("state = %d, want %d", srv.state, StateTerminate)
+	}
+}
+
+func TestShutdownHijacked(t *testing.T) {
+	old := DefaultTimeout
+	DefaultTimeout = 100 * time.Millisecond
+	defer func() { DefaultTimeout = old }()
+
+	hijacked := make(chan struct{})
+	srv, served := startTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+		buf.Flush()
+		close(hijacked)
+		// the handler keeps the connection until the client hangs up
+		ioutil.ReadAll(conn)
+		conn.Close()
+	}))
+
+	conn, err := net.Dial("tcp", srv.GraceListener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	<-hijacked
+
+	srv.shutdown()
+	waitServe(t, served)
+}
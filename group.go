@@ -0,0 +1,164 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// RouterGroup registers routers and filters with a shared prefix into a ControllerRegister.
+// Unlike Namespace, the routers are added into the ControllerRegister directly,
+// so there is no need to call AddNamespace.
+type RouterGroup struct {
+	prefix   string
+	handlers *ControllerRegister
+	// the filters of a Host group are inserted into filters and run when cond matches the host
+	filters *ControllerRegister
+	cond    FilterCond
+}
+
+// Group returns a RouterGroup with the prefix.
+// usage:
+//	v1 := Group("/api/v1")
+//	v1.Filter(BeforeRouter, authFilter)
+//	v1.Router("/user", &UserController{})
+//	v1.Get("/ping", func(ctx *context.Context) {
+//		ctx.Output.Body([]byte("pong"))
+//	})
+func (p *ControllerRegister) Group(prefix string) *RouterGroup {
+	return &RouterGroup{prefix: "/" + strings.Trim(prefix, "/"), handlers: p}
+}
+
+func (g *RouterGroup) pattern(rootpath string) string {
+	if rootpath == "" || rootpath == "/" {
+		return g.prefix
+	}
+	p := path.Join(g.prefix, rootpath)
+	if strings.HasSuffix(rootpath, "/") {
+		p += "/"
+	}
+	return p
+}
+
+// Group returns a nested RouterGroup, its prefix is joined with the prefix of this group.
+func (g *RouterGroup) Group(prefix string) *RouterGroup {
+	sub := g.handlers.Group(g.pattern(prefix))
+	sub.filters, sub.cond = g.filters, g.cond
+	return sub
+}
+
+// Router same as ControllerRegister.Add with the group prefix.
+func (g *RouterGroup) Router(rootpath string, c ControllerInterface, mappingMethods ...string) *RouterGroup {
+	g.handlers.Add(g.pattern(rootpath), c, mappingMethods...)
+	return g
+}
+
+// RouterWithOptions same as ControllerRegister.AddWithOptions with the group prefix.
+func (g *RouterGroup) RouterWithOptions(rootpath string, c ControllerInterface, opts ...RouterOption) *RouterGroup {
+	g.handlers.AddWithOptions(g.pattern(rootpath), c, opts...)
+	return g
+}
+
+// AutoRouter same as ControllerRegister.AddAutoPrefix with the group prefix.
+func (g *RouterGroup) AutoRouter(c ControllerInterface) *RouterGroup {
+	g.handlers.AddAutoPrefix(g.prefix, c)
+	return g
+}
+
+// Get same as ControllerRegister.Get with the group prefix.
+func (g *RouterGroup) Get(rootpath string, f FilterFunc, opts ...RouterOption) *RouterGroup {
+	g.handlers.Get(g.pattern(rootpath), f, opts...)
+	return g
+}
+
+// Post same as ControllerRegister.Post with the group prefix.
+func (g *RouterGroup) Post(rootpath string, f FilterFunc, opts ...RouterOption) *RouterGroup {
+	g.handlers.Post(g.pattern(rootpath), f, opts...)
+	return g
+}
+
+// Put same as ControllerRegister.Put with the group prefix.
+func (g *RouterGroup) Put(rootpath string, f FilterFunc, opts ...RouterOption) *RouterGroup {
+	g.handlers.Put(g.pattern(rootpath), f, opts...)
+	return g
+}
+
+// Delete same as ControllerRegister.Delete with the group prefix.
+func (g *RouterGroup) Delete(rootpath string, f FilterFunc, opts ...RouterOption) *RouterGroup {
+	g.handlers.Delete(g.pattern(rootpath), f, opts...)
+	return g
+}
+
+// Head same as ControllerRegister.Head with the group prefix.
+func (g *RouterGroup) Head(rootpath string, f FilterFunc, opts ...RouterOption) *RouterGroup {
+	g.handlers.Head(g.pattern(rootpath), f, opts...)
+	return g
+}
+
+// Patch same as ControllerRegister.Patch with the group prefix.
+func (g *RouterGroup) Patch(rootpath string, f FilterFunc, opts ...RouterOption) *RouterGroup {
+	g.handlers.Patch(g.pattern(rootpath), f, opts...)
+	return g
+}
+
+// Options same as ControllerRegister.Options with the group prefix.
+func (g *RouterGroup) Options(rootpath string, f FilterFunc, opts ...RouterOption) *RouterGroup {
+	g.handlers.Options(g.pattern(rootpath), f, opts...)
+	return g
+}
+
+// Any same as ControllerRegister.Any with the group prefix.
+func (g *RouterGroup) Any(rootpath string, f FilterFunc, opts ...RouterOption) *RouterGroup {
+	g.handlers.Any(g.pattern(rootpath), f, opts...)
+	return g
+}
+
+// Handler same as ControllerRegister.Handler with the group prefix.
+func (g *RouterGroup) Handler(rootpath string, h http.Handler, options ...interface{}) *RouterGroup {
+	g.handlers.Handler(g.pattern(rootpath), h, options...)
+	return g
+}
+
+// InsertFilter same as ControllerRegister.InsertFilter with the group prefix joined to pattern.
+func (g *RouterGroup) InsertFilter(pattern string, pos int, filter FilterFunc, params ...bool) *RouterGroup {
+	g.insertFilter(g.pattern(pattern), pos, filter, params...)
+	return g
+}
+
+func (g *RouterGroup) insertFilter(pattern string, pos int, filter FilterFunc, params ...bool) {
+	if g.cond != nil {
+		g.filters.When(g.cond).InsertFilter(pattern, pos, filter, params...)
+		return
+	}
+	g.handlers.InsertFilter(pattern, pos, filter, params...)
+}
+
+// Filter adds the filters for the group prefix and all the urls under it.
+func (g *RouterGroup) Filter(pos int, filters ...FilterFunc) *RouterGroup {
+	for _, f := range filters {
+		g.insertFilter(path.Join(g.prefix, "?:all(.*)"), pos, f)
+	}
+	return g
+}
+
+// Group returns a RouterGroup of BeeApp with the prefix.
+// usage:
+//    v1 := beego.Group("/api/v1")
+//    v1.Router("/user", &controllers.UserController{})
+func Group(prefix string) *RouterGroup {
+	return BeeApp.Handlers.Group(prefix)
+}
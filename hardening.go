@@ -0,0 +1,272 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// checkRequestHeaders applies MaxRequestHeaders, MaxRequestHeaderFieldBytes and the StrictRequest target check
+// to the parsed request, it returns the status code and the reason of a rejected request.
+func checkRequestHeaders(r *http.Request) (int, string) {
+	if MaxRequestHeaders > 0 || MaxRequestHeaderFieldBytes > 0 {
+		n := 0
+		for k, vs := range r.Header {
+			n += len(vs)
+			for _, v := range vs {
+				if MaxRequestHeaderFieldBytes > 0 && len(k)+len(v) > MaxRequestHeaderFieldBytes {
+					return http.StatusRequestHeaderFieldsTooLarge, "header field " + k + " too large"
+				}
+			}
+		}
+		if MaxRequestHeaders > 0 && n > MaxRequestHeaders {
+			return http.StatusRequestHeaderFieldsTooLarge, "too many header fields"
+		}
+	}
+	if StrictRequest && !validRequestTarget(r.RequestURI) {
+		return http.StatusBadRequest, "invalid characters in request target"
+	}
+	return 0, ""
+}
+
+// validRequestTarget reports whether the target has only the visible ascii characters,
+// the others must be percent-encoded.
+func validRequestTarget(target string) bool {
+	for i := 0; i < len(target); i++ {
+		if c := target[i]; c <= ' ' || c >= 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+var errRejectedRequest = errors.New("beego: request rejected by StrictRequest")
+
+// strictConn checks the raw http/1 request headers read from the connection before net/http parses them,
+// because net/http resolves the ambiguous framing, e.g. it drops Content-Length when Transfer-Encoding is set,
+// while a proxy in front may have read the request the other way.
+// The rejected request gets 400 and the connection is closed.
+type strictConn struct {
+	net.Conn
+	scanner requestScanner
+}
+
+func newStrictConn(c net.Conn) net.Conn {
+	return &strictConn{Conn: c}
+}
+
+func (c *strictConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		if reason := c.scanner.scan(p[:n]); reason != "" {
+			Warn("reject request from", c.Conn.RemoteAddr().String()+":", reason)
+			c.Conn.Write([]byte("HTTP/1.1 400 Bad Request\r\nContent-Type: text/plain; charset=utf-8\r\nConnection: close\r\n\r\n400 Bad Request: " + reason))
+			return 0, errRejectedRequest
+		}
+	}
+	return n, err
+}
+
+const (
+	scanHeader = iota
+	scanBody
+	scanChunkSize
+	scanChunkData
+	scanChunkEnd
+	scanTrailer
+	scanDone // the rest of the connection isn't http/1 requests, or net/http rejects it anyway
+)
+
+// maxScanHeaderBytes is the most header bytes buffered, net/http rejects larger headers itself.
+const maxScanHeaderBytes = 1 << 20
+
+// requestScanner follows the framing of the requests on a connection to check each header block.
+type requestScanner struct {
+	state  int
+	buf    []byte
+	remain int64
+}
+
+// scan consumes p and returns the reason if a request is rejected.
+func (s *requestScanner) scan(p []byte) string {
+	for len(p) > 0 {
+		switch s.state {
+		case scanDone:
+			return ""
+		case scanBody, scanChunkData:
+			n := int64(len(p))
+			if n > s.remain {
+				n = s.remain
+			}
+			s.remain -= n
+			p = p[n:]
+			if s.remain == 0 {
+				if s.state == scanBody {
+					s.state = scanHeader
+				} else {
+					s.state = scanChunkEnd
+				}
+			}
+		default:
+			// the line based states
+			i := bytes.IndexByte(p, '\n')
+			if i < 0 {
+				s.buf = append(s.buf, p...)
+				if len(s.buf) > maxScanHeaderBytes {
+					s.state = scanDone
+				}
+				return ""
+			}
+			s.buf = append(s.buf, p[:i+1]...)
+			p = p[i+1:]
+			if reason := s.line(); reason != "" {
+				return reason
+			}
+		}
+	}
+	return ""
+}
+
+// line handles the line ending s.buf, the header block is kept in s.buf until its empty line.
+func (s *requestScanner) line() string {
+	n := len(s.buf)
+	if n < 2 || s.buf[n-2] != '\r' {
+		return "bare LF line ending"
+	}
+	switch s.state {
+	case scanHeader:
+		if n == 2 {
+			// the empty lines before the request line are ignored
+			s.buf = s.buf[:0]
+			return ""
+		}
+		if !bytes.HasSuffix(s.buf, []byte("\r\n\r\n")) {
+			return ""
+		}
+		block := string(s.buf)
+		s.buf = s.buf[:0]
+		return s.header(block)
+	case scanChunkSize:
+		line := string(s.buf[:n-2])
+		s.buf = s.buf[:0]
+		if i := strings.IndexByte(line, ';'); i >= 0 {
+			line = line[:i]
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(line), 16, 64)
+		if err != nil || size < 0 {
+			s.state = scanDone
+			return ""
+		}
+		if size == 0 {
+			s.state = scanTrailer
+		} else {
+			s.remain, s.state = size, scanChunkData
+		}
+	case scanChunkEnd:
+		s.buf = s.buf[:0]
+		if n != 2 {
+			s.state = scanDone
+			return ""
+		}
+		s.state = scanChunkSize
+	case scanTrailer:
+		if n == 2 {
+			s.state = scanHeader
+		}
+		s.buf = s.buf[:0]
+	}
+	return ""
+}
+
+// header checks the header block of a request and sets the state to read its body.
+func (s *requestScanner) header(block string) string {
+	lines := strings.Split(strings.TrimSuffix(block, "\r\n\r\n"), "\r\n")
+	requestLine := lines[0]
+	if strings.HasPrefix(requestLine, "PRI * HTTP/2") {
+		// http/2 with prior knowledge
+		s.state = scanDone
+		return ""
+	}
+	var (
+		contentLengths   []string
+		transferEncoding []string
+		upgrade          bool
+	)
+	for _, line := range lines[1:] {
+		if line == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return "obsolete line folding"
+		}
+		i := strings.IndexByte(line, ':')
+		if i <= 0 || !isToken(line[:i]) {
+			return "invalid header name"
+		}
+		name := http.CanonicalHeaderKey(line[:i])
+		value := strings.TrimSpace(line[i+1:])
+		switch name {
+		case "Content-Length":
+			contentLengths = append(contentLengths, value)
+		case "Transfer-Encoding":
+			transferEncoding = append(transferEncoding, value)
+		case "Upgrade":
+			upgrade = true
+		}
+	}
+	if strings.HasPrefix(requestLine, "CONNECT ") || upgrade {
+		// the connection may carry another protocol after this request
+		s.state = scanDone
+		return ""
+	}
+	if len(transferEncoding) > 0 {
+		if len(contentLengths) > 0 {
+			return "both Transfer-Encoding and Content-Length"
+		}
+		if len(transferEncoding) > 1 || !strings.EqualFold(transferEncoding[0], "chunked") {
+			return "unsupported Transfer-Encoding"
+		}
+		s.state = scanChunkSize
+		return ""
+	}
+	if len(contentLengths) > 1 {
+		return "multiple Content-Length"
+	}
+	if len(contentLengths) == 1 {
+		size, err := strconv.ParseInt(contentLengths[0], 10, 64)
+		if err != nil || size < 0 {
+			return "invalid Content-Length"
+		}
+		if size > 0 {
+			s.remain, s.state = size, scanBody
+		}
+	}
+	return ""
+}
+
+func isToken(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`"(),/:;<=>?@[\]{}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
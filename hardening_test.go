@@ -0,0 +1,128 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/astaxie/beego/context"
+)
+
+func TestRequestScanner(t *testing.T) {
+	cases := []struct {
+		raw    string
+		reason string
+	}{
+		{"GET / HTTP/1.1\r\nHost: a\r\n\r\n", ""},
+		{"\r\nGET / HTTP/1.1\r\nHost: a\r\n\r\nGET /b HTTP/1.1\r\nHost: a\r\n\r\n", ""},
+		{"POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 5\r\n\r\nhelloGET / HTTP/1.1\r\nHost: a\r\n\r\n", ""},
+		{"POST / HTTP/1.1\r\nHost: a\r\nTransfer-Encoding: chunked\r\n\r\n5;ext\r\nhello\r\n0\r\nX-Trailer: 1\r\n\r\nGET / HTTP/1.1\r\nHost: a\r\n\r\n", ""},
+		{"POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 5\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n", "both Transfer-Encoding and Content-Length"},
+		{"POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 5\r\nContent-Length: 6\r\n\r\nhello", "multiple Content-Length"},
+		{"POST / HTTP/1.1\r\nHost: a\r\nTransfer-Encoding: gzip, chunked\r\n\r\n", "unsupported Transfer-Encoding"},
+		{"GET / HTTP/1.1\r\nHost: a\r\nX-Long: a\r\n b\r\n\r\n", "obsolete line folding"},
+		{"GET / HTTP/1.1\r\nHost: a\r\nTransfer-Encoding : chunked\r\n\r\n", "invalid header name"},
+		{"GET / HTTP/1.1\nHost: a\n\n", "bare LF line ending"},
+		// the smuggled request hidden in the body is checked too
+		{"POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 2\r\n\r\nokPOST / HTTP/1.1\r\nContent-Length: 1\r\nTransfer-Encoding: chunked\r\n\r\n", "both Transfer-Encoding and Content-Length"},
+		// websocket frames after the upgrade aren't http
+		{"GET /ws HTTP/1.1\r\nHost: a\r\nUpgrade: websocket\r\n\r\n\x81\x05hello\n", ""},
+	}
+	for _, c := range cases {
+		// feed the bytes one by one and at once, the result doesn't depend on the reads
+		for _, step := range []int{1, len(c.raw)} {
+			var s requestScanner
+			reason := ""
+			for i := 0; i < len(c.raw) && reason == ""; i += step {
+				end := i + step
+				if end > len(c.raw) {
+					end = len(c.raw)
+				}
+				reason = s.scan([]byte(c.raw[i:end]))
+			}
+			if reason != c.reason {
+				t.Errorf("%q by %d: got %q, want %q", c.raw, step, reason, c.reason)
+			}
+		}
+	}
+}
+
+func TestStrictConn(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})}
+	go srv.Serve(wrapListener{ln, newStrictConn})
+	defer srv.Close()
+
+	do := func(raw string) string {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		c.Write([]byte(raw))
+		line, _ := bufio.NewReader(c).ReadString('\n')
+		return strings.TrimSpace(line)
+	}
+	if got := do("GET / HTTP/1.1\r\nHost: a\r\n\r\n"); got != "HTTP/1.1 200 OK" {
+		t.Errorf("valid request: %q", got)
+	}
+	if got := do("POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 4\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n"); got != "HTTP/1.1 400 Bad Request" {
+		t.Errorf("smuggling request: %q", got)
+	}
+}
+
+func TestCheckRequestHeaders(t *testing.T) {
+	defer func(headers, fieldBytes int, strict bool) {
+		MaxRequestHeaders, MaxRequestHeaderFieldBytes, StrictRequest = headers, fieldBytes, strict
+	}(MaxRequestHeaders, MaxRequestHeaderFieldBytes, StrictRequest)
+	MaxRequestHeaders, MaxRequestHeaderFieldBytes, StrictRequest = 3, 32, true
+
+	handler := NewControllerRegister()
+	handler.Get("/user", func(ctx *context.Context) {
+		ctx.Output.Body([]byte("ok"))
+	})
+	do := func(target string, header map[string]string) int {
+		r, _ := http.NewRequest("GET", "/user", nil)
+		r.RequestURI = target
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+	if code := do("/user", map[string]string{"A": "1", "B": "2"}); code != http.StatusOK {
+		t.Errorf("valid request: %d", code)
+	}
+	if code := do("/user", map[string]string{"A": "1", "B": "2", "C": "3", "D": "4"}); code != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("too many headers: %d", code)
+	}
+	if code := do("/user", map[string]string{"A": strings.Repeat("a", 40)}); code != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("large header: %d", code)
+	}
+	if code := do("/user?name=\xe4\xb8\xad", nil); code != http.StatusBadRequest {
+		t.Errorf("raw non-ascii target: %d", code)
+	}
+}
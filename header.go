@@ -0,0 +1,69 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"net/http"
+	"strings"
+)
+
+// headerRouter stores a default response header for the routers matched by pattern.
+type headerRouter struct {
+	tree    *Tree
+	pattern string
+	key     string
+	value   string
+}
+
+// DefaultHeader sets a default response header for the requests matched by pattern.
+// The header is written before filters and controllers run, so they can still override it.
+// usage:
+//	DefaultHeader("/api/*", "Cache-Control", "no-store")
+func (p *ControllerRegister) DefaultHeader(pattern, key, value string) {
+	hr := &headerRouter{
+		tree:    NewTree(),
+		pattern: pattern,
+		key:     key,
+		value:   value,
+	}
+	if !RouterCaseSensitive {
+		pattern = strings.ToLower(pattern)
+	}
+	hr.tree.AddRouter(pattern, true)
+	p.headers = append(p.headers, hr)
+}
+
+// setDefaultHeaders writes the global ResponseHeaders and the headers of the matched patterns.
+func (p *ControllerRegister) setDefaultHeaders(h http.Header, urlPath string) {
+	for k, v := range ResponseHeaders {
+		h.Set(k, v)
+	}
+	for _, hr := range p.headers {
+		if ok, _ := hr.tree.Match(urlPath); ok != nil {
+			h.Set(hr.key, hr.value)
+		}
+	}
+}
+
+// parseResponseHeaders parses "key:value;key:value" into a header map.
+func parseResponseHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, kv := range strings.Split(s, ";") {
+		if kv := strings.SplitN(kv, ":", 2); len(kv) == 2 && strings.TrimSpace(kv[0]) != "" {
+			headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	return headers
+}
@@ -4,10 +4,13 @@ import (
 	"mime"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"net/http"
 
+	"github.com/astaxie/beego/i18n"
 	"github.com/astaxie/beego/session"
+	"github.com/astaxie/beego/toolbox"
 )
 
 //
@@ -27,6 +30,7 @@ func registerDefaultErrorHandler() error {
 		"403": forbidden,
 		"404": notFound,
 		"405": methodNotAllowed,
+		"413": requestEntityTooLarge,
 		"500": internalServerError,
 		"501": notImplemented,
 		"502": badGateway,
@@ -40,18 +44,29 @@ func registerDefaultErrorHandler() error {
 	return nil
 }
 
+// defaultSessionConfig returns the session.NewManager config of the Session settings.
+func defaultSessionConfig() string {
+	return `{"cookieName":"` + SessionName + `",` +
+		`"gclifetime":` + strconv.FormatInt(SessionGCMaxLifetime, 10) + `,` +
+		`"providerConfig":"` + filepath.ToSlash(SessionProviderConfig) + `",` +
+		`"secure":` + strconv.FormatBool(EnableHTTPTLS) + `,` +
+		`"enableSetCookie":` + strconv.FormatBool(SessionAutoSetCookie) + `,` +
+		`"domain":"` + SessionDomain + `",` +
+		`"cookieLifeTime":` + strconv.Itoa(SessionCookieLifeTime) + `,` +
+		`"gcInterval":` + strconv.FormatInt(SessionGCInterval, 10) + `,` +
+		`"gcBatchSize":` + strconv.Itoa(SessionGCBatchSize) + `,` +
+		`"gcBatchPause":` + strconv.FormatInt(SessionGCBatchPause, 10) + `,` +
+		`"gcMaxDuration":` + strconv.FormatInt(SessionGCMaxDuration, 10) + `,` +
+		`"sessionIDAlphabet":"` + SessionIDAlphabet + `",` +
+		`"maxSessionsPerUser":` + strconv.Itoa(SessionMaxPerUser) + `}`
+}
+
 func registerSession() error {
 	if SessionOn {
 		var err error
 		sessionConfig := AppConfig.String("sessionConfig")
 		if sessionConfig == "" {
-			sessionConfig = `{"cookieName":"` + SessionName + `",` +
-				`"gclifetime":` + strconv.FormatInt(SessionGCMaxLifetime, 10) + `,` +
-				`"providerConfig":"` + filepath.ToSlash(SessionProviderConfig) + `",` +
-				`"secure":` + strconv.FormatBool(EnableHTTPTLS) + `,` +
-				`"enableSetCookie":` + strconv.FormatBool(SessionAutoSetCookie) + `,` +
-				`"domain":"` + SessionDomain + `",` +
-				`"cookieLifeTime":` + strconv.Itoa(SessionCookieLifeTime) + `}`
+			sessionConfig = defaultSessionConfig()
 		}
 		GlobalSessions, err = session.NewManager(SessionProvider, sessionConfig)
 		if err != nil {
@@ -63,12 +78,21 @@ func registerSession() error {
 }
 
 func registerTemplate() error {
-	if AutoRender {
+	if TemplatePrecompile {
+		if err := buildTemplate(ViewsPath, true); err != nil {
+			return err
+		}
+	} else if AutoRender {
 		err := BuildTemplate(ViewsPath)
 		if err != nil && RunMode == "dev" {
 			Warn(err)
 		}
 	}
+	if TemplateAudit && RunMode == "dev" {
+		for _, issue := range AuditTemplates() {
+			Warn("template audit:", issue)
+		}
+	}
 	return nil
 }
 
@@ -77,11 +101,37 @@ func registerDocs() error {
 		Get("/docs", serverDocs)
 		Get("/docs/*", serverDocs)
 	}
+	if EnableOpenAPI {
+		Get(OpenAPIPath, serveOpenAPI, withoutDoc())
+		if EnableOpenAPIUI {
+			Get("/swagger/", serveOpenAPIUI, withoutDoc())
+		}
+	}
+	return nil
+}
+
+func registerReadiness() error {
+	if ReadinessPath != "" {
+		Get(ReadinessPath, serveReadiness, withoutDoc())
+	}
+	return nil
+}
+
+func registerI18n() error {
+	if I18nPath == "" {
+		return nil
+	}
+	b := i18n.NewBundle(I18nDefaultLocale)
+	if err := b.LoadDir(I18nPath); err != nil {
+		return err
+	}
+	SetI18n(b)
 	return nil
 }
 
 func registerAdmin() error {
 	if EnableAdmin {
+		toolbox.StatisticsMap.SlowThreshold = time.Duration(WatchdogThreshold) * time.Millisecond
 		go beeAdminApp.Run()
 	}
 	return nil
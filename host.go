@@ -0,0 +1,126 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"net"
+	"strings"
+
+	"github.com/astaxie/beego/context"
+)
+
+// hostRouter holds the routers of a host pattern added by ControllerRegister.Host.
+type hostRouter struct {
+	pattern  string
+	labels   []string
+	wildcard bool
+	handlers *ControllerRegister
+}
+
+// Host returns a RouterGroup whose routers only match the requests of the host pattern,
+// a label starting with ":" captures the label as a param, e.g. ":tenant.example.com".
+// The routers of the matched host are searched before the routers without host,
+// the exact hosts are tried before the patterns with params.
+// usage:
+//
+//	api := beego.BeeApp.Handlers.Host("api.example.com")
+//	api.Get("/user/:id", getUser)
+//	tenant := beego.BeeApp.Handlers.Host(":tenant.example.com")
+//	tenant.Router("/", &TenantController{}) // this.Ctx.Input.Param(":tenant")
+func (p *ControllerRegister) Host(pattern string) *RouterGroup {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	var h *hostRouter
+	for _, hr := range p.hosts {
+		if hr.pattern == pattern {
+			h = hr
+			break
+		}
+	}
+	if h == nil {
+		h = &hostRouter{
+			pattern:  pattern,
+			labels:   strings.Split(pattern, "."),
+			handlers: NewControllerRegister(),
+		}
+		for _, l := range h.labels {
+			if strings.HasPrefix(l, ":") {
+				h.wildcard = true
+			}
+		}
+		p.hosts = append(p.hosts, h)
+	}
+	return &RouterGroup{prefix: "/", handlers: h.handlers, filters: p, cond: func(ctx *context.Context) bool {
+		matched, _ := p.matchHost(ctx.Request.Host)
+		return matched == h
+	}}
+}
+
+// Host returns a RouterGroup of BeeApp for the host pattern.
+// usage:
+//
+//	beego.Host(":tenant.example.com").Router("/", &controllers.TenantController{})
+func Host(pattern string) *RouterGroup {
+	return BeeApp.Handlers.Host(pattern)
+}
+
+// requestHost returns the lower-cased host without the port.
+func requestHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// match returns the params captured from host.
+func (h *hostRouter) match(host string) (map[string]string, bool) {
+	labels := strings.Split(host, ".")
+	if len(labels) != len(h.labels) {
+		return nil, false
+	}
+	var params map[string]string
+	for i, l := range h.labels {
+		if strings.HasPrefix(l, ":") {
+			if labels[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[l] = labels[i]
+		} else if l != labels[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// matchHost returns the host routers of the request host and the captured params.
+func (p *ControllerRegister) matchHost(host string) (*hostRouter, map[string]string) {
+	if len(p.hosts) == 0 {
+		return nil, nil
+	}
+	host = requestHost(host)
+	for _, wildcard := range []bool{false, true} {
+		for _, h := range p.hosts {
+			if h.wildcard != wildcard {
+				continue
+			}
+			if params, ok := h.match(host); ok {
+				return h, params
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build http3
+// +build http3
+
+package beego
+
+import (
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// http3Supported is whether beego is built with the http3 tag.
+const http3Supported = true
+
+// serveHTTP3 serves handler over HTTP/3 on the UDP addr with the TLS config of app.Server.
+func (app *App) serveHTTP3(addr string, handler http.Handler) error {
+	server := &http3.Server{
+		Addr:      addr,
+		Handler:   handler,
+		TLSConfig: http3.ConfigureTLSConfig(app.Server.TLSConfig.Clone()),
+	}
+	if HTTPCertFile != "" || HTTPKeyFile != "" {
+		return server.ListenAndServeTLS(HTTPCertFile, HTTPKeyFile)
+	}
+	// the certificates of TLSConfig or AutoTLS
+	return server.ListenAndServe()
+}
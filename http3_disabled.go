@@ -0,0 +1,30 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !http3
+// +build !http3
+
+package beego
+
+import (
+	"errors"
+	"net/http"
+)
+
+// http3Supported is whether beego is built with the http3 tag.
+const http3Supported = false
+
+func (app *App) serveHTTP3(addr string, handler http.Handler) error {
+	return errors.New("beego is built without HTTP/3, build with -tags http3")
+}
@@ -0,0 +1,47 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"github.com/astaxie/beego/context"
+)
+
+// SetI18n sets the translator of ctx.Tr, Controller.Tr and the tr template function, e.g. an i18n.Bundle.
+// It's set from the catalogs of I18nPath at the start if the path is configured.
+// usage:
+//
+//	b := i18n.NewBundle("en-US")
+//	b.LoadDir("conf/locale")
+//	beego.SetI18n(b)
+func SetI18n(t context.Translator) *App {
+	context.DefaultTranslator = t
+	return BeeApp
+}
+
+// Tr translates the message key to the locale of the request, see context.Context.Tr.
+func (c *Controller) Tr(key string, args ...interface{}) string {
+	return c.Ctx.Tr(key, args...)
+}
+
+// tr is the tr template function translating the message key to the locale, the Lang of the controller data.
+// usage:
+//
+//	{{tr .Lang "inbox" .Count}}
+func tr(locale, key string, args ...interface{}) string {
+	if context.DefaultTranslator == nil {
+		return key
+	}
+	return context.DefaultTranslator.Tr(locale, key, args...)
+}
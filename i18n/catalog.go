@@ -0,0 +1,141 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// catalogParsers parse the catalog files by the extension into the messages keyed by the dotted keys.
+var catalogParsers = map[string]func([]byte) (map[string]string, error){
+	".ini":  parseINI,
+	".toml": parseTOML,
+	".json": parseJSON,
+}
+
+// parseINI parses the key = value lines, the [section] lines prefix the following keys,
+// the lines starting with ; or # are comments and the quoted values are unquoted.
+func parseINI(data []byte) (map[string]string, error) {
+	return parseKeyValues(data, func(v string) (string, error) {
+		if len(v) >= 2 && (v[0] == '"' && v[len(v)-1] == '"' || v[0] == '\'' && v[len(v)-1] == '\'') {
+			return v[1 : len(v)-1], nil
+		}
+		return v, nil
+	})
+}
+
+// parseTOML parses the string keys of a TOML file, the tables prefix their keys.
+// The values must be the basic or the literal strings on a line.
+func parseTOML(data []byte) (map[string]string, error) {
+	return parseKeyValues(data, func(v string) (string, error) {
+		switch {
+		case strings.HasPrefix(v, `"""`) || strings.HasPrefix(v, "'''"):
+			return "", fmt.Errorf("the multi-line string %s isn't supported", v)
+		case len(v) >= 2 && v[0] == '"':
+			end := closingQuote(v)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated string %s", v)
+			}
+			return strconv.Unquote(v[:end+1])
+		case len(v) >= 2 && v[0] == '\'':
+			end := strings.IndexByte(v[1:], '\'')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated string %s", v)
+			}
+			return v[1 : end+1], nil
+		}
+		return "", fmt.Errorf("%s isn't a string", v)
+	})
+}
+
+// closingQuote returns the index of the quote closing the basic string v, or -1.
+func closingQuote(v string) int {
+	for i := 1; i < len(v); i++ {
+		switch v[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func parseKeyValues(data []byte, value func(string) (string, error)) (map[string]string, error) {
+	messages := make(map[string]string)
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\xef\xbb\xbf")
+		}
+		switch {
+		case line == "" || line[0] == ';' || line[0] == '#':
+			continue
+		case line[0] == '[':
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: bad section %s", n, line)
+			}
+			section = strings.Trim(strings.TrimSpace(line[1:len(line)-1]), `"`)
+			continue
+		}
+		i := strings.IndexByte(line, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("line %d: no key = value in %s", n, line)
+		}
+		key := strings.Trim(strings.TrimSpace(line[:i]), `"`)
+		v, err := value(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		messages[key] = v
+	}
+	return messages, scanner.Err()
+}
+
+// parseJSON parses a JSON object of the strings, the nested objects prefix their keys.
+func parseJSON(data []byte) (map[string]string, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	messages := make(map[string]string)
+	var flatten func(prefix string, m map[string]interface{}) error
+	flatten = func(prefix string, m map[string]interface{}) error {
+		for k, v := range m {
+			switch v := v.(type) {
+			case string:
+				messages[prefix+k] = v
+			case map[string]interface{}:
+				if err := flatten(prefix+k+".", v); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("%s%s isn't a string", prefix, k)
+			}
+		}
+		return nil
+	}
+	return messages, flatten("", root)
+}
@@ -0,0 +1,272 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package i18n translates the messages of an application by the message catalogs of the locales.
+// The catalogs are INI, JSON or TOML files named by the locale, e.g. conf/locale/zh-CN.ini,
+// the plural forms of a message are its keys suffixed by the CLDR plural category, e.g. "inbox.one" and "inbox.other".
+// The locale of a request is negotiated from the query, the cookie and the Accept-Language header.
+// Usage:
+//
+//	import "github.com/astaxie/beego/i18n"
+//
+//	b := i18n.NewBundle("en-US")
+//	if err := b.LoadDir("conf/locale"); err != nil {
+//		log.Fatal(err)
+//	}
+//	beego.SetI18n(b)
+//
+//	// conf/locale/en-US.ini
+//	hello = Hello, %s!
+//	[inbox]
+//	one = You have %d message.
+//	other = You have %d messages.
+//
+//	// in the controller
+//	this.Tr("hello", "astaxie")
+//	this.Tr("inbox", 3)
+//
+//	// in the template
+//	{{tr .Lang "inbox" .Count}}
+package i18n
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Bundle holds the message catalogs of the locales.
+type Bundle struct {
+	// QueryParam is the query parameter choosing the locale, "lang" by default, empty disables it.
+	QueryParam string
+	// CookieName is the cookie storing the locale, "lang" by default, empty disables it.
+	CookieName string
+
+	defaultLocale string
+	lock          sync.RWMutex
+	catalogs      map[string]map[string]string // the messages keyed by the locale
+}
+
+// NewBundle returns an empty Bundle, the messages missing in a locale are translated by defaultLocale.
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		QueryParam:    "lang",
+		CookieName:    "lang",
+		defaultLocale: defaultLocale,
+		catalogs:      make(map[string]map[string]string),
+	}
+}
+
+// DefaultLocale returns the locale of the missing messages and of the requests which don't match a locale.
+func (b *Bundle) DefaultLocale() string {
+	return b.defaultLocale
+}
+
+// Add adds the messages of the locale, they override the messages of the same keys.
+func (b *Bundle) Add(locale string, messages map[string]string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	c, ok := b.catalogs[locale]
+	if !ok {
+		c = make(map[string]string, len(messages))
+		b.catalogs[locale] = c
+	}
+	for k, v := range messages {
+		c[k] = v
+	}
+}
+
+// LoadDir loads the catalogs in dir, see LoadFS.
+func (b *Bundle) LoadDir(dir string) error {
+	return b.LoadFS(os.DirFS(dir), ".")
+}
+
+// LoadFS loads the catalogs in dir of fsys, the locale of a catalog is its file name without the extension:
+// .ini and .toml are the INI and TOML files whose sections prefix the keys, .json is a JSON object
+// whose nested objects prefix the keys. The other files are skipped.
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		ext := path.Ext(e.Name())
+		parse, ok := catalogParsers[ext]
+		if e.IsDir() || !ok {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		messages, err := parse(data)
+		if err != nil {
+			return fmt.Errorf("i18n: %s: %v", e.Name(), err)
+		}
+		b.Add(strings.TrimSuffix(e.Name(), ext), messages)
+	}
+	return nil
+}
+
+// Locales returns the sorted locales of the bundle.
+func (b *Bundle) Locales() []string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	locales := make([]string, 0, len(b.catalogs))
+	for locale := range b.catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Tr translates the message key to the locale, then formats it by fmt.Sprintf with args if it has verbs.
+// The message is looked up in the locale, its language, e.g. zh for zh-TW, and the default locale,
+// the key is returned if none has it.
+// If the first arg is an integer the plural form of the locale for it is used, "key.other" if the form is missing.
+func (b *Bundle) Tr(locale, key string, args ...interface{}) string {
+	keys := []string{key}
+	if len(args) > 0 {
+		if n, ok := pluralCount(args[0]); ok {
+			keys = []string{key + "." + PluralForm(locale, n), key + ".other", key}
+		}
+	}
+	b.lock.RLock()
+	msg, ok := "", false
+	for _, l := range []string{locale, language(locale), b.defaultLocale} {
+		if c, found := b.catalogs[l]; found {
+			for _, k := range keys {
+				if msg, ok = c[k]; ok {
+					break
+				}
+			}
+		}
+		if ok {
+			break
+		}
+	}
+	b.lock.RUnlock()
+	if !ok {
+		return key
+	}
+	if len(args) > 0 && strings.Contains(msg, "%") {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Negotiate returns the locale of the request: the one of QueryParam or CookieName if the bundle has it,
+// then the best match of the Accept-Language header, then the default locale.
+func (b *Bundle) Negotiate(r *http.Request) string {
+	if b.QueryParam != "" {
+		if l := b.Match(r.URL.Query().Get(b.QueryParam)); l != "" {
+			return l
+		}
+	}
+	if b.CookieName != "" {
+		if ck, err := r.Cookie(b.CookieName); err == nil {
+			if l := b.Match(ck.Value); l != "" {
+				return l
+			}
+		}
+	}
+	for _, tag := range parseAcceptLanguage(r.Header.Get("Accept-Language")) {
+		if l := b.Match(tag); l != "" {
+			return l
+		}
+	}
+	return b.defaultLocale
+}
+
+// Match returns the locale of the bundle for the language tag, compared case insensitively:
+// the same locale, then the locale of its language, then a locale of the same language,
+// the default locale if it's one of them or else the first in order. It's empty if none.
+func (b *Bundle) Match(tag string) string {
+	tag = strings.Replace(strings.TrimSpace(tag), "_", "-", -1)
+	if tag == "" {
+		return ""
+	}
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	var sameLanguage []string
+	for locale := range b.catalogs {
+		if strings.EqualFold(locale, tag) {
+			return locale
+		}
+		if strings.EqualFold(language(locale), language(tag)) {
+			sameLanguage = append(sameLanguage, locale)
+		}
+	}
+	if len(sameLanguage) == 0 {
+		return ""
+	}
+	sort.Strings(sameLanguage)
+	for _, locale := range sameLanguage {
+		if strings.EqualFold(locale, language(tag)) {
+			return locale
+		}
+	}
+	for _, locale := range sameLanguage {
+		if locale == b.defaultLocale {
+			return locale
+		}
+	}
+	return sameLanguage[0]
+}
+
+// language returns the language of the locale, zh of zh-TW.
+func language(locale string) string {
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		return locale[:i]
+	}
+	return locale
+}
+
+// parseAcceptLanguage returns the language tags of the header by preference, q=0 tags are dropped.
+func parseAcceptLanguage(header string) []string {
+	type tag struct {
+		name string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		t := tag{name: strings.TrimSpace(params[0]), q: 1}
+		if t.name == "" || t.name == "*" {
+			continue
+		}
+		for _, p := range params[1:] {
+			if p = strings.TrimSpace(p); strings.HasPrefix(p, "q=") {
+				if q, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					t.q = q
+				}
+			}
+		}
+		if t.q > 0 {
+			tags = append(tags, t)
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.name
+	}
+	return names
+}
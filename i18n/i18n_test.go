@@ -0,0 +1,119 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestBundle(t *testing.T) *Bundle {
+	fsys := fstest.MapFS{
+		"locale/en-US.ini":  {Data: []byte("hello = Hello, %s!\n[inbox]\none = You have %d message.\nother = You have %d messages.\n")},
+		"locale/ru.json":    {Data: []byte(`{"hello": "Привет, %s!", "inbox": {"one": "%d сообщение", "few": "%d сообщения", "many": "%d сообщений"}}`)},
+		"locale/zh-CN.toml": {Data: []byte("hello = \"你好, %s!\"\n[inbox]\nother = \"你有 %d 条消息\"\n")},
+		"locale/README.md":  {Data: []byte("not a catalog")},
+	}
+	b := NewBundle("en-US")
+	if err := b.LoadFS(fsys, "locale"); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestTr(t *testing.T) {
+	b := newTestBundle(t)
+	tests := []struct {
+		locale, key string
+		args        []interface{}
+		want        string
+	}{
+		{"en-US", "hello", []interface{}{"astaxie"}, "Hello, astaxie!"},
+		{"zh-CN", "hello", []interface{}{"astaxie"}, "你好, astaxie!"},
+		{"en-US", "inbox", []interface{}{1}, "You have 1 message."},
+		{"en-US", "inbox", []interface{}{0}, "You have 0 messages."},
+		{"ru", "inbox", []interface{}{1}, "1 сообщение"},
+		{"ru", "inbox", []interface{}{3}, "3 сообщения"},
+		{"ru", "inbox", []interface{}{11}, "11 сообщений"},
+		{"ru-RU", "inbox", []interface{}{22}, "22 сообщения"},
+		{"zh-CN", "inbox", []interface{}{1}, "你有 1 条消息"},
+		{"fr", "hello", []interface{}{"astaxie"}, "Hello, astaxie!"},
+		{"en-US", "missing", nil, "missing"},
+	}
+	for _, tt := range tests {
+		if got := b.Tr(tt.locale, tt.key, tt.args...); got != tt.want {
+			t.Errorf("Tr(%q, %q, %v) = %q, want %q", tt.locale, tt.key, tt.args, got, tt.want)
+		}
+	}
+	if got := b.Locales(); len(got) != 3 {
+		t.Errorf("Locales() = %v, want 3 locales", got)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	b := newTestBundle(t)
+	tests := []struct {
+		url, cookie, accept string
+		want                string
+	}{
+		{"/", "", "", "en-US"},
+		{"/", "", "fr;q=0.9, zh-TW;q=0.8, ru;q=0.1", "zh-CN"},
+		{"/", "", "ru-RU, en;q=0.5", "ru"},
+		{"/", "", "zh-CN;q=0, de", "en-US"},
+		{"/", "zh_cn", "ru", "zh-CN"},
+		{"/?lang=ru", "zh-CN", "en", "ru"},
+		{"/?lang=de", "", "zh", "zh-CN"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.url, nil)
+		if tt.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+		}
+		if tt.accept != "" {
+			r.Header.Set("Accept-Language", tt.accept)
+		}
+		if got := b.Negotiate(r); got != tt.want {
+			t.Errorf("Negotiate(%s, cookie %q, %q) = %q, want %q", tt.url, tt.cookie, tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	b := NewBundle("pt-PT")
+	for _, locale := range []string{"pt-BR", "pt-PT", "es-MX", "es-AR", "de", "de-AT"} {
+		b.Add(locale, map[string]string{"hello": locale})
+	}
+	tests := []struct {
+		tag, want string
+	}{
+		{"pt-br", "pt-BR"},
+		{"pt", "pt-PT"},
+		{"pt-AO", "pt-PT"},
+		{"es", "es-AR"},
+		{"es-ES", "es-AR"},
+		{"de-CH", "de"},
+		{"fr", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 10; i++ {
+			if got := b.Match(tt.tag); got != tt.want {
+				t.Fatalf("Match(%q) = %q, want %q", tt.tag, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"strings"
+	"sync"
+)
+
+// The CLDR plural categories.
+const (
+	Zero  = "zero"
+	One   = "one"
+	Two   = "two"
+	Few   = "few"
+	Many  = "many"
+	Other = "other"
+)
+
+// PluralRule returns the plural category of the count n.
+type PluralRule func(n int) string
+
+var (
+	pluralLock  sync.RWMutex
+	pluralRules = make(map[string]PluralRule)
+)
+
+func init() {
+	for _, lang := range []string{"zh", "ja", "ko", "vi", "th", "id", "ms", "tr"} {
+		pluralRules[lang] = func(n int) string { return Other }
+	}
+	for _, lang := range []string{"fr", "pt-BR", "hi"} {
+		pluralRules[lang] = func(n int) string {
+			if n == 0 || n == 1 {
+				return One
+			}
+			return Other
+		}
+	}
+	for _, lang := range []string{"ru", "uk", "be", "sr", "hr", "bs"} {
+		pluralRules[lang] = slavicPlural
+	}
+	pluralRules["pl"] = func(n int) string {
+		if n == 1 {
+			return One
+		}
+		return polishPlural(n)
+	}
+	for _, lang := range []string{"cs", "sk"} {
+		pluralRules[lang] = func(n int) string {
+			switch {
+			case n == 1:
+				return One
+			case n >= 2 && n <= 4:
+				return Few
+			}
+			return Other
+		}
+	}
+	pluralRules["ar"] = func(n int) string {
+		switch m := n % 100; {
+		case n == 0:
+			return Zero
+		case n == 1:
+			return One
+		case n == 2:
+			return Two
+		case m >= 3 && m <= 10:
+			return Few
+		case m >= 11:
+			return Many
+		}
+		return Other
+	}
+}
+
+// slavicPlural is the rule of Russian, Ukrainian and the languages alike.
+func slavicPlural(n int) string {
+	m10, m100 := n%10, n%100
+	switch {
+	case m10 == 1 && m100 != 11:
+		return One
+	case m10 >= 2 && m10 <= 4 && (m100 < 12 || m100 > 14):
+		return Few
+	}
+	return Many
+}
+
+func polishPlural(n int) string {
+	m10, m100 := n%10, n%100
+	if m10 >= 2 && m10 <= 4 && (m100 < 12 || m100 > 14) {
+		return Few
+	}
+	return Many
+}
+
+// RegisterPluralRule sets the plural rule of the locale or the language, e.g. "pt-BR" or "pt".
+func RegisterPluralRule(locale string, rule PluralRule) {
+	pluralLock.Lock()
+	pluralRules[locale] = rule
+	pluralLock.Unlock()
+}
+
+// PluralForm returns the plural category of the count n in the locale,
+// the languages without a rule are one for 1 and other for the rest, as English.
+func PluralForm(locale string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+	pluralLock.RLock()
+	rule, ok := pluralRules[locale]
+	if !ok {
+		rule, ok = pluralRules[strings.Replace(locale, "_", "-", -1)]
+	}
+	if !ok {
+		rule, ok = pluralRules[language(locale)]
+	}
+	pluralLock.RUnlock()
+	if ok {
+		return rule(n)
+	}
+	if n == 1 {
+		return One
+	}
+	return Other
+}
+
+// pluralCount returns the count of the integer arg.
+func pluralCount(arg interface{}) (int, bool) {
+	switch n := arg.(type) {
+	case int:
+		return n, true
+	case int8:
+		return int(n), true
+	case int16:
+		return int(n), true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case uint:
+		return int(n), true
+	case uint8:
+		return int(n), true
+	case uint16:
+		return int(n), true
+	case uint32:
+		return int(n), true
+	case uint64:
+		return int(n), true
+	}
+	return 0, false
+}
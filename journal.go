@@ -0,0 +1,216 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/utils"
+)
+
+// JournalEntry is a request persisted by the Journal before it's processed.
+type JournalEntry struct {
+	ID      string      `json:"id"`
+	Time    time.Time   `json:"time"`
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Pattern string      `json:"pattern"`
+	Header  http.Header `json:"header"`
+	Body    []byte      `json:"body,omitempty"`
+}
+
+// Request rebuilds the http request of the entry, e.g. to replay it after a crash.
+func (e *JournalEntry) Request() (*http.Request, error) {
+	r, err := http.NewRequest(e.Method, e.URL, bytes.NewReader(e.Body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range e.Header {
+		r.Header[k] = v
+	}
+	return r, nil
+}
+
+// Journal is a write-ahead journal of the requests of the routers added WithJournal.
+type Journal interface {
+	// Append persists the entry durably, the request is refused with 503 if it fails.
+	Append(e *JournalEntry) error
+	// Done marks the entry processed, status is the status code of the response.
+	Done(id string, status int) error
+	// Pending returns the entries which were appended but never done, the oldest first.
+	// After a crash these requests may be processed partially or not at all.
+	Pending() ([]*JournalEntry, error)
+}
+
+// WithJournal persists the requests of the router in j before the handler runs and marks them done after,
+// the requests whose handler didn't finish, e.g. because the process crashed or panicked, are left pending.
+// The uploaded files aren't journaled, only the form values.
+// usage:
+//
+//	j, err := beego.NewFileJournal("data/journal")
+//	beego.RouterWithOptions("/order", &OrderController{}, beego.WithMethods("post:Create"), beego.WithJournal(j))
+//	// at startup
+//	pending, err := j.Pending()
+func WithJournal(j Journal) RouterOption {
+	return func(o *routerOptions) {
+		o.journal = j
+	}
+}
+
+// journalRequest appends the request of ctx to the journal of the router.
+func (c *controllerInfo) journalRequest(ctx *context.Context) (*JournalEntry, error) {
+	r := ctx.Request
+	body := ctx.Input.RequestBody
+	if body == nil {
+		if len(r.PostForm) > 0 {
+			body = []byte(r.PostForm.Encode())
+		} else if r.Body != nil {
+			body = ctx.Input.CopyBody()
+		}
+	}
+	header := make(http.Header, len(r.Header))
+	for k, v := range r.Header {
+		header[k] = v
+	}
+	if len(r.PostForm) > 0 && ctx.Input.IsUpload() {
+		header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	e := &JournalEntry{
+		ID:      string(utils.RandomCreateBytes(16)),
+		Time:    time.Now(),
+		Method:  r.Method,
+		URL:     r.URL.RequestURI(),
+		Pattern: c.pattern,
+		Header:  header,
+		Body:    body,
+	}
+	if err := c.journal.Append(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (c *controllerInfo) journalDone(e *JournalEntry, status int) {
+	if err := c.journal.Done(e.ID, status); err != nil {
+		Error("journal the request done:", err)
+	}
+}
+
+// fileJournal stores an entry per file, the file is removed when the entry is done.
+type fileJournal struct {
+	dir  string
+	lock sync.Mutex
+	seq  int64
+}
+
+// NewFileJournal returns a Journal storing the entries in dir, each entry is synced to the disk before the request is processed.
+func NewFileJournal(dir string) (Journal, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &fileJournal{dir: dir}, nil
+}
+
+func (j *fileJournal) path(id string) string {
+	return filepath.Join(j.dir, id+".json")
+}
+
+func (j *fileJournal) Append(e *JournalEntry) error {
+	j.lock.Lock()
+	j.seq++
+	// the time and the sequence make the file names sort in order
+	e.ID = fmt.Sprintf("%020d-%010d-%s", e.Time.UnixNano(), j.seq, e.ID)
+	j.lock.Unlock()
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tmp := j.path(e.ID) + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, j.path(e.ID))
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return syncDir(j.dir)
+}
+
+func (j *fileJournal) Done(id string, status int) error {
+	if err := os.Remove(j.path(id)); err != nil {
+		return err
+	}
+	return syncDir(j.dir)
+}
+
+func (j *fileJournal) Pending() ([]*JournalEntry, error) {
+	files, err := ioutil.ReadDir(j.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), ".json") {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+	entries := make([]*JournalEntry, 0, len(names))
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(j.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		e := &JournalEntry{}
+		if err := json.Unmarshal(data, e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// not supported on all the platforms, the rename is durable on most file systems anyway
+	d.Sync()
+	return nil
+}
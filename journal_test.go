@@ -0,0 +1,83 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/astaxie/beego/context"
+)
+
+type journalController struct {
+	Controller
+}
+
+func (c *journalController) Post() {
+	c.Ctx.WriteString("ok")
+	c.StopRun()
+}
+
+func TestRouterJournal(t *testing.T) {
+	j, err := NewFileJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewControllerRegister()
+	handler.Post("/order", func(ctx *context.Context) {
+		pending, _ := j.Pending()
+		if len(pending) != 1 || string(pending[0].Body) != `{"item":1}` {
+			t.Errorf("the request should be journaled before the handler: %v", pending)
+		}
+		if string(ctx.Input.CopyBody()) != `{"item":1}` {
+			t.Error("the handler should still read the body")
+		}
+		if ctx.Input.Query("crash") != "" {
+			panic("crash")
+		}
+	}, WithJournal(j))
+	handler.AddWithOptions("/stop", &journalController{}, WithJournal(j))
+
+	post := func(url string) {
+		r, _ := http.NewRequest("POST", url, strings.NewReader(`{"item":1}`))
+		r.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	post("/order")
+	post("/stop")
+	if pending, _ := j.Pending(); len(pending) != 0 {
+		t.Fatalf("the finished requests should be done: %v", pending)
+	}
+
+	post("/order?crash=1")
+	pending, err := j.Pending()
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("the crashed request should be pending: %v %v", pending, err)
+	}
+	r, err := pending[0].Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	if r.URL.String() != "/order?crash=1" || r.Header.Get("Content-Type") != "application/json" || string(body) != `{"item":1}` {
+		t.Errorf("unexpected replayed request: %s %v %s", r.URL, r.Header, body)
+	}
+	if err := j.Done(pending[0].ID, 200); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -38,6 +38,7 @@ import (
 	"path"
 	"runtime"
 	"sync"
+	"sync/atomic"
 )
 
 // RFC5424 log message levels.
@@ -96,6 +97,7 @@ type BeeLogger struct {
 	asynchronous        bool
 	msg                 chan *logMsg
 	outputs             map[string]Logger
+	redactor            atomic.Value // *Redactor, read without the lock by every message
 }
 
 type logMsg struct {
@@ -156,6 +158,9 @@ func (bl *BeeLogger) DelLogger(adaptername string) error {
 func (bl *BeeLogger) writerMsg(loglevel int, msg string) error {
 	lm := new(logMsg)
 	lm.level = loglevel
+	if r, _ := bl.redactor.Load().(*Redactor); r != nil {
+		msg = r.Redact(msg)
+	}
 	if bl.enableFuncCallDepth {
 		_, file, line, ok := runtime.Caller(bl.loggerFuncCallDepth)
 		if !ok {
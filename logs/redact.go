@@ -0,0 +1,115 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logs
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// DefaultRedactMask replaces the sensitive values.
+const DefaultRedactMask = "******"
+
+// Redactor masks sensitive values in log messages before they are written to the adapters.
+// The values of the keys such as password=xxx, password: xxx and "password":"xxx" are masked,
+// and card numbers which pass the Luhn check keep only the last 4 digits.
+type Redactor struct {
+	Keys        []string `json:"keys"`        // key patterns, regexp is supported, e.g. "passw(or)?d"
+	Mask        string   `json:"mask"`        // default is DefaultRedactMask
+	CardNumbers bool     `json:"cardNumbers"` // mask card numbers
+
+	keyRegexp *regexp.Regexp
+}
+
+var cardNumberRegexp = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// NewRedactor returns a Redactor with json config string:
+//	{"keys":["password","token","secret"],"mask":"***","cardNumbers":true}
+func NewRedactor(config string) (*Redactor, error) {
+	r := &Redactor{}
+	if config != "" {
+		if err := json.Unmarshal([]byte(config), r); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.Compile(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Compile prepares the Redactor after the fields are set, it must be called before Redact.
+func (r *Redactor) Compile() error {
+	if r.Mask == "" {
+		r.Mask = DefaultRedactMask
+	}
+	if len(r.Keys) == 0 {
+		return nil
+	}
+	// group 1 is the key with the separator, group 2 is the value.
+	re, err := regexp.Compile(`(?i)("?[\w.-]*(?:` + strings.Join(r.Keys, "|") + `)[\w.-]*"?\s*[:=]\s*"?)([^"\s,&;}]*)`)
+	if err != nil {
+		return err
+	}
+	r.keyRegexp = re
+	return nil
+}
+
+// Redact returns msg with the sensitive values masked.
+func (r *Redactor) Redact(msg string) string {
+	if r.keyRegexp != nil {
+		msg = r.keyRegexp.ReplaceAllString(msg, "${1}"+r.Mask)
+	}
+	if r.CardNumbers {
+		msg = cardNumberRegexp.ReplaceAllStringFunc(msg, func(s string) string {
+			digits := strings.NewReplacer(" ", "", "-", "").Replace(s)
+			if !luhn(digits) {
+				return s
+			}
+			return r.Mask + digits[len(digits)-4:]
+		})
+	}
+	return msg
+}
+
+// luhn reports whether the digits pass the Luhn checksum.
+func luhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// SetRedactor sets the Redactor which masks all the messages before writing to any adapter, nil disables it.
+func (bl *BeeLogger) SetRedactor(r *Redactor) {
+	bl.redactor.Store(r)
+}
+
+// Redactor returns the Redactor set by SetRedactor, it's nil if there is none.
+func (bl *BeeLogger) Redactor() *Redactor {
+	r, _ := bl.redactor.Load().(*Redactor)
+	return r
+}
@@ -0,0 +1,55 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logs
+
+import (
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	r, err := NewRedactor(`{"keys":["password","token"],"cardNumbers":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"login user=astaxie password=123456":      "login user=astaxie password=******",
+		`{"name":"astaxie","access_token":"abc"}`: `{"name":"astaxie","access_token":"******"}`,
+		"Password: secret, next":                  "Password: ******, next",
+		"card 4111 1111 1111 1111 paid":           "card ******1111 paid",
+		"order 1234567890123 created":             "order 1234567890123 created",
+	}
+	for msg, want := range cases {
+		if got := r.Redact(msg); got != want {
+			t.Errorf("Redact(%q) = %q, want %q", msg, got, want)
+		}
+	}
+}
+
+func TestSetRedactorConcurrent(t *testing.T) {
+	bl := NewLogger(100)
+	r, _ := NewRedactor(`{"keys":["password"]}`)
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			bl.SetRedactor(r)
+			bl.SetRedactor(nil)
+		}
+		close(done)
+	}()
+	for i := 0; i < 100; i++ {
+		bl.writerMsg(LevelInfo, "password=secret")
+	}
+	<-done
+}
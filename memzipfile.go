@@ -18,25 +18,93 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"container/list"
 	"errors"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-var (
-	gmfim = make(map[string]*memFileInfo)
-	lock  sync.RWMutex
-)
+var staticFileCache = newMemFileCache()
+
+// memFileCache is a LRU cache of the static files in memory, it is limited by
+// StaticCacheMaxBytes in total and StaticCacheFileMaxBytes per file.
+// The entries are invalid when the file changes or after StaticCacheTTL.
+type memFileCache struct {
+	lock  sync.Mutex
+	size  int64
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type memFileCacheEntry struct {
+	key     string
+	fi      *memFileInfo
+	expires time.Time
+}
+
+func newMemFileCache() *memFileCache {
+	return &memFileCache{ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// get returns the cached file if the modtime and size are not changed and it's not expired.
+func (c *memFileCache) get(key string, modtime time.Time, fileSize int64) (*memFileInfo, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*memFileCacheEntry)
+	if !entry.fi.ModTime().Equal(modtime) || entry.fi.fileSize != fileSize ||
+		!entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(e)
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return entry.fi, true
+}
+
+// set caches the file and evicts the least recently used files over StaticCacheMaxBytes.
+func (c *memFileCache) set(key string, fi *memFileInfo) {
+	size := int64(len(fi.content))
+	if size > StaticCacheFileMaxBytes || size > StaticCacheMaxBytes {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+	entry := &memFileCacheEntry{key: key, fi: fi}
+	if StaticCacheTTL > 0 {
+		entry.expires = time.Now().Add(time.Duration(StaticCacheTTL) * time.Second)
+	}
+	c.items[key] = c.ll.PushFront(entry)
+	c.size += size
+	for c.size > StaticCacheMaxBytes {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *memFileCache) remove(e *list.Element) {
+	entry := e.Value.(*memFileCacheEntry)
+	c.ll.Remove(e)
+	delete(c.items, entry.key)
+	c.size -= int64(len(entry.fi.content))
+}
 
 // OpenMemZipFile returns MemFile object with a compressed static file.
 // it's used for serve static file if gzip enable.
-func openMemZipFile(path string, zip string) (*memFile, error) {
-	osfile, e := os.Open(path)
+// The file is read from fsys if it isn't nil, key is the key of the file in staticFileCache.
+func openMemZipFile(fsys fs.FS, key, path string, zip string) (*memFile, error) {
+	osfile, e := staticOpen(fsys, path)
 	if e != nil {
 		return nil, e
 	}
@@ -49,10 +117,8 @@ func openMemZipFile(path string, zip string) (*memFile, error) {
 
 	modtime := osfileinfo.ModTime()
 	fileSize := osfileinfo.Size()
-	lock.RLock()
-	cfi, ok := gmfim[zip+":"+path]
-	lock.RUnlock()
-	if !(ok && cfi.ModTime() == modtime && cfi.fileSize == fileSize) {
+	cfi, ok := staticFileCache.get(zip+":"+key, modtime, fileSize)
+	if !ok {
 		var content []byte
 		if zip == "gzip" {
 			var zipbuf bytes.Buffer
@@ -92,9 +158,7 @@ func openMemZipFile(path string, zip string) (*memFile, error) {
 		}
 
 		cfi = &memFileInfo{osfileinfo, modtime, content, int64(len(content)), fileSize}
-		lock.Lock()
-		defer lock.Unlock()
-		gmfim[zip+":"+path] = cfi
+		staticFileCache.set(zip+":"+key, cfi)
 	}
 	return &memFile{fi: cfi, offset: 0}, nil
 }
@@ -111,7 +175,7 @@ type memFileInfo struct {
 
 // Name returns the compressed filename.
 func (fi *memFileInfo) Name() string {
-	return fi.Name()
+	return fi.FileInfo.Name()
 }
 
 // Size returns the raw file content size, not compressed size.
@@ -121,7 +185,7 @@ func (fi *memFileInfo) Size() int64 {
 
 // Mode returns file mode.
 func (fi *memFileInfo) Mode() os.FileMode {
-	return fi.Mode()
+	return fi.FileInfo.Mode()
 }
 
 // ModTime returns the last modified time of raw file.
@@ -131,7 +195,7 @@ func (fi *memFileInfo) ModTime() time.Time {
 
 // IsDir returns the compressing file is a directory or not.
 func (fi *memFileInfo) IsDir() bool {
-	return fi.IsDir()
+	return fi.FileInfo.IsDir()
 }
 
 // return nil. implement the os.FileInfo interface method.
@@ -212,3 +276,23 @@ func getAcceptEncodingZip(r *http.Request) string {
 		return ""
 	}
 }
+
+// acceptEncoding reports whether the Accept-Encoding of the request accepts the encoding, q=0 refuses it.
+func acceptEncoding(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(strings.ToLower(r.Header.Get("Accept-Encoding")), ",") {
+		name, params := part, ""
+		if i := strings.Index(part, ";"); i >= 0 {
+			name, params = part[:i], part[i+1:]
+		}
+		if strings.TrimSpace(name) != encoding {
+			continue
+		}
+		params = strings.TrimSpace(params)
+		if strings.HasPrefix(params, "q=") {
+			q, err := strconv.ParseFloat(params[2:], 64)
+			return err == nil && q > 0
+		}
+		return true
+	}
+	return false
+}
@@ -96,6 +96,14 @@ func (n *Namespace) Filter(action string, filter ...FilterFunc) *Namespace {
 	return n
 }
 
+// NamedFilter same as Filter, but with the filters registered by RegisterFilter.
+// It panics if a filter isn't registered.
+// usage:
+// NamedFilter("before", "auth", "audit")
+func (n *Namespace) NamedFilter(action string, names ...string) *Namespace {
+	return n.Filter(action, mustGetFilters(names)...)
+}
+
 // Router same as beego.Rourer
 // refer: https://godoc.org/github.com/astaxie/beego#Router
 func (n *Namespace) Router(rootpath string, c ControllerInterface, mappingMethods ...string) *Namespace {
@@ -103,6 +111,13 @@ func (n *Namespace) Router(rootpath string, c ControllerInterface, mappingMethod
 	return n
 }
 
+// RouterWithOptions same as beego.RouterWithOptions
+// refer: https://godoc.org/github.com/astaxie/beego#RouterWithOptions
+func (n *Namespace) RouterWithOptions(rootpath string, c ControllerInterface, opts ...RouterOption) *Namespace {
+	n.handlers.AddWithOptions(rootpath, c, opts...)
+	return n
+}
+
 // AutoRouter same as beego.AutoRouter
 // refer: https://godoc.org/github.com/astaxie/beego#AutoRouter
 func (n *Namespace) AutoRouter(c ControllerInterface) *Namespace {
@@ -217,6 +232,9 @@ func (n *Namespace) Namespace(ns ...*Namespace) *Namespace {
 				n.handlers.routers[k] = t
 			}
 		}
+		for name, route := range ni.handlers.names {
+			n.handlers.addName(name, route)
+		}
 		if ni.handlers.enableFilter {
 			for pos, filterList := range ni.handlers.filters {
 				for _, mr := range filterList {
@@ -246,6 +264,10 @@ func AddNamespace(nl ...*Namespace) {
 				BeeApp.Handlers.routers[k] = t
 			}
 		}
+		// the patterns of the named routes are prefixed by addPrefix
+		for name, route := range n.handlers.names {
+			BeeApp.Handlers.addName(name, route)
+		}
 		if n.handlers.enableFilter {
 			for pos, filterList := range n.handlers.filters {
 				for _, mr := range filterList {
@@ -290,6 +312,13 @@ func NSBefore(filiterList ...FilterFunc) LinkNamespace {
 	}
 }
 
+// NSNamedFilter add Namespace filters registered by RegisterFilter, action is before or after
+func NSNamedFilter(action string, names ...string) LinkNamespace {
+	return func(ns *Namespace) {
+		ns.NamedFilter(action, names...)
+	}
+}
+
 // NSAfter add Namespace FinishRouter filter
 func NSAfter(filiterList ...FilterFunc) LinkNamespace {
 	return func(ns *Namespace) {
@@ -311,6 +340,13 @@ func NSRouter(rootpath string, c ControllerInterface, mappingMethods ...string)
 	}
 }
 
+// NSRouterWithOptions call Namespace RouterWithOptions
+func NSRouterWithOptions(rootpath string, c ControllerInterface, opts ...RouterOption) LinkNamespace {
+	return func(ns *Namespace) {
+		ns.RouterWithOptions(rootpath, c, opts...)
+	}
+}
+
 // NSGet call Namespace Get
 func NSGet(rootpath string, f FilterFunc) LinkNamespace {
 	return func(ns *Namespace) {
@@ -133,6 +133,26 @@ func TestNamespaceFilter(t *testing.T) {
 	}
 }
 
+func TestNamespaceNamedFilter(t *testing.T) {
+	RegisterFilter("testNamespaceNamedFilter", func(ctx *context.Context) {
+		ctx.Output.Body([]byte("this is NamedFilter"))
+	})
+	r, _ := http.NewRequest("GET", "/namedfilter/user/123", nil)
+	w := httptest.NewRecorder()
+
+	ns := NewNamespace("/namedfilter",
+		NSNamedFilter("before", "testNamespaceNamedFilter"),
+		NSGet("/user/:id", func(ctx *context.Context) {
+			ctx.Output.Body([]byte(ctx.Input.Param(":id")))
+		}),
+	)
+	AddNamespace(ns)
+	BeeApp.Handlers.ServeHTTP(w, r)
+	if w.Body.String() != "this is NamedFilter" {
+		t.Errorf("TestNamespaceNamedFilter can't run, get the response is " + w.Body.String())
+	}
+}
+
 func TestNamespaceCond(t *testing.T) {
 	r, _ := http.NewRequest("GET", "/v2/test/list", nil)
 	w := httptest.NewRecorder()
@@ -169,3 +189,14 @@ func TestNamespaceInside(t *testing.T) {
 		t.Errorf("TestNamespaceInside can't run, get the response is " + w.Body.String())
 	}
 }
+
+func TestNamespaceURLForName(t *testing.T) {
+	ns := NewNamespace("/v1",
+		NSNamespace("/shop",
+			NSRouterWithOptions("/item/:id", &TestController{}, WithName("shop.item")),
+		),
+	)
+	if url := ns.handlers.URLForName("shop.item", ":id", 3); url != "/shop/item/3" {
+		t.Errorf("TestNamespaceURLForName got %q", url)
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/swagger"
+)
+
+// OpenAPIInfo is the info of the document served at OpenAPIPath, the title is AppName if it's empty.
+var OpenAPIInfo = swagger.OpenAPIInfo{Version: "1.0"}
+
+// routeDoc documents a router in the OpenAPI document.
+type routeDoc struct {
+	summary  string
+	request  reflect.Type
+	response reflect.Type
+	hidden   bool
+}
+
+func (o *routerOptions) routeDoc() *routeDoc {
+	if o.doc == nil {
+		o.doc = &routeDoc{}
+	}
+	return o.doc
+}
+
+// WithSummary sets the summary of the router in the OpenAPI document.
+func WithSummary(summary string) RouterOption {
+	return func(o *routerOptions) {
+		o.routeDoc().summary = summary
+	}
+}
+
+// WithRequestSchema documents the json request body of the router by the struct bound by ParseBody.
+// usage:
+//
+//	Post("/user", createUser, WithRequestSchema(User{}), WithResponseSchema(User{}))
+func WithRequestSchema(v interface{}) RouterOption {
+	return func(o *routerOptions) {
+		o.routeDoc().request = reflect.TypeOf(v)
+	}
+}
+
+// WithResponseSchema documents the json response body of the router by the value served.
+func WithResponseSchema(v interface{}) RouterOption {
+	return func(o *routerOptions) {
+		o.routeDoc().response = reflect.TypeOf(v)
+	}
+}
+
+// withoutDoc leaves the router out of the OpenAPI document.
+func withoutDoc() RouterOption {
+	return func(o *routerOptions) {
+		o.routeDoc().hidden = true
+	}
+}
+
+// OpenAPI generates the OpenAPI 3 document of the routers: the patterns, the http methods of the controllers,
+// the summaries from WithSummary or the controller comments, and the schemas of WithRequestSchema and WithResponseSchema.
+// The routers added by Host are included, the http.Handler routers are left out.
+func (p *ControllerRegister) OpenAPI(info swagger.OpenAPIInfo) *swagger.OpenAPI {
+	g := &openAPIGenerator{
+		doc: &swagger.OpenAPI{
+			OpenAPI: swagger.OpenAPIVersion,
+			Info:    info,
+			Paths:   make(map[string]map[string]*swagger.OpenAPIOperation),
+		},
+		schemas: make(map[string]*swagger.OpenAPISchema),
+	}
+	methods := make([]string, 0, len(p.routers))
+	for method := range p.routers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		g.walk(method, p.routers[method])
+	}
+	// the routers of the hosts share the paths
+	for _, h := range p.hosts {
+		methods = methods[:0]
+		for method := range h.handlers.routers {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			g.walk(method, h.handlers.routers[method])
+		}
+	}
+	if len(g.schemas) > 0 {
+		g.doc.Components = &swagger.OpenAPIComponents{Schemas: g.schemas}
+	}
+	return g.doc
+}
+
+type openAPIGenerator struct {
+	doc     *swagger.OpenAPI
+	schemas map[string]*swagger.OpenAPISchema
+}
+
+// walk visits the routers of the tree, the patterns of the namespace routers have the prefix.
+func (g *openAPIGenerator) walk(method string, t *Tree) {
+	for _, sub := range t.fixrouters {
+		g.walk(method, sub)
+	}
+	if t.wildcard != nil {
+		g.walk(method, t.wildcard)
+	}
+	for _, l := range t.leaves {
+		if route, ok := l.runObject.(*controllerInfo); ok {
+			for _, variant := range patternVariants(route.pattern) {
+				g.add(method, variant, route)
+			}
+		}
+	}
+}
+
+func (g *openAPIGenerator) add(method, pattern string, route *controllerInfo) {
+	if route.doc != nil && route.doc.hidden || route.routerType == routerTypeHandler {
+		return
+	}
+	op := &swagger.OpenAPIOperation{
+		Responses: map[string]*swagger.OpenAPIResponse{"200": {Description: "OK"}},
+	}
+	if route.routerType == routerTypeBeego {
+		action := route.methods[method]
+		if action == "" {
+			action = route.methods["*"]
+		}
+		if action == "" {
+			if len(route.methods) > 0 {
+				return
+			}
+			action = method[:1] + strings.ToLower(method[1:])
+			if !declaresMethod(route.controllerType, action) {
+				return
+			}
+		}
+		op.OperationID = route.controllerType.Name() + "." + action
+		op.Tags = []string{strings.TrimSuffix(route.controllerType.Name(), "Controller")}
+	}
+
+	path, params := openAPIPath(pattern)
+	op.Parameters = params
+	if doc := route.doc; doc != nil {
+		op.Summary = doc.summary
+		if doc.request != nil {
+			op.RequestBody = &swagger.OpenAPIRequestBody{
+				Required: true,
+				Content:  map[string]swagger.OpenAPIMediaType{applicationJSON: {Schema: g.schema(doc.request)}},
+			}
+		}
+		if doc.response != nil {
+			op.Responses["200"].Content = map[string]swagger.OpenAPIMediaType{applicationJSON: {Schema: g.schema(doc.response)}}
+		}
+	}
+	if g.doc.Paths[path] == nil {
+		g.doc.Paths[path] = make(map[string]*swagger.OpenAPIOperation)
+	}
+	g.doc.Paths[path][strings.ToLower(method)] = op
+}
+
+// declaresMethod returns whether the controller implements the action itself, the methods of the
+// embedded Controller are promoted by the wrappers generated by the compiler.
+func declaresMethod(t reflect.Type, name string) bool {
+	m, ok := reflect.PtrTo(t).MethodByName(name)
+	if !ok {
+		return false
+	}
+	fn := runtime.FuncForPC(m.Func.Pointer())
+	if fn == nil {
+		return false
+	}
+	file, _ := fn.FileLine(fn.Entry())
+	return file != "<autogenerated>"
+}
+
+var openAPIParamRegexp = regexp.MustCompile(`\??:(\w+)(:int|:string)?(\([^)]*\))?`)
+
+// openAPIPath converts the router pattern to the OpenAPI path and its parameters,
+// e.g. /user/:id:int to /user/{id} with an integer id.
+func openAPIPath(pattern string) (string, []*swagger.OpenAPIParameter) {
+	var params []*swagger.OpenAPIParameter
+	param := func(name, typ string) string {
+		params = append(params, &swagger.OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &swagger.OpenAPISchema{Type: typ},
+		})
+		return "{" + name + "}"
+	}
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		switch seg {
+		case "*.*":
+			segments[i] = param("path", "string") + "." + param("ext", "string")
+			continue
+		case "*":
+			segments[i] = param("splat", "string")
+			continue
+		}
+		if name, _ := catchAllName(seg); name != "" {
+			segments[i] = param(name[1:], "string")
+			continue
+		}
+		segments[i] = openAPIParamRegexp.ReplaceAllStringFunc(seg, func(s string) string {
+			m := openAPIParamRegexp.FindStringSubmatch(s)
+			typ := "string"
+			if m[2] == ":int" || m[3] == `([0-9]+)` || m[3] == `(\d+)` {
+				typ = "integer"
+			}
+			return param(m[1], typ)
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the schema of t, the named structs are referred from the components.
+func (g *openAPIGenerator) schema(t reflect.Type) *swagger.OpenAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &swagger.OpenAPISchema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &swagger.OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &swagger.OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &swagger.OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &swagger.OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &swagger.OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &swagger.OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &swagger.OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &swagger.OpenAPISchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &swagger.OpenAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			// registered first for the recursive types
+			s := &swagger.OpenAPISchema{}
+			g.schemas[t.Name()] = s
+			*s = *g.object(t)
+		}
+		return &swagger.OpenAPISchema{Ref: "#/components/schemas/" + t.Name()}
+	}
+	return &swagger.OpenAPISchema{}
+}
+
+// object returns the schema of the json object of the struct.
+func (g *openAPIGenerator) object(t reflect.Type) *swagger.OpenAPISchema {
+	s := &swagger.OpenAPISchema{Type: "object", Properties: make(map[string]*swagger.OpenAPISchema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" || f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			embedded := g.object(ft)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schema(f.Type)
+		if f.Tag.Get("binding") == "required" {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+func serveOpenAPI(ctx *context.Context) {
+	info := OpenAPIInfo
+	if info.Title == "" {
+		info.Title = AppName
+	}
+	ctx.Output.JSON(BeeApp.Handlers.OpenAPI(info), RunMode == "dev", false)
+}
+
+func serveOpenAPIUI(ctx *context.Context) {
+	ctx.Output.Header("Content-Type", "text/html; charset=utf-8")
+	ctx.Output.Body([]byte(strings.Replace(openAPIUITpl, "{{.URL}}", OpenAPIPath, 1)))
+}
+
+// openAPIUITpl is the page of the Swagger UI, it loads the assets of the UI from unpkg.com.
+var openAPIUITpl = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>Swagger UI</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+	<script>
+		window.ui = SwaggerUIBundle({url: "{{.URL}}", dom_id: "#swagger-ui"});
+	</script>
+</body>
+</html>
+`
@@ -0,0 +1,95 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"testing"
+	"time"
+
+	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/swagger"
+)
+
+type openAPIBase struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type openAPIUser struct {
+	openAPIBase
+	Name    string            `json:"name" binding:"required"`
+	Tags    []string          `json:"tags,omitempty"`
+	Friends []*openAPIUser    `json:"friends"`
+	Extra   map[string]string `json:"-"`
+}
+
+type OpenAPIController struct {
+	Controller
+}
+
+func (c *OpenAPIController) Get() {}
+
+func (c *OpenAPIController) List() {}
+
+func TestOpenAPI(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Add("/user/:id:int", &OpenAPIController{})
+	handler.Add("/users", &OpenAPIController{}, "get:List")
+	handler.Post("/user", func(ctx *context.Context) {},
+		WithSummary("create a user"), WithRequestSchema(openAPIUser{}), WithResponseSchema(&openAPIUser{}))
+	handler.Get("/internal", func(ctx *context.Context) {}, withoutDoc())
+	ns := NewNamespace("/v1", NSGet("/files/*", func(ctx *context.Context) {}))
+	// mounted like AddNamespace
+	addPrefix(ns.handlers.routers["GET"], "/v1")
+	handler.routers["GET"].AddTree("/v1", ns.handlers.routers["GET"])
+
+	doc := handler.OpenAPI(swagger.OpenAPIInfo{Title: "test", Version: "1.0"})
+	if doc.OpenAPI != swagger.OpenAPIVersion || doc.Info.Title != "test" {
+		t.Errorf("info: %+v", doc)
+	}
+
+	user := doc.Paths["/user/{id}"]
+	if len(user) != 1 || user["get"] == nil || user["get"].OperationID != "OpenAPIController.Get" {
+		t.Fatalf("controller operations: %+v", user)
+	}
+	if p := user["get"].Parameters; len(p) != 1 || p[0].Name != "id" || p[0].In != "path" || p[0].Schema.Type != "integer" {
+		t.Errorf("path parameters: %+v", p)
+	}
+	if op := doc.Paths["/users"]; len(op) != 1 || op["get"].OperationID != "OpenAPIController.List" {
+		t.Errorf("mapped methods: %+v", op)
+	}
+
+	create := doc.Paths["/user"]["post"]
+	if create == nil || create.Summary != "create a user" || create.RequestBody.Content["application/json"].Schema.Ref != "#/components/schemas/openAPIUser" {
+		t.Fatalf("documented function: %+v", create)
+	}
+	s := doc.Components.Schemas["openAPIUser"]
+	if s == nil || s.Properties["id"].Format != "int64" || s.Properties["created"].Format != "date-time" || s.Properties["name"].Type != "string" {
+		t.Fatalf("schema: %+v", s)
+	}
+	if s.Properties["friends"].Items.Ref != "#/components/schemas/openAPIUser" || s.Properties["Extra"] != nil || s.Properties["extra"] != nil {
+		t.Errorf("schema fields: %+v", s.Properties)
+	}
+	if len(s.Required) != 1 || s.Required[0] != "name" {
+		t.Errorf("required: %v", s.Required)
+	}
+
+	if _, ok := doc.Paths["/internal"]; ok {
+		t.Error("the hidden router should not be documented")
+	}
+	if op := doc.Paths["/v1/files/{splat}"]; op == nil || op["get"] == nil {
+		t.Errorf("namespace router: %v", doc.Paths)
+	}
+}
@@ -282,13 +282,13 @@ func (d *dbBase) InsertStmt(stmt stmtQuerier, mi *modelInfo, ind reflect.Value,
 		row := stmt.QueryRow(values...)
 		var id int64
 		err := row.Scan(&id)
-		return id, err
+		return id, d.ins.TranslateError(mi, err)
 	}
 	res, err := stmt.Exec(values...)
 	if err == nil {
 		return res.LastInsertId()
 	}
-	return 0, err
+	return 0, d.ins.TranslateError(mi, err)
 }
 
 // query sql ,read records and persist in dbBaser.
@@ -447,12 +447,12 @@ func (d *dbBase) InsertValue(q dbQuerier, mi *modelInfo, isMulti bool, names []s
 			}
 			return res.LastInsertId()
 		}
-		return 0, err
+		return 0, d.ins.TranslateError(mi, err)
 	}
 	row := q.QueryRow(query, values...)
 	var id int64
 	err := row.Scan(&id)
-	return id, err
+	return id, d.ins.TranslateError(mi, err)
 }
 
 // execute update sql dbQuerier with given struct reflect.Value.
@@ -488,10 +488,11 @@ func (d *dbBase) Update(q dbQuerier, mi *modelInfo, ind reflect.Value, tz *time.
 
 	d.ins.ReplaceMarks(&query)
 
-	if res, err := q.Exec(query, setValues...); err == nil {
+	res, err := q.Exec(query, setValues...)
+	if err == nil {
 		return res.RowsAffected()
 	}
-	return 0, err
+	return 0, d.ins.TranslateError(mi, err)
 }
 
 // execute delete sql dbQuerier with given struct reflect.Value.
@@ -528,7 +529,7 @@ func (d *dbBase) Delete(q dbQuerier, mi *modelInfo, ind reflect.Value, tz *time.
 		}
 		return num, err
 	}
-	return 0, err
+	return 0, d.ins.TranslateError(mi, err)
 }
 
 // update table-related record by querySet.
@@ -603,7 +604,7 @@ func (d *dbBase) UpdateBatch(q dbQuerier, qs *querySet, mi *modelInfo, cond *Con
 	if err == nil {
 		return res.RowsAffected()
 	}
-	return 0, err
+	return 0, d.ins.TranslateError(mi, err)
 }
 
 // delete related records.
@@ -702,7 +703,7 @@ func (d *dbBase) DeleteBatch(q dbQuerier, qs *querySet, mi *modelInfo, cond *Con
 		}
 		return num, nil
 	}
-	return 0, err
+	return 0, d.ins.TranslateError(mi, err)
 }
 
 // read related records.
@@ -1647,3 +1648,8 @@ func (d *dbBase) ShowColumnsQuery(table string) string {
 func (d *dbBase) IndexExists(dbQuerier, string, string) bool {
 	panic(ErrNotImplement)
 }
+
+// translate the driver errors, the drivers return them as is by default.
+func (d *dbBase) TranslateError(mi *modelInfo, err error) error {
+	return err
+}
@@ -18,6 +18,7 @@ import (
 	"database/sql"
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 	"time"
 )
@@ -94,6 +95,18 @@ func (ac *_dbCache) get(name string) (al *alias, ok bool) {
 	return
 }
 
+// names returns the sorted alias names.
+func (ac *_dbCache) names() []string {
+	ac.mux.RLock()
+	defer ac.mux.RUnlock()
+	names := make([]string, 0, len(ac.cache))
+	for name := range ac.cache {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // get default alias.
 func (ac *_dbCache) getDefault() (al *alias) {
 	al, _ = ac.get("default")
@@ -280,6 +293,11 @@ func SetMaxOpenConns(aliasName string, maxOpenConns int) {
 	}
 }
 
+// DataBaseAliases returns the sorted names of the registered databases.
+func DataBaseAliases() []string {
+	return dataBaseCache.names()
+}
+
 // GetDB Get *sql.DB from registered database by db alias name.
 // Use "default" as alias name if you not set.
 func GetDB(aliasNames ...string) (*sql.DB, error) {
@@ -0,0 +1,258 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sentinelError is an orm error which stands for a database/sql error,
+// e.g. ErrNoRows matches sql.ErrNoRows with errors.Is.
+type sentinelError struct {
+	msg string
+	err error
+}
+
+func newSentinelError(msg string, err error) error {
+	return &sentinelError{msg: msg, err: err}
+}
+
+func (e *sentinelError) Error() string {
+	return e.msg
+}
+
+func (e *sentinelError) Unwrap() error {
+	return e.err
+}
+
+// ConstraintError is a unique or foreign key violation normalized from the error of the driver.
+// It matches ErrDuplicateKey or ErrFKViolation with errors.Is, the driver error is kept in Err.
+// usage:
+//
+//	if _, err := o.Insert(user); errors.Is(err, orm.ErrDuplicateKey) {
+//		var ce *orm.ConstraintError
+//		errors.As(err, &ce)
+//		fmt.Println(ce.Columns)
+//	}
+type ConstraintError struct {
+	Kind       error    // ErrDuplicateKey or ErrFKViolation
+	Table      string   // the table of the constraint, if known
+	Constraint string   // the name of the index or constraint, if reported by the database
+	Columns    []string // the columns of the constraint, if known
+	Err        error    // the driver error
+}
+
+// ConstraintKind returns Kind, the typed handlers of beego answer the violations as 409 by it.
+func (e *ConstraintError) ConstraintKind() error {
+	return e.Kind
+}
+
+// ConstraintColumns returns Columns.
+func (e *ConstraintError) ConstraintColumns() []string {
+	return e.Columns
+}
+
+func (e *ConstraintError) Error() string {
+	msg := e.Kind.Error()
+	if len(e.Columns) > 0 {
+		msg += " on " + strings.Join(e.Columns, ", ")
+	} else if e.Constraint != "" {
+		msg += " on " + e.Constraint
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+// Unwrap returns the driver error.
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is the kind of the violation.
+func (e *ConstraintError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// newConstraintError returns the ConstraintError of the model, the table defaults to the table of the model.
+func newConstraintError(kind error, mi *modelInfo, table, constraint string, columns []string, err error) error {
+	if table == "" && mi != nil {
+		table = mi.table
+	}
+	return &ConstraintError{
+		Kind:       kind,
+		Table:      table,
+		Constraint: constraint,
+		Columns:    columns,
+		Err:        err,
+	}
+}
+
+// driverField returns the field of the first struct in the error chain which has it,
+// the drivers are not imported so their errors are read by reflection, e.g. Number of *mysql.MySQLError.
+func driverField(err error, name string) string {
+	for ; err != nil; err = errors.Unwrap(err) {
+		v := reflect.ValueOf(err)
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				break
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		f := v.FieldByName(name)
+		switch f.Kind() {
+		case reflect.String:
+			return f.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.FormatInt(f.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return strconv.FormatUint(f.Uint(), 10)
+		}
+	}
+	return ""
+}
+
+// splitColumns splits the column list reported by the database, the quotes and the table prefixes are removed.
+func splitColumns(list string) []string {
+	var columns []string
+	for _, col := range strings.Split(list, ",") {
+		col = strings.Trim(strings.TrimSpace(col), "`\"")
+		if i := strings.LastIndex(col, "."); i >= 0 {
+			col = col[i+1:]
+		}
+		if col != "" {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// indexColumns guesses the columns of the index by its name,
+// the databases name the unnamed indexes after their first column, e.g. user_name or user_name_2 in mysql.
+func indexColumns(mi *modelInfo, index string) []string {
+	if mi == nil || index == "" {
+		return nil
+	}
+	if index == "PRIMARY" && mi.fields.pk != nil {
+		return []string{mi.fields.pk.column}
+	}
+	for _, fi := range mi.fields.fieldsDB {
+		if fi.column == index || fi.column == strings.TrimRight(strings.TrimRight(index, "0123456789"), "_") {
+			return []string{fi.column}
+		}
+	}
+	return nil
+}
+
+var (
+	mysqlDuplicateKey = regexp.MustCompile("for key '([^']+)'")
+	mysqlForeignKey   = regexp.MustCompile("`([^`]+)`, CONSTRAINT `([^`]+)` FOREIGN KEY \\(([^)]+)\\)")
+)
+
+// mysqlConstraintError translates the errors of the mysql and tidb drivers.
+func mysqlConstraintError(mi *modelInfo, err error) error {
+	switch driverField(err, "Number") {
+	case "1062", "1586":
+		var index string
+		if m := mysqlDuplicateKey.FindStringSubmatch(err.Error()); m != nil {
+			index = m[1]
+			// mysql 8 reports table.index
+			if i := strings.LastIndex(index, "."); i >= 0 {
+				index = index[i+1:]
+			}
+		}
+		return newConstraintError(ErrDuplicateKey, mi, "", index, indexColumns(mi, index), err)
+	case "1216", "1217", "1451", "1452":
+		if m := mysqlForeignKey.FindStringSubmatch(err.Error()); m != nil {
+			return newConstraintError(ErrFKViolation, nil, m[1], m[2], splitColumns(m[3]), err)
+		}
+		return newConstraintError(ErrFKViolation, mi, "", "", nil, err)
+	}
+	return err
+}
+
+var postgresKey = regexp.MustCompile(`Key \(([^)]+)\)=`)
+
+// postgresConstraintError translates the errors of the lib/pq and pgx drivers by the SQLSTATE.
+func postgresConstraintError(mi *modelInfo, err error) error {
+	var kind error
+	switch driverField(err, "Code") {
+	case "23505":
+		kind = ErrDuplicateKey
+	case "23503":
+		kind = ErrFKViolation
+	default:
+		return err
+	}
+	table := driverField(err, "Table")
+	if table == "" {
+		table = driverField(err, "TableName")
+	}
+	constraint := driverField(err, "Constraint")
+	if constraint == "" {
+		constraint = driverField(err, "ConstraintName")
+	}
+	var columns []string
+	if m := postgresKey.FindStringSubmatch(driverField(err, "Detail")); m != nil {
+		columns = splitColumns(m[1])
+	}
+	if table != "" {
+		mi = nil
+	}
+	return newConstraintError(kind, mi, table, constraint, columns, err)
+}
+
+var sqliteUnique = regexp.MustCompile(`(?:UNIQUE|PRIMARY KEY) constraint failed: (.+)$|columns? (.+) (?:is|are) not unique`)
+
+// sqliteConstraintError translates the errors of the sqlite3 driver by the message.
+func sqliteConstraintError(mi *modelInfo, err error) error {
+	msg := err.Error()
+	if m := sqliteUnique.FindStringSubmatch(msg); m != nil {
+		list := m[1] + m[2]
+		table := ""
+		if i := strings.Index(list, "."); i > 0 {
+			table = list[:i]
+			mi = nil
+		}
+		return newConstraintError(ErrDuplicateKey, mi, table, "", splitColumns(list), err)
+	}
+	if strings.Contains(msg, "FOREIGN KEY constraint failed") {
+		return newConstraintError(ErrFKViolation, mi, "", "", nil, err)
+	}
+	return err
+}
+
+var oracleConstraint = regexp.MustCompile(`ORA-(00001|02291|02292): \w+ constraint \(([^)]+)\)`)
+
+// oracleConstraintError translates the ORA- errors by the message.
+func oracleConstraintError(mi *modelInfo, err error) error {
+	m := oracleConstraint.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	constraint := m[2]
+	if i := strings.LastIndex(constraint, "."); i >= 0 {
+		constraint = constraint[i+1:]
+	}
+	if m[1] == "00001" {
+		return newConstraintError(ErrDuplicateKey, mi, "", constraint, nil, err)
+	}
+	return newConstraintError(ErrFKViolation, mi, "", constraint, nil, err)
+}
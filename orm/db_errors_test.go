@@ -0,0 +1,219 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// the errors of the drivers, shaped like *mysql.MySQLError and *pq.Error
+type fakeMysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *fakeMysqlError) Error() string {
+	return fmt.Sprintf("Error %d: %s", e.Number, e.Message)
+}
+
+type fakePostgresError struct {
+	Code, Table, Constraint, Detail string
+}
+
+func (e *fakePostgresError) Error() string {
+	return "pq: " + e.Code
+}
+
+// fakePgxError has the names of the fields of *pgconn.PgError
+type fakePgxError struct {
+	Code, TableName, ConstraintName, Detail string
+}
+
+func (e *fakePgxError) Error() string {
+	return "pgx: " + e.Code
+}
+
+func testErrorModel() *modelInfo {
+	mi := &modelInfo{table: "user", fields: newFields()}
+	mi.fields.pk = &fieldInfo{column: "id"}
+	mi.fields.fieldsDB = []*fieldInfo{mi.fields.pk, {column: "name"}, {column: "email"}}
+	return mi
+}
+
+func TestDriverField(t *testing.T) {
+	wrapped := fmt.Errorf("insert: %w", &fakeMysqlError{Number: 1062, Message: "dup"})
+	tests := []struct {
+		err  error
+		name string
+		want string
+	}{
+		{&fakeMysqlError{Number: 1062}, "Number", "1062"},
+		{wrapped, "Number", "1062"},
+		{wrapped, "Message", "dup"},
+		{&fakePostgresError{Code: "23505"}, "Code", "23505"},
+		{struct{ error }{errors.New("x")}, "Code", ""},
+		{errors.New("plain"), "Number", ""},
+		{nil, "Number", ""},
+	}
+	for _, tt := range tests {
+		if got := driverField(tt.err, tt.name); got != tt.want {
+			t.Errorf("driverField(%v, %s) = %q, want %q", tt.err, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIndexColumns(t *testing.T) {
+	mi := testErrorModel()
+	tests := []struct {
+		mi    *modelInfo
+		index string
+		want  []string
+	}{
+		{mi, "PRIMARY", []string{"id"}},
+		{mi, "name", []string{"name"}},
+		{mi, "email_2", []string{"email"}},
+		{mi, "idx_user_email", nil},
+		{mi, "", nil},
+		{nil, "name", nil},
+	}
+	for _, tt := range tests {
+		if got := indexColumns(tt.mi, tt.index); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("indexColumns(%q) = %v, want %v", tt.index, got, tt.want)
+		}
+	}
+}
+
+// checkConstraintError compares the translated error with the want ConstraintError, nil wants the error untouched.
+func checkConstraintError(t *testing.T, name string, err, got error, want *ConstraintError) {
+	t.Helper()
+	if want == nil {
+		if got != err {
+			t.Errorf("%s: the error should be returned untouched, got %v", name, got)
+		}
+		return
+	}
+	want.Err = err
+	var ce *ConstraintError
+	if !errors.As(got, &ce) {
+		t.Errorf("%s: ConstraintError expected, got %v", name, got)
+		return
+	}
+	if !errors.Is(got, want.Kind) || ce.Table != want.Table || ce.Constraint != want.Constraint ||
+		!reflect.DeepEqual(ce.Columns, want.Columns) || ce.Err != err {
+		t.Errorf("%s: got %+v, want %+v", name, ce, want)
+	}
+}
+
+func TestMysqlConstraintError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want *ConstraintError
+	}{
+		{
+			&fakeMysqlError{Number: 1062, Message: "Duplicate entry 'bob' for key 'name'"},
+			&ConstraintError{Kind: ErrDuplicateKey, Table: "user", Constraint: "name", Columns: []string{"name"}},
+		},
+		{
+			&fakeMysqlError{Number: 1062, Message: "Duplicate entry '1' for key 'user.PRIMARY'"},
+			&ConstraintError{Kind: ErrDuplicateKey, Table: "user", Constraint: "PRIMARY", Columns: []string{"id"}},
+		},
+		{
+			&fakeMysqlError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails " +
+				"(`test`.`post`, CONSTRAINT `post_user_fk` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`))"},
+			&ConstraintError{Kind: ErrFKViolation, Table: "post", Constraint: "post_user_fk", Columns: []string{"user_id"}},
+		},
+		{
+			&fakeMysqlError{Number: 1451, Message: "Cannot delete or update a parent row"},
+			&ConstraintError{Kind: ErrFKViolation, Table: "user"},
+		},
+		{&fakeMysqlError{Number: 1146, Message: "Table 'test.user' doesn't exist"}, nil},
+		{errors.New("driver: bad connection"), nil},
+	}
+	for _, tt := range tests {
+		checkConstraintError(t, tt.err.Error(), tt.err, mysqlConstraintError(testErrorModel(), tt.err), tt.want)
+	}
+}
+
+func TestPostgresConstraintError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want *ConstraintError
+	}{
+		{
+			&fakePostgresError{Code: "23505", Table: "person", Constraint: "person_email_key", Detail: "Key (email)=(a@b.c) already exists."},
+			&ConstraintError{Kind: ErrDuplicateKey, Table: "person", Constraint: "person_email_key", Columns: []string{"email"}},
+		},
+		{
+			&fakePostgresError{Code: "23505", Detail: `Key ("first", "last")=(a, b) already exists.`},
+			&ConstraintError{Kind: ErrDuplicateKey, Table: "user", Columns: []string{"first", "last"}},
+		},
+		{
+			&fakePgxError{Code: "23503", TableName: "post", ConstraintName: "post_user_fk", Detail: `Key (user_id)=(9) is not present in table "user".`},
+			&ConstraintError{Kind: ErrFKViolation, Table: "post", Constraint: "post_user_fk", Columns: []string{"user_id"}},
+		},
+		{&fakePostgresError{Code: "42P01"}, nil},
+		{errors.New("pq: no code"), nil},
+	}
+	for _, tt := range tests {
+		checkConstraintError(t, tt.err.Error(), tt.err, postgresConstraintError(testErrorModel(), tt.err), tt.want)
+	}
+}
+
+func TestSqliteConstraintError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want *ConstraintError
+	}{
+		{
+			errors.New("UNIQUE constraint failed: user.first, user.last"),
+			&ConstraintError{Kind: ErrDuplicateKey, Table: "user", Columns: []string{"first", "last"}},
+		},
+		{
+			errors.New("column name is not unique"),
+			&ConstraintError{Kind: ErrDuplicateKey, Table: "user", Columns: []string{"name"}},
+		},
+		{
+			errors.New("FOREIGN KEY constraint failed"),
+			&ConstraintError{Kind: ErrFKViolation, Table: "user"},
+		},
+		{errors.New("no such table: user"), nil},
+	}
+	for _, tt := range tests {
+		checkConstraintError(t, tt.err.Error(), tt.err, sqliteConstraintError(testErrorModel(), tt.err), tt.want)
+	}
+}
+
+func TestOracleConstraintError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want *ConstraintError
+	}{
+		{
+			errors.New("ORA-00001: unique constraint (APP.USER_EMAIL_UK) violated"),
+			&ConstraintError{Kind: ErrDuplicateKey, Table: "user", Constraint: "USER_EMAIL_UK"},
+		},
+		{
+			errors.New("ORA-02291: integrity constraint (APP.POST_USER_FK) violated - parent key not found"),
+			&ConstraintError{Kind: ErrFKViolation, Table: "user", Constraint: "POST_USER_FK"},
+		},
+		{errors.New("ORA-00942: table or view does not exist"), nil},
+	}
+	for _, tt := range tests {
+		checkConstraintError(t, tt.err.Error(), tt.err, oracleConstraintError(testErrorModel(), tt.err), tt.want)
+	}
+}
@@ -102,3 +102,11 @@ func newdbBaseMysql() dbBaser {
 	b.ins = b
 	return b
 }
+
+// translate the mysql constraint violations to ConstraintError.
+func (d *dbBaseMysql) TranslateError(mi *modelInfo, err error) error {
+	if err == nil {
+		return nil
+	}
+	return mysqlConstraintError(mi, err)
+}
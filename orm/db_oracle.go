@@ -27,3 +27,11 @@ func newdbBaseOracle() dbBaser {
 	b.ins = b
 	return b
 }
+
+// translate the oracle constraint violations to ConstraintError.
+func (d *dbBaseOracle) TranslateError(mi *modelInfo, err error) error {
+	if err == nil {
+		return nil
+	}
+	return oracleConstraintError(mi, err)
+}
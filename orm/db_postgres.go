@@ -163,3 +163,11 @@ func newdbBasePostgres() dbBaser {
 	b.ins = b
 	return b
 }
+
+// translate the postgres constraint violations to ConstraintError.
+func (d *dbBasePostgres) TranslateError(mi *modelInfo, err error) error {
+	if err == nil {
+		return nil
+	}
+	return postgresConstraintError(mi, err)
+}
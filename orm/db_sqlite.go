@@ -148,3 +148,11 @@ func newdbBaseSqlite() dbBaser {
 	b.ins = b
 	return b
 }
+
+// translate the sqlite constraint violations to ConstraintError.
+func (d *dbBaseSqlite) TranslateError(mi *modelInfo, err error) error {
+	if err == nil {
+		return nil
+	}
+	return sqliteConstraintError(mi, err)
+}
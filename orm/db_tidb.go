@@ -61,3 +61,11 @@ func newdbBaseTidb() dbBaser {
 	b.ins = b
 	return b
 }
+
+// translate the tidb constraint violations to ConstraintError.
+func (d *dbBaseTidb) TranslateError(mi *modelInfo, err error) error {
+	if err == nil {
+		return nil
+	}
+	return mysqlConstraintError(mi, err)
+}
@@ -52,6 +52,7 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -75,10 +76,12 @@ var (
 	ErrTxHasBegan    = errors.New("<Ormer.Begin> transaction already begin")
 	ErrTxDone        = errors.New("<Ormer.Commit/Rollback> transaction not begin")
 	ErrMultiRows     = errors.New("<QuerySeter> return multi rows")
-	ErrNoRows        = errors.New("<QuerySeter> no row found")
+	ErrNoRows        = newSentinelError("<QuerySeter> no row found", sql.ErrNoRows)
 	ErrStmtClosed    = errors.New("<QuerySeter> stmt already closed")
 	ErrArgs          = errors.New("<Ormer> args error may be empty")
 	ErrNotImplement  = errors.New("have not implement")
+	ErrDuplicateKey  = errors.New("<Ormer> duplicate key")
+	ErrFKViolation   = errors.New("<Ormer> foreign key violation")
 )
 
 // Params stores the Params
@@ -91,9 +94,12 @@ type orm struct {
 	alias *alias
 	db    dbQuerier
 	isTx  bool
+	tag   string
+	// the changes of the models reported after Commit
+	changes []modelChange
 }
 
-var _ Ormer = new(orm)
+var _ ContextOrmer = new(orm)
 
 // get model info and model reflect value
 func (o *orm) getMiInd(md interface{}, needPtr bool) (mi *modelInfo, ind reflect.Value) {
@@ -152,6 +158,7 @@ func (o *orm) Insert(md interface{}) (int64, error) {
 	}
 
 	o.setPk(mi, ind, id)
+	o.changed(ActionInsert, md)
 
 	return id, nil
 }
@@ -192,12 +199,19 @@ func (o *orm) InsertMulti(bulk int, mds interface{}) (int64, error) {
 			}
 
 			o.setPk(mi, ind, id)
+			o.changed(ActionInsert, ind.Interface())
 
 			cnt++
 		}
 	} else {
 		mi, _ := o.getMiInd(sind.Index(0).Interface(), false)
-		return o.alias.DbBaser.InsertMulti(o.db, mi, sind, bulk, o.alias.TZ)
+		cnt, err := o.alias.DbBaser.InsertMulti(o.db, mi, sind, bulk, o.alias.TZ)
+		if err == nil {
+			for i := 0; i < sind.Len(); i++ {
+				o.changed(ActionInsert, sind.Index(i).Interface())
+			}
+		}
+		return cnt, err
 	}
 	return cnt, nil
 }
@@ -210,6 +224,9 @@ func (o *orm) Update(md interface{}, cols ...string) (int64, error) {
 	if err != nil {
 		return num, err
 	}
+	if num > 0 {
+		o.changed(ActionUpdate, md)
+	}
 	return num, nil
 }
 
@@ -221,6 +238,7 @@ func (o *orm) Delete(md interface{}) (int64, error) {
 		return num, err
 	}
 	if num > 0 {
+		o.changed(ActionDelete, md)
 		o.setPk(mi, ind, 0)
 	}
 	return num, nil
@@ -425,7 +443,7 @@ func (o *orm) Using(name string) error {
 	if al, ok := dataBaseCache.get(name); ok {
 		o.alias = al
 		if Debug {
-			o.db = newDbQueryLog(al, al.DB)
+			o.db = newDbQueryLog(al, o.tag, al.DB)
 		} else {
 			o.db = al.DB
 		}
@@ -446,6 +464,7 @@ func (o *orm) Begin() error {
 		return err
 	}
 	o.isTx = true
+	o.changes = nil
 	if Debug {
 		o.db.(*dbQueryLog).SetDB(tx)
 	} else {
@@ -463,6 +482,9 @@ func (o *orm) Commit() error {
 	if err == nil {
 		o.isTx = false
 		o.Using(o.alias.Name)
+		changes := o.changes
+		o.changes = nil
+		notifyChanges(changes...)
 	} else if err == sql.ErrTxDone {
 		return ErrTxDone
 	}
@@ -477,6 +499,7 @@ func (o *orm) Rollback() error {
 	err := o.db.(txEnder).Rollback()
 	if err == nil {
 		o.isTx = false
+		o.changes = nil
 		o.Using(o.alias.Name)
 	} else if err == sql.ErrTxDone {
 		return ErrTxDone
@@ -494,6 +517,22 @@ func (o *orm) Driver() Driver {
 	return driver(o.alias.Name)
 }
 
+// WithContext returns a copy of the Ormer which tags its debug query logs with the QueryTag of ctx.
+// e.g. in a beego controller:
+//	o := orm.NewOrm().(orm.ContextOrmer).WithContext(c.Ctx.Context())
+func (o *orm) WithContext(ctx context.Context) Ormer {
+	n := *o
+	if tag, ok := QueryTagFromContext(ctx); ok {
+		n.tag = tag.String()
+	}
+	if d, ok := o.db.(*dbQueryLog); ok {
+		nd := *d
+		nd.tag = n.tag
+		n.db = &nd
+	}
+	return &n
+}
+
 // NewOrm create new orm
 func NewOrm() Ormer {
 	BootStrap() // execute only once
@@ -525,7 +564,7 @@ func NewOrmWithDB(driverName, aliasName string, db *sql.DB) (Ormer, error) {
 	o.alias = al
 
 	if Debug {
-		o.db = newDbQueryLog(o.alias, db)
+		o.db = newDbQueryLog(o.alias, o.tag, db)
 	} else {
 		o.db = db
 	}
@@ -0,0 +1,69 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"sync"
+)
+
+// the actions of the model changes passed to the ChangeFunc.
+const (
+	ActionInsert = "insert"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// ChangeFunc is called after a model is inserted, updated or deleted by an Ormer.
+type ChangeFunc func(action string, md interface{})
+
+type modelChange struct {
+	action string
+	md     interface{}
+}
+
+var (
+	changeLock  sync.RWMutex
+	changeFuncs []ChangeFunc
+)
+
+// OnChange adds fn to the functions called after Insert, InsertMulti, Update and Delete changed a model,
+// e.g. to purge the cached pages of the model.
+// The changes in a transaction are reported after Commit and dropped by Rollback.
+// The changes by QuerySeter and Raw aren't reported, their models aren't known.
+func OnChange(fn ChangeFunc) {
+	changeLock.Lock()
+	defer changeLock.Unlock()
+	changeFuncs = append(changeFuncs, fn)
+}
+
+// changed reports the change of md, it's postponed until Commit in a transaction.
+func (o *orm) changed(action string, md interface{}) {
+	if o.isTx {
+		o.changes = append(o.changes, modelChange{action, md})
+		return
+	}
+	notifyChanges(modelChange{action, md})
+}
+
+func notifyChanges(changes ...modelChange) {
+	changeLock.RLock()
+	fns := changeFuncs
+	changeLock.RUnlock()
+	for _, c := range changes {
+		for _, fn := range fns {
+			fn(c.action, c.md)
+		}
+	}
+}
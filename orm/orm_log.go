@@ -15,6 +15,7 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io"
@@ -35,14 +36,49 @@ func NewLog(out io.Writer) *Log {
 	return d
 }
 
-func debugLogQueies(alias *alias, operaton, query string, t time.Time, err error, args ...interface{}) {
+// QueryTag identifies the request which runs the queries, it's printed in the debug query logs.
+type QueryTag struct {
+	RequestID string
+	Route     string
+}
+
+func (t QueryTag) String() string {
+	var tags []string
+	if t.RequestID != "" {
+		tags = append(tags, "request:"+t.RequestID)
+	}
+	if t.Route != "" {
+		tags = append(tags, "route:"+t.Route)
+	}
+	return strings.Join(tags, " ")
+}
+
+type queryTagKey struct{}
+
+// WithQueryTag returns a copy of ctx which carries the QueryTag,
+// the Ormer returned by ContextOrmer.WithContext(ctx) tags its debug query logs with it.
+func WithQueryTag(ctx context.Context, tag QueryTag) context.Context {
+	return context.WithValue(ctx, queryTagKey{}, tag)
+}
+
+// QueryTagFromContext returns the QueryTag stored in ctx by WithQueryTag.
+func QueryTagFromContext(ctx context.Context) (QueryTag, bool) {
+	tag, ok := ctx.Value(queryTagKey{}).(QueryTag)
+	return tag, ok
+}
+
+func debugLogQueies(alias *alias, tag, operaton, query string, t time.Time, err error, args ...interface{}) {
 	sub := time.Now().Sub(t) / 1e5
 	elsp := float64(int(sub)) / 10.0
 	flag := "  OK"
 	if err != nil {
 		flag = "FAIL"
 	}
-	con := fmt.Sprintf(" - %s - [Queries/%s] - [%s / %11s / %7.1fms] - [%s]", t.Format(formatDateTime), alias.Name, flag, operaton, elsp, query)
+	con := fmt.Sprintf(" - %s - [Queries/%s]", t.Format(formatDateTime), alias.Name)
+	if tag != "" {
+		con += " - [" + tag + "]"
+	}
+	con += fmt.Sprintf(" - [%s / %11s / %7.1fms] - [%s]", flag, operaton, elsp, query)
 	cons := make([]string, 0, len(args))
 	for _, arg := range args {
 		cons = append(cons, fmt.Sprintf("%v", arg))
@@ -60,6 +96,7 @@ func debugLogQueies(alias *alias, operaton, query string, t time.Time, err error
 // if dev mode, use stmtQueryLog, or use stmtQuerier.
 type stmtQueryLog struct {
 	alias *alias
+	tag   string
 	query string
 	stmt  stmtQuerier
 }
@@ -69,35 +106,36 @@ var _ stmtQuerier = new(stmtQueryLog)
 func (d *stmtQueryLog) Close() error {
 	a := time.Now()
 	err := d.stmt.Close()
-	debugLogQueies(d.alias, "st.Close", d.query, a, err)
+	debugLogQueies(d.alias, d.tag, "st.Close", d.query, a, err)
 	return err
 }
 
 func (d *stmtQueryLog) Exec(args ...interface{}) (sql.Result, error) {
 	a := time.Now()
 	res, err := d.stmt.Exec(args...)
-	debugLogQueies(d.alias, "st.Exec", d.query, a, err, args...)
+	debugLogQueies(d.alias, d.tag, "st.Exec", d.query, a, err, args...)
 	return res, err
 }
 
 func (d *stmtQueryLog) Query(args ...interface{}) (*sql.Rows, error) {
 	a := time.Now()
 	res, err := d.stmt.Query(args...)
-	debugLogQueies(d.alias, "st.Query", d.query, a, err, args...)
+	debugLogQueies(d.alias, d.tag, "st.Query", d.query, a, err, args...)
 	return res, err
 }
 
 func (d *stmtQueryLog) QueryRow(args ...interface{}) *sql.Row {
 	a := time.Now()
 	res := d.stmt.QueryRow(args...)
-	debugLogQueies(d.alias, "st.QueryRow", d.query, a, nil, args...)
+	debugLogQueies(d.alias, d.tag, "st.QueryRow", d.query, a, nil, args...)
 	return res
 }
 
-func newStmtQueryLog(alias *alias, stmt stmtQuerier, query string) stmtQuerier {
+func newStmtQueryLog(alias *alias, tag string, stmt stmtQuerier, query string) stmtQuerier {
 	d := new(stmtQueryLog)
 	d.stmt = stmt
 	d.alias = alias
+	d.tag = tag
 	d.query = query
 	return d
 }
@@ -106,6 +144,7 @@ func newStmtQueryLog(alias *alias, stmt stmtQuerier, query string) stmtQuerier {
 // if dev mode, use dbQueryLog, or use dbQuerier.
 type dbQueryLog struct {
 	alias *alias
+	tag   string
 	db    dbQuerier
 	tx    txer
 	txe   txEnder
@@ -118,49 +157,49 @@ var _ txEnder = new(dbQueryLog)
 func (d *dbQueryLog) Prepare(query string) (*sql.Stmt, error) {
 	a := time.Now()
 	stmt, err := d.db.Prepare(query)
-	debugLogQueies(d.alias, "db.Prepare", query, a, err)
+	debugLogQueies(d.alias, d.tag, "db.Prepare", query, a, err)
 	return stmt, err
 }
 
 func (d *dbQueryLog) Exec(query string, args ...interface{}) (sql.Result, error) {
 	a := time.Now()
 	res, err := d.db.Exec(query, args...)
-	debugLogQueies(d.alias, "db.Exec", query, a, err, args...)
+	debugLogQueies(d.alias, d.tag, "db.Exec", query, a, err, args...)
 	return res, err
 }
 
 func (d *dbQueryLog) Query(query string, args ...interface{}) (*sql.Rows, error) {
 	a := time.Now()
 	res, err := d.db.Query(query, args...)
-	debugLogQueies(d.alias, "db.Query", query, a, err, args...)
+	debugLogQueies(d.alias, d.tag, "db.Query", query, a, err, args...)
 	return res, err
 }
 
 func (d *dbQueryLog) QueryRow(query string, args ...interface{}) *sql.Row {
 	a := time.Now()
 	res := d.db.QueryRow(query, args...)
-	debugLogQueies(d.alias, "db.QueryRow", query, a, nil, args...)
+	debugLogQueies(d.alias, d.tag, "db.QueryRow", query, a, nil, args...)
 	return res
 }
 
 func (d *dbQueryLog) Begin() (*sql.Tx, error) {
 	a := time.Now()
 	tx, err := d.db.(txer).Begin()
-	debugLogQueies(d.alias, "db.Begin", "START TRANSACTION", a, err)
+	debugLogQueies(d.alias, d.tag, "db.Begin", "START TRANSACTION", a, err)
 	return tx, err
 }
 
 func (d *dbQueryLog) Commit() error {
 	a := time.Now()
 	err := d.db.(txEnder).Commit()
-	debugLogQueies(d.alias, "tx.Commit", "COMMIT", a, err)
+	debugLogQueies(d.alias, d.tag, "tx.Commit", "COMMIT", a, err)
 	return err
 }
 
 func (d *dbQueryLog) Rollback() error {
 	a := time.Now()
 	err := d.db.(txEnder).Rollback()
-	debugLogQueies(d.alias, "tx.Rollback", "ROLLBACK", a, err)
+	debugLogQueies(d.alias, d.tag, "tx.Rollback", "ROLLBACK", a, err)
 	return err
 }
 
@@ -168,9 +207,10 @@ func (d *dbQueryLog) SetDB(db dbQuerier) {
 	d.db = db
 }
 
-func newDbQueryLog(alias *alias, db dbQuerier) dbQuerier {
+func newDbQueryLog(alias *alias, tag string, db dbQuerier) dbQuerier {
 	d := new(dbQueryLog)
 	d.alias = alias
+	d.tag = tag
 	d.db = db
 	return d
 }
@@ -0,0 +1,84 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// fakeQuerier is a dbQuerier which runs nothing.
+type fakeQuerier struct{}
+
+func (fakeQuerier) Prepare(query string) (*sql.Stmt, error)                    { return nil, nil }
+func (fakeQuerier) Exec(query string, args ...interface{}) (sql.Result, error) { return nil, nil }
+func (fakeQuerier) Query(query string, args ...interface{}) (*sql.Rows, error) { return nil, nil }
+func (fakeQuerier) QueryRow(query string, args ...interface{}) *sql.Row        { return nil }
+
+func TestQueryTag(t *testing.T) {
+	tests := []struct {
+		tag  QueryTag
+		want string
+	}{
+		{QueryTag{}, ""},
+		{QueryTag{RequestID: "4f2a"}, "request:4f2a"},
+		{QueryTag{Route: "/user/:id"}, "route:/user/:id"},
+		{QueryTag{RequestID: "4f2a", Route: "/user/:id"}, "request:4f2a route:/user/:id"},
+	}
+	for _, tt := range tests {
+		if got := tt.tag.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+
+	ctx := WithQueryTag(context.Background(), QueryTag{RequestID: "4f2a"})
+	if tag, ok := QueryTagFromContext(ctx); !ok || tag.RequestID != "4f2a" {
+		t.Errorf("QueryTagFromContext = %#v %v", tag, ok)
+	}
+	if _, ok := QueryTagFromContext(context.Background()); ok {
+		t.Error("QueryTagFromContext should find no tag in an empty context")
+	}
+}
+
+func TestWithContextQueryLog(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(l *Log) { DebugLog = l }(DebugLog)
+	DebugLog = NewLog(&buf)
+
+	al := &alias{Name: "default"}
+	var o Ormer = &orm{alias: al, db: newDbQueryLog(al, "", fakeQuerier{})}
+	ctx := WithQueryTag(context.Background(), QueryTag{RequestID: "4f2a", Route: "/user/:id"})
+	tagged := o.(ContextOrmer).WithContext(ctx).(*orm)
+
+	tagged.db.Exec("SELECT 1")
+	if !strings.Contains(buf.String(), "[Queries/default] - [request:4f2a route:/user/:id] - [  OK /     db.Exec") {
+		t.Errorf("the query log should be tagged: %s", buf.String())
+	}
+
+	buf.Reset()
+	o.(*orm).db.Exec("SELECT 1")
+	if strings.Contains(buf.String(), "request:") {
+		t.Errorf("the original Ormer shouldn't be tagged: %s", buf.String())
+	}
+
+	// an Ormer without the debug log keeps its querier
+	plain := &orm{alias: al, db: fakeQuerier{}}
+	if n := plain.WithContext(ctx).(*orm); n.tag != "request:4f2a route:/user/:id" || n.db != plain.db {
+		t.Errorf("WithContext of the plain Ormer = %#v", n)
+	}
+}
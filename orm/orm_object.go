@@ -79,7 +79,7 @@ func newInsertSet(orm *orm, mi *modelInfo) (Inserter, error) {
 		return nil, err
 	}
 	if Debug {
-		bi.stmt = newStmtQueryLog(orm.alias, st, query)
+		bi.stmt = newStmtQueryLog(orm.alias, orm.tag, st, query)
 	} else {
 		bi.stmt = st
 	}
@@ -32,7 +32,8 @@ func (o *rawPrepare) Exec(args ...interface{}) (sql.Result, error) {
 	if o.closed {
 		return nil, ErrStmtClosed
 	}
-	return o.stmt.Exec(args...)
+	res, err := o.stmt.Exec(args...)
+	return res, o.rs.orm.alias.DbBaser.TranslateError(nil, err)
 }
 
 func (o *rawPrepare) Close() error {
@@ -52,7 +53,7 @@ func newRawPreparer(rs *rawSet) (RawPreparer, error) {
 		return nil, err
 	}
 	if Debug {
-		o.stmt = newStmtQueryLog(rs.orm.alias, st, query)
+		o.stmt = newStmtQueryLog(rs.orm.alias, rs.orm.tag, st, query)
 	} else {
 		o.stmt = st
 	}
@@ -80,7 +81,8 @@ func (o *rawSet) Exec() (sql.Result, error) {
 	o.orm.alias.DbBaser.ReplaceMarks(&query)
 
 	args := getFlatParams(nil, o.args, o.orm.alias.TZ)
-	return o.orm.db.Exec(query, args...)
+	res, err := o.orm.db.Exec(query, args...)
+	return res, o.orm.alias.DbBaser.TranslateError(nil, err)
 }
 
 // set field value to row container
@@ -17,6 +17,7 @@ package orm
 import (
 	"bytes"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -1860,3 +1861,29 @@ func TestReadOrCreate(t *testing.T) {
 
 	dORM.Delete(u)
 }
+
+func TestConstraintErrors(t *testing.T) {
+	err := dORM.Read(&User{ID: 1 << 30})
+	throwFail(t, AssertIs(err, ErrNoRows))
+	throwFail(t, AssertIs(errors.Is(err, sql.ErrNoRows), true))
+
+	u := &User{UserName: "unique_user", Email: "unique@example.com"}
+	_, err = dORM.Insert(u)
+	throwFailNow(t, err)
+	defer dORM.Delete(u)
+
+	_, err = dORM.Insert(&User{UserName: "unique_user"})
+	throwFail(t, AssertIs(errors.Is(err, ErrDuplicateKey), true))
+	var ce *ConstraintError
+	throwFailNow(t, AssertIs(errors.As(err, &ce), true))
+	throwFail(t, AssertIs(ce.Table, "user"))
+	if len(ce.Columns) > 0 {
+		throwFail(t, AssertIs(ce.Columns[0], "user_name"))
+	}
+
+	_, err = dORM.Raw("INSERT INTO user_profile (id, age, money) VALUES (?, ?, ?)", 1<<30, 1, 1).Exec()
+	throwFail(t, err)
+	defer dORM.Raw("DELETE FROM user_profile WHERE id = ?", 1<<30).Exec()
+	_, err = dORM.Raw("INSERT INTO user_profile (id, age, money) VALUES (?, ?, ?)", 1<<30, 1, 1).Exec()
+	throwFail(t, AssertIs(errors.Is(err, ErrDuplicateKey), true))
+}
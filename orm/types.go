@@ -15,6 +15,7 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"reflect"
 	"time"
@@ -53,6 +54,16 @@ type Ormer interface {
 	Driver() Driver
 }
 
+// ContextOrmer is an Ormer which tags its debug query logs with the QueryTag of a context,
+// the Ormer of NewOrm implements it.
+// usage:
+//
+//	o := orm.NewOrm().(orm.ContextOrmer).WithContext(c.Ctx.Context())
+type ContextOrmer interface {
+	Ormer
+	WithContext(context.Context) Ormer
+}
+
 // Inserter insert prepared statement
 type Inserter interface {
 	Insert(interface{}) (int64, error)
@@ -180,5 +191,6 @@ type dbBaser interface {
 	ShowTablesQuery() string
 	ShowColumnsQuery(string) string
 	IndexExists(dbQuerier, string, string) bool
+	TranslateError(*modelInfo, error) error
 	collectFieldValue(*modelInfo, *fieldInfo, reflect.Value, bool, *time.Location) (interface{}, error)
 }
@@ -0,0 +1,104 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// paramType is the type of a typed path parameter like ":id:int".
+type paramType struct {
+	expr     string            // the regexp of the segment, it has one capturing group
+	validate func(string) bool // checks the matched value, nil means the regexp is enough
+}
+
+var paramTypes = map[string]*paramType{
+	"int":    {`([0-9]+)`, validIntParam},
+	"string": {`([\w]+)`, nil},
+	"uuid":   {`([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})`, nil},
+	// 2006-01-02 or RFC3339 like 2006-01-02T15:04:05Z07:00
+	"datetime": {`([0-9]{4}-[0-9]{2}-[0-9]{2}[0-9T:.Z+-]*)`, validDatetimeParam},
+}
+
+// AddParamType registers a type of the path parameters used as ":name:typ" in the patterns.
+// expr is the regexp of the value in a single capturing group, validate checks the matched value and may be nil,
+// a request whose typed parameters aren't valid doesn't match the router.
+// It must be called before the routers using the type are added.
+// usage:
+//
+//	beego.AddParamType("hex", `([0-9a-f]+)`, nil)
+//	beego.Router("/commit/:sha:hex", &CommitController{})
+func AddParamType(typ, expr string, validate func(string) bool) {
+	if _, err := regexp.Compile(expr); err != nil {
+		panic("beego: invalid regexp of the param type " + typ + ": " + err.Error())
+	}
+	paramTypes[typ] = &paramType{expr, validate}
+}
+
+func validIntParam(v string) bool {
+	_, err := strconv.ParseInt(v, 10, 64)
+	return err == nil
+}
+
+func validDatetimeParam(v string) bool {
+	if _, err := time.Parse("2006-01-02", v); err == nil {
+		return true
+	}
+	_, err := time.Parse(time.RFC3339, v)
+	return err == nil
+}
+
+// paramTypePrefix returns the longest type name in paramTypes which s starts with.
+func paramTypePrefix(s string) string {
+	typ := ""
+	for name := range paramTypes {
+		if len(name) > len(typ) && strings.HasPrefix(s, name) {
+			typ = name
+		}
+	}
+	return typ
+}
+
+var typedParamRegexp = regexp.MustCompile(`:(\w+):(\w+)`)
+
+// typedParams returns the types of the typed parameters of pattern which have a validate function.
+func typedParams(pattern string) map[string]*paramType {
+	var types map[string]*paramType
+	for _, m := range typedParamRegexp.FindAllStringSubmatch(pattern, -1) {
+		if t := paramTypes[paramTypePrefix(m[2])]; t != nil && t.validate != nil {
+			if types == nil {
+				types = make(map[string]*paramType)
+			}
+			types[":"+m[1]] = t
+		}
+	}
+	return types
+}
+
+// validParams checks the typed parameters matched by the router.
+func (c *controllerInfo) validParams(params *treeParams) bool {
+	if len(c.paramTypes) == 0 {
+		return true
+	}
+	for i, key := range params.keys {
+		if t, ok := c.paramTypes[key]; ok && params.values[i] != "" && !t.validate(params.values[i]) {
+			return false
+		}
+	}
+	return true
+}
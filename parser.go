@@ -25,6 +25,7 @@ import (
 	"os"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/astaxie/beego/utils"
@@ -93,6 +94,16 @@ func parserPkg(pkgRealpath, pkgpath string) error {
 
 func parserComments(comments *ast.CommentGroup, funcName, controllerName, pkgpath string) error {
 	if comments != nil && comments.List != nil {
+		// @filter name1 name2 applies the named filters to every @router of the method
+		var filters, summary []string
+		for _, c := range comments.List {
+			t := strings.TrimSpace(strings.TrimLeft(c.Text, "//"))
+			if strings.HasPrefix(t, "@filter") {
+				filters = append(filters, strings.Fields(strings.TrimPrefix(t, "@filter"))...)
+			} else if t != "" && !strings.HasPrefix(t, "@") {
+				summary = append(summary, t)
+			}
+		}
 		for _, c := range comments.List {
 			t := strings.TrimSpace(strings.TrimLeft(c.Text, "//"))
 			if strings.HasPrefix(t, "@router") {
@@ -122,6 +133,8 @@ func parserComments(comments *ast.CommentGroup, funcName, controllerName, pkgpat
 						cc.Params = append(cc.Params, map[string]string{strings.Join(kk[:len(kk)-1], ":"): kk[len(kk)-1]})
 					}
 				}
+				cc.Filters = filters
+				cc.Summary = strings.Join(summary, " ")
 				genInfoList[key] = append(genInfoList[key], cc)
 			}
 		}
@@ -161,13 +174,19 @@ func genRouterCode() {
 				}
 				params = strings.TrimRight(params, ",") + "}"
 			}
+			filters := "nil"
+			if len(c.Filters) > 0 {
+				filters = `[]string{"` + strings.Join(c.Filters, `","`) + `"}`
+			}
 			globalinfo = globalinfo + `
 	beego.GlobalControllerRouter["` + k + `"] = append(beego.GlobalControllerRouter["` + k + `"],
 		beego.ControllerComments{
-			"` + strings.TrimSpace(c.Method) + `",
-			` + "`" + c.Router + "`" + `,
-			` + allmethod + `,
-			` + params + `})
+			Method:           "` + strings.TrimSpace(c.Method) + `",
+			Router:           ` + "`" + c.Router + "`" + `,
+			AllowHTTPMethods: ` + allmethod + `,
+			Params:           ` + params + `,
+			Filters:          ` + filters + `,
+			Summary:          ` + strconv.Quote(c.Summary) + `})
 `
 		}
 	}
@@ -58,9 +58,12 @@ func Basic(username string, password string) beego.FilterFunc {
 func NewBasicAuthenticator(secrets SecretProvider, Realm string) beego.FilterFunc {
 	return func(ctx *context.Context) {
 		a := &BasicAuth{Secrets: secrets, Realm: Realm}
-		if username := a.CheckAuth(ctx.Request); username == "" {
+		username := a.CheckAuth(ctx.Request)
+		if username == "" {
 			a.RequireAuth(ctx.ResponseWriter, ctx.Request)
+			return
 		}
+		ctx.SetPrincipal(context.NewPrincipal(username))
 	}
 }
 
@@ -0,0 +1,55 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package autotls provides the Let's Encrypt certificates for beego.EnableAutoTLS.
+//
+// depend on golang.org/x/crypto/acme/autocert
+//
+// go install golang.org/x/crypto/acme/autocert
+//
+// Usage:
+//	import (
+//		"github.com/astaxie/beego"
+//		_ "github.com/astaxie/beego/plugins/autotls"
+//	)
+//
+// and in app.conf:
+//
+//	EnableAutoTLS = true
+//	AutoTLSHosts = example.com;www.example.com
+//	AutoTLSCacheDir = /var/lib/myapp/certs
+//	HTTPPort = 80
+//	HTTPSPort = 443
+//
+// the HTTP port must be reachable for the HTTP-01 challenges.
+package autotls
+
+import (
+	"github.com/astaxie/beego"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func newManager(hosts []string, cacheDir, email string) (beego.AutoTLSManager, error) {
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(hosts...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      email,
+	}, nil
+}
+
+func init() {
+	beego.NewAutoTLSManager = newManager
+}
@@ -0,0 +1,222 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lambda runs beego application as an AWS Lambda function behind API Gateway,
+// the events are translated to http.Request and the responses back to the events.
+//
+// depend on github.com/aws/aws-lambda-go
+//
+// go get github.com/aws/aws-lambda-go/lambda
+//
+// Usage:
+//	import (
+//		"github.com/astaxie/beego"
+//		"github.com/astaxie/beego/plugins/lambda"
+//	)
+//
+//	func main() {
+//		beego.Router("/user/:id", &controllers.UserController{})
+//		// REST API (payload 1.0), use lambda.StartHTTPAPI for the HTTP API (payload 2.0)
+//		lambda.Start()
+//	}
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/astaxie/beego"
+	beecontext "github.com/astaxie/beego/context"
+
+	"github.com/aws/aws-lambda-go/events"
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+)
+
+// Start runs beego application as the Lambda handler of the API Gateway REST API events.
+func Start() error {
+	return beego.RunWith(func(h http.Handler) error {
+		awslambda.Start(Handler(h))
+		return nil
+	})
+}
+
+// StartHTTPAPI runs beego application as the Lambda handler of the API Gateway HTTP API events.
+func StartHTTPAPI() error {
+	return beego.RunWith(func(h http.Handler) error {
+		awslambda.Start(HTTPAPIHandler(h))
+		return nil
+	})
+}
+
+// Handler translates the API Gateway REST API events (payload 1.0) for h.
+func Handler(h http.Handler) func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, e events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		query := url.Values(e.MultiValueQueryStringParameters)
+		if len(query) == 0 {
+			query = make(url.Values, len(e.QueryStringParameters))
+			for k, v := range e.QueryStringParameters {
+				query.Set(k, v)
+			}
+		}
+		header := http.Header{}
+		for k, vs := range e.MultiValueHeaders {
+			for _, v := range vs {
+				header.Add(k, v)
+			}
+		}
+		if len(e.MultiValueHeaders) == 0 {
+			for k, v := range e.Headers {
+				header.Set(k, v)
+			}
+		}
+		r, err := newRequest(ctx, e.HTTPMethod, e.Path, query.Encode(), header, e.Body, e.IsBase64Encoded)
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+		r.RemoteAddr = e.RequestContext.Identity.SourceIP
+		setRequestID(r, e.RequestContext.RequestID)
+
+		w := newResponseWriter()
+		h.ServeHTTP(w, r)
+		body, encoded := w.body()
+		return events.APIGatewayProxyResponse{
+			StatusCode:        w.status,
+			MultiValueHeaders: w.header,
+			Body:              body,
+			IsBase64Encoded:   encoded,
+		}, nil
+	}
+}
+
+// HTTPAPIHandler translates the API Gateway HTTP API events (payload 2.0) for h.
+func HTTPAPIHandler(h http.Handler) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, e events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		header := http.Header{}
+		for k, v := range e.Headers {
+			// the repeated headers are joined by commas
+			header.Set(k, v)
+		}
+		if len(e.Cookies) > 0 {
+			header.Set("Cookie", strings.Join(e.Cookies, "; "))
+		}
+		r, err := newRequest(ctx, e.RequestContext.HTTP.Method, e.RawPath, e.RawQueryString, header, e.Body, e.IsBase64Encoded)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{}, err
+		}
+		r.RemoteAddr = e.RequestContext.HTTP.SourceIP
+		setRequestID(r, e.RequestContext.RequestID)
+
+		w := newResponseWriter()
+		h.ServeHTTP(w, r)
+		body, encoded := w.body()
+		cookies := w.header["Set-Cookie"]
+		delete(w.header, "Set-Cookie")
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode:        w.status,
+			MultiValueHeaders: w.header,
+			Body:              body,
+			IsBase64Encoded:   encoded,
+			Cookies:           cookies,
+		}, nil
+	}
+}
+
+func newRequest(ctx context.Context, method, path, rawQuery string, header http.Header, body string, base64Encoded bool) (*http.Request, error) {
+	b := []byte(body)
+	if base64Encoded {
+		var err error
+		if b, err = base64.StdEncoding.DecodeString(body); err != nil {
+			return nil, err
+		}
+	}
+	u := &url.URL{Path: path, RawQuery: rawQuery}
+	r, err := http.NewRequest(method, u.RequestURI(), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	r = r.WithContext(ctx)
+	r.Header = header
+	r.Host = header.Get("Host")
+	r.ContentLength = int64(len(b))
+	if header.Get("X-Forwarded-Proto") == "https" {
+		r.URL.Scheme = "https"
+	}
+	return r, nil
+}
+
+// setRequestID passes the API Gateway request id as the trace id of the request, unless the client sent one.
+func setRequestID(r *http.Request, id string) {
+	if id != "" && r.Header.Get(beecontext.RequestIDHeader) == "" {
+		r.Header.Set(beecontext.RequestIDHeader, id)
+	}
+}
+
+// responseWriter buffers the response of the handler for the event.
+type responseWriter struct {
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{header: http.Header{}, status: http.StatusOK}
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.buf.Write(p)
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+}
+
+// body returns the response body, base64 encoded if it isn't text.
+func (w *responseWriter) body() (string, bool) {
+	w.header.Set("Content-Length", strconv.Itoa(w.buf.Len()))
+	b := w.buf.Bytes()
+	if w.header.Get("Content-Type") == "" && len(b) > 0 {
+		w.header.Set("Content-Type", http.DetectContentType(b))
+	}
+	if isText(w.header.Get("Content-Type")) && utf8.Valid(b) {
+		return string(b), false
+	}
+	return base64.StdEncoding.EncodeToString(b), true
+}
+
+func isText(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	return strings.HasPrefix(ct, "text/") ||
+		strings.Contains(ct, "json") ||
+		strings.Contains(ct, "xml") ||
+		strings.Contains(ct, "javascript") ||
+		strings.Contains(ct, "x-www-form-urlencoded")
+}
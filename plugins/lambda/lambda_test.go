@@ -0,0 +1,141 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lambda
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// echoHandler answers the request it got as headers, and the body back.
+func echoHandler(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Header().Set("X-Method", r.Method)
+	w.Header().Set("X-Uri", r.URL.RequestURI())
+	w.Header().Set("X-Host", r.Host)
+	w.Header().Set("X-Remote", r.RemoteAddr)
+	w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+	w.Header().Set("X-Cookie", r.Header.Get("Cookie"))
+	w.Header().Add("Set-Cookie", "a=1")
+	w.Header().Add("Set-Cookie", "b=2")
+	if r.URL.Query().Get("binary") != "" {
+		w.Header().Set("Content-Type", "image/png")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(body)
+}
+
+func TestHandler(t *testing.T) {
+	h := Handler(http.HandlerFunc(echoHandler))
+	resp, err := h(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:                      "POST",
+		Path:                            "/user/1",
+		MultiValueQueryStringParameters: map[string][]string{"tag": {"a", "b"}},
+		MultiValueHeaders:               map[string][]string{"Host": {"api.example.com"}},
+		Body:                            base64.StdEncoding.EncodeToString([]byte("name=astaxie")),
+		IsBase64Encoded:                 true,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID: "4f2a",
+			Identity:  events.APIGatewayRequestIdentity{SourceIP: "10.0.0.1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"X-Method":     "POST",
+		"X-Uri":        "/user/1?tag=a&tag=b",
+		"X-Host":       "api.example.com",
+		"X-Remote":     "10.0.0.1",
+		"X-Request-Id": "4f2a",
+	}
+	for k, v := range want {
+		if got := resp.MultiValueHeaders[k]; len(got) != 1 || got[0] != v {
+			t.Errorf("%s = %v, want %s", k, got, v)
+		}
+	}
+	if resp.StatusCode != http.StatusCreated || resp.Body != "name=astaxie" || resp.IsBase64Encoded {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if got := resp.MultiValueHeaders["Set-Cookie"]; !reflect.DeepEqual(got, []string{"a=1", "b=2"}) {
+		t.Errorf("Set-Cookie = %v", got)
+	}
+
+	// the single value fields are used without the multi value ones, the binary bodies are base64 encoded
+	resp, err = h(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:            "PUT",
+		Path:                  "/logo",
+		QueryStringParameters: map[string]string{"binary": "1"},
+		Headers:               map[string]string{"Host": "cdn.example.com"},
+		Body:                  "\x89PNG",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.MultiValueHeaders["X-Uri"]; len(got) != 1 || got[0] != "/logo?binary=1" {
+		t.Errorf("X-Uri = %v", got)
+	}
+	if got := resp.MultiValueHeaders["X-Host"]; len(got) != 1 || got[0] != "cdn.example.com" {
+		t.Errorf("X-Host = %v", got)
+	}
+	if !resp.IsBase64Encoded || resp.Body != base64.StdEncoding.EncodeToString([]byte("\x89PNG")) {
+		t.Errorf("the binary body should be base64 encoded: %+v", resp)
+	}
+}
+
+func TestHTTPAPIHandler(t *testing.T) {
+	h := HTTPAPIHandler(http.HandlerFunc(echoHandler))
+	resp, err := h(context.Background(), events.APIGatewayV2HTTPRequest{
+		RawPath:        "/user/1",
+		RawQueryString: "tag=a&tag=b",
+		Cookies:        []string{"sid=1", "lang=en"},
+		Headers:        map[string]string{"host": "api.example.com", "x-request-id": "client-id"},
+		Body:           "hello",
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RequestID: "4f2a",
+			HTTP:      events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "PATCH", SourceIP: "10.0.0.2"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"X-Method":     "PATCH",
+		"X-Uri":        "/user/1?tag=a&tag=b",
+		"X-Host":       "api.example.com",
+		"X-Remote":     "10.0.0.2",
+		"X-Request-Id": "client-id",
+		"X-Cookie":     "sid=1; lang=en",
+	}
+	for k, v := range want {
+		if got := resp.MultiValueHeaders[k]; len(got) != 1 || got[0] != v {
+			t.Errorf("%s = %v, want %s", k, got, v)
+		}
+	}
+	if resp.StatusCode != http.StatusCreated || resp.Body != "hello" || resp.IsBase64Encoded {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if !reflect.DeepEqual(resp.Cookies, []string{"a=1", "b=2"}) || resp.MultiValueHeaders["Set-Cookie"] != nil {
+		t.Errorf("the cookies should be moved to Cookies: %v %v", resp.Cookies, resp.MultiValueHeaders["Set-Cookie"])
+	}
+}
@@ -0,0 +1,562 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package modeladmin generates the admin pages to list, search, create, edit and delete the models.
+// The forms are built from the struct fields and their valid tags, the records are stored by a resource.Store,
+// the orm by default, so the same models can be served by the resource plugin.
+//
+// Usage:
+//
+//	import (
+//		"github.com/astaxie/beego"
+//		"github.com/astaxie/beego/plugins/auth"
+//		"github.com/astaxie/beego/plugins/modeladmin"
+//	)
+//
+//	func main() {
+//		site := modeladmin.New("/manage", modeladmin.Options{Auth: auth.Basic("admin", "secret")})
+//		site.Register(&models.User{}, modeladmin.ModelOptions{
+//			List:   []string{"Id", "Name", "Email"},
+//			Search: "name__icontains",
+//		})
+//		beego.Run()
+//	}
+//
+// The pages are themeable, the views modeladmin/index.tpl, modeladmin/list.tpl and modeladmin/form.tpl
+// replace the built-in templates, they're executed with a *Page.
+package modeladmin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/orm"
+	"github.com/astaxie/beego/plugins/resource"
+	"github.com/astaxie/beego/validation"
+)
+
+// Options holds the options of the admin site.
+type Options struct {
+	// Auth protects every page of the site, e.g. auth.Basic or a filter checking the session, it's required.
+	Auth beego.FilterFunc
+	// Title is the title of the pages, default is "Administration".
+	Title string
+	// Handlers is the router the pages are added to, default is beego.BeeApp.Handlers.
+	Handlers *beego.ControllerRegister
+}
+
+// ModelOptions holds the options of a model of the site.
+type ModelOptions struct {
+	// Name is the path segment of the model, default is the lower case type name, e.g. user.
+	Name string
+	// List are the fields shown in the list, default is the primary key and the fields of the form.
+	List []string
+	// Search is the orm filter expression the search box is applied to, e.g. name__icontains,
+	// there is no search box if it's empty.
+	Search string
+	// Order is the orm order of the list, e.g. -id.
+	Order []string
+	// PageSize is the records per page, default is 20.
+	PageSize int
+	// Store stores the records, default is resource.NewStore of the model.
+	Store resource.Store
+	// BeforeSave runs once a record is bound and validated, BeforeDelete runs before a record is deleted,
+	// they can stop the request by writing the response.
+	BeforeSave   resource.Hook
+	BeforeDelete resource.Hook
+}
+
+// Page is the data the templates are executed with.
+type Page struct {
+	Title  string
+	Prefix string // the url of the site
+	Models []Link
+	Model  string // the name of the current model
+	XSRF   string // the value of the _xsrf form field
+
+	// the list page
+	Columns          []string
+	Rows             []Row
+	Search           bool // whether the model has a search box
+	Query            string
+	Page, Pages      int
+	Total            int64
+	PrevURL, NextURL string
+
+	// the form page
+	ID     string // the primary key of the record, empty for a new record
+	Fields []Field
+	Error  string // the error of the whole form
+}
+
+// Link is a model in the navigation.
+type Link struct {
+	Name, URL string
+}
+
+// Row is a record in the list.
+type Row struct {
+	ID, URL string
+	Cells   []string
+}
+
+// Field is an input of the form.
+type Field struct {
+	Name     string // the form parameter
+	Label    string
+	Type     string // checkbox, number, email, text, textarea or datetime
+	Value    string
+	Checked  bool
+	Required bool
+	Attrs    template.HTMLAttr // the constraints of the valid tags, e.g. maxlength="30"
+	Error    string
+}
+
+// Site is the admin pages under a prefix.
+type Site struct {
+	prefix   string
+	opts     Options
+	handlers *beego.ControllerRegister
+	models   []*model
+}
+
+// New adds the index page of the admin site under prefix, every page of the site runs opts.Auth first.
+func New(prefix string, opts Options) *Site {
+	if opts.Auth == nil {
+		panic("modeladmin: the Auth filter is required")
+	}
+	if opts.Title == "" {
+		opts.Title = "Administration"
+	}
+	s := &Site{prefix: strings.TrimRight(prefix, "/"), opts: opts, handlers: opts.Handlers}
+	if s.handlers == nil {
+		s.handlers = beego.BeeApp.Handlers
+	}
+	s.handlers.InsertFilter(s.prefix, beego.BeforeRouter, opts.Auth)
+	s.handlers.InsertFilter(s.prefix+"/*", beego.BeforeRouter, opts.Auth)
+	s.handlers.Get(s.prefix, s.index)
+	return s
+}
+
+// Register adds the pages of the model, md is a pointer to the model struct.
+func (s *Site) Register(md interface{}, opts ModelOptions) {
+	typ := reflect.TypeOf(md)
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		panic("modeladmin: the model must be a struct pointer")
+	}
+	typ = typ.Elem()
+	m := &model{site: s, typ: typ, pk: resource.PK(typ), opts: opts, store: opts.Store}
+	if m.pk < 0 {
+		panic("modeladmin: can't find the primary key of " + typ.String())
+	}
+	m.name = opts.Name
+	if m.name == "" {
+		m.name = strings.ToLower(typ.Name())
+	}
+	if m.opts.PageSize <= 0 {
+		m.opts.PageSize = 20
+	}
+	if m.store == nil {
+		m.store = resource.NewStore(md, nil)
+	}
+	m.fields = formFields(typ, m.pk)
+	if len(opts.List) == 0 {
+		m.list = append(m.list, m.pk)
+		for _, f := range m.fields {
+			m.list = append(m.list, f.index)
+		}
+	}
+	for _, name := range opts.List {
+		f, ok := typ.FieldByName(name)
+		if !ok || len(f.Index) != 1 {
+			panic("modeladmin: " + typ.String() + " has no field " + name)
+		}
+		m.list = append(m.list, f.Index[0])
+	}
+	s.models = append(s.models, m)
+
+	base := m.url("")
+	s.handlers.Get(base, m.listPage)
+	s.handlers.Get(base+"/new", m.formPage)
+	s.handlers.Post(base+"/new", m.save)
+	s.handlers.Get(base+"/:id", m.formPage)
+	s.handlers.Post(base+"/:id", m.save)
+	s.handlers.Post(base+"/:id/delete", m.delete)
+}
+
+func (s *Site) index(ctx *context.Context) {
+	s.render(ctx, "modeladmin/index.tpl", http.StatusOK, &Page{})
+}
+
+// render executes the view of name if it's in beego.BeeTemplates, or else the built-in template.
+func (s *Site) render(ctx *context.Context, name string, status int, p *Page) {
+	p.Title, p.Prefix = s.opts.Title, s.prefix
+	p.XSRF = ctx.XSRFToken(beego.XSRFKEY, int64(beego.XSRFExpire))
+	for _, m := range s.models {
+		p.Models = append(p.Models, Link{Name: m.name, URL: m.url("")})
+	}
+	t, ok := beego.BeeTemplates[name]
+	if !ok {
+		t = defaultTemplates
+	}
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, name, p); err != nil {
+		beego.Error("modeladmin:", err)
+		ctx.Abort(500, "500")
+	}
+	ctx.Output.Header("Content-Type", "text/html; charset=utf-8")
+	if status != http.StatusOK {
+		ctx.Output.SetStatus(status)
+	}
+	ctx.Output.Body(buf.Bytes())
+}
+
+// checkXSRF aborts the writing request with 403 without the _xsrf token of the form.
+func (s *Site) checkXSRF(ctx *context.Context) {
+	ctx.XSRFToken(beego.XSRFKEY, int64(beego.XSRFExpire))
+	ctx.CheckXSRFCookie()
+}
+
+type model struct {
+	site   *Site
+	name   string
+	typ    reflect.Type
+	pk     int
+	fields []field
+	list   []int
+	opts   ModelOptions
+	store  resource.Store
+}
+
+type field struct {
+	index    int
+	name     string // the struct field
+	param    string // the form parameter
+	typ      string
+	required bool
+	attrs    string
+}
+
+// url returns the url of the list, or of the record if id isn't empty.
+func (m *model) url(id string) string {
+	u := m.site.prefix + "/" + m.name
+	if id != "" {
+		u += "/" + url.PathEscape(id)
+	}
+	return u
+}
+
+func (m *model) listPage(ctx *context.Context) {
+	q := &resource.Query{Page: 1, PageSize: m.opts.PageSize, Filters: make(map[string]string), Order: m.opts.Order}
+	if page, err := strconv.Atoi(ctx.Input.Query("page")); err == nil && page > 0 {
+		q.Page = page
+	}
+	query := strings.TrimSpace(ctx.Input.Query("q"))
+	if query != "" && m.opts.Search != "" {
+		q.Filters[m.opts.Search] = query
+	}
+	container := reflect.New(reflect.SliceOf(reflect.PtrTo(m.typ)))
+	total, err := m.store.List(ctx, q, container.Interface())
+	if err != nil {
+		m.fail(ctx, err)
+		return
+	}
+
+	p := &Page{
+		Model:  m.name,
+		Search: m.opts.Search != "",
+		Query:  query,
+		Page:   q.Page,
+		Pages:  int((total + int64(q.PageSize) - 1) / int64(q.PageSize)),
+		Total:  total,
+	}
+	pageURL := func(page int) string {
+		v := url.Values{"page": {strconv.Itoa(page)}}
+		if query != "" {
+			v.Set("q", query)
+		}
+		return m.url("") + "?" + v.Encode()
+	}
+	if p.Page > 1 {
+		p.PrevURL = pageURL(p.Page - 1)
+	}
+	if p.Page < p.Pages {
+		p.NextURL = pageURL(p.Page + 1)
+	}
+	for _, i := range m.list {
+		p.Columns = append(p.Columns, m.typ.Field(i).Name)
+	}
+	items := container.Elem()
+	for i := 0; i < items.Len(); i++ {
+		v := items.Index(i).Elem()
+		id := formatValue(v.Field(m.pk))
+		row := Row{ID: id, URL: m.url(id)}
+		for _, j := range m.list {
+			row.Cells = append(row.Cells, formatValue(v.Field(j)))
+		}
+		p.Rows = append(p.Rows, row)
+	}
+	m.site.render(ctx, "modeladmin/list.tpl", http.StatusOK, p)
+}
+
+// load returns a new record, or the record of the :id parameter, it answers 404 if it's not found.
+func (m *model) load(ctx *context.Context) (reflect.Value, bool) {
+	md := reflect.New(m.typ)
+	id := ctx.Input.Param(":id")
+	if id == "" {
+		return md, true
+	}
+	if err := resource.SetPK(md.Elem().Field(m.pk), id); err != nil {
+		ctx.Abort(404, "404")
+	}
+	if err := m.store.Read(ctx, md.Interface()); err != nil {
+		m.fail(ctx, err)
+		return md, false
+	}
+	return md, true
+}
+
+func (m *model) formPage(ctx *context.Context) {
+	if md, ok := m.load(ctx); ok {
+		m.renderForm(ctx, md, http.StatusOK, nil, "")
+	}
+}
+
+func (m *model) renderForm(ctx *context.Context, md reflect.Value, status int, errs map[string]string, msg string) {
+	p := &Page{Model: m.name, ID: ctx.Input.Param(":id"), Error: msg}
+	v := md.Elem()
+	for _, f := range m.fields {
+		ff := Field{
+			Name:     f.param,
+			Label:    f.name,
+			Type:     f.typ,
+			Required: f.required,
+			Attrs:    template.HTMLAttr(f.attrs),
+			Error:    errs[f.name],
+		}
+		if f.typ == "checkbox" {
+			ff.Checked = v.Field(f.index).Bool()
+		} else {
+			ff.Value = formatValue(v.Field(f.index))
+		}
+		p.Fields = append(p.Fields, ff)
+	}
+	m.site.render(ctx, "modeladmin/form.tpl", status, p)
+}
+
+func (m *model) save(ctx *context.Context) {
+	m.site.checkXSRF(ctx)
+	md, ok := m.load(ctx)
+	if !ok {
+		return
+	}
+	pk := reflect.ValueOf(md.Elem().Field(m.pk).Interface())
+	form := ctx.Request.Form
+	if form == nil {
+		ctx.Request.ParseForm()
+		form = ctx.Request.Form
+	}
+	for _, f := range m.fields {
+		switch {
+		case f.typ == "checkbox" && form.Get(f.param) == "":
+			// the unchecked boxes aren't sent
+			md.Elem().Field(f.index).SetBool(false)
+		case f.typ == "datetime" && form.Get(f.param) == "":
+			md.Elem().Field(f.index).Set(reflect.Zero(md.Elem().Field(f.index).Type()))
+			form.Del(f.param)
+		}
+	}
+	if err := ctx.Input.BindRequest(md.Interface()); err != nil {
+		m.renderForm(ctx, md, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+	md.Elem().Field(m.pk).Set(pk)
+
+	valid := validation.Validation{}
+	passed, err := valid.Valid(md.Interface())
+	if err != nil {
+		m.fail(ctx, err)
+		return
+	}
+	if !passed {
+		errs := make(map[string]string, len(valid.Errors))
+		for _, e := range valid.Errors {
+			errs[e.Field] = e.Message
+		}
+		m.renderForm(ctx, md, http.StatusUnprocessableEntity, errs, "")
+		return
+	}
+	if m.opts.BeforeSave != nil {
+		if m.opts.BeforeSave(ctx, md.Interface()); ctx.Written() {
+			return
+		}
+	}
+	if ctx.Input.Param(":id") == "" {
+		err = m.store.Insert(ctx, md.Interface())
+	} else {
+		err = m.store.Update(ctx, md.Interface())
+	}
+	if errors.Is(err, orm.ErrDuplicateKey) || errors.Is(err, orm.ErrFKViolation) {
+		m.renderForm(ctx, md, http.StatusConflict, m.constraintErrors(err), err.Error())
+		return
+	}
+	if err != nil {
+		m.fail(ctx, err)
+		return
+	}
+	ctx.Redirect(302, m.url(""))
+}
+
+func (m *model) delete(ctx *context.Context) {
+	m.site.checkXSRF(ctx)
+	md, ok := m.load(ctx)
+	if !ok {
+		return
+	}
+	if m.opts.BeforeDelete != nil {
+		if m.opts.BeforeDelete(ctx, md.Interface()); ctx.Written() {
+			return
+		}
+	}
+	if err := m.store.Delete(ctx, md.Interface()); err != nil {
+		m.fail(ctx, err)
+		return
+	}
+	ctx.Redirect(302, m.url(""))
+}
+
+// constraintErrors returns the form errors of the columns of the violated constraint.
+func (m *model) constraintErrors(err error) map[string]string {
+	var ce *orm.ConstraintError
+	if !errors.As(err, &ce) {
+		return nil
+	}
+	errs := make(map[string]string)
+	for _, col := range ce.Columns {
+		col = strings.Replace(col, "_", "", -1)
+		for _, f := range m.fields {
+			if strings.EqualFold(f.name, col) {
+				errs[f.name] = ce.Kind.Error()
+			}
+		}
+	}
+	return errs
+}
+
+// fail answers the error of the store, the missing records are 404.
+func (m *model) fail(ctx *context.Context, err error) {
+	if errors.Is(err, orm.ErrNoRows) {
+		ctx.Abort(404, "404")
+	}
+	beego.Error("modeladmin:", m.name, err)
+	ctx.Abort(500, "500")
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	validTag = regexp.MustCompile(`^(\w+)(?:\((.*)\))?$`)
+)
+
+// formFields returns the inputs of the exported fields of the model but the primary key,
+// the fields which aren't a string, a number, a bool or a time are left out.
+func formFields(typ reflect.Type, pk int) []field {
+	var fields []field
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if i == pk || sf.PkgPath != "" || sf.Anonymous || sf.Tag.Get("orm") == "-" {
+			continue
+		}
+		f := field{index: i, name: sf.Name, param: sf.Name}
+		if name := strings.Split(sf.Tag.Get("form"), ",")[0]; name == "-" {
+			continue
+		} else if name != "" {
+			f.param = name
+		}
+		switch sf.Type.Kind() {
+		case reflect.Bool:
+			f.typ = "checkbox"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f.typ = "number"
+		case reflect.Float32, reflect.Float64:
+			f.typ = "number"
+			f.attrs = ` step="any"`
+		case reflect.String:
+			f.typ = "text"
+			if strings.Contains(sf.Tag.Get("orm"), "type(text)") {
+				f.typ = "textarea"
+			}
+		case reflect.Struct:
+			if sf.Type == timeType {
+				f.typ = "datetime"
+			}
+		}
+		if f.typ == "" {
+			continue
+		}
+		for _, tag := range strings.Split(sf.Tag.Get("valid"), ";") {
+			match := validTag.FindStringSubmatch(strings.TrimSpace(tag))
+			if match == nil {
+				continue
+			}
+			var args []int
+			for _, arg := range strings.Split(match[2], ",") {
+				if n, err := strconv.Atoi(strings.TrimSpace(arg)); err == nil {
+					args = append(args, n)
+				}
+			}
+			switch {
+			case match[1] == "Required":
+				f.required = true
+			case match[1] == "Email" && f.typ == "text":
+				f.typ = "email"
+			case match[1] == "MaxSize" && len(args) == 1:
+				f.attrs += fmt.Sprintf(` maxlength="%d"`, args[0])
+			case match[1] == "MinSize" && len(args) == 1:
+				f.attrs += fmt.Sprintf(` minlength="%d"`, args[0])
+			case match[1] == "Min" && len(args) == 1:
+				f.attrs += fmt.Sprintf(` min="%d"`, args[0])
+			case match[1] == "Max" && len(args) == 1:
+				f.attrs += fmt.Sprintf(` max="%d"`, args[0])
+			case match[1] == "Range" && len(args) == 2:
+				f.attrs += fmt.Sprintf(` min="%d" max="%d"`, args[0], args[1])
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// formatValue formats the field for the list and the form, the times are in RFC 3339 as BindRequest parses them.
+func formatValue(v reflect.Value) string {
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,172 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modeladmin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/orm"
+	"github.com/astaxie/beego/plugins/resource"
+)
+
+type item struct {
+	ID     int
+	Name   string `valid:"Required;MaxSize(10)"`
+	Price  float64
+	Active bool
+}
+
+type memStore struct {
+	items  map[int]*item
+	nextID int
+}
+
+func (s *memStore) List(ctx *context.Context, q *resource.Query, container interface{}) (int64, error) {
+	list := container.(*[]*item)
+	for id := 1; id <= s.nextID; id++ {
+		if it, ok := s.items[id]; ok && strings.Contains(it.Name, q.Filters["name__icontains"]) {
+			*list = append(*list, it)
+		}
+	}
+	return int64(len(*list)), nil
+}
+
+func (s *memStore) Read(ctx *context.Context, md interface{}) error {
+	it, ok := s.items[md.(*item).ID]
+	if !ok {
+		return orm.ErrNoRows
+	}
+	*md.(*item) = *it
+	return nil
+}
+
+func (s *memStore) Insert(ctx *context.Context, md interface{}) error {
+	s.nextID++
+	md.(*item).ID = s.nextID
+	return s.Update(ctx, md)
+}
+
+func (s *memStore) Update(ctx *context.Context, md interface{}) error {
+	it := *md.(*item)
+	s.items[it.ID] = &it
+	return nil
+}
+
+func (s *memStore) Delete(ctx *context.Context, md interface{}) error {
+	delete(s.items, md.(*item).ID)
+	return nil
+}
+
+var xsrfField = regexp.MustCompile(`name="_xsrf" value="([^"]+)"`)
+
+func TestSite(t *testing.T) {
+	handlers := beego.NewControllerRegister()
+	store := &memStore{items: make(map[int]*item)}
+	site := New("/manage", Options{
+		Handlers: handlers,
+		Auth: func(ctx *context.Context) {
+			if ctx.Input.Header("X-Admin") != "yes" {
+				ctx.Output.SetStatus(401)
+				ctx.Output.Body([]byte("unauthorized"))
+			}
+		},
+	})
+	site.Register(&item{}, ModelOptions{Search: "name__icontains", Store: store})
+
+	var cookies []*http.Cookie
+	do := func(method, path string, form url.Values) *httptest.ResponseRecorder {
+		var r *http.Request
+		if form != nil {
+			r, _ = http.NewRequest(method, path, strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			r, _ = http.NewRequest(method, path, nil)
+		}
+		r.Header.Set("X-Admin", "yes")
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		rw := httptest.NewRecorder()
+		handlers.ServeHTTP(rw, r)
+		if c := (&http.Response{Header: rw.Header()}).Cookies(); len(c) > 0 {
+			cookies = c
+		}
+		return rw
+	}
+
+	r, _ := http.NewRequest("GET", "/manage/item", nil)
+	rw := httptest.NewRecorder()
+	handlers.ServeHTTP(rw, r)
+	if rw.Code != 401 {
+		t.Errorf("TestSite without auth got %d", rw.Code)
+	}
+
+	rw = do("GET", "/manage/item/new", nil)
+	body := rw.Body.String()
+	if rw.Code != 200 || !strings.Contains(body, `name="Name" value="" required maxlength="10"`) ||
+		!strings.Contains(body, `type="checkbox" id="Active"`) || !strings.Contains(body, `step="any"`) {
+		t.Fatalf("TestSite new form got %d %s", rw.Code, body)
+	}
+	m := xsrfField.FindStringSubmatch(body)
+	if m == nil {
+		t.Fatal("TestSite the form has no _xsrf")
+	}
+	xsrf := m[1]
+
+	if rw = do("POST", "/manage/item/new", url.Values{"Name": {"pen"}}); rw.Code != 403 {
+		t.Errorf("TestSite without _xsrf got %d", rw.Code)
+	}
+	if rw = do("POST", "/manage/item/new", url.Values{"_xsrf": {xsrf}, "Name": {"a very long name"}}); rw.Code != 422 || !strings.Contains(rw.Body.String(), `class="error"`) {
+		t.Errorf("TestSite invalid got %d %s", rw.Code, rw.Body.String())
+	}
+	for _, name := range []string{"pen", "pencil"} {
+		rw = do("POST", "/manage/item/new", url.Values{"_xsrf": {xsrf}, "Name": {name}, "Price": {"1.5"}, "Active": {"true"}})
+		if rw.Code != 302 || rw.Header().Get("Location") != "/manage/item" {
+			t.Errorf("TestSite create got %d %s", rw.Code, rw.Body.String())
+		}
+	}
+	if it := store.items[1]; it == nil || it.Name != "pen" || it.Price != 1.5 || !it.Active {
+		t.Errorf("TestSite created %+v", it)
+	}
+
+	body = do("GET", "/manage/item?q=cil", nil).Body.String()
+	if !strings.Contains(body, `<a href="/manage/item/2">pencil</a>`) || strings.Contains(body, `>pen<`) {
+		t.Errorf("TestSite search got %s", body)
+	}
+
+	if rw = do("POST", "/manage/item/1", url.Values{"_xsrf": {xsrf}, "Name": {"marker"}}); rw.Code != 302 {
+		t.Errorf("TestSite update got %d %s", rw.Code, rw.Body.String())
+	}
+	if it := store.items[1]; it.Name != "marker" || it.Price != 1.5 || it.Active {
+		t.Errorf("TestSite updated %+v", it)
+	}
+
+	if rw = do("POST", "/manage/item/1/delete", url.Values{"_xsrf": {xsrf}}); rw.Code != 302 || store.items[1] != nil {
+		t.Errorf("TestSite delete got %d", rw.Code)
+	}
+	if rw = do("GET", "/manage/item/1", nil); rw.Code != 404 {
+		t.Errorf("TestSite deleted got %d", rw.Code)
+	}
+	if body = do("GET", "/manage", nil).Body.String(); !strings.Contains(body, `<a href="/manage/item">item</a>`) {
+		t.Errorf("TestSite index got %s", body)
+	}
+}
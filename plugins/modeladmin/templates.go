@@ -0,0 +1,83 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modeladmin
+
+import "html/template"
+
+// defaultTemplates are the built-in pages, a view of the same name in beego.BeeTemplates replaces each of them.
+var defaultTemplates = template.Must(template.New("modeladmin").Parse(`
+{{define "modeladmin/header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{if .Model}}{{.Model}} - {{end}}{{.Title}}</title>
+<style>
+body{font-family:sans-serif;margin:0;color:#333}
+header{background:#2c3e50;color:#fff;padding:10px 20px}
+header a{color:#fff;text-decoration:none;margin-right:16px}
+main{padding:20px}
+table{border-collapse:collapse;width:100%}
+th,td{border-bottom:1px solid #ddd;padding:6px 8px;text-align:left}
+label{display:block;margin-top:12px;font-weight:bold}
+input[type=text],input[type=email],input[type=number],textarea{width:400px;padding:4px}
+.error{color:#c0392b}
+</style>
+</head>
+<body>
+<header><a href="{{.Prefix}}"><b>{{.Title}}</b></a>{{range .Models}}<a href="{{.URL}}">{{.Name}}</a>{{end}}</header>
+<main>
+{{end}}
+
+{{define "modeladmin/footer"}}</main>
+</body>
+</html>
+{{end}}
+
+{{define "modeladmin/index.tpl"}}{{template "modeladmin/header" .}}
+<ul>
+{{range .Models}}<li><a href="{{.URL}}">{{.Name}}</a></li>
+{{end}}</ul>
+{{template "modeladmin/footer" .}}{{end}}
+
+{{define "modeladmin/list.tpl"}}{{template "modeladmin/header" .}}
+<h2>{{.Model}} ({{.Total}})</h2>
+<p><a href="{{.Prefix}}/{{.Model}}/new">add {{.Model}}</a></p>
+{{if .Search}}<form method="get"><input type="text" name="q" value="{{.Query}}"> <button type="submit">search</button></form>{{end}}
+<table>
+<tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{$url := .URL}}{{range .Cells}}<td><a href="{{$url}}">{{.}}</a></td>{{end}}</tr>
+{{end}}</table>
+<p>{{if .PrevURL}}<a href="{{.PrevURL}}">previous</a> {{end}}page {{.Page}} of {{.Pages}}{{if .NextURL}} <a href="{{.NextURL}}">next</a>{{end}}</p>
+{{template "modeladmin/footer" .}}{{end}}
+
+{{define "modeladmin/form.tpl"}}{{template "modeladmin/header" .}}
+<h2>{{if .ID}}{{.Model}} {{.ID}}{{else}}new {{.Model}}{{end}}</h2>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post">
+<input type="hidden" name="_xsrf" value="{{.XSRF}}">
+{{range .Fields}}<label for="{{.Name}}">{{.Label}}</label>
+{{if eq .Type "checkbox"}}<input type="checkbox" id="{{.Name}}" name="{{.Name}}" value="true"{{if .Checked}} checked{{end}}>
+{{else if eq .Type "textarea"}}<textarea id="{{.Name}}" name="{{.Name}}" rows="6" cols="60"{{if .Required}} required{{end}}>{{.Value}}</textarea>
+{{else if eq .Type "datetime"}}<input type="text" id="{{.Name}}" name="{{.Name}}" value="{{.Value}}" placeholder="2006-01-02T15:04:05Z07:00"{{if .Required}} required{{end}}>
+{{else}}<input type="{{.Type}}" id="{{.Name}}" name="{{.Name}}" value="{{.Value}}"{{if .Required}} required{{end}}{{.Attrs}}>
+{{end}}{{if .Error}}<span class="error">{{.Error}}</span>{{end}}
+{{end}}<p><button type="submit">save</button> <a href="{{.Prefix}}/{{.Model}}">cancel</a></p>
+</form>
+{{if .ID}}<form method="post" action="{{.Prefix}}/{{.Model}}/{{.ID}}/delete">
+<input type="hidden" name="_xsrf" value="{{.XSRF}}">
+<button type="submit">delete</button>
+</form>{{end}}
+{{template "modeladmin/footer" .}}{{end}}
+`))
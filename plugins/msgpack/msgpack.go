@@ -0,0 +1,56 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package msgpack adds the MessagePack Encoder, ctx.Output.Serve and Controller.ServeFormat answer it
+// to the requests accepting application/msgpack, and ctx.Input.BindRequest decodes the msgpack bodies.
+//
+// depend on github.com/vmihailenco/msgpack/v5
+//
+// go get github.com/vmihailenco/msgpack/v5
+//
+// Usage:
+//	import (
+//		"github.com/astaxie/beego"
+//		_ "github.com/astaxie/beego/plugins/msgpack"
+//	)
+//
+//	func (c *UserController) Get() {
+//		c.ServeFormat(users)
+//	}
+package msgpack
+
+import (
+	"github.com/astaxie/beego/context"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MediaTypes are the media types of the encoder, the first one is the Content-Type of the responses.
+var MediaTypes = []string{"application/msgpack", "application/x-msgpack"}
+
+// Encoder is the msgpack context.Encoder.
+type Encoder struct{}
+
+// Marshal encodes v in msgpack.
+func (Encoder) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal decodes the msgpack data into v.
+func (Encoder) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+func init() {
+	context.RegisterEncoder(Encoder{}, MediaTypes...)
+}
@@ -0,0 +1,71 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package protobuf adds the Protocol Buffers Encoder, ctx.Output.Serve and Controller.ServeFormat answer it
+// to the requests accepting application/x-protobuf, and ctx.Input.BindRequest decodes the protobuf bodies.
+// The values must be proto.Message, the generated message types.
+//
+// depend on google.golang.org/protobuf
+//
+// go get google.golang.org/protobuf
+//
+// Usage:
+//	import (
+//		"github.com/astaxie/beego"
+//		_ "github.com/astaxie/beego/plugins/protobuf"
+//	)
+//
+//	func (c *UserController) Post() {
+//		req := &pb.CreateUserRequest{}
+//		if err := c.ParseBody(req); err != nil {
+//			c.CustomAbort(400, err.Error())
+//		}
+//		c.ServeFormat(&pb.User{Name: req.Name})
+//	}
+package protobuf
+
+import (
+	"fmt"
+
+	"github.com/astaxie/beego/context"
+	"google.golang.org/protobuf/proto"
+)
+
+// MediaTypes are the media types of the encoder, the first one is the Content-Type of the responses.
+var MediaTypes = []string{"application/x-protobuf", "application/protobuf", "application/vnd.google.protobuf"}
+
+// Encoder is the protobuf context.Encoder.
+type Encoder struct{}
+
+// Marshal encodes the proto.Message v.
+func (Encoder) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf: %T isn't a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal decodes the data into the proto.Message v.
+func (Encoder) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf: %T isn't a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+func init() {
+	context.RegisterEncoder(Encoder{}, MediaTypes...)
+}
@@ -0,0 +1,249 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package purge purges the pages cached by a CDN or a caching proxy when their content changes.
+// The responses are tagged with the surrogate keys by Keys, the keys or urls are purged by a Purger
+// when the models change (OnModelChange) or after the writing requests (AfterWrite).
+//
+// Usage:
+//
+//	import (
+//		"github.com/astaxie/beego"
+//		"github.com/astaxie/beego/plugins/purge"
+//	)
+//
+//	func main() {
+//		cdn := &purge.HTTPPurger{Endpoint: "http://varnish:6081"}
+//		purge.OnModelChange(cdn, func(action string, md interface{}) []string {
+//			if p, ok := md.(*models.Post); ok {
+//				return []string{"posts", fmt.Sprintf("post-%d", p.Id)}
+//			}
+//			return nil
+//		})
+//		beego.InsertFilter("/admin/*", beego.FinishRouter, purge.AfterWrite(cdn, nil), false)
+//		beego.Run()
+//	}
+//
+//	// in the controller
+//	purge.Keys(this.Ctx, "posts", fmt.Sprintf("post-%d", post.Id))
+//	purge.Cache(this.Ctx, time.Hour, time.Minute)
+package purge
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/orm"
+)
+
+// the headers read by the CDNs, they're usually stripped before the response reaches the browser.
+const (
+	SurrogateKeyHeader     = "Surrogate-Key"
+	SurrogateControlHeader = "Surrogate-Control"
+)
+
+// Purger purges the cached responses from a CDN.
+type Purger interface {
+	// PurgeURLs purges the responses of the absolute urls.
+	PurgeURLs(urls ...string) error
+	// PurgeKeys purges the responses tagged with any of the surrogate keys.
+	PurgeKeys(keys ...string) error
+}
+
+// HTTPPurger purges by http requests, it's the generic purge of Varnish, Fastly and the nginx cache purge.
+type HTTPPurger struct {
+	// Endpoint is the base url the purge requests are sent to, e.g. http://varnish:6081,
+	// the urls are purged on their own host if it's empty. It's required by PurgeKeys.
+	Endpoint string
+	// Method is the method of the purge requests, default is PURGE.
+	Method string
+	// KeyHeader is the request header carrying the surrogate keys to purge, default is Surrogate-Key.
+	KeyHeader string
+	// Header is added to every purge request, e.g. the api token of the CDN.
+	Header http.Header
+	// Client sends the purge requests, default is a client with a 10 seconds timeout.
+	Client *http.Client
+}
+
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// PurgeURLs sends a purge request for each url, it returns the first error.
+func (p *HTTPPurger) PurgeURLs(urls ...string) error {
+	var first error
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return err
+		}
+		target := raw
+		if p.Endpoint != "" {
+			target = strings.TrimSuffix(p.Endpoint, "/") + u.RequestURI()
+		}
+		req, err := http.NewRequest(p.method(), target, nil)
+		if err != nil {
+			return err
+		}
+		req.Host = u.Host
+		if err := p.do(req); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// PurgeKeys sends a purge request with the keys separated by spaces in KeyHeader to Endpoint.
+func (p *HTTPPurger) PurgeKeys(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if p.Endpoint == "" {
+		return errors.New("purge: HTTPPurger.Endpoint is required to purge the keys")
+	}
+	req, err := http.NewRequest(p.method(), p.Endpoint, nil)
+	if err != nil {
+		return err
+	}
+	header := p.KeyHeader
+	if header == "" {
+		header = SurrogateKeyHeader
+	}
+	req.Header.Set(header, strings.Join(keys, " "))
+	return p.do(req)
+}
+
+func (p *HTTPPurger) method() string {
+	if p.Method == "" {
+		return "PURGE"
+	}
+	return p.Method
+}
+
+func (p *HTTPPurger) do(req *http.Request) error {
+	for k, v := range p.Header {
+		req.Header[k] = v
+	}
+	client := p.Client
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	// 404 means the url isn't cached
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("purge: %s %s: %s", req.Method, req.URL, resp.Status)
+	}
+	return nil
+}
+
+// Keys tags the response with the surrogate keys, it's purged from the CDN when one of them is purged.
+func Keys(ctx *context.Context, keys ...string) {
+	h := ctx.ResponseWriter.Header()
+	list := strings.Fields(h.Get(SurrogateKeyHeader))
+	seen := make(map[string]bool, len(list))
+	for _, k := range list {
+		seen[k] = true
+	}
+	for _, k := range keys {
+		if k != "" && !seen[k] {
+			seen[k] = true
+			list = append(list, k)
+		}
+	}
+	h.Set(SurrogateKeyHeader, strings.Join(list, " "))
+}
+
+// Cache lets the CDN cache the response for edge and the browsers for browser.
+// The CDN keeps it until it expires or it's purged, so edge can be long,
+// the browsers can't be purged, so browser should be short, 0 makes them revalidate every time.
+// The responses of the sessions or the other cookies mustn't be cached by the CDN.
+func Cache(ctx *context.Context, edge, browser time.Duration) {
+	h := ctx.ResponseWriter.Header()
+	h.Set(SurrogateControlHeader, "max-age="+strconv.Itoa(int(edge/time.Second)))
+	if browser > 0 {
+		h.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(browser/time.Second)))
+	} else {
+		h.Set("Cache-Control", "no-cache")
+	}
+}
+
+// OnModelChange purges the keys of the models inserted, updated or deleted by the orm,
+// key returns the keys of a changed model or nil. The purge runs in a new goroutine, its errors are logged.
+func OnModelChange(p Purger, key func(action string, md interface{}) []string) {
+	orm.OnChange(func(action string, md interface{}) {
+		keys := key(action, md)
+		if len(keys) == 0 {
+			return
+		}
+		go func() {
+			if err := p.PurgeKeys(keys...); err != nil {
+				beego.Error("purge the keys", keys, "error:", err)
+			}
+		}()
+	})
+}
+
+// AfterWrite returns a FinishRouter filter which purges the keys of the successful POST, PUT, PATCH and DELETE requests,
+// the url of the request is purged if keys is nil. The purge runs in a new goroutine, its errors are logged.
+func AfterWrite(p Purger, keys func(ctx *context.Context) []string) beego.FilterFunc {
+	return func(ctx *context.Context) {
+		switch ctx.Request.Method {
+		case "POST", "PUT", "PATCH", "DELETE":
+		default:
+			return
+		}
+		if status := responseStatus(ctx); status < 200 || status >= 300 {
+			return
+		}
+		if keys == nil {
+			u := ctx.Input.Scheme() + "://" + ctx.Request.Host + ctx.Input.URL()
+			go func() {
+				if err := p.PurgeURLs(u); err != nil {
+					beego.Error("purge the url", u, "error:", err)
+				}
+			}()
+			return
+		}
+		list := keys(ctx)
+		if len(list) == 0 {
+			return
+		}
+		go func() {
+			if err := p.PurgeKeys(list...); err != nil {
+				beego.Error("purge the keys", list, "error:", err)
+			}
+		}()
+	}
+}
+
+func responseStatus(ctx *context.Context) int {
+	if w, ok := ctx.ResponseWriter.(interface {
+		Status() int
+	}); ok && w.Status() != 0 {
+		return w.Status()
+	}
+	if ctx.Output.Status != 0 {
+		return ctx.Output.Status
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,106 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package purge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astaxie/beego/context"
+)
+
+func TestHTTPPurger(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.Host+r.URL.Path+" "+r.Header.Get(SurrogateKeyHeader)+" "+r.Header.Get("X-Token"))
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	p := &HTTPPurger{Endpoint: ts.URL, Header: http.Header{"X-Token": {"secret"}}}
+	if err := p.PurgeURLs("http://www.example.com/post/1?page=2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.PurgeKeys("posts", "post-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.PurgeURLs("http://www.example.com/fail"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Error("the refused purge should fail:", err)
+	}
+	want := []string{
+		"PURGE www.example.com/post/1  secret",
+		"PURGE " + strings.TrimPrefix(ts.URL, "http://") + "/ posts post-1 secret",
+		"PURGE www.example.com/fail  secret",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got the purge requests\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if err := (&HTTPPurger{}).PurgeKeys("posts"); err == nil {
+		t.Error("the keys can't be purged without Endpoint")
+	}
+}
+
+type recordPurger struct {
+	urls chan string
+}
+
+func (p *recordPurger) PurgeURLs(urls ...string) error {
+	for _, u := range urls {
+		p.urls <- u
+	}
+	return nil
+}
+
+func (p *recordPurger) PurgeKeys(keys ...string) error {
+	p.urls <- "keys:" + strings.Join(keys, " ")
+	return nil
+}
+
+func TestKeysAndAfterWrite(t *testing.T) {
+	r, _ := http.NewRequest("PUT", "http://www.example.com/post/1", nil)
+	w := httptest.NewRecorder()
+	ctx := &context.Context{Request: r, ResponseWriter: w, Input: context.NewInput(r), Output: context.NewOutput()}
+	ctx.Output.Context = ctx
+
+	Keys(ctx, "posts", "post-1")
+	Keys(ctx, "post-1", "user-2")
+	Cache(ctx, time.Hour, 0)
+	if h := w.Header(); h.Get(SurrogateKeyHeader) != "posts post-1 user-2" || h.Get(SurrogateControlHeader) != "max-age=3600" || h.Get("Cache-Control") != "no-cache" {
+		t.Errorf("unexpected headers: %v", h)
+	}
+
+	p := &recordPurger{urls: make(chan string, 1)}
+	AfterWrite(p, nil)(ctx)
+	if u := <-p.urls; u != "http://www.example.com/post/1" {
+		t.Error("the request url isn't purged:", u)
+	}
+	AfterWrite(p, func(*context.Context) []string { return []string{"posts"} })(ctx)
+	if u := <-p.urls; u != "keys:posts" {
+		t.Error("the keys aren't purged:", u)
+	}
+
+	ctx.Output.Status = 500
+	AfterWrite(p, nil)(ctx)
+	select {
+	case u := <-p.urls:
+		t.Error("the failed request shouldn't purge:", u)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit provides the filters limiting the request rate of the clients.
+// the limit is kept by a token bucket or a sliding window per key, the key is the client ip by default,
+// the requests over the limit get 429 Too Many Requests with the Retry-After header.
+//
+// Usage:
+//	import (
+//		"github.com/astaxie/beego"
+//		"github.com/astaxie/beego/plugins/ratelimit"
+//	)
+//
+//	func main() {
+//		// 10 requests per second with bursts of 20 for each ip
+//		beego.InsertFilter("/api/*", beego.BeforeRouter, ratelimit.Limit(&ratelimit.Options{
+//			Limiter: ratelimit.NewTokenBucket(10, 20),
+//		}))
+//		// 100 requests per hour for each api key
+//		beego.InsertFilter("/api/search", beego.BeforeRouter, ratelimit.Limit(&ratelimit.Options{
+//			Limiter: ratelimit.NewSlidingWindow(100, time.Hour),
+//			Key:     ratelimit.ByHeader("X-Api-Key"),
+//		}))
+//		beego.Run()
+//	}
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+)
+
+// Limiter decides whether a request of the key is allowed,
+// if not it returns how long the client should wait before retrying.
+type Limiter interface {
+	Allow(key string) (ok bool, retryAfter time.Duration)
+}
+
+// KeyFunc returns the key the requests are limited by.
+type KeyFunc func(ctx *context.Context) string
+
+// ByIP limits the requests by the client ip, see context.BeegoInput.IP for the proxy headers.
+func ByIP(ctx *context.Context) string {
+	return ctx.Input.IP()
+}
+
+// ByHeader limits the requests by the value of the request header, e.g. the api key.
+// the requests without the header are limited by the client ip.
+func ByHeader(name string) KeyFunc {
+	return func(ctx *context.Context) string {
+		if v := ctx.Input.Header(name); v != "" {
+			return name + ":" + v
+		}
+		return ByIP(ctx)
+	}
+}
+
+// Options are the options of the Limit filter.
+type Options struct {
+	// Limiter keeps the rate of the keys.
+	Limiter Limiter
+	// Key returns the key of the request, default is ByIP.
+	Key KeyFunc
+	// Message is the body of the 429 response, default is "Too Many Requests".
+	Message string
+}
+
+// Limit returns the filter sending 429 with Retry-After to the requests over the limit of opts.Limiter.
+func Limit(opts *Options) beego.FilterFunc {
+	key := opts.Key
+	if key == nil {
+		key = ByIP
+	}
+	message := opts.Message
+	if message == "" {
+		message = "Too Many Requests"
+	}
+	return func(ctx *context.Context) {
+		ok, retryAfter := opts.Limiter.Allow(key(ctx))
+		if ok {
+			return
+		}
+		ctx.Output.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10))
+		ctx.Output.SetStatus(429)
+		ctx.Output.Body([]byte(message))
+	}
+}
+
+// sweepEvery is how often the idle keys are removed, in number of the calls of Allow.
+const sweepEvery = 1024
+
+// TokenBucket allows rate requests per second for each key, with bursts of burst requests.
+type TokenBucket struct {
+	rate    float64
+	burst   float64
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a TokenBucket refilled with rate tokens per second up to burst tokens.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token of the key's bucket.
+func (l *TokenBucket) Allow(key string) (bool, time.Duration) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.fill(b, now)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+func (l *TokenBucket) fill(b *bucket, now time.Time) float64 {
+	return math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+}
+
+// sweep removes the full buckets, they're the same as the new ones.
+func (l *TokenBucket) sweep(now time.Time) {
+	if l.calls++; l.calls < sweepEvery {
+		return
+	}
+	l.calls = 0
+	for k, b := range l.buckets {
+		if l.fill(b, now) >= l.burst {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// SlidingWindow allows limit requests in any window for each key.
+// the count of the window is approximated by the count of the current window
+// plus the count of the previous window weighted by its overlap.
+type SlidingWindow struct {
+	limit   float64
+	window  time.Duration
+	mu      sync.Mutex
+	windows map[string]*window
+	calls   int
+	now     func() time.Time
+}
+
+type window struct {
+	start time.Time
+	prev  float64
+	curr  float64
+}
+
+// NewSlidingWindow returns a SlidingWindow allowing limit requests per window.
+func NewSlidingWindow(limit int, d time.Duration) *SlidingWindow {
+	if limit < 1 {
+		limit = 1
+	}
+	return &SlidingWindow{
+		limit:   float64(limit),
+		window:  d,
+		windows: make(map[string]*window),
+		now:     time.Now,
+	}
+}
+
+// Allow counts the request of the key if the count of the window is under the limit.
+func (l *SlidingWindow) Allow(key string) (bool, time.Duration) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	w, ok := l.windows[key]
+	if !ok {
+		w = &window{start: now.Truncate(l.window)}
+		l.windows[key] = w
+	}
+	l.slide(w, now)
+	elapsed := float64(now.Sub(w.start)) / float64(l.window)
+	if w.prev*(1-elapsed)+w.curr < l.limit {
+		w.curr++
+		return true, 0
+	}
+	return false, l.retryAfter(w, now)
+}
+
+// slide moves the window of w to now.
+func (l *SlidingWindow) slide(w *window, now time.Time) {
+	start := now.Truncate(l.window)
+	switch {
+	case start.Equal(w.start):
+		return
+	case start.Sub(w.start) == l.window:
+		w.prev = w.curr
+	default:
+		w.prev = 0
+	}
+	w.curr = 0
+	w.start = start
+}
+
+// retryAfter returns the time the weighted count falls under the limit.
+func (l *SlidingWindow) retryAfter(w *window, now time.Time) time.Duration {
+	end := w.start.Add(l.window)
+	if w.curr < l.limit {
+		// within the current window, as the previous window's weight drops
+		f := 1 - (l.limit-w.curr)/w.prev
+		return w.start.Add(time.Duration(f*float64(l.window))).Sub(now) + time.Millisecond
+	}
+	// in the next window, the current window becomes the previous one
+	f := 1 - l.limit/w.curr
+	return end.Add(time.Duration(f*float64(l.window))).Sub(now) + time.Millisecond
+}
+
+// sweep removes the keys without a request in the last two windows.
+func (l *SlidingWindow) sweep(now time.Time) {
+	if l.calls++; l.calls < sweepEvery {
+		return
+	}
+	l.calls = 0
+	for k, w := range l.windows {
+		if now.Sub(w.start) >= 2*l.window {
+			delete(l.windows, k)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+)
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func TestTokenBucket(t *testing.T) {
+	c := &clock{time.Unix(1000, 0)}
+	l := NewTokenBucket(2, 3)
+	l.now = c.now
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("burst request %d is limited", i)
+		}
+	}
+	ok, retry := l.Allow("a")
+	if ok || retry != 500*time.Millisecond {
+		t.Fatalf("want limited for 500ms, got %v %v", ok, retry)
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Fatal("the other key is limited")
+	}
+	c.t = c.t.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("the refilled token is limited")
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	c := &clock{time.Unix(1000, 0)}
+	l := NewSlidingWindow(4, 10*time.Second)
+	l.now = c.now
+	for i := 0; i < 4; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d is limited", i)
+		}
+	}
+	ok, retry := l.Allow("a")
+	if ok || retry < 10*time.Second || retry > 11*time.Second {
+		t.Fatalf("want limited until the next window, got %v %v", ok, retry)
+	}
+
+	// a quarter into the next window the previous one still weighs 3 requests
+	c.t = c.t.Add(12500 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("the request under the weighted count is limited")
+	}
+	if ok, retry := l.Allow("a"); ok || retry <= 0 {
+		t.Fatalf("want limited, got %v %v", ok, retry)
+	}
+	c.t = c.t.Add(retry)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("the request after Retry-After is limited")
+	}
+}
+
+func TestLimit(t *testing.T) {
+	handler := beego.NewControllerRegister()
+	handler.InsertFilter("/api/*", beego.BeforeRouter, Limit(&Options{
+		Limiter: NewTokenBucket(1, 1),
+		Key:     ByHeader("X-Api-Key"),
+	}))
+	handler.Any("/api/user", func(ctx *context.Context) {
+		ctx.Output.Body([]byte("ok"))
+	})
+
+	do := func(key string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest("GET", "/api/user", nil)
+		r.Header.Set("X-Api-Key", key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	if w := do("a"); w.Code != http.StatusOK {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	w := do("a")
+	if w.Code != 429 || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("want 429 with Retry-After 1, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := do("b"); w.Code != http.StatusOK {
+		t.Fatalf("the other key: %d", w.Code)
+	}
+}
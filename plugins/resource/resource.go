@@ -0,0 +1,522 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resource generates the CRUD routes of an orm model with hooks.
+//
+// Usage:
+//
+//	import (
+//		"github.com/astaxie/beego"
+//		"github.com/astaxie/beego/plugins/resource"
+//	)
+//
+//	func main() {
+//		resource.Add("/users", &models.User{}, resource.Options{
+//			Filters:  []string{"status", "name__icontains"},
+//			Orders:   []string{"id", "created"},
+//			ReadOnly: []string{"IsAdmin"},
+//		})
+//		beego.Run()
+//	}
+//
+// The routes are
+//
+//	GET    /users        list, e.g. ?page=2&page_size=50&status=1&order=-created
+//	POST   /users        create
+//	GET    /users/:id    get
+//	PUT    /users/:id    update, the fields missing in the body are kept, PATCH is the same
+//	DELETE /users/:id    delete
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/orm"
+	"github.com/astaxie/beego/utils"
+	"github.com/astaxie/beego/validation"
+)
+
+// Query is the list query of a resource parsed from the request,
+// the hooks can change it, e.g. BeforeList adds a filter scoping the records to the user.
+type Query struct {
+	Page     int               // from 1
+	PageSize int               // the records per page
+	Filters  map[string]string // orm filter expressions, e.g. status or name__icontains, to the values
+	Order    []string          // orm order expressions, e.g. -created
+}
+
+// Store loads and saves the records of a resource, the records are pointers to the model struct.
+// The default store runs on the orm, the errors of the orm are answered as 404, 409 etc.
+type Store interface {
+	// List loads the page of the query into container, a pointer to a slice of the records, and returns the total count.
+	List(ctx *context.Context, q *Query, container interface{}) (int64, error)
+	// Read loads the record by its primary key.
+	Read(ctx *context.Context, md interface{}) error
+	Insert(ctx *context.Context, md interface{}) error
+	Update(ctx *context.Context, md interface{}) error
+	Delete(ctx *context.Context, md interface{}) error
+}
+
+// Hook customizes a step of the generated routes, it can stop the request by writing the response,
+// e.g. by ctx.Abort(403, "forbidden").
+type Hook func(ctx *context.Context, md interface{})
+
+// Options holds the options of the routes generated by Add.
+type Options struct {
+	// Actions limits the generated routes to list, get, create, update and delete, default is all of them.
+	Actions []string
+	// PageSize is the default size of the list page, default is 20.
+	PageSize int
+	// MaxPageSize limits the page_size parameter, default is 100.
+	MaxPageSize int
+	// Filters are the query parameters which filter the list, they are orm filter expressions,
+	// e.g. "status" or "name__icontains".
+	Filters []string
+	// Orders are the fields the list can be ordered by with the order parameter, e.g. order=-created,name.
+	Orders []string
+	// Fields are the struct fields the clients can set by create and update, default is all of them.
+	// The primary key is never bound.
+	Fields []string
+	// ReadOnly are the struct fields the clients can't set, e.g. IsAdmin or OwnerID,
+	// they keep their value on update and can be set by BeforeCreate and BeforeUpdate.
+	ReadOnly []string
+	// Ormer returns the orm of the request, default is orm.NewOrm(). It's ignored if Store is set.
+	Ormer func(ctx *context.Context) orm.Ormer
+	// Store replaces the orm store.
+	Store Store
+	// Handlers is the router the routes are added to, default is beego.BeeApp.Handlers.
+	Handlers *beego.ControllerRegister
+
+	// BeforeList runs before the list is loaded, md is the *Query.
+	BeforeList Hook
+	// AfterRead runs once a record is read by get, update and delete.
+	AfterRead Hook
+	// BeforeCreate and BeforeUpdate run after the record is bound and validated.
+	BeforeCreate Hook
+	BeforeUpdate Hook
+	BeforeDelete Hook
+}
+
+// Add adds the CRUD routes of the orm model to the Handlers of opts, beego.BeeApp.Handlers by default.
+func Add(rootpath string, model interface{}, opts Options) {
+	r := newResource(model, opts)
+	p := opts.Handlers
+	if p == nil {
+		p = beego.BeeApp.Handlers
+	}
+	rootpath = strings.TrimRight(rootpath, "/")
+	item := rootpath + "/:id"
+	for _, action := range r.actions() {
+		switch action {
+		case "list":
+			p.Get(rootpath, r.list)
+		case "create":
+			p.Post(rootpath, r.create)
+		case "get":
+			p.Get(item, r.get)
+		case "update":
+			p.Put(item, r.update)
+			p.Patch(item, r.update)
+		case "delete":
+			p.Delete(item, r.delete)
+		default:
+			panic("resource: unknown action " + action)
+		}
+	}
+}
+
+type resource struct {
+	typ       reflect.Type
+	pk        int
+	protected []int // the fields which aren't bound, the primary key, the ReadOnly fields and the ones not in Fields
+	opts      Options
+	store     Store
+}
+
+func newResource(model interface{}, opts Options) *resource {
+	typ := reflect.TypeOf(model)
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		panic("resource: the model must be a struct pointer")
+	}
+	typ = typ.Elem()
+	r := &resource{typ: typ, pk: PK(typ), opts: opts, store: opts.Store}
+	if r.pk < 0 {
+		panic("resource: can't find the primary key of the model " + typ.String())
+	}
+	r.protected = resourceProtectedFields(typ, r.pk, opts.Fields, opts.ReadOnly)
+	if r.opts.PageSize <= 0 {
+		r.opts.PageSize = 20
+	}
+	if r.opts.MaxPageSize <= 0 {
+		r.opts.MaxPageSize = 100
+	}
+	if r.store == nil {
+		r.store = NewStore(model, opts.Ormer)
+	}
+	return r
+}
+
+// PK returns the index of the primary key the orm uses, the field tagged pk or auto,
+// or else the integer field named id, it's -1 if the struct type has none.
+func PK(typ reflect.Type) int {
+	id := -1
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		for _, attr := range strings.Split(f.Tag.Get("orm"), ";") {
+			if attr == "pk" || attr == "auto" {
+				return i
+			}
+		}
+		if strings.ToLower(f.Name) == "id" {
+			switch f.Type.Kind() {
+			case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+				id = i
+			}
+		}
+	}
+	return id
+}
+
+// resourceProtectedFields returns the indexes of the fields which the clients can't set.
+func resourceProtectedFields(typ reflect.Type, pk int, fields, readOnly []string) []int {
+	index := func(name string) int {
+		f, ok := typ.FieldByName(name)
+		if !ok || len(f.Index) != 1 {
+			panic("resource: unknown field " + name + " of the model " + typ.String())
+		}
+		return f.Index[0]
+	}
+	protected := make(map[int]bool)
+	protected[pk] = true
+	for _, name := range readOnly {
+		protected[index(name)] = true
+	}
+	if len(fields) > 0 {
+		allowed := make(map[int]bool, len(fields))
+		for _, name := range fields {
+			allowed[index(name)] = true
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			if !allowed[i] {
+				protected[i] = true
+			}
+		}
+	}
+	indexes := make([]int, 0, len(protected))
+	for i := 0; i < typ.NumField(); i++ {
+		if protected[i] {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func (r *resource) actions() []string {
+	if len(r.opts.Actions) > 0 {
+		return r.opts.Actions
+	}
+	return []string{"list", "create", "get", "update", "delete"}
+}
+
+// hook runs the hook and returns whether the request goes on.
+func (r *resource) hook(h Hook, ctx *context.Context, md interface{}) bool {
+	if h == nil {
+		return true
+	}
+	h(ctx, md)
+	return !ctx.Written()
+}
+
+func (r *resource) query(ctx *context.Context) (*Query, error) {
+	q := &Query{Page: 1, PageSize: r.opts.PageSize, Filters: make(map[string]string)}
+	if v := ctx.Input.Query("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return nil, fmt.Errorf("invalid page %q", v)
+		}
+		q.Page = page
+	}
+	if v := ctx.Input.Query("page_size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 1 {
+			return nil, fmt.Errorf("invalid page_size %q", v)
+		}
+		if size > r.opts.MaxPageSize {
+			size = r.opts.MaxPageSize
+		}
+		q.PageSize = size
+	}
+	for _, f := range r.opts.Filters {
+		if v := ctx.Input.Query(f); v != "" {
+			q.Filters[f] = v
+		}
+	}
+	if v := ctx.Input.Query("order"); v != "" {
+		for _, o := range strings.Split(v, ",") {
+			if !utils.InSlice(strings.TrimPrefix(o, "-"), r.opts.Orders) {
+				return nil, fmt.Errorf("can't order by %q", o)
+			}
+			q.Order = append(q.Order, o)
+		}
+	}
+	return q, nil
+}
+
+func (r *resource) list(ctx *context.Context) {
+	q, err := r.query(ctx)
+	if err != nil {
+		writeError(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if !r.hook(r.opts.BeforeList, ctx, q) {
+		return
+	}
+	container := reflect.New(reflect.SliceOf(reflect.PtrTo(r.typ)))
+	total, err := r.store.List(ctx, q, container.Interface())
+	if err != nil {
+		r.storeError(ctx, err)
+		return
+	}
+	ctx.Output.JSON(map[string]interface{}{
+		"items":     container.Elem().Interface(),
+		"total":     total,
+		"page":      q.Page,
+		"page_size": q.PageSize,
+	}, false, false)
+}
+
+// read loads the record of the :id parameter.
+func (r *resource) read(ctx *context.Context) (reflect.Value, bool) {
+	md := reflect.New(r.typ)
+	if err := SetPK(md.Elem().Field(r.pk), ctx.Input.Param(":id")); err != nil {
+		writeError(ctx, http.StatusNotFound, "", nil)
+		return md, false
+	}
+	if err := r.store.Read(ctx, md.Interface()); err != nil {
+		r.storeError(ctx, err)
+		return md, false
+	}
+	return md, r.hook(r.opts.AfterRead, ctx, md.Interface())
+}
+
+func (r *resource) get(ctx *context.Context) {
+	if md, ok := r.read(ctx); ok {
+		ctx.Output.JSON(md.Interface(), false, false)
+	}
+}
+
+// bind binds the request into the record and validates it, the primary key can't be changed.
+func (r *resource) bind(ctx *context.Context, md reflect.Value) bool {
+	// the protected fields keep the value of the record, or the zero value on create
+	kept := make([]reflect.Value, len(r.protected))
+	for i, f := range r.protected {
+		kept[i] = reflect.New(r.typ.Field(f).Type).Elem()
+		kept[i].Set(md.Elem().Field(f))
+	}
+	if err := ctx.Input.BindRequest(md.Interface()); err != nil {
+		writeError(ctx, http.StatusBadRequest, err.Error(), nil)
+		return false
+	}
+	for i, f := range r.protected {
+		md.Elem().Field(f).Set(kept[i])
+	}
+	valid := validation.Validation{}
+	ok, err := valid.Valid(md.Interface())
+	if err != nil {
+		writeError(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return false
+	}
+	if !ok {
+		fields := make(map[string]string, len(valid.Errors))
+		for _, e := range valid.Errors {
+			fields[e.Field] = e.Message
+		}
+		writeError(ctx, http.StatusUnprocessableEntity, "validation failed", fields)
+		return false
+	}
+	return true
+}
+
+func (r *resource) create(ctx *context.Context) {
+	md := reflect.New(r.typ)
+	if !r.bind(ctx, md) || !r.hook(r.opts.BeforeCreate, ctx, md.Interface()) {
+		return
+	}
+	if err := r.store.Insert(ctx, md.Interface()); err != nil {
+		r.storeError(ctx, err)
+		return
+	}
+	ctx.Output.SetStatus(http.StatusCreated)
+	ctx.Output.JSON(md.Interface(), false, false)
+}
+
+func (r *resource) update(ctx *context.Context) {
+	md, ok := r.read(ctx)
+	if !ok || !r.bind(ctx, md) || !r.hook(r.opts.BeforeUpdate, ctx, md.Interface()) {
+		return
+	}
+	if err := r.store.Update(ctx, md.Interface()); err != nil {
+		r.storeError(ctx, err)
+		return
+	}
+	ctx.Output.JSON(md.Interface(), false, false)
+}
+
+func (r *resource) delete(ctx *context.Context) {
+	md, ok := r.read(ctx)
+	if !ok || !r.hook(r.opts.BeforeDelete, ctx, md.Interface()) {
+		return
+	}
+	if err := r.store.Delete(ctx, md.Interface()); err != nil {
+		r.storeError(ctx, err)
+		return
+	}
+	ctx.Output.SetStatus(http.StatusNoContent)
+}
+
+// storeError answers the error of the store, the missing records are 404 and the constraint violations are 409.
+func (r *resource) storeError(ctx *context.Context, err error) {
+	switch {
+	case errors.Is(err, orm.ErrNoRows):
+		writeError(ctx, http.StatusNotFound, "", nil)
+	case errors.Is(err, orm.ErrDuplicateKey), errors.Is(err, orm.ErrFKViolation):
+		conflictError(ctx, err)
+	default:
+		beego.Error("resource", ctx.Input.URL(), err)
+		writeError(ctx, http.StatusInternalServerError, "", nil)
+	}
+}
+
+// conflictError answers 409 for the constraint violation err with its kind and columns,
+// the message of the driver isn't sent, it may show the data and the schema.
+func conflictError(ctx *context.Context, err error) {
+	kind := orm.ErrDuplicateKey
+	if errors.Is(err, orm.ErrFKViolation) {
+		kind = orm.ErrFKViolation
+	}
+	var fields map[string]string
+	var ce *orm.ConstraintError
+	if errors.As(err, &ce) && len(ce.Columns) > 0 {
+		fields = make(map[string]string, len(ce.Columns))
+		for _, col := range ce.Columns {
+			fields[col] = kind.Error()
+		}
+	}
+	writeError(ctx, http.StatusConflict, kind.Error(), fields)
+}
+
+// writeError writes the error as problem+json if beego.EnableProblemJSON is on,
+// or else as {"error": "...", "fields": {...}}.
+func writeError(ctx *context.Context, status int, detail string, fields map[string]string) {
+	if beego.EnableProblemJSON {
+		beego.WriteProblem(ctx, status, detail)
+		return
+	}
+	if detail == "" {
+		detail = http.StatusText(status)
+	}
+	body := map[string]interface{}{"error": detail}
+	if len(fields) > 0 {
+		body["fields"] = fields
+	}
+	ctx.Output.SetStatus(status)
+	ctx.Output.JSON(body, false, false)
+}
+
+// SetPK parses the :id parameter into the primary key field fv.
+func SetPK(fv reflect.Value, id string) error {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.String:
+		fv.SetString(id)
+	default:
+		return errors.New("unsupported primary key type " + fv.Type().String())
+	}
+	return nil
+}
+
+// NewStore returns the Store of the orm model which Add uses by default,
+// ormer returns the orm of the request, orm.NewOrm() is used if it's nil.
+func NewStore(model interface{}, ormer func(ctx *context.Context) orm.Ormer) Store {
+	return &ormStore{model: model, ormer: ormer}
+}
+
+// ormStore is the Store on the orm.
+type ormStore struct {
+	model interface{}
+	ormer func(ctx *context.Context) orm.Ormer
+}
+
+func (s *ormStore) newOrm(ctx *context.Context) orm.Ormer {
+	if s.ormer != nil {
+		return s.ormer(ctx)
+	}
+	return orm.NewOrm()
+}
+
+func (s *ormStore) List(ctx *context.Context, q *Query, container interface{}) (int64, error) {
+	qs := s.newOrm(ctx).QueryTable(s.model)
+	for k, v := range q.Filters {
+		qs = qs.Filter(k, v)
+	}
+	total, err := qs.Count()
+	if err != nil {
+		return 0, err
+	}
+	if len(q.Order) > 0 {
+		qs = qs.OrderBy(q.Order...)
+	}
+	_, err = qs.Limit(q.PageSize, (q.Page-1)*q.PageSize).All(container)
+	return total, err
+}
+
+func (s *ormStore) Read(ctx *context.Context, md interface{}) error {
+	return s.newOrm(ctx).Read(md)
+}
+
+func (s *ormStore) Insert(ctx *context.Context, md interface{}) error {
+	_, err := s.newOrm(ctx).Insert(md)
+	return err
+}
+
+func (s *ormStore) Update(ctx *context.Context, md interface{}) error {
+	_, err := s.newOrm(ctx).Update(md)
+	return err
+}
+
+func (s *ormStore) Delete(ctx *context.Context, md interface{}) error {
+	num, err := s.newOrm(ctx).Delete(md)
+	if err == nil && num == 0 {
+		return orm.ErrNoRows
+	}
+	return err
+}
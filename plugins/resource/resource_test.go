@@ -0,0 +1,187 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/orm"
+)
+
+type resourceUser struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name" valid:"Required"`
+	Email string `json:"email"`
+	Admin bool   `json:"admin,omitempty"`
+}
+
+type memStore struct {
+	users  map[int]*resourceUser
+	nextID int
+}
+
+func (s *memStore) List(ctx *context.Context, q *Query, container interface{}) (int64, error) {
+	var all []*resourceUser
+	for _, u := range s.users {
+		if name, ok := q.Filters["name"]; ok && u.Name != name {
+			continue
+		}
+		all = append(all, u)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	list := container.(*[]*resourceUser)
+	for i := (q.Page - 1) * q.PageSize; i < len(all) && len(*list) < q.PageSize; i++ {
+		*list = append(*list, all[i])
+	}
+	return int64(len(all)), nil
+}
+
+func (s *memStore) Read(ctx *context.Context, md interface{}) error {
+	u, ok := s.users[md.(*resourceUser).ID]
+	if !ok {
+		return orm.ErrNoRows
+	}
+	*md.(*resourceUser) = *u
+	return nil
+}
+
+func (s *memStore) Insert(ctx *context.Context, md interface{}) error {
+	u := md.(*resourceUser)
+	for _, o := range s.users {
+		if o.Name == u.Name {
+			return &orm.ConstraintError{Kind: orm.ErrDuplicateKey, Columns: []string{"name"}, Err: orm.ErrDuplicateKey}
+		}
+	}
+	s.nextID++
+	u.ID = s.nextID
+	c := *u
+	s.users[u.ID] = &c
+	return nil
+}
+
+func (s *memStore) Update(ctx *context.Context, md interface{}) error {
+	c := *md.(*resourceUser)
+	s.users[c.ID] = &c
+	return nil
+}
+
+func (s *memStore) Delete(ctx *context.Context, md interface{}) error {
+	delete(s.users, md.(*resourceUser).ID)
+	return nil
+}
+
+func TestResource(t *testing.T) {
+	mux := beego.NewControllerRegister()
+	Add("/users", &resourceUser{}, Options{
+		Handlers: mux,
+		Filters:  []string{"name"},
+		Orders:   []string{"id"},
+		Store:    &memStore{users: make(map[int]*resourceUser)},
+		BeforeDelete: func(ctx *context.Context, md interface{}) {
+			if md.(*resourceUser).Name == "admin" {
+				ctx.Output.SetStatus(403)
+				ctx.Output.Body([]byte("forbidden"))
+			}
+		},
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			r.Header.Set("Content-Type", "application/json")
+		}
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, r)
+		return rw
+	}
+
+	cases := []struct {
+		method, path, body string
+		code               int
+		contains           string
+	}{
+		{"POST", "/users", `{"name":"admin","email":"admin@example.com"}`, 201, `"id":1`},
+		{"POST", "/users", `{"name":"slene"}`, 201, `"id":2`},
+		{"POST", "/users", `{"email":"nobody@example.com"}`, 422, `"Name"`},
+		{"POST", "/users", `{"name":"slene"}`, 409, `"name"`},
+		{"GET", "/users/1", "", 200, `"name":"admin"`},
+		{"GET", "/users/3", "", 404, ""},
+		{"GET", "/users/abc", "", 404, ""},
+		{"PATCH", "/users/2", `{"id":9,"email":"slene@example.com"}`, 200, `{"id":2,"name":"slene","email":"slene@example.com"}`},
+		{"GET", "/users?page=2&page_size=1", "", 200, `"items":[{"id":2,`},
+		{"GET", "/users?name=admin", "", 200, `"total":1`},
+		{"GET", "/users?order=-email", "", 400, ""},
+		{"DELETE", "/users/1", "", 403, "forbidden"},
+		{"DELETE", "/users/2", "", 204, ""},
+		{"GET", "/users/2", "", 404, ""},
+	}
+	for _, c := range cases {
+		rw := do(c.method, c.path, c.body)
+		if rw.Code != c.code || !strings.Contains(rw.Body.String(), c.contains) {
+			t.Errorf("TestResource %s %s got %d %s", c.method, c.path, rw.Code, rw.Body.String())
+		}
+	}
+
+	var page struct {
+		Items    []*resourceUser
+		Total    int64
+		PageSize int `json:"page_size"`
+	}
+	if err := json.Unmarshal(do("GET", "/users?page_size=500", "").Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 || page.PageSize != 100 {
+		t.Errorf("TestResource list got %+v", page)
+	}
+}
+
+func TestResourceProtectedFields(t *testing.T) {
+	store := &memStore{users: make(map[int]*resourceUser)}
+	mux := beego.NewControllerRegister()
+	Add("/users", &resourceUser{}, Options{Handlers: mux, Store: store, ReadOnly: []string{"Admin"}})
+	Add("/names", &resourceUser{}, Options{Handlers: mux, Store: store, Fields: []string{"Name"}})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, r)
+		return rw
+	}
+
+	if rw := do("POST", "/users", `{"name":"slene","admin":true}`); rw.Code != 201 || store.users[1].Admin {
+		t.Errorf("the read only field is set on create: %d %q", rw.Code, rw.Body.String())
+	}
+	store.users[1].Admin = true
+	if rw := do("PUT", "/users/1", `{"email":"slene@example.com","admin":false}`); rw.Code != 200 || !store.users[1].Admin {
+		t.Errorf("the read only field is changed on update: %d %q", rw.Code, rw.Body.String())
+	}
+	if rw := do("PUT", "/names/1", `{"name":"astaxie","email":"astaxie@example.com"}`); rw.Code != 200 ||
+		store.users[1].Name != "astaxie" || store.users[1].Email != "slene@example.com" {
+		t.Errorf("only the allowed fields should be updated: %d %q", rw.Code, rw.Body.String())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("an unknown field should panic")
+		}
+	}()
+	Add("/bad", &resourceUser{}, Options{Handlers: mux, Store: store, ReadOnly: []string{"IsAdmin"}})
+}
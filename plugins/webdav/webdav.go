@@ -0,0 +1,101 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webdav serves a directory over WebDAV (PROPFIND, MKCOL, COPY, MOVE, LOCK...).
+//
+// depend on golang.org/x/net/webdav
+//
+// go install golang.org/x/net/webdav
+//
+// Usage:
+//	import (
+//		"github.com/astaxie/beego"
+//		"github.com/astaxie/beego/plugins/auth"
+//		"github.com/astaxie/beego/plugins/webdav"
+//	)
+//
+//	func main() {
+//		// manage the files of the static directory, the filters of the route run before every request
+//		webdav.Mount("/dav", beego.StaticDir["/static"], beego.WithFilters(auth.Basic("admin", "secret")))
+//		// browse only
+//		webdav.Mount("/public", "public", beego.WithFilters(webdav.ReadOnly))
+//		beego.Run()
+//	}
+package webdav
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+
+	"golang.org/x/net/webdav"
+)
+
+// Methods are the http methods of WebDAV added to beego.HTTPMETHOD.
+var Methods = []string{"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK"}
+
+var writeMethods = map[string]bool{
+	"PUT":       true,
+	"DELETE":    true,
+	"PROPPATCH": true,
+	"MKCOL":     true,
+	"COPY":      true,
+	"MOVE":      true,
+	"LOCK":      true,
+	"UNLOCK":    true,
+}
+
+// Handler returns the WebDAV handler of dir for the requests under prefix.
+// the locks are kept in memory.
+func Handler(prefix, dir string) http.Handler {
+	return &webdav.Handler{
+		Prefix:     prefix,
+		FileSystem: webdav.Dir(dir),
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil && beego.RunMode == "dev" {
+				beego.Warn("webdav:", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+}
+
+// Mount registers the WebDAV handler of dir on prefix and on prefix/* for the nested paths in beego application.
+// the options are the beego.RouterOption of the routes, use beego.WithFilters for the auth.
+func Mount(prefix, dir string, opts ...beego.RouterOption) *beego.App {
+	options := make([]interface{}, 0, len(opts))
+	for _, opt := range opts {
+		options = append(options, opt)
+	}
+	h := Handler(prefix, dir)
+	beego.Handler(prefix, h, options...)
+	return beego.Handler(path.Join(prefix, "*"), h, options...)
+}
+
+// ReadOnly is the route filter which rejects the WebDAV requests changing the files.
+func ReadOnly(ctx *context.Context) {
+	if writeMethods[ctx.Input.Method()] {
+		ctx.Output.Header("Allow", "OPTIONS, GET, HEAD, PROPFIND")
+		ctx.Output.SetStatus(http.StatusMethodNotAllowed)
+		ctx.Output.Body([]byte(http.StatusText(http.StatusMethodNotAllowed)))
+	}
+}
+
+func init() {
+	for _, m := range Methods {
+		beego.HTTPMETHOD[m] = m
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yaml enables the yaml responses and request bodies, by Controller.ServeYAML,
+// ctx.Output.YAML, ctx.Output.Serve and ctx.Input.BindRequest with the application/x-yaml Content-Type.
+//
+// depend on gopkg.in/yaml.v2
+//
+// go get gopkg.in/yaml.v2
+//
+// Usage:
+//	import (
+//		"github.com/astaxie/beego"
+//		_ "github.com/astaxie/beego/plugins/yaml"
+//	)
+//
+//	func (c *ConfigController) Put() {
+//		var conf AppConf
+//		if err := c.ParseBody(&conf); err != nil {
+//			c.CustomAbort(400, err.Error())
+//		}
+//		c.Data["yaml"] = conf
+//		c.ServeYAML()
+//	}
+package yaml
+
+import (
+	"github.com/astaxie/beego/context"
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	context.YAMLMarshal = yaml.Marshal
+	context.YAMLUnmarshal = yaml.Unmarshal
+}
@@ -0,0 +1,124 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privacy
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// AuditRecord records an export or erasure.
+type AuditRecord struct {
+	Time     time.Time         `json:"time"`
+	Action   string            `json:"action"` // export or erase
+	Subject  string            `json:"subject"`
+	Operator string            `json:"operator,omitempty"`
+	Reason   string            `json:"reason,omitempty"`
+	Models   []string          `json:"models"`
+	Errors   map[string]string `json:"errors,omitempty"`
+	Duration time.Duration     `json:"duration"`
+}
+
+// AuditLog stores the audit records.
+type AuditLog interface {
+	Record(*AuditRecord) error
+}
+
+// AuditReader is an AuditLog which returns the latest records, it's shown by the admin module.
+type AuditReader interface {
+	AuditLog
+	Latest(n int) []*AuditRecord
+}
+
+var (
+	auditLock sync.RWMutex
+	auditLog  AuditLog = NewMemoryAuditLog(1000)
+)
+
+// SetAuditLog sets the audit log, the default keeps the latest 1000 records in memory,
+// set a persistent one to keep the records as the compliance requires.
+func SetAuditLog(l AuditLog) {
+	auditLock.Lock()
+	defer auditLock.Unlock()
+	auditLog = l
+}
+
+// CurrentAuditLog returns the audit log set by SetAuditLog.
+func CurrentAuditLog() AuditLog {
+	auditLock.RLock()
+	defer auditLock.RUnlock()
+	return auditLog
+}
+
+// MemoryAuditLog keeps the latest records in memory.
+type MemoryAuditLog struct {
+	lock    sync.Mutex
+	size    int
+	records []*AuditRecord
+}
+
+// NewMemoryAuditLog returns a MemoryAuditLog keeping the latest size records.
+func NewMemoryAuditLog(size int) *MemoryAuditLog {
+	return &MemoryAuditLog{size: size}
+}
+
+// Record adds the record and drops the oldest one when it's full.
+func (l *MemoryAuditLog) Record(r *AuditRecord) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.records = append(l.records, r)
+	if len(l.records) > l.size {
+		l.records = append(l.records[:0], l.records[len(l.records)-l.size:]...)
+	}
+	return nil
+}
+
+// Latest returns at most n records, the latest first.
+func (l *MemoryAuditLog) Latest(n int) []*AuditRecord {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if n > len(l.records) {
+		n = len(l.records)
+	}
+	records := make([]*AuditRecord, n)
+	for i := range records {
+		records[i] = l.records[len(l.records)-1-i]
+	}
+	return records
+}
+
+// jsonAuditLog writes a JSON object per line.
+type jsonAuditLog struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+// NewJSONAuditLog returns an AuditLog which writes a JSON object per line to w, such as an append-only file.
+func NewJSONAuditLog(w io.Writer) AuditLog {
+	return &jsonAuditLog{w: w}
+}
+
+func (l *jsonAuditLog) Record(r *AuditRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	_, err = l.w.Write(append(b, '\n'))
+	return err
+}
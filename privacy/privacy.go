@@ -0,0 +1,196 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package privacy orchestrates the export and the erasure of the personal data of a subject,
+// such as for the GDPR access and erasure requests.
+// The models holding personal data register their export and erasure functions, keyed by the subject id,
+// every export and erasure is recorded in the audit log.
+// Usage:
+//
+//	import "github.com/astaxie/beego/privacy"
+//
+//	privacy.Register("orders", func(ctx context.Context, subject string) (interface{}, error) {
+//		return loadOrders(ctx, subject)
+//	}, func(ctx context.Context, subject string) error {
+//		return anonymizeOrders(ctx, subject)
+//	})
+//
+//	result, err := privacy.Export(ctx, privacy.Request{Subject: "42", Operator: "alice"})
+//
+// The admin module serves them on /privacy.
+package privacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exporter returns the personal data of the subject held by a model, it's serialized as json.
+type Exporter func(ctx context.Context, subject string) (interface{}, error)
+
+// Eraser erases or anonymizes the personal data of the subject held by a model.
+type Eraser func(ctx context.Context, subject string) error
+
+type model struct {
+	export Exporter
+	erase  Eraser
+}
+
+var (
+	lock   sync.RWMutex
+	models = make(map[string]model)
+)
+
+// Register adds the export and the erasure functions of a model, either may be nil.
+// It panics if the model is registered twice.
+func Register(name string, export Exporter, erase Eraser) {
+	lock.Lock()
+	defer lock.Unlock()
+	if export == nil && erase == nil {
+		panic("privacy: Register " + name + " without functions")
+	}
+	if _, ok := models[name]; ok {
+		panic("privacy: Register called twice for model " + name)
+	}
+	models[name] = model{export, erase}
+}
+
+// Models returns the names of the registered models.
+func Models() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Request is an export or erasure request.
+type Request struct {
+	Subject  string // the id of the subject
+	Operator string // who made the request, for the audit log
+	Reason   string
+}
+
+// Result is the outcome of an export or erasure, the models are run in the order of the names.
+type Result struct {
+	Subject string                 `json:"subject"`
+	Data    map[string]interface{} `json:"data,omitempty"`   // the exported data by model
+	Erased  []string               `json:"erased,omitempty"` // the erased models
+	Errors  map[string]string      `json:"errors,omitempty"` // the failures by model
+}
+
+// Err returns the failures of the models as an error, nil if all succeeded.
+func (r *Result) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(r.Errors))
+	for name := range r.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = name + ": " + r.Errors[name]
+	}
+	return errors.New("privacy: " + strings.Join(msgs, "; "))
+}
+
+// ErrNoSubject is returned for a request without a subject.
+var ErrNoSubject = errors.New("privacy: the request has no subject")
+
+// Export collects the data of the subject from the models with an Exporter.
+// A failed model doesn't stop the others, the failures are returned by Result.Err.
+func Export(ctx context.Context, req Request) (*Result, error) {
+	return run(ctx, "export", req, func(ctx context.Context, name string, m model, r *Result) (bool, error) {
+		if m.export == nil {
+			return false, nil
+		}
+		data, err := m.export(ctx, req.Subject)
+		if err == nil {
+			r.Data[name] = data
+		}
+		return true, err
+	})
+}
+
+// Erase erases the data of the subject from the models with an Eraser.
+// A failed model doesn't stop the others, the failures are returned by Result.Err.
+func Erase(ctx context.Context, req Request) (*Result, error) {
+	return run(ctx, "erase", req, func(ctx context.Context, name string, m model, r *Result) (bool, error) {
+		if m.erase == nil {
+			return false, nil
+		}
+		err := m.erase(ctx, req.Subject)
+		if err == nil {
+			r.Erased = append(r.Erased, name)
+		}
+		return true, err
+	})
+}
+
+func run(ctx context.Context, action string, req Request, fn func(context.Context, string, model, *Result) (bool, error)) (*Result, error) {
+	if req.Subject == "" {
+		return nil, ErrNoSubject
+	}
+	r := &Result{Subject: req.Subject, Data: make(map[string]interface{}), Errors: make(map[string]string)}
+	record := &AuditRecord{
+		Time:     time.Now(),
+		Action:   action,
+		Subject:  req.Subject,
+		Operator: req.Operator,
+		Reason:   req.Reason,
+	}
+	for _, name := range Models() {
+		lock.RLock()
+		m := models[name]
+		lock.RUnlock()
+		if err := ctx.Err(); err != nil {
+			r.Errors[name] = err.Error()
+			continue
+		}
+		ran, err := safeRun(ctx, name, m, r, fn)
+		if !ran {
+			continue
+		}
+		record.Models = append(record.Models, name)
+		if err != nil {
+			r.Errors[name] = err.Error()
+		}
+	}
+	record.Errors = r.Errors
+	record.Duration = time.Since(record.Time)
+	if err := CurrentAuditLog().Record(record); err != nil {
+		return r, fmt.Errorf("privacy: record the audit log: %v", err)
+	}
+	return r, r.Err()
+}
+
+// safeRun runs fn of a model, a panic is returned as the error of the model.
+func safeRun(ctx context.Context, name string, m model, r *Result, fn func(context.Context, string, model, *Result) (bool, error)) (ran bool, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			ran, err = true, fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return fn(ctx, name, m, r)
+}
@@ -0,0 +1,84 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privacy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExportErase(t *testing.T) {
+	defer func(old map[string]model) { models = old }(models)
+	models = make(map[string]model)
+	log := NewMemoryAuditLog(2)
+	SetAuditLog(log)
+
+	users := map[string]string{"42": "astaxie"}
+	Register("users", func(ctx context.Context, subject string) (interface{}, error) {
+		return users[subject], nil
+	}, func(ctx context.Context, subject string) error {
+		delete(users, subject)
+		return nil
+	})
+	Register("orders", func(ctx context.Context, subject string) (interface{}, error) {
+		return nil, errors.New("db is down")
+	}, nil)
+	Register("logs", nil, func(ctx context.Context, subject string) error {
+		panic("boom")
+	})
+
+	if _, err := Export(context.Background(), Request{}); err != ErrNoSubject {
+		t.Errorf("no subject: %v", err)
+	}
+
+	r, err := Export(context.Background(), Request{Subject: "42", Operator: "alice"})
+	if err == nil || !strings.Contains(err.Error(), "orders: db is down") {
+		t.Errorf("export error: %v", err)
+	}
+	if r.Data["users"] != "astaxie" || len(r.Data) != 1 {
+		t.Errorf("exported data: %v", r.Data)
+	}
+
+	r, err = Erase(context.Background(), Request{Subject: "42", Operator: "bob", Reason: "ticket 7"})
+	if err == nil || r.Errors["logs"] != "panic: boom" {
+		t.Errorf("erase error: %v %v", err, r.Errors)
+	}
+	if len(r.Erased) != 1 || r.Erased[0] != "users" || users["42"] != "" {
+		t.Errorf("erased: %v %v", r.Erased, users)
+	}
+
+	records := log.Latest(10)
+	if len(records) != 2 || records[0].Action != "erase" || records[0].Operator != "bob" || records[1].Action != "export" {
+		t.Fatalf("audit records: %+v", records)
+	}
+	if strings.Join(records[0].Models, ",") != "logs,users" || strings.Join(records[1].Models, ",") != "orders,users" {
+		t.Errorf("audited models: %v %v", records[0].Models, records[1].Models)
+	}
+	Export(context.Background(), Request{Subject: "1"})
+	if records := log.Latest(10); len(records) != 2 || records[0].Subject != "1" {
+		t.Errorf("the memory log should keep the latest records: %+v", records)
+	}
+
+	var buf bytes.Buffer
+	SetAuditLog(NewJSONAuditLog(&buf))
+	defer SetAuditLog(NewMemoryAuditLog(1000))
+	Export(context.Background(), Request{Subject: "42"})
+	if !strings.Contains(buf.String(), `"action":"export","subject":"42"`) {
+		t.Errorf("json audit log: %s", buf.String())
+	}
+}
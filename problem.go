@@ -0,0 +1,83 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/astaxie/beego/context"
+)
+
+const problemDataKey = "beego.problem"
+
+// Problem is the problem details of an error response defined by RFC 7807.
+type Problem struct {
+	Type     string `json:"type,omitempty"` // about:blank if it's empty
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// WriteProblem writes the problem details of the status as application/problem+json,
+// the title is the status text and the instance is the request uri.
+func WriteProblem(ctx *context.Context, status int, detail string) {
+	writeProblem(ctx, &Problem{
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: ctx.Request.URL.RequestURI(),
+	})
+}
+
+func writeProblem(ctx *context.Context, p *Problem) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		Error("marshal the problem:", err)
+		return
+	}
+	ctx.Input.SetData(problemDataKey, p)
+	if !ctx.Written() {
+		h := ctx.ResponseWriter.Header()
+		h.Set("Content-Type", "application/problem+json")
+		h.Set("Content-Length", strconv.Itoa(len(b)))
+		ctx.ResponseWriter.WriteHeader(p.Status)
+	}
+	ctx.ResponseWriter.Write(b)
+}
+
+// problemWritten returns whether the problem of the request is already written.
+func problemWritten(ctx *context.Context) bool {
+	return ctx.Input.GetData(problemDataKey) != nil
+}
+
+// abortProblem writes the problem of ctx.Abort, the body is the detail unless it's the status code,
+// which is the key of ErrorMaps.
+func abortProblem(ctx *context.Context, status int, body string) bool {
+	if !EnableProblemJSON || ctx.Written() {
+		return false
+	}
+	if body == strconv.Itoa(status) {
+		body = ""
+	}
+	WriteProblem(ctx, status, body)
+	return true
+}
+
+func init() {
+	context.AbortHandler = abortProblem
+}
@@ -0,0 +1,113 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	gocontext "context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/astaxie/beego/context"
+)
+
+// ReadinessStats is the snapshot of the readiness and the requests in flight.
+type ReadinessStats struct {
+	Ready    bool      // the readiness probe passes
+	Draining bool      // Drain is called or the servers are shutting down
+	DrainAt  time.Time // when the drain started
+	InFlight int64     // the requests being served
+}
+
+type readinessState struct {
+	mu       sync.Mutex
+	drainAt  time.Time
+	inFlight int64
+}
+
+var readiness = &readinessState{}
+
+func (s *readinessState) begin() {
+	atomic.AddInt64(&s.inFlight, 1)
+}
+
+func (s *readinessState) end() {
+	atomic.AddInt64(&s.inFlight, -1)
+}
+
+// Drain flips the readiness probe at ReadinessPath to failing while the app keeps serving,
+// so the load balancers stop sending the traffic before the graceful shutdown begins.
+// usage:
+//
+//	beego.Drain()
+//	beego.WaitIdle(ctx)
+//	server.Shutdown(ctx)
+func Drain() *App {
+	readiness.mu.Lock()
+	if readiness.drainAt.IsZero() {
+		readiness.drainAt = time.Now()
+	}
+	readiness.mu.Unlock()
+	return BeeApp
+}
+
+// Resume flips the readiness probe back to passing, e.g. when the canary is taken back into the rotation.
+func Resume() *App {
+	readiness.mu.Lock()
+	readiness.drainAt = time.Time{}
+	readiness.mu.Unlock()
+	return BeeApp
+}
+
+// Readiness returns the readiness and the number of the requests in flight.
+func Readiness() ReadinessStats {
+	readiness.mu.Lock()
+	drainAt := readiness.drainAt
+	readiness.mu.Unlock()
+	if conns := Connections(); drainAt.IsZero() && conns.Draining {
+		drainAt = conns.DrainAt
+	}
+	return ReadinessStats{
+		Ready:    drainAt.IsZero(),
+		Draining: !drainAt.IsZero(),
+		DrainAt:  drainAt,
+		InFlight: atomic.LoadInt64(&readiness.inFlight),
+	}
+}
+
+// WaitIdle waits until no request is in flight or ctx is done.
+func WaitIdle(ctx gocontext.Context) error {
+	t := time.NewTicker(50 * time.Millisecond)
+	defer t.Stop()
+	for atomic.LoadInt64(&readiness.inFlight) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return nil
+}
+
+// serveReadiness is the readiness probe, it's 200 ok while the app is ready and 503 draining after Drain.
+func serveReadiness(ctx *context.Context) {
+	ctx.Output.Header("Cache-Control", "no-store")
+	if !Readiness().Ready {
+		ctx.Output.SetStatus(503)
+		ctx.Output.Body([]byte("draining"))
+		return
+	}
+	ctx.Output.Body([]byte("ok"))
+}
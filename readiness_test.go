@@ -0,0 +1,79 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	gocontext "context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/astaxie/beego/context"
+)
+
+func TestReadinessDrain(t *testing.T) {
+	defer Resume()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := NewControllerRegister()
+	handler.Get("/readyz", serveReadiness)
+	handler.Get("/slow", func(ctx *context.Context) {
+		close(started)
+		<-release
+	})
+
+	probe := func() int {
+		r, _ := http.NewRequest("GET", "/readyz", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+	if code := probe(); code != 200 {
+		t.Errorf("ready probe = %d", code)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r, _ := http.NewRequest("GET", "/slow", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		close(done)
+	}()
+	<-started
+	Drain()
+	stats := Readiness()
+	if stats.Ready || !stats.Draining || stats.InFlight != 1 {
+		t.Errorf("draining stats = %+v", stats)
+	}
+	if code := probe(); code != 503 {
+		t.Errorf("draining probe = %d", code)
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 10*time.Millisecond)
+	if err := WaitIdle(ctx); err == nil {
+		t.Error("WaitIdle should time out while the request is in flight")
+	}
+	cancel()
+	close(release)
+	<-done
+	if err := WaitIdle(gocontext.Background()); err != nil {
+		t.Error(err)
+	}
+
+	Resume()
+	if code := probe(); code != 200 {
+		t.Errorf("resumed probe = %d", code)
+	}
+}
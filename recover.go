@@ -0,0 +1,51 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"github.com/astaxie/beego/context"
+)
+
+// RecoverFunc is called with the request, the panic value and the stack of the goroutine
+// when a handler panics, e.g. to send the panics to an error tracker.
+type RecoverFunc func(ctx *context.Context, err interface{}, stack []byte)
+
+var recoverFuncs []RecoverFunc
+
+// AddRecoverFunc adds the function called on the panics of the handlers,
+// they're called after the panic is logged and before the error page is written.
+// usage:
+//
+//	beego.AddRecoverFunc(func(ctx *context.Context, err interface{}, stack []byte) {
+//		tracker.Report(ctx.Request, fmt.Sprint(err), stack)
+//	})
+func AddRecoverFunc(f RecoverFunc) *App {
+	recoverFuncs = append(recoverFuncs, f)
+	return BeeApp
+}
+
+// runRecoverFuncs calls the RecoverFuncs, a panicking reporter doesn't stop the others.
+func runRecoverFuncs(ctx *context.Context, err interface{}, stack []byte) {
+	for _, f := range recoverFuncs {
+		func() {
+			defer func() {
+				if e := recover(); e != nil {
+					Error("the recover func panics:", e)
+				}
+			}()
+			f(ctx, err, stack)
+		}()
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/astaxie/beego/context"
+)
+
+// ErrRenderBufferFull is returned by Render when the page is larger than RenderBufferSize,
+// set StreamRender of the controller to render the large pages.
+var ErrRenderBufferFull = errors.New("beego: the rendered page is larger than RenderBufferSize")
+
+// renderBuffer is the buffer of the rendered templates, it refuses to grow over max bytes.
+type renderBuffer struct {
+	bytes.Buffer
+	max int64
+}
+
+func newRenderBuffer() *renderBuffer {
+	return &renderBuffer{max: RenderBufferSize}
+}
+
+func (b *renderBuffer) Write(p []byte) (int, error) {
+	if b.max > 0 && int64(b.Len()+len(p)) > b.max {
+		return 0, ErrRenderBufferFull
+	}
+	return b.Buffer.Write(p)
+}
+
+// renderError answers the request whose template failed to render.
+// Nothing of the buffered page is sent, so the client gets a clean 500, the error details are shown in dev mode.
+// A streamed page which is partly sent is cut off.
+func renderError(ctx *context.Context, err error) {
+	Error("the request url is", ctx.Input.URL(), "template render err:", err)
+	if ctx.Written() {
+		return
+	}
+	if RunMode == "dev" {
+		showErr(err, ctx, "")
+		return
+	}
+	exception("500", ctx)
+}
@@ -0,0 +1,151 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ResponseSizePolicy decides what happens to the responses larger than the max response size.
+type ResponseSizePolicy int
+
+const (
+	// ResponseSizeAbort replaces the response by 500 and logs the error,
+	// a response whose size isn't known until it's partly sent is cut off.
+	ResponseSizeAbort ResponseSizePolicy = iota
+	// ResponseSizeTruncate sends the first max bytes of the response and logs a warning,
+	// the X-Response-Truncated header is set if the size is known before the header is sent.
+	ResponseSizeTruncate
+)
+
+// ResponseTruncatedHeader is set to the original size on the responses truncated by ResponseSizeTruncate.
+const ResponseTruncatedHeader = "X-Response-Truncated"
+
+// ErrResponseTooLarge is returned by the writes over the max response size.
+var ErrResponseTooLarge = errors.New("beego: response is larger than the max response size")
+
+var responseSizePolicies = map[string]ResponseSizePolicy{
+	"abort":    ResponseSizeAbort,
+	"truncate": ResponseSizeTruncate,
+}
+
+// responseSizeRouter stores the max response size of the routers matched by pattern.
+type responseSizeRouter struct {
+	tree    *Tree
+	pattern string
+	size    int64
+	policy  ResponseSizePolicy
+}
+
+// SetMaxResponseSize sets the max response size of the requests matched by pattern, it overrides MaxResponseSize.
+// It guards against the runaway responses, such as an unbounded query result marshalled to json,
+// the static files aren't limited. A zero size disables the limit for the pattern.
+// usage:
+//
+//	SetMaxResponseSize("/api/*", 10<<20, ResponseSizeAbort)
+//	SetMaxResponseSize("/logs/*", 1<<20, ResponseSizeTruncate)
+func (p *ControllerRegister) SetMaxResponseSize(pattern string, size int64, policy ResponseSizePolicy) {
+	rr := &responseSizeRouter{
+		tree:    NewTree(),
+		pattern: pattern,
+		size:    size,
+		policy:  policy,
+	}
+	if !RouterCaseSensitive {
+		pattern = strings.ToLower(pattern)
+	}
+	rr.tree.AddRouter(pattern, true)
+	p.responseSizes = append(p.responseSizes, rr)
+}
+
+// maxResponseSizeFor returns the max response size and policy of the request path, the latest matched pattern wins.
+func (p *ControllerRegister) maxResponseSizeFor(urlPath string) (int64, ResponseSizePolicy) {
+	for i := len(p.responseSizes) - 1; i >= 0; i-- {
+		if ok, _ := p.responseSizes[i].tree.Match(urlPath); ok != nil {
+			return p.responseSizes[i].size, p.responseSizes[i].policy
+		}
+	}
+	return MaxResponseSize, responseSizePolicies[MaxResponseSizePolicy]
+}
+
+// responseLimit enforces the max response size on a responseWriter.
+type responseLimit struct {
+	size     int64
+	policy   ResponseSizePolicy
+	path     string
+	exceeded bool
+}
+
+// writeHeader checks the Content-Length before the header is sent,
+// it returns true if the response is replaced by 500.
+func (l *responseLimit) writeHeader(w *responseWriter, code int) bool {
+	h := w.writer.Header()
+	cl, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64)
+	if err != nil || cl <= l.size {
+		return false
+	}
+	l.exceeded = true
+	if l.policy == ResponseSizeTruncate {
+		Warn(fmt.Sprintf("the response of %s is truncated, %d bytes is larger than the limit %d", l.path, cl, l.size))
+		h.Set("Content-Length", strconv.FormatInt(l.size, 10))
+		h.Set(ResponseTruncatedHeader, strconv.FormatInt(cl, 10))
+		return false
+	}
+	Error(fmt.Sprintf("the response of %s is aborted, %d bytes is larger than the limit %d", l.path, cl, l.size))
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.status = http.StatusInternalServerError
+	w.started = true
+	w.wroteHeader = true
+	w.writer.WriteHeader(http.StatusInternalServerError)
+	n, _ := w.writer.Write([]byte(http.StatusText(http.StatusInternalServerError) + "\n"))
+	w.size += int64(n)
+	// drop the rest of the original response
+	l.size = 0
+	return true
+}
+
+// write writes the part of p under the limit.
+func (l *responseLimit) write(w *responseWriter, p []byte) (int, error) {
+	rest := l.size - w.size
+	if int64(len(p)) <= rest {
+		n, err := w.writer.Write(p)
+		w.size += int64(n)
+		return n, err
+	}
+	if !l.exceeded {
+		l.exceeded = true
+		if l.policy == ResponseSizeTruncate {
+			Warn(fmt.Sprintf("the response of %s is truncated at the limit %d", l.path, l.size))
+		} else {
+			Error(fmt.Sprintf("the response of %s is cut off at the limit %d", l.path, l.size))
+		}
+	}
+	if l.policy == ResponseSizeAbort || rest <= 0 {
+		return 0, ErrResponseTooLarge
+	}
+	n, err := w.writer.Write(p[:rest])
+	w.size += int64(n)
+	if err == nil {
+		err = ErrResponseTooLarge
+	}
+	return n, err
+}
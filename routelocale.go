@@ -0,0 +1,81 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"github.com/astaxie/beego/context"
+)
+
+const routeLocaleDataKey = "Locale"
+
+// WithTranslations adds the translated patterns of the router keyed by the locale,
+// they dispatch to the same controller and the matched locale is set on the context, see RouteLocale.
+// URLForLocale and Controller.URLForName generate the url of the translated pattern.
+// The SLO of WithSLO is observed for each pattern on its own.
+// usage:
+//
+//	AddWithOptions("/products/:slug", &ProductController{}, WithName("product"),
+//		WithTranslations(map[string]string{"en": "/en/products/:slug", "de": "/de/produkte/:slug"}))
+func WithTranslations(patterns map[string]string) RouterOption {
+	return func(o *routerOptions) {
+		if o.translations == nil {
+			o.translations = make(map[string]string, len(patterns))
+		}
+		for locale, pattern := range patterns {
+			o.translations[locale] = pattern
+		}
+	}
+}
+
+// translate returns the copy of the route for the translated pattern.
+func (c *controllerInfo) translate(locale, pattern string) *controllerInfo {
+	t := *c
+	t.pattern = pattern
+	t.locale = locale
+	t.paramTypes = nil
+	t.catchAll = ""
+	t.translations = nil
+	if c.slo != nil {
+		// the copy is observed under its own pattern
+		t.slo = &routeSLO{slo: c.slo.slo}
+	}
+	if c.translations == nil {
+		c.translations = make(map[string]*controllerInfo)
+	}
+	c.translations[locale] = &t
+	return &t
+}
+
+// RouteLocale returns the locale of the translated route matched by the request, it's empty for the other routes.
+func RouteLocale(ctx *context.Context) string {
+	v, _ := ctx.Input.GetData(routeLocaleDataKey).(string)
+	return v
+}
+
+// URLForLocale same as URLForName, but uses the pattern translated to locale if the route has it.
+// usage:
+//
+//	URLForLocale("product", "de", ":slug", "tisch") // /de/produkte/tisch
+func (p *ControllerRegister) URLForLocale(name, locale string, values ...interface{}) string {
+	route, ok := p.namedRoute(name)
+	if !ok {
+		Warn("urlforlocale: unknown route name", name)
+		return ""
+	}
+	if t, ok := route.translations[locale]; ok {
+		route = t
+	}
+	return routeURL(name, route, values)
+}
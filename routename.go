@@ -0,0 +1,153 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// WithName names the router, URLForName generates its url by the name,
+// so the links don't depend on the controller and method names.
+// The name must be unique in the ControllerRegister.
+func WithName(name string) RouterOption {
+	return func(o *routerOptions) {
+		o.name = name
+	}
+}
+
+// addName registers the named route, it panics if the name is already used.
+func (p *ControllerRegister) addName(name string, route *controllerInfo) {
+	if name == "" {
+		return
+	}
+	if p.names == nil {
+		p.names = make(map[string]*controllerInfo)
+	}
+	if r, ok := p.names[name]; ok && r != route {
+		panic("the route name '" + name + "' is already used by " + r.pattern)
+	}
+	p.names[name] = route
+}
+
+// routeParamRegexp matches the parameters of a pattern segment: :id, ?:id, :id:int and :id([0-9]+).
+var routeParamRegexp = regexp.MustCompile(`(\?)?:(\w+)(:\w+|\([^)]*\))?`)
+
+// URLForName returns the url of the route named by WithName, values are the key-value pairs of the parameters,
+// the ones which aren't in the pattern are appended as the query string.
+// It returns "" if the name is unknown or a required parameter is missing.
+// usage:
+//
+//	AddWithOptions("/user/:id", &UserController{}, WithName("user.show"))
+//	URLForName("user.show", ":id", 5, "tab", "posts") // /user/5?tab=posts
+func (p *ControllerRegister) URLForName(name string, values ...interface{}) string {
+	route, ok := p.namedRoute(name)
+	if !ok {
+		Warn("urlforname: unknown route name", name)
+		return ""
+	}
+	return routeURL(name, route, values)
+}
+
+// namedRoute returns the route named name, the routers added by Host are searched after the others.
+func (p *ControllerRegister) namedRoute(name string) (*controllerInfo, bool) {
+	if route, ok := p.names[name]; ok {
+		return route, true
+	}
+	for _, h := range p.hosts {
+		if route, ok := h.handlers.names[name]; ok {
+			return route, true
+		}
+	}
+	return nil, false
+}
+
+// routeURL builds the url of the named route with the key-value pairs of the parameters.
+func routeURL(name string, route *controllerInfo, values []interface{}) string {
+	if len(values)%2 != 0 {
+		Warn("urlforname params must key-value pair")
+		return ""
+	}
+	params := make(map[string]string, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		params[fmt.Sprint(values[i])] = fmt.Sprint(values[i+1])
+	}
+
+	missing := ""
+	for _, variant := range patternVariants(route.pattern) {
+		var u string
+		if u, missing = buildURL(variant, params); missing == "" {
+			return u
+		}
+	}
+	Warn("urlforname: route", name, "requires", missing)
+	return ""
+}
+
+// buildURL fills the params in pattern, the others are appended as the query string.
+// It returns the name of the first required param which is missing.
+func buildURL(pattern string, values map[string]string) (string, string) {
+	params := make(map[string]string, len(values))
+	for k, v := range values {
+		params[k] = v
+	}
+	segments := strings.Split(pattern, "/")
+	url := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		switch {
+		case seg == "*.*":
+			p, e := params[":path"], params[":ext"]
+			if p == "" || e == "" {
+				return "", ":path"
+			}
+			delete(params, ":path")
+			delete(params, ":ext")
+			seg = p + "." + e
+		case strings.HasPrefix(seg, "*"):
+			key := ":splat"
+			if name, _ := catchAllName(seg); name != "" {
+				key = name
+			}
+			seg = params[key]
+			delete(params, key)
+		default:
+			missing := ""
+			seg = routeParamRegexp.ReplaceAllStringFunc(seg, func(m string) string {
+				sub := routeParamRegexp.FindStringSubmatch(m)
+				key := ":" + sub[2]
+				v, ok := params[key]
+				if !ok && sub[1] == "" {
+					missing = key
+				}
+				delete(params, key)
+				return v
+			})
+			if missing != "" {
+				return "", missing
+			}
+		}
+		if seg != "" || len(url) == 0 {
+			url = append(url, seg)
+		}
+	}
+	u := strings.Join(url, "/")
+	if u == "" {
+		u = "/"
+	} else if strings.HasSuffix(pattern, "/") && !strings.HasSuffix(u, "/") {
+		u += "/"
+	}
+	return u + tourl(params), ""
+}
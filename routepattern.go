@@ -0,0 +1,81 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"regexp"
+	"strings"
+)
+
+// The router patterns support two explicit forms besides the ":id", "?:id" and "*" segments:
+//
+//	/posts(/:page)         the group is optional, it matches /posts and /posts/2
+//	/archive(/:year(/:mon)) the groups can be nested, it matches /archive, /archive/2015 and /archive/2015/06
+//	/files/*filepath       the catch-all matches the rest of the path, it's the param ":filepath"
+//
+// A group is optional only if it starts with "(/", the other parentheses are the regexps of the params.
+// An optional pattern is added as all its variants, the precedence of the tree applies to each of them:
+// the fixed segments match before the params, the params before the catch-all.
+// The catch-all value is also kept as ":splat" for the code written for the "*" segment.
+
+var catchAllRegexp = regexp.MustCompile(`^\*(\w+)$`)
+
+// patternVariants expands the optional groups of pattern, the longest variant first.
+func patternVariants(pattern string) []string {
+	i := strings.Index(pattern, "(/")
+	if i < 0 {
+		return []string{pattern}
+	}
+	depth, j := 0, i
+	for ; j < len(pattern); j++ {
+		if pattern[j] == '(' {
+			depth++
+		} else if pattern[j] == ')' {
+			depth--
+			if depth == 0 {
+				break
+			}
+		}
+	}
+	if j == len(pattern) {
+		panic("the optional group of the router pattern " + pattern + " isn't closed")
+	}
+	head, group, rest := pattern[:i], pattern[i+1:j], pattern[j+1:]
+	var variants []string
+	for _, g := range patternVariants(group) {
+		for _, r := range patternVariants(rest) {
+			variants = append(variants, head+g+r)
+		}
+	}
+	for _, r := range patternVariants(rest) {
+		variants = append(variants, head+r)
+	}
+	if variants[len(variants)-1] == "" {
+		variants[len(variants)-1] = "/"
+	}
+	return variants
+}
+
+// catchAllName returns the param name of the named catch-all of pattern, e.g. ":filepath" of "/files/*filepath",
+// and the pattern with the plain "*" the tree understands.
+func catchAllName(pattern string) (string, string) {
+	segments := strings.Split(pattern, "/")
+	last := segments[len(segments)-1]
+	if m := catchAllRegexp.FindStringSubmatch(last); m != nil {
+		segments[len(segments)-1] = "*"
+		return ":" + m[1], strings.Join(segments, "/")
+	}
+	return "", pattern
+}
@@ -16,8 +16,10 @@ package beego
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
@@ -25,8 +27,11 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	beecontext "github.com/astaxie/beego/context"
@@ -110,24 +115,126 @@ type controllerInfo struct {
 	handler        http.Handler
 	runFunction    FilterFunc
 	routerType     int
+	filters        []FilterFunc
+
+	requestTransformers  []RequestTransformer
+	responseTransformers []ResponseTransformer
+	doc                  *routeDoc
+	paramTypes           map[string]*paramType
+	catchAll             string // the param name of the named catch-all
+	journal              Journal
+	slo                  *routeSLO
+	coalesce             *coalescer
+	locale               string                     // the locale of a translated route
+	translations         map[string]*controllerInfo // the translated routes keyed by the locale
+	variant              *routeVariant
+	methodIndexes        map[string]int // the index of the custom methods on the controller pointer type
+}
+
+// RouterOption configures a single router, see AddWithOptions.
+type RouterOption func(*routerOptions)
+
+type routerOptions struct {
+	mappingMethods       string
+	name                 string
+	filters              []FilterFunc
+	requestTransformers  []RequestTransformer
+	responseTransformers []ResponseTransformer
+	doc                  *routeDoc
+	journal              Journal
+	slo                  *routeSLO
+	coalesce             *coalescer
+	translations         map[string]string
+	variant              *routeVariant
+}
+
+// WithMethods maps the http methods to the controller methods, same as the mappingMethods of Add.
+func WithMethods(mappingMethods string) RouterOption {
+	return func(o *routerOptions) {
+		o.mappingMethods = mappingMethods
+	}
+}
+
+// WithFilters adds filters which only run when this router matches the request.
+// They run after the BeforeExec filters, the request stops if a filter writes output.
+func WithFilters(filters ...FilterFunc) RouterOption {
+	return func(o *routerOptions) {
+		o.filters = append(o.filters, filters...)
+	}
+}
+
+// WithNamedFilters same as WithFilters, but with the filters registered by RegisterFilter.
+// It panics if a filter isn't registered.
+// usage:
+//	AddWithOptions("/admin", &AdminController{}, WithNamedFilters("auth", "audit"))
+func WithNamedFilters(names ...string) RouterOption {
+	filters := mustGetFilters(names)
+	return func(o *routerOptions) {
+		o.filters = append(o.filters, filters...)
+	}
+}
+
+func newRouterOptions(opts []RouterOption) *routerOptions {
+	o := &routerOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
 }
 
 // ControllerRegister containers registered router rules, controller handlers and filters.
 type ControllerRegister struct {
-	routers      map[string]*Tree
-	enableFilter bool
-	filters      map[int][]*FilterRouter
+	routers       map[string]*Tree
+	enableFilter  bool
+	filters       map[int][]*FilterRouter
+	headers       []*headerRouter
+	timeouts      []*timeoutRouter
+	bodySizes     []*bodySizeRouter
+	responseSizes []*responseSizeRouter
+	middlewares   []MiddleWare
+	chain         http.Handler
+	names         map[string]*controllerInfo
+	hosts         []*hostRouter
+	versions      []*versionRouter
+	// the contexts, response writers and matched params are reused by the requests, see Context.Reset
+	contextPool sync.Pool
+	writerPool  sync.Pool
+	paramsPool  sync.Pool
 }
 
 // NewControllerRegister returns a new ControllerRegister.
 func NewControllerRegister() *ControllerRegister {
-	return &ControllerRegister{
+	p := &ControllerRegister{
 		routers: make(map[string]*Tree),
 		filters: make(map[int][]*FilterRouter),
 	}
+	p.contextPool.New = func() interface{} {
+		return &beecontext.Context{Input: beecontext.NewInput(nil), Output: beecontext.NewOutput()}
+	}
+	p.writerPool.New = func() interface{} {
+		return &responseWriter{}
+	}
+	p.paramsPool.New = func() interface{} {
+		return &treeParams{}
+	}
+	return p
+}
+
+// releaseContext puts the context and the response writer of a finished request back into the pools,
+// unless the context is retained by Context.Retain. It waits for the functions started by ctx.Go first,
+// so a context isn't reused while they are running.
+func (p *ControllerRegister) releaseContext(ctx *beecontext.Context, w *responseWriter) {
+	if ctx.Retained() {
+		return
+	}
+	ctx.Wait()
+	p.contextPool.Put(ctx)
+	p.writerPool.Put(w)
 }
 
 // Add controller handler and pattern rules to ControllerRegister.
+// Without a mapping only the methods the controller implements, e.g. Get and Post, are routed,
+// the others are answered with 405 and the Allow header, and OPTIONS with the Allow header.
 // usage:
 //	default methods is the same name as method
 //	Add("/user",&UserController{})
@@ -137,12 +244,27 @@ func NewControllerRegister() *ControllerRegister {
 //	Add("/api/delete",&RestController{},"delete:DeleteFood")
 //	Add("/api",&RestController{},"get,post:ApiFunc")
 //	Add("/simple",&SimpleController{},"get:GetFunc;post:PostFunc")
+//	Add("/posts(/:page)",&PostController{})
+//	Add("/files/*filepath",&FileController{})
 func (p *ControllerRegister) Add(pattern string, c ControllerInterface, mappingMethods ...string) {
+	var opts []RouterOption
+	if len(mappingMethods) > 0 {
+		opts = append(opts, WithMethods(mappingMethods[0]))
+	}
+	p.AddWithOptions(pattern, c, opts...)
+}
+
+// AddWithOptions same as Add, but the router is configured with RouterOption.
+// usage:
+//	AddWithOptions("/admin", &AdminController{}, WithFilters(authFilter, auditFilter))
+//	AddWithOptions("/api", &RestController{}, WithMethods("get:List;post:Create"), WithFilters(authFilter))
+func (p *ControllerRegister) AddWithOptions(pattern string, c ControllerInterface, opts ...RouterOption) {
+	o := newRouterOptions(opts)
 	reflectVal := reflect.ValueOf(c)
 	t := reflect.Indirect(reflectVal).Type()
 	methods := make(map[string]string)
-	if len(mappingMethods) > 0 {
-		semi := strings.Split(mappingMethods[0], ";")
+	if o.mappingMethods != "" {
+		semi := strings.Split(o.mappingMethods, ";")
 		for _, v := range semi {
 			colon := strings.Split(v, ":")
 			if len(colon) != 2 {
@@ -168,12 +290,31 @@ func (p *ControllerRegister) Add(pattern string, c ControllerInterface, mappingM
 	route.methods = methods
 	route.routerType = routerTypeBeego
 	route.controllerType = t
-	if len(methods) == 0 {
-		for _, m := range HTTPMETHOD {
+	route.filters = o.filters
+	route.requestTransformers = o.requestTransformers
+	route.responseTransformers = o.responseTransformers
+	route.doc = o.doc
+	route.journal = o.journal
+	route.slo = o.slo
+	route.coalesce = o.coalesce
+	route.cacheMethods()
+	route.setVariant(o.variant)
+	p.addName(o.name, route)
+	p.addControllerRoute(pattern, route)
+	for locale, pattern := range o.translations {
+		p.addControllerRoute(pattern, route.translate(locale, pattern))
+	}
+}
+
+// addControllerRoute adds the controller router for its mapped methods, or the methods its controller implements,
+// the other methods are answered with 405 and the Allow header.
+func (p *ControllerRegister) addControllerRoute(pattern string, route *controllerInfo) {
+	if len(route.methods) == 0 {
+		for _, m := range route.httpMethods() {
 			p.addToRouter(m, pattern, route)
 		}
 	} else {
-		for k := range methods {
+		for k := range route.methods {
 			if k == "*" {
 				for _, m := range HTTPMETHOD {
 					p.addToRouter(m, pattern, route)
@@ -185,16 +326,105 @@ func (p *ControllerRegister) Add(pattern string, c ControllerInterface, mappingM
 	}
 }
 
+// controllerHTTPMethods maps the http methods to the request functions of Controller.
+var controllerHTTPMethods = map[string]string{
+	"GET":     "Get",
+	"POST":    "Post",
+	"PUT":     "Put",
+	"DELETE":  "Delete",
+	"PATCH":   "Patch",
+	"OPTIONS": "Options",
+	"HEAD":    "Head",
+}
+
+// httpMethods returns the http methods whose request function the controller of the router, or of its variant, overrides.
+// All the methods are returned for a controller overriding none, it may answer the requests in Prepare.
+func (c *controllerInfo) httpMethods() []string {
+	types := []reflect.Type{reflect.PtrTo(c.controllerType)}
+	if c.variant != nil && c.variant.route != nil {
+		types = append(types, reflect.PtrTo(c.variant.route.controllerType))
+	}
+	var methods []string
+	for m, name := range controllerHTTPMethods {
+		for _, t := range types {
+			if overridesMethod(t, name) {
+				methods = append(methods, m)
+				break
+			}
+		}
+	}
+	if len(methods) == 0 {
+		for _, m := range HTTPMETHOD {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+var baseControllerType = reflect.TypeOf(&Controller{})
+
+// overridesMethod reports whether the method name of the pointer type t isn't the one of the embedded *Controller.
+// The methods promoted from an embedded field are compiler generated wrappers, they are followed to the field.
+func overridesMethod(t reflect.Type, name string) bool {
+	if t == baseControllerType {
+		return false
+	}
+	m, ok := t.MethodByName(name)
+	if !ok {
+		return false
+	}
+	if !generatedMethod(m) {
+		return true
+	}
+	if m, ok := t.Elem().MethodByName(name); ok && !generatedMethod(m) {
+		return true
+	}
+	if t.Elem().Kind() != reflect.Struct {
+		return true
+	}
+	for i := 0; i < t.Elem().NumField(); i++ {
+		f := t.Elem().Field(i)
+		if !f.Anonymous {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() != reflect.Ptr {
+			ft = reflect.PtrTo(ft)
+		}
+		if _, ok := ft.MethodByName(name); ok {
+			return overridesMethod(ft, name)
+		}
+	}
+	return true
+}
+
+func generatedMethod(m reflect.Method) bool {
+	f := runtime.FuncForPC(m.Func.Pointer())
+	if f == nil {
+		return false
+	}
+	file, _ := f.FileLine(f.Entry())
+	return file == "<autogenerated>"
+}
+
 func (p *ControllerRegister) addToRouter(method, pattern string, r *controllerInfo) {
-	if !RouterCaseSensitive {
-		pattern = strings.ToLower(pattern)
+	if r.paramTypes == nil {
+		r.paramTypes = typedParams(pattern)
 	}
-	if t, ok := p.routers[method]; ok {
-		t.AddRouter(pattern, r)
-	} else {
-		t := NewTree()
-		t.AddRouter(pattern, r)
-		p.routers[method] = t
+	for _, variant := range patternVariants(pattern) {
+		name, variant := catchAllName(variant)
+		if name != "" {
+			r.catchAll = name
+		}
+		if !RouterCaseSensitive {
+			variant = strings.ToLower(variant)
+		}
+		t, ok := p.routers[method]
+		if !ok {
+			t = NewTree()
+			p.routers[method] = t
+		}
+		t.AddRouter(variant, r)
 	}
 }
 
@@ -234,7 +464,20 @@ func (p *ControllerRegister) Include(cList ...ControllerInterface) {
 		key := t.PkgPath() + ":" + t.Name()
 		if comm, ok := GlobalControllerRouter[key]; ok {
 			for _, a := range comm {
-				p.Add(a.Router, c, strings.Join(a.AllowHTTPMethods, ",")+":"+a.Method)
+				// the filters of the annotations only run for the route of the method
+				var filters []FilterFunc
+				for _, name := range a.Filters {
+					f, ok := GetFilter(name)
+					if !ok {
+						// the route fails closed, e.g. if the missing filter is an authentication
+						Error(fmt.Sprintf("beego: unknown filter %q of %s.%s (forgotten RegisterFilter?)", name, key, a.Method))
+						f = func(ctx *beecontext.Context) {
+							exception("500", ctx)
+						}
+					}
+					filters = append(filters, f)
+				}
+				p.AddWithOptions(a.Router, c, WithMethods(strings.Join(a.AllowHTTPMethods, ",")+":"+a.Method), WithSummary(a.Summary), WithFilters(filters...))
 			}
 		}
 	}
@@ -245,8 +488,8 @@ func (p *ControllerRegister) Include(cList ...ControllerInterface) {
 //    Get("/", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func (p *ControllerRegister) Get(pattern string, f FilterFunc) {
-	p.AddMethod("get", pattern, f)
+func (p *ControllerRegister) Get(pattern string, f FilterFunc, opts ...RouterOption) {
+	p.AddMethod("get", pattern, f, opts...)
 }
 
 // Post add post method
@@ -254,8 +497,8 @@ func (p *ControllerRegister) Get(pattern string, f FilterFunc) {
 //    Post("/api", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func (p *ControllerRegister) Post(pattern string, f FilterFunc) {
-	p.AddMethod("post", pattern, f)
+func (p *ControllerRegister) Post(pattern string, f FilterFunc, opts ...RouterOption) {
+	p.AddMethod("post", pattern, f, opts...)
 }
 
 // Put add put method
@@ -263,8 +506,8 @@ func (p *ControllerRegister) Post(pattern string, f FilterFunc) {
 //    Put("/api/:id", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func (p *ControllerRegister) Put(pattern string, f FilterFunc) {
-	p.AddMethod("put", pattern, f)
+func (p *ControllerRegister) Put(pattern string, f FilterFunc, opts ...RouterOption) {
+	p.AddMethod("put", pattern, f, opts...)
 }
 
 // Delete add delete method
@@ -272,8 +515,8 @@ func (p *ControllerRegister) Put(pattern string, f FilterFunc) {
 //    Delete("/api/:id", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func (p *ControllerRegister) Delete(pattern string, f FilterFunc) {
-	p.AddMethod("delete", pattern, f)
+func (p *ControllerRegister) Delete(pattern string, f FilterFunc, opts ...RouterOption) {
+	p.AddMethod("delete", pattern, f, opts...)
 }
 
 // Head add head method
@@ -281,8 +524,8 @@ func (p *ControllerRegister) Delete(pattern string, f FilterFunc) {
 //    Head("/api/:id", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func (p *ControllerRegister) Head(pattern string, f FilterFunc) {
-	p.AddMethod("head", pattern, f)
+func (p *ControllerRegister) Head(pattern string, f FilterFunc, opts ...RouterOption) {
+	p.AddMethod("head", pattern, f, opts...)
 }
 
 // Patch add patch method
@@ -290,8 +533,8 @@ func (p *ControllerRegister) Head(pattern string, f FilterFunc) {
 //    Patch("/api/:id", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func (p *ControllerRegister) Patch(pattern string, f FilterFunc) {
-	p.AddMethod("patch", pattern, f)
+func (p *ControllerRegister) Patch(pattern string, f FilterFunc, opts ...RouterOption) {
+	p.AddMethod("patch", pattern, f, opts...)
 }
 
 // Options add options method
@@ -299,8 +542,8 @@ func (p *ControllerRegister) Patch(pattern string, f FilterFunc) {
 //    Options("/api/:id", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func (p *ControllerRegister) Options(pattern string, f FilterFunc) {
-	p.AddMethod("options", pattern, f)
+func (p *ControllerRegister) Options(pattern string, f FilterFunc, opts ...RouterOption) {
+	p.AddMethod("options", pattern, f, opts...)
 }
 
 // Any add all method
@@ -308,8 +551,8 @@ func (p *ControllerRegister) Options(pattern string, f FilterFunc) {
 //    Any("/api/:id", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func (p *ControllerRegister) Any(pattern string, f FilterFunc) {
-	p.AddMethod("*", pattern, f)
+func (p *ControllerRegister) Any(pattern string, f FilterFunc, opts ...RouterOption) {
+	p.AddMethod("*", pattern, f, opts...)
 }
 
 // AddMethod add http method router
@@ -317,7 +560,7 @@ func (p *ControllerRegister) Any(pattern string, f FilterFunc) {
 //    AddMethod("get","/api/:id", func(ctx *context.Context){
 //          ctx.Output.Body("hello world")
 //    })
-func (p *ControllerRegister) AddMethod(method, pattern string, f FilterFunc) {
+func (p *ControllerRegister) AddMethod(method, pattern string, f FilterFunc, opts ...RouterOption) {
 	if _, ok := HTTPMETHOD[strings.ToUpper(method)]; method != "*" && !ok {
 		panic("not support http method: " + method)
 	}
@@ -325,6 +568,15 @@ func (p *ControllerRegister) AddMethod(method, pattern string, f FilterFunc) {
 	route.pattern = pattern
 	route.routerType = routerTypeRESTFul
 	route.runFunction = f
+	o := newRouterOptions(opts)
+	route.filters = o.filters
+	route.requestTransformers = o.requestTransformers
+	route.responseTransformers = o.responseTransformers
+	route.doc = o.doc
+	route.journal = o.journal
+	route.slo = o.slo
+	route.coalesce = o.coalesce
+	p.addName(o.name, route)
 	methods := make(map[string]string)
 	if method == "*" {
 		for _, val := range HTTPMETHOD {
@@ -334,6 +586,7 @@ func (p *ControllerRegister) AddMethod(method, pattern string, f FilterFunc) {
 		methods[strings.ToUpper(method)] = strings.ToUpper(method)
 	}
 	route.methods = methods
+	route.setVariant(o.variant)
 	for k := range methods {
 		if k == "*" {
 			for _, m := range HTTPMETHOD {
@@ -346,6 +599,8 @@ func (p *ControllerRegister) AddMethod(method, pattern string, f FilterFunc) {
 }
 
 // Handler add user defined Handler
+// options can be a bool which also matches one path segment under pattern, as pattern/?:all, and RouterOption.
+// Register pattern/* too for all the nested urls.
 func (p *ControllerRegister) Handler(pattern string, h http.Handler, options ...interface{}) {
 	route := &controllerInfo{}
 	route.pattern = pattern
@@ -356,6 +611,15 @@ func (p *ControllerRegister) Handler(pattern string, h http.Handler, options ...
 			pattern = path.Join(pattern, "?:all")
 		}
 	}
+	var opts []RouterOption
+	for _, option := range options {
+		if opt, ok := option.(RouterOption); ok {
+			opts = append(opts, opt)
+		}
+	}
+	o := newRouterOptions(opts)
+	route.filters = o.filters
+	p.addName(o.name, route)
 	for _, m := range HTTPMETHOD {
 		p.addToRouter(m, pattern, route)
 	}
@@ -386,6 +650,7 @@ func (p *ControllerRegister) AddAutoPrefix(prefix string, c ControllerInterface)
 			route.routerType = routerTypeBeego
 			route.methods = map[string]string{"*": rt.Method(i).Name}
 			route.controllerType = ct
+			route.cacheMethods()
 			pattern := path.Join(prefix, strings.ToLower(controllerName), strings.ToLower(rt.Method(i).Name), "*")
 			patternInit := path.Join(prefix, controllerName, rt.Method(i).Name, "*")
 			patternfix := path.Join(prefix, strings.ToLower(controllerName), strings.ToLower(rt.Method(i).Name))
@@ -404,7 +669,29 @@ func (p *ControllerRegister) AddAutoPrefix(prefix string, c ControllerInterface)
 // InsertFilter Add a FilterFunc with pattern rule and action constant.
 // The bool params is for setting the returnOnOutput value (false allows multiple filters to execute)
 func (p *ControllerRegister) InsertFilter(pattern string, pos int, filter FilterFunc, params ...bool) error {
+	return p.insertFilterRouter(pos, newFilterRouter(pattern, filter, params...))
+}
+
+// InsertNamedFilter adds the filter registered by RegisterFilter with pattern rule and action constant.
+func (p *ControllerRegister) InsertNamedFilter(pattern string, pos int, name string, params ...bool) error {
+	filter, ok := GetFilter(name)
+	if !ok {
+		return fmt.Errorf("beego: unknown filter %q (forgotten RegisterFilter?)", name)
+	}
+	return p.InsertFilter(pattern, pos, filter, params...)
+}
 
+// When returns a FilterCondition, the filters inserted by it only run when cond returns true.
+// cond is evaluated before the filter pattern is matched, so it should be cheap.
+// usage:
+//	When(func(ctx *context.Context) bool {
+//		return ctx.Input.Header("X-Debug") != ""
+//	}).InsertFilter("*", BeforeRouter, debugFilter)
+func (p *ControllerRegister) When(cond FilterCond) *FilterCondition {
+	return &FilterCondition{handlers: p, cond: cond}
+}
+
+func newFilterRouter(pattern string, filter FilterFunc, params ...bool) *FilterRouter {
 	mr := new(FilterRouter)
 	mr.tree = NewTree()
 	mr.pattern = pattern
@@ -418,12 +705,32 @@ func (p *ControllerRegister) InsertFilter(pattern string, pos int, filter Filter
 		mr.returnOnOutput = params[0]
 	}
 	mr.tree.AddRouter(pattern, true)
+	return mr
+}
+
+// InsertFilterPriority adds a FilterFunc like InsertFilter with an explicit priority.
+// Filters with a lower priority run first at the same position, filters added by
+// InsertFilter have priority 0 and filters with the same priority run in registration order.
+// usage:
+//	InsertFilterPriority("*", BeforeRouter, -10, authFilter)
+//	InsertFilterPriority("*", BeforeRouter, 10, auditFilter)
+func (p *ControllerRegister) InsertFilterPriority(pattern string, pos int, priority int, filter FilterFunc, params ...bool) error {
+	mr := newFilterRouter(pattern, filter, params...)
+	mr.priority = priority
 	return p.insertFilterRouter(pos, mr)
 }
 
-// add Filter into
+// add Filter into, the filters are kept sorted by priority.
 func (p *ControllerRegister) insertFilterRouter(pos int, mr *FilterRouter) error {
-	p.filters[pos] = append(p.filters[pos], mr)
+	l := p.filters[pos]
+	i := len(l)
+	for i > 0 && l[i-1].priority > mr.priority {
+		i--
+	}
+	l = append(l, nil)
+	copy(l[i+1:], l[i:])
+	l[i] = mr
+	p.filters[pos] = l
 	p.enableFilter = true
 	return nil
 }
@@ -573,31 +880,152 @@ func (p *ControllerRegister) geturl(t *Tree, url, controllName, methodName strin
 	return false, ""
 }
 
+// routed reports whether a router of the http method matches urlPath.
+func (p *ControllerRegister) routed(host, method, urlPath string) bool {
+	for _, m := range p.allowMethods(host, urlPath) {
+		if m == method && m != "OPTIONS" {
+			return true
+		}
+	}
+	return false
+}
+
+// MiddleWare wraps an http.Handler, it is compatible with the common net/http middlewares.
+type MiddleWare func(http.Handler) http.Handler
+
+// allowMethods returns the sorted http methods which have a router matching urlPath,
+// the routers of the request host included, OPTIONS is included as it is answered automatically.
+func (p *ControllerRegister) allowMethods(host, urlPath string) []string {
+	routers := []map[string]*Tree{p.routers}
+	if h, _ := p.matchHost(host); h != nil {
+		routers = append(routers, h.handlers.routers)
+	}
+	var allow []string
+	allowed := make(map[string]bool)
+	for _, methodRouters := range routers {
+		for m, t := range methodRouters {
+			if m == "OPTIONS" || allowed[m] {
+				continue
+			}
+			var params treeParams
+			if obj := t.matchParams(urlPath, &params); obj != nil {
+				if r, ok := obj.(*controllerInfo); ok && !r.validParams(&params) {
+					continue
+				}
+				allowed[m] = true
+				allow = append(allow, m)
+			}
+		}
+	}
+	if len(allow) > 0 {
+		allow = append(allow, "OPTIONS")
+		sort.Strings(allow)
+	}
+	return allow
+}
+
+// Use appends net/http middlewares which wrap the whole request handling, filters included.
+// The first middleware is the outermost one. It should be called before the server starts.
+// usage:
+//	Use(func(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			w.Header().Set("X-Frame-Options", "DENY")
+//			next.ServeHTTP(w, r)
+//		})
+//	})
+func (p *ControllerRegister) Use(mws ...MiddleWare) {
+	p.middlewares = append(p.middlewares, mws...)
+	var h http.Handler = http.HandlerFunc(p.serveHTTP)
+	for i := len(p.middlewares) - 1; i >= 0; i-- {
+		h = p.middlewares[i](h)
+	}
+	p.chain = h
+}
+
 // Implement http.Handler interface.
 func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	readiness.begin()
+	if status, reason := checkRequestHeaders(r); status != 0 {
+		readiness.end()
+		http.Error(rw, reason, status)
+		return
+	}
+	if timeout := p.timeoutFor(r.URL.Path); timeout > 0 {
+		// the request stays in flight until its handler returns, also after the timeout
+		p.serveTimeout(rw, r, timeout)
+		return
+	}
+	defer readiness.end()
+	p.serve(rw, r)
+}
+
+func (p *ControllerRegister) serve(rw http.ResponseWriter, r *http.Request) {
+	if p.chain != nil {
+		p.chain.ServeHTTP(rw, r)
+		return
+	}
+	p.serveHTTP(rw, r)
+}
+
+func (p *ControllerRegister) serveHTTP(rw http.ResponseWriter, r *http.Request) {
 	starttime := time.Now()
 	var runrouter reflect.Type
 	var findrouter bool
 	var runMethod string
 	var routerInfo *controllerInfo
+	var journalEntry *JournalEntry
+	var ctrlPool *sync.Pool
+	var pooledController ControllerInterface
+
+	w := p.writerPool.Get().(*responseWriter)
+	*w = responseWriter{writer: rw}
+
+	if EnableAdmin {
+		toolbox.Metrics.Begin()
+		defer toolbox.Metrics.End()
+	}
 
-	w := &responseWriter{writer: rw}
+	var allocs *allocMeter
+	if EnableAllocStats {
+		allocs = newAllocMeter()
+		w.onHeader = allocs.serverTiming
+	}
+
+	var watched *watchedRequest
+	if WatchdogThreshold > 0 {
+		watched = watchdog.watch(r.Method, r.URL.Path)
+		defer watchdog.done(watched)
+	}
 
 	if RunMode == "dev" {
 		w.Header().Set("Server", BeegoServerName)
 	}
 
 	// init context
-	context := &beecontext.Context{
-		ResponseWriter: w,
-		Request:        r,
-		Input:          beecontext.NewInput(r),
-		Output:         beecontext.NewOutput(),
-	}
-	context.Output.Context = context
+	context := p.contextPool.Get().(*beecontext.Context)
+	context.Reset(w, r)
+	// it runs last, after the deferred functions below
+	defer p.releaseContext(context, w)
 	context.Output.EnableGzip = EnableGzip
+	context.Output.JSONPrefix = JSONPrefix
+	context.Output.JSONNoEscapeHTML = !JSONEscapeHTML
+	context.Output.ServeFormat = ServeFormat
+	context.Output.ServeIndent = RunMode == "dev"
 
 	defer p.recoverPanic(context)
+	// wait for the functions started by context.Go, also when the handler panics, stops or aborts
+	waited := false
+	waitWorkers := func() {
+		waited = true
+		if err := context.Wait(); err != nil {
+			Error("request worker error:", err)
+		}
+	}
+	defer func() {
+		if !waited {
+			waitWorkers()
+		}
+	}()
 
 	var urlPath string
 	if !RouterCaseSensitive {
@@ -605,6 +1033,17 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 	} else {
 		urlPath = r.URL.Path
 	}
+
+	version, urlPath, byAccept := p.matchVersion(r.Header.Get("Accept"), urlPath)
+	if byAccept {
+		w.Header().Add("Vary", "Accept")
+	}
+	if version != nil {
+		context.Input.SetData(apiVersionDataKey, version.version)
+	}
+
+	p.setDefaultHeaders(w.Header(), urlPath)
+
 	// defined filter function
 	doFilter := func(pos int) (started bool) {
 		if p.enableFilter {
@@ -613,12 +1052,15 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 					if filterR.returnOnOutput && w.started {
 						return true
 					}
+					if context.Canceled() {
+						return true
+					}
+					if filterR.cond != nil && !filterR.cond(context) {
+						continue
+					}
 					if ok, params := filterR.ValidRouter(urlPath); ok {
 						for k, v := range params {
-							if context.Input.Params == nil {
-								context.Input.Params = make(map[string]string)
-							}
-							context.Input.Params[k] = v
+							context.Input.SetParam(k, v)
 						}
 						filterR.filterFunc(context)
 					}
@@ -642,12 +1084,18 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 		goto Admin
 	}
 
-	serverStaticRouter(context)
+	serverStaticRouter(context, func(urlPath string) bool {
+		return p.routed(r.Host, r.Method, urlPath)
+	})
 	if w.started {
 		findrouter = true
 		goto Admin
 	}
 
+	if size, policy := p.maxResponseSizeFor(urlPath); size > 0 {
+		w.limit = &responseLimit{size: size, policy: policy, path: r.URL.Path}
+	}
+
 	// session init
 	if SessionOn {
 		var err error
@@ -663,10 +1111,22 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 	}
 
 	if r.Method != "GET" && r.Method != "HEAD" {
+		var body *maxBodyReader
+		if size := p.maxBodySizeFor(urlPath); size > 0 {
+			var ok bool
+			if body, ok = limitBody(w, context.Request, size); !ok {
+				exception("413", context)
+				goto Admin
+			}
+		}
 		if CopyRequestBody && !context.Input.IsUpload() {
 			context.Input.CopyBody()
 		}
 		context.Input.ParseFormOrMulitForm(MaxMemory)
+		if body != nil && body.exceeded {
+			exception("413", context)
+			goto Admin
+		}
 	}
 
 	if doFilter(BeforeRouter) {
@@ -690,27 +1150,71 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 			httpMethod = "DELETE"
 		}
 
-		if t, ok := p.routers[httpMethod]; ok {
-			runObject, p := t.Match(urlPath)
-			if r, ok := runObject.(*controllerInfo); ok {
-				routerInfo = r
-				findrouter = true
-				if splat, ok := p[":splat"]; ok {
-					splatlist := strings.Split(splat, "/")
-					for k, v := range splatlist {
-						p[strconv.Itoa(k)] = v
+		var routers []map[string]*Tree
+		if version != nil {
+			routers = append(routers, version.handlers.routers)
+		}
+		host, hostParams := p.matchHost(r.Host)
+		if host != nil {
+			routers = append(routers, host.handlers.routers)
+		}
+		routers = append(routers, p.routers)
+		// the params are matched into a reused slice, then copied into the input
+		params := p.paramsPool.Get().(*treeParams)
+		for _, methodRouters := range routers {
+			if t, ok := methodRouters[httpMethod]; ok {
+				params.reset()
+				runObject := t.matchParams(urlPath, params)
+				if r, ok := runObject.(*controllerInfo); ok && r.validParams(params) {
+					routerInfo = r
+					findrouter = true
+					context.Input.RouterPattern = r.pattern
+					if r.locale != "" {
+						context.Input.SetData(routeLocaleDataKey, r.locale)
 					}
-				}
-				if p != nil {
-					context.Input.Params = p
+					for i, k := range params.keys {
+						v := params.values[i]
+						context.Input.SetParam(k, v)
+						if k == ":splat" {
+							if r.catchAll != "" {
+								context.Input.SetParam(r.catchAll, v)
+							}
+							splatlist := strings.Split(v, "/")
+							for k, v := range splatlist {
+								context.Input.SetParam(strconv.Itoa(k), v)
+							}
+						}
+					}
+					break
 				}
 			}
 		}
+		p.paramsPool.Put(params)
+		for k, v := range hostParams {
+			context.Input.SetParam(k, v)
+		}
+		// the variant is chosen once the params are set, so the splitter can look at them
+		if routerInfo != nil {
+			routerInfo = routerInfo.choose(context)
+		}
+		if watched != nil && routerInfo != nil {
+			watchdog.route(watched, routerInfo.pattern)
+		}
 
 	}
 
 	//if no matches to url, throw a not found exception
 	if !findrouter {
+		//the url matches other methods, answer OPTIONS or throw a method not allowed exception
+		if allow := p.allowMethods(r.Host, urlPath); len(allow) > 0 {
+			w.Header().Set("Allow", strings.Join(allow, ", "))
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				exception("405", context)
+			}
+			goto Admin
+		}
 		exception("404", context)
 		goto Admin
 	}
@@ -720,6 +1224,66 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 		if doFilter(BeforeExec) {
 			goto Admin
 		}
+		//execute the filters of the router
+		if routerInfo != nil {
+			routerInfo.setTransformers(context)
+			for _, f := range routerInfo.filters {
+				f(context)
+				if w.started {
+					goto Admin
+				}
+			}
+		}
+		// the client is gone, don't start the handler
+		if context.Canceled() {
+			goto Admin
+		}
+		// share the execution of the identical concurrent GET requests
+		if routerInfo != nil && routerInfo.coalesce != nil && r.Method == "GET" && routerInfo.routerType != routerTypeHandler {
+			call, leader := routerInfo.coalesce.join(context)
+			if call == nil {
+				// not coalesced
+			} else if !leader {
+				if call.wait(context) {
+					call.replay(w)
+					goto Admin
+				}
+				if context.Canceled() {
+					goto Admin
+				}
+			} else {
+				rec := &coalesceRecorder{ResponseWriter: w.writer, max: CoalesceMaxBytes}
+				w.writer = rec
+				c := routerInfo.coalesce
+				defer func() {
+					// StopRun ends the handler normally
+					err := recover()
+					c.finish(call, rec, err == nil || err == ErrAbort)
+					if err != nil {
+						panic(err)
+					}
+				}()
+			}
+		}
+		// persist the request before the handler runs, it's marked done below unless the handler panics
+		if routerInfo != nil && routerInfo.journal != nil {
+			entry, err := routerInfo.journalRequest(context)
+			if err != nil {
+				Error("journal the request:", err)
+				exception("503", context)
+				goto Admin
+			}
+			journalEntry = entry
+			defer func() {
+				// StopRun ends the handler normally
+				if err := recover(); err != nil {
+					if err == ErrAbort {
+						routerInfo.journalDone(entry, responseStatus(context, w))
+					}
+					panic(err)
+				}
+			}()
+		}
 		isRunable := false
 		if routerInfo != nil {
 			if routerInfo.routerType == routerTypeRESTFul {
@@ -727,12 +1291,13 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 					isRunable = true
 					routerInfo.runFunction(context)
 				} else {
+					w.Header().Set("Allow", strings.Join(p.allowMethods(r.Host, urlPath), ", "))
 					exception("405", context)
 					goto Admin
 				}
 			} else if routerInfo.routerType == routerTypeHandler {
 				isRunable = true
-				routerInfo.handler.ServeHTTP(rw, r)
+				routerInfo.handler.ServeHTTP(rw, context.Request)
 			} else {
 				runrouter = routerInfo.controllerType
 				method := r.Method
@@ -755,7 +1320,7 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 		// also defined runrouter & runMethod from filter
 		if !isRunable {
 			//Invoke the request handler
-			vc := reflect.New(runrouter)
+			vc, pool := newController(runrouter)
 			execController, ok := vc.Interface().(ControllerInterface)
 			if !ok {
 				panic("controller is not ControllerInterface")
@@ -763,6 +1328,9 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 
 			//call the controller init function
 			execController.Init(context, runrouter.Name(), runMethod, vc.Interface())
+			if h, ok := execController.(interface{ setHandlers(*ControllerRegister) }); ok {
+				h.setHandlers(p)
+			}
 
 			//call prepare function
 			execController.Prepare()
@@ -778,7 +1346,7 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 
 			execController.URLMapping()
 
-			if !w.started {
+			if !w.started && !context.Canceled() {
 				//exec main logic
 				switch runMethod {
 				case "GET":
@@ -797,9 +1365,11 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 					execController.Options()
 				default:
 					if !execController.HandlerFunc(runMethod) {
-						var in []reflect.Value
-						method := vc.MethodByName(runMethod)
-						method.Call(in)
+						var indexes map[string]int
+						if routerInfo != nil {
+							indexes = routerInfo.methodIndexes
+						}
+						dispatchMethod(vc, execController, runMethod, indexes)
 					}
 				}
 
@@ -807,7 +1377,7 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 				if !w.started && context.Output.Status == 0 {
 					if AutoRender {
 						if err := execController.Render(); err != nil {
-							panic(err)
+							renderError(context, err)
 						}
 					}
 				}
@@ -815,6 +1385,8 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 
 			// finish all runrouter. release resource
 			execController.Finish()
+			// the pooled controller is released once the workers of the request are done
+			ctrlPool, pooledController = pool, execController
 		}
 
 		//execute middleware filters
@@ -826,14 +1398,34 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 	doFilter(FinishRouter)
 
 Admin:
+	waitWorkers()
+	if ctrlPool != nil {
+		releaseController(ctrlPool, pooledController)
+	}
+
+	if journalEntry != nil {
+		routerInfo.journalDone(journalEntry, responseStatus(context, w))
+	}
+
 	timeend := time.Since(starttime)
+	if watched != nil && timeend >= time.Duration(WatchdogThreshold)*time.Millisecond {
+		Warn(slowRequestReport(context, watched, timeend))
+	}
+	if allocs != nil {
+		bytes, objects, _ := allocs.delta()
+		toolbox.Allocs.Observe(r.Method, context.Input.RouterPattern, bytes, objects)
+	}
+	if routerInfo != nil && routerInfo.slo != nil {
+		routerInfo.observeSLO(responseStatus(context, w), timeend)
+	}
 	//admin module record QPS
 	if EnableAdmin {
 		if FilterMonitorFunc(r.Method, r.URL.Path, timeend) {
+			toolbox.Metrics.Observe(r.Method, context.Input.RouterPattern, responseStatus(context, w), timeend, w.size)
 			if runrouter != nil {
-				go toolbox.StatisticsMap.AddStatistics(r.Method, r.URL.Path, runrouter.Name(), timeend)
+				go toolbox.StatisticsMap.AddStatistics(r.Method, context.Input.RouterPattern, runrouter.Name(), timeend)
 			} else {
-				go toolbox.StatisticsMap.AddStatistics(r.Method, r.URL.Path, "", timeend)
+				go toolbox.StatisticsMap.AddStatistics(r.Method, context.Input.RouterPattern, "", timeend)
 			}
 		}
 	}
@@ -849,11 +1441,19 @@ Admin:
 		} else {
 			devinfo = fmt.Sprintf("| % -10s | % -40s | % -16s | % -10s |", r.Method, r.URL.Path, timeend.String(), "notmatch")
 		}
-		if DefaultAccessLogFilter == nil || !DefaultAccessLogFilter.Filter(context) {
-			Debug(devinfo)
+		if logAccess(context, responseStatus(context, w), timeend) {
+			if accessLogSink != nil {
+				writeAccessLog(context, w, starttime, timeend)
+			} else {
+				Debug(devinfo)
+			}
 		}
 	}
 
+	// the header of an empty response is sent by net/http
+	if !w.wroteHeader {
+		w.beforeHeader()
+	}
 	// Call WriteHeader if status code has been set changed
 	if context.Output.Status != 0 {
 		w.writer.WriteHeader(context.Output.Status)
@@ -866,8 +1466,13 @@ func (p *ControllerRegister) recoverPanic(context *beecontext.Context) {
 			return
 		}
 		if !RecoverPanic {
+			runRecoverFuncs(context, err, debug.Stack())
 			panic(err)
 		} else {
+			// the problem is already written by ctx.Abort
+			if problemWritten(context) {
+				return
+			}
 			if EnableErrorsShow {
 				if _, ok := ErrorMaps[fmt.Sprint(err)]; ok {
 					exception(fmt.Sprint(err), context)
@@ -885,19 +1490,41 @@ func (p *ControllerRegister) recoverPanic(context *beecontext.Context) {
 				Critical(fmt.Sprintf("%s:%d", file, line))
 				stack = stack + fmt.Sprintln(fmt.Sprintf("%s:%d", file, line))
 			}
-			if RunMode == "dev" {
+			runRecoverFuncs(context, err, debug.Stack())
+			if EnableProblemJSON {
+				var detail string
+				if RunMode == "dev" {
+					detail = fmt.Sprint(err)
+				}
+				WriteProblem(context, http.StatusInternalServerError, detail)
+			} else if RunMode == "dev" {
 				showErr(err, context, stack)
 			}
 		}
 	}
 }
 
+// responseStatus returns the status sent or to be sent for the request.
+func responseStatus(ctx *beecontext.Context, w *responseWriter) int {
+	if w.status != 0 {
+		return w.status
+	}
+	if ctx.Output.Status != 0 {
+		return ctx.Output.Status
+	}
+	return http.StatusOK
+}
+
 //responseWriter is a wrapper for the http.ResponseWriter
 //started set to true if response was written to then don't execute other handler
 type responseWriter struct {
-	writer  http.ResponseWriter
-	started bool
-	status  int
+	writer      http.ResponseWriter
+	started     bool
+	status      int
+	wroteHeader bool
+	size        int64
+	limit       *responseLimit
+	onHeader    func(http.Header) // called once before the header is sent
 }
 
 // Header returns the header map that will be sent by WriteHeader.
@@ -909,24 +1536,89 @@ func (w *responseWriter) Header() http.Header {
 // and sets `started` to true.
 // started means the response has sent out.
 func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.limit != nil {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		return w.limit.write(w, p)
+	}
 	w.started = true
-	return w.writer.Write(p)
+	if !w.wroteHeader {
+		// net/http writes the status 200 before the first body
+		w.beforeHeader()
+		w.wroteHeader = true
+		w.status = http.StatusOK
+	}
+	n, err := w.writer.Write(p)
+	w.size += int64(n)
+	return n, err
 }
 
 // WriteHeader sends an HTTP response header with status code,
 // and sets `started` to true.
+// The header can only be sent once, the following calls are ignored
+// and logged with the stack of the caller.
 func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		Warn(fmt.Sprintf("superfluous WriteHeader(%d), the status %d is already sent, called by:\n%s", code, w.status, callerStack(2, 8)))
+		return
+	}
+	w.beforeHeader()
+	if w.limit != nil && w.limit.writeHeader(w, code) {
+		return
+	}
 	w.status = code
 	w.started = true
+	w.wroteHeader = true
 	w.writer.WriteHeader(code)
 }
 
+// beforeHeader runs onHeader once, the header can still be modified.
+func (w *responseWriter) beforeHeader() {
+	if w.onHeader != nil {
+		f := w.onHeader
+		w.onHeader = nil
+		f(w.writer.Header())
+	}
+}
+
+// Written returns whether the status code is sent, by WriteHeader or the first Write.
+// Filters and controllers can check it with ctx.Written().
+func (w *responseWriter) Written() bool {
+	return w.wroteHeader
+}
+
+// Status returns the status code sent, it's 0 before the response is written.
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// callerStack returns at most depth frames of the stack, skip is the same as runtime.Caller.
+func callerStack(skip, depth int) string {
+	var buf bytes.Buffer
+	for i := skip; i < skip+depth; i++ {
+		pc, file, line, ok := runtime.Caller(i)
+		if !ok {
+			break
+		}
+		name := "???"
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			name = fn.Name()
+		}
+		fmt.Fprintf(&buf, "\t%s:%d %s\n", file, line, name)
+	}
+	return buf.String()
+}
+
 // hijacker for http
 func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	hj, ok := w.writer.(http.Hijacker)
 	if !ok {
 		return nil, nil, errors.New("webserver doesn't support hijacking")
 	}
+	// the connection belongs to the caller now, nothing else should be written
+	w.started = true
+	w.wroteHeader = true
 	return hj.Hijack()
 }
 
@@ -937,6 +1629,50 @@ func (w *responseWriter) Flush() {
 	}
 }
 
+// Push implements http.Pusher, it returns http.ErrNotSupported if the underlying writer doesn't support HTTP/2 push.
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.writer.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// ReadFrom implements io.ReaderFrom, so the server can use sendfile when serving files.
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.limit != nil {
+		return io.Copy(struct{ io.Writer }{w}, r)
+	}
+	w.started = true
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = http.StatusOK
+	}
+	var n int64
+	var err error
+	if rf, ok := w.writer.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{w.writer}, r)
+	}
+	w.size += n
+	return n, err
+}
+
+// CloseNotify implements http.CloseNotifier, the channel never receives if the underlying writer doesn't support it.
+// Deprecated: use Request.Context().Done() instead.
+func (w *responseWriter) CloseNotify() <-chan bool {
+	if cn, ok := w.writer.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// Unwrap returns the underlying http.ResponseWriter, it's used by http.ResponseController
+// and middlewares which check the interfaces of the original writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.writer
+}
+
 func tourl(params map[string]string) string {
 	if len(params) == 0 {
 		return ""
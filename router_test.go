@@ -15,12 +15,25 @@
 package beego
 
 import (
+	"bufio"
+	"bytes"
+	gocontext "context"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/logs"
+	"github.com/astaxie/beego/swagger"
+	"github.com/astaxie/beego/toolbox"
 )
 
 type TestController struct {
@@ -333,6 +346,30 @@ func TestRouterHandler(t *testing.T) {
 	}
 }
 
+func TestRouterHandlerPrefix(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Handler("/dav", http.HandlerFunc(sayhello), true)
+	// the nested paths are matched by a splat, like webdav.Mount does
+	handler.Handler("/files", http.HandlerFunc(sayhello))
+	handler.Handler("/files/*", http.HandlerFunc(sayhello))
+	for url, matched := range map[string]bool{
+		"/dav":           true,
+		"/dav/a":         true,
+		"/dav/a/b/c.txt": false,
+		"/davx":          false,
+		"/files":         true,
+		"/files/a":       true,
+		"/files/a/b.txt": true,
+		"/filesx":        false,
+	} {
+		rw, r := testRequest("PUT", url)
+		handler.ServeHTTP(rw, r)
+		if (rw.Body.String() == "sayhello") != matched {
+			t.Errorf("TestRouterHandlerPrefix %s: matched should be %v, got %d", url, matched, rw.Code)
+		}
+	}
+}
+
 //
 // Benchmarks NewApp:
 //
@@ -380,6 +417,45 @@ func BenchmarkController(b *testing.B) {
 	}
 }
 
+type jsonController struct {
+	Controller
+}
+
+func (c *jsonController) Get() {
+	c.Data["json"] = map[string]string{"id": c.Ctx.Input.Param(":id")}
+	c.ServeJSON()
+}
+
+type pooledJSONController struct {
+	jsonController
+	requests int
+}
+
+func (c *pooledJSONController) Reset() {
+	c.requests = 0
+}
+
+func BenchmarkControllerJSON(b *testing.B) {
+	benchmarkController(b, &jsonController{})
+}
+
+func BenchmarkControllerJSONPooled(b *testing.B) {
+	benchmarkController(b, &pooledJSONController{})
+}
+
+func benchmarkController(b *testing.B, c ControllerInterface) {
+	defer func(mode string) { RunMode = mode }(RunMode)
+	RunMode = "prod"
+	mux := NewControllerRegister()
+	mux.Add("/user/:id", c)
+	r, _ := http.NewRequest("GET", "/user/1", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+	}
+}
+
 func testRequest(method, path string) (*httptest.ResponseRecorder, *http.Request) {
 	request, _ := http.NewRequest(method, path, nil)
 	recorder := httptest.NewRecorder()
@@ -579,3 +655,1500 @@ func beegoFinishRouter1(ctx *context.Context) {
 func beegoFinishRouter2(ctx *context.Context) {
 	ctx.WriteString("|FinishRouter2")
 }
+
+func TestRouterDefaultHeader(t *testing.T) {
+	mux := NewControllerRegister()
+	mux.DefaultHeader("/api/*", "Cache-Control", "no-store")
+	mux.Get("/api/user", func(ctx *context.Context) {
+		ctx.Output.Body([]byte("user"))
+	})
+	mux.Get("/home", func(ctx *context.Context) {
+		ctx.Output.Body([]byte("home"))
+	})
+
+	rw, r := testRequest("GET", "/api/user")
+	mux.ServeHTTP(rw, r)
+	if rw.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("TestRouterDefaultHeader should set Cache-Control for /api/user")
+	}
+
+	rw, r = testRequest("GET", "/home")
+	mux.ServeHTTP(rw, r)
+	if rw.Header().Get("Cache-Control") != "" {
+		t.Errorf("TestRouterDefaultHeader should not set Cache-Control for /home")
+	}
+}
+
+func TestRouterGroup(t *testing.T) {
+	mux := NewControllerRegister()
+	v1 := mux.Group("/api/v1")
+	v1.Filter(BeforeExec, func(ctx *context.Context) {
+		ctx.Output.Header("X-Group", "v1")
+	})
+	v1.Get("/ping", func(ctx *context.Context) {
+		ctx.WriteString("pong")
+	})
+	v1.Group("/user").Router("/:id", &TestController{}, "get:Param")
+	mux.Get("/ping", func(ctx *context.Context) {
+		ctx.WriteString("root")
+	})
+
+	rw, r := testRequest("GET", "/api/v1/ping")
+	mux.ServeHTTP(rw, r)
+	if rw.Body.String() != "pong" || rw.Header().Get("X-Group") != "v1" {
+		t.Errorf("TestRouterGroup /api/v1/ping can't run: %s", rw.Body.String())
+	}
+
+	rw, r = testRequest("GET", "/api/v1/user/astaxie")
+	mux.ServeHTTP(rw, r)
+	if rw.Header().Get("X-Group") != "v1" {
+		t.Errorf("TestRouterGroup group filter should run for nested groups")
+	}
+
+	rw, r = testRequest("GET", "/ping")
+	mux.ServeHTTP(rw, r)
+	if rw.Body.String() != "root" || rw.Header().Get("X-Group") != "" {
+		t.Errorf("TestRouterGroup group filter should not run out of the group")
+	}
+}
+
+func TestRouterUse(t *testing.T) {
+	mux := NewControllerRegister()
+	mux.Get("/user", func(ctx *context.Context) {
+		ctx.WriteString(ctx.Request.Header.Get("X-Order"))
+	})
+	mw := func(name string) MiddleWare {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.Header.Set("X-Order", r.Header.Get("X-Order")+name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	mux.Use(mw("a"), mw("b"))
+	mux.Use(mw("c"))
+
+	rw, r := testRequest("GET", "/user")
+	mux.ServeHTTP(rw, r)
+	if rw.Body.String() != "abc" {
+		t.Errorf("TestRouterUse middlewares run in wrong order: %s", rw.Body.String())
+	}
+}
+
+func TestRouterWithFilters(t *testing.T) {
+	mux := NewControllerRegister()
+	auth := func(ctx *context.Context) {
+		if ctx.Input.Query("token") == "" {
+			ctx.Output.SetStatus(401)
+			ctx.Output.Body([]byte("unauthorized"))
+		}
+	}
+	mux.AddWithOptions("/admin", &TestController{}, WithMethods("get:List"), WithFilters(auth))
+	mux.Add("/user", &TestController{}, "get:List")
+	mux.Get("/ping", func(ctx *context.Context) {
+		ctx.WriteString("pong")
+	}, WithFilters(auth))
+
+	rw, r := testRequest("GET", "/admin")
+	mux.ServeHTTP(rw, r)
+	if rw.Code != 401 {
+		t.Errorf("TestRouterWithFilters router filter should stop the request, got %d", rw.Code)
+	}
+
+	rw, r = testRequest("GET", "/admin?token=1")
+	mux.ServeHTTP(rw, r)
+	if rw.Body.String() != "i am list" {
+		t.Errorf("TestRouterWithFilters /admin can't run: %s", rw.Body.String())
+	}
+
+	rw, r = testRequest("GET", "/user")
+	mux.ServeHTTP(rw, r)
+	if rw.Body.String() != "i am list" {
+		t.Errorf("TestRouterWithFilters router filter should not run for other routers")
+	}
+
+	rw, r = testRequest("GET", "/ping")
+	mux.ServeHTTP(rw, r)
+	if rw.Code != 401 {
+		t.Errorf("TestRouterWithFilters router filter should run for function routers")
+	}
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	mux := NewControllerRegister()
+	mux.Add("/user", &TestController{}, "get:List;post:Post")
+	mux.Put("/user", func(ctx *context.Context) {})
+
+	rw, r := testRequest("DELETE", "/user")
+	mux.ServeHTTP(rw, r)
+	if rw.Code != 405 || rw.Header().Get("Allow") != "GET, OPTIONS, POST, PUT" {
+		t.Errorf("TestRouterMethodNotAllowed want 405 with Allow header, got %d %q", rw.Code, rw.Header().Get("Allow"))
+	}
+
+	rw, r = testRequest("OPTIONS", "/user")
+	mux.ServeHTTP(rw, r)
+	if rw.Code != 200 || rw.Header().Get("Allow") != "GET, OPTIONS, POST, PUT" {
+		t.Errorf("TestRouterMethodNotAllowed OPTIONS should be answered, got %d %q", rw.Code, rw.Header().Get("Allow"))
+	}
+
+	rw, r = testRequest("DELETE", "/nothing")
+	mux.ServeHTTP(rw, r)
+	if rw.Code != 404 {
+		t.Errorf("TestRouterMethodNotAllowed unknown url should be 404, got %d", rw.Code)
+	}
+}
+
+func TestRouterDoubleWriteHeader(t *testing.T) {
+	mux := NewControllerRegister()
+	mux.Get("/user", func(ctx *context.Context) {
+		if ctx.Written() {
+			t.Error("TestRouterDoubleWriteHeader nothing is written yet")
+		}
+		ctx.ResponseWriter.WriteHeader(201)
+		ctx.ResponseWriter.WriteHeader(500)
+		if !ctx.Written() {
+			t.Error("TestRouterDoubleWriteHeader Written should be true after WriteHeader")
+		}
+	})
+
+	rw, r := testRequest("GET", "/user")
+	mux.ServeHTTP(rw, r)
+	if rw.Code != 201 {
+		t.Errorf("TestRouterDoubleWriteHeader the second WriteHeader should be ignored, got %d", rw.Code)
+	}
+}
+
+func TestResponseWriterInterfaces(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &responseWriter{writer: rec}
+	var _ http.Pusher = w
+	var _ io.ReaderFrom = w
+	if w.Unwrap() != rec {
+		t.Error("Unwrap should return the underlying writer")
+	}
+	if err := w.Push("/static/app.js", nil); err != http.ErrNotSupported {
+		t.Error("Push should not be supported by the recorder", err)
+	}
+	if n, _ := w.ReadFrom(strings.NewReader("hello")); n != 5 || rec.Body.String() != "hello" || !w.Written() {
+		t.Error("ReadFrom should write into the underlying writer")
+	}
+}
+
+func TestRouterWebSocket(t *testing.T) {
+	mux := NewControllerRegister()
+	mux.InsertFilter("/ws", BeforeRouter, func(ctx *context.Context) {
+		if ctx.Input.Query("token") == "" {
+			ctx.Output.SetStatus(401)
+			ctx.Output.Body([]byte("unauthorized"))
+		}
+	})
+	mux.WebSocket("/ws", func(ctx *context.Context, conn net.Conn) {
+		io.Copy(conn, conn)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 401 {
+		t.Errorf("TestRouterWebSocket filters should run before the handshake, got %d", resp.StatusCode)
+	}
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "GET /ws?token=1 HTTP/1.1\r\nHost: "+strings.TrimPrefix(ts.URL, "http://")+
+		"\r\nConnection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\nping")
+	br := bufio.NewReader(conn)
+	resp, err = http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 101 || resp.Header.Get("Sec-Websocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("TestRouterWebSocket handshake failed: %d %s", resp.StatusCode, resp.Header.Get("Sec-Websocket-Accept"))
+	}
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(br, b); err != nil || string(b) != "ping" {
+		t.Errorf("TestRouterWebSocket the connection should be echoed: %q %v", b, err)
+	}
+}
+
+type requestKey struct{}
+
+func TestRouterRequestContext(t *testing.T) {
+	mux := NewControllerRegister()
+	mux.InsertFilter("/ctx", BeforeExec, func(ctx *context.Context) {
+		ctx.WithValue(requestKey{}, "astaxie")
+	})
+	mux.Get("/ctx", func(ctx *context.Context) {
+		if _, ok := ctx.Context().Deadline(); !ok {
+			ctx.WriteString("no deadline ")
+		}
+		ctx.WriteString(ctx.Value(requestKey{}).(string))
+	})
+	mux.Handler("/handler", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Context().Value(requestKey{}).(string)))
+	}))
+	mux.InsertFilter("/handler", BeforeExec, func(ctx *context.Context) {
+		ctx.WithValue(requestKey{}, "handler")
+	})
+
+	rw, r := testRequest("GET", "/ctx")
+	mux.ServeHTTP(rw, r)
+	if rw.Body.String() != "no deadline astaxie" {
+		t.Errorf("TestRouterRequestContext value is not passed to the handler: %q", rw.Body.String())
+	}
+	rw, r = testRequest("GET", "/handler")
+	mux.ServeHTTP(rw, r)
+	if rw.Body.String() != "handler" {
+		t.Errorf("TestRouterRequestContext value is not passed to the http.Handler: %q", rw.Body.String())
+	}
+
+	rw, r = testRequest("GET", "/ctx")
+	c, cancel := gocontext.WithCancel(r.Context())
+	cancel()
+	mux.ServeHTTP(rw, r.WithContext(c))
+	if rw.Body.Len() != 0 {
+		t.Errorf("TestRouterRequestContext handler should not run after the client is gone: %q", rw.Body.String())
+	}
+}
+
+func TestRouterTimeout(t *testing.T) {
+	mux := NewControllerRegister()
+	mux.SetTimeout("/slow/*", 20*time.Millisecond)
+	mux.SetTimeout("/slow/stream", 0)
+	mux.Get("/slow/wait", func(ctx *context.Context) {
+		<-ctx.Context().Done()
+		ctx.WriteString("too late")
+	})
+	mux.Get("/slow/partial", func(ctx *context.Context) {
+		ctx.WriteString("partial")
+		<-ctx.Context().Done()
+		ctx.WriteString(" too late")
+	})
+	mux.Get("/slow/stream", func(ctx *context.Context) {
+		if _, ok := ctx.Context().Deadline(); ok {
+			ctx.WriteString("deadline")
+			return
+		}
+		ctx.WriteString("stream")
+	})
+
+	rw, r := testRequest("GET", "/slow/wait")
+	mux.ServeHTTP(rw, r)
+	if rw.Code != 503 || strings.Contains(rw.Body.String(), "too late") {
+		t.Errorf("TestRouterTimeout should answer 503, got %d %q", rw.Code, rw.Body.String())
+	}
+
+	rw, r = testRequest("GET", "/slow/partial")
+	mux.ServeHTTP(rw, r)
+	if rw.Code != 200 || rw.Body.String() != "partial" {
+		t.Errorf("TestRouterTimeout should cut off the partial response, got %d %q", rw.Code, rw.Body.String())
+	}
+
+	rw, r = testRequest("GET", "/slow/stream")
+	mux.ServeHTTP(rw, r)
+	if rw.Body.String() != "stream" {
+		t.Errorf("TestRouterTimeout zero timeout should disable the timeout, got %q", rw.Body.String())
+	}
+}
+
+func TestRouterPattern(t *testing.T) {
+	mux := NewControllerRegister()
+	mux.Get("/user/:id", func(ctx *context.Context) {
+		ctx.WriteString(ctx.Input.RouterPattern)
+	})
+	rw, r := testRequest("GET", "/user/1")
+	mux.ServeHTTP(rw, r)
+	if rw.Body.String() != "/user/:id" {
+		t.Errorf("TestRouterPattern should keep the matched pattern, got %q", rw.Body.String())
+	}
+}
+
+type testAccessLogSink struct {
+	records []*AccessLogRecord
+}
+
+func (s *testAccessLogSink) WriteAccessLog(r *AccessLogRecord) error {
+	s.records = append(s.records, r)
+	return nil
+}
+
+func TestRouterAccessLogSink(t *testing.T) {
+	sink := &testAccessLogSink{}
+	SetAccessLogSink(sink)
+	defer SetAccessLogSink(nil)
+
+	mux := NewControllerRegister()
+	mux.Get("/user/:id", func(ctx *context.Context) {
+		ctx.Output.SetStatus(201)
+		ctx.Output.Body([]byte("created"))
+	})
+	rw, r := testRequest("GET", "/user/1")
+	r.Header.Set("User-Agent", "beego-test")
+	r.RemoteAddr = "10.0.0.1:1234"
+	mux.ServeHTTP(rw, r)
+
+	if len(sink.records) != 1 {
+		t.Fatal("TestRouterAccessLogSink should write one record, got", len(sink.records))
+	}
+	rec := sink.records[0]
+	if rec.Method != "GET" || rec.Path != "/user/1" || rec.Pattern != "/user/:id" || rec.Status != 201 ||
+		rec.Bytes != 7 || rec.RemoteIP != "10.0.0.1" || rec.UserAgent != "beego-test" {
+		t.Errorf("TestRouterAccessLogSink unexpected record %+v", rec)
+	}
+
+	var buf bytes.Buffer
+	NewJSONAccessLogSink(&buf).WriteAccessLog(rec)
+	if !strings.Contains(buf.String(), `"pattern":"/user/:id"`) || !strings.HasSuffix(buf.String(), "}\n") {
+		t.Errorf("TestRouterAccessLogSink unexpected json %s", buf.String())
+	}
+}
+
+func TestRouterAccessLogRedact(t *testing.T) {
+	sink := &testAccessLogSink{}
+	SetAccessLogSink(sink)
+	redactor, _ := logs.NewRedactor(`{"keys":["token"],"cardNumbers":true}`)
+	BeeLogger.SetRedactor(redactor)
+	defer func() {
+		SetAccessLogSink(nil)
+		BeeLogger.SetRedactor(nil)
+	}()
+
+	mux := NewControllerRegister()
+	mux.Get("/redact/:card", func(ctx *context.Context) {})
+	rw, r := testRequest("GET", "/redact/4111111111111111")
+	r.Header.Set("User-Agent", "beego-test token=secret")
+	mux.ServeHTTP(rw, r)
+
+	if len(sink.records) != 1 {
+		t.Fatal("TestRouterAccessLogRedact should write one record, got", len(sink.records))
+	}
+	rec := sink.records[0]
+	if strings.Contains(rec.Path, "4111111111111111") || strings.Contains(rec.UserAgent, "secret") {
+		t.Errorf("TestRouterAccessLogRedact should mask the record %+v", rec)
+	}
+}
+
+func TestRouterAccessLogRules(t *testing.T) {
+	sink := &testAccessLogSink{}
+	SetAccessLogSink(sink)
+	AccessLogsSampleRate = 0
+	AccessLogsSlowThreshold = 20
+	AccessLogsSkipPrefixes = []string{"/rules/healthz"}
+	defer func() {
+		SetAccessLogSink(nil)
+		AccessLogsSampleRate = 100
+		AccessLogsSlowThreshold = 0
+		AccessLogsSkipPrefixes = nil
+	}()
+
+	mux := NewControllerRegister()
+	mux.Get("/rules/fast", func(ctx *context.Context) {})
+	mux.Get("/rules/slow", func(ctx *context.Context) {
+		time.Sleep(25 * time.Millisecond)
+	})
+	mux.Get("/rules/fail", func(ctx *context.Context) {
+		ctx.Output.SetStatus(502)
+	})
+	mux.Get("/rules/healthz", func(ctx *context.Context) {
+		ctx.Output.SetStatus(503)
+	})
+	for _, path := range []string{"/rules/fast", "/rules/slow", "/rules/fail", "/rules/healthz"} {
+		rw, r := testRequest("GET", path)
+		mux.ServeHTTP(rw, r)
+	}
+	logged := func() string {
+		var paths []string
+		for _, rec := range sink.records {
+			// the handlers of the other tests may still be finishing
+			if strings.HasPrefix(rec.Path, "/rules/") {
+				paths = append(paths, rec.Path)
+			}
+		}
+		sink.records = nil
+		return strings.Join(paths, ",")
+	}
+	if paths := logged(); paths != "/rules/slow,/rules/fail,/rules/healthz" {
+		t.Errorf("logged %s", paths)
+	}
+
+	AccessLogsSampleRate = 100
+	for _, path := range []string{"/rules/fast", "/rules/healthz/live"} {
+		rw, r := testRequest("GET", path)
+		mux.ServeHTTP(rw, r)
+	}
+	if paths := logged(); paths != "/rules/fast" {
+		t.Errorf("the skipped prefix is logged: %s", paths)
+	}
+}
+
+func TestRouterWatchdog(t *testing.T) {
+	WatchdogThreshold = 60000
+	defer func() { WatchdogThreshold = 0 }()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	mux := NewControllerRegister()
+	mux.Get("/stuck/:id", func(ctx *context.Context) {
+		close(started)
+		<-release
+	})
+
+	done := make(chan struct{})
+	go func() {
+		rw, r := testRequest("GET", "/stuck/1")
+		mux.ServeHTTP(rw, r)
+		close(done)
+	}()
+	<-started
+	if reports := watchdog.check(time.Now(), time.Minute); len(reports) != 0 {
+		t.Errorf("the request isn't over the threshold yet: %v", reports)
+	}
+	reports := watchdog.check(time.Now().Add(time.Minute), time.Minute)
+	if len(reports) != 1 || !strings.Contains(reports[0], "GET /stuck/1 (/stuck/:id)") || !strings.Contains(reports[0], "TestRouterWatchdog") {
+		t.Errorf("unexpected reports %v", reports)
+	}
+	if reports := watchdog.check(time.Now().Add(time.Minute), time.Minute); len(reports) != 0 {
+		t.Error("the request should be reported once")
+	}
+	close(release)
+	<-done
+	watchdog.mu.Lock()
+	n := len(watchdog.requests)
+	watchdog.mu.Unlock()
+	if n != 0 {
+		t.Errorf("%d requests are still watched", n)
+	}
+}
+
+var allocSink []byte
+
+func TestRouterAllocStats(t *testing.T) {
+	EnableAllocStats = true
+	defer func() {
+		EnableAllocStats = false
+		toolbox.Allocs.Reset()
+	}()
+	mux := NewControllerRegister()
+	mux.Get("/heavy/:id", func(ctx *context.Context) {
+		allocSink = make([]byte, 1<<20)
+		ctx.WriteString("ok")
+	})
+	rw, r := testRequest("GET", "/heavy/1")
+	mux.ServeHTTP(rw, r)
+	if st := rw.Header().Get("Server-Timing"); !strings.HasPrefix(st, `alloc;desc="`) || !strings.Contains(st, "heap;desc=") {
+		t.Errorf("Server-Timing = %q", st)
+	}
+	var found bool
+	for _, s := range toolbox.Allocs.Snapshot() {
+		if s.Pattern == "/heavy/:id" {
+			found = true
+			if s.Requests != 1 || s.Bytes < 1<<20 || s.MaxBytes != s.Bytes {
+				t.Errorf("unexpected stat %+v", s)
+			}
+		}
+	}
+	if !found {
+		t.Error("the route isn't in the statistics")
+	}
+}
+
+func TestSlowRequest(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/report/2015", nil)
+	ctx := &context.Context{Request: r, Input: context.NewInput(r)}
+	ctx.Input.RouterPattern = "/report/:year"
+	ctx.Input.SetParam(":year", "2015")
+
+	wd := watchdog
+	req := wd.watch("GET", "/report/2015")
+	defer wd.done(req)
+	report := slowRequestReport(ctx, req, 30*time.Millisecond)
+	for _, want := range []string{"GET /report/2015 (/report/:year) took 30ms", "params [:year=2015]", "before the watchdog took its stack"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("the report doesn't contain %q:\n%s", want, report)
+		}
+	}
+	wd.check(req.start.Add(time.Second), 10*time.Millisecond)
+	if report := slowRequestReport(ctx, req, time.Second); !strings.Contains(report, "logged by the watchdog") {
+		t.Errorf("the report should refer to the stack of the watchdog:\n%s", report)
+	}
+}
+
+func TestRouterMaxBodySize(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.SetMaxBodySize("/upload/*", 8)
+	post := func(ctx *context.Context) {
+		ctx.Output.Body([]byte(ctx.Input.Query("name")))
+	}
+	handler.Post("/upload/file", post)
+	handler.Post("/user", post)
+
+	do := func(path, body string, chunked bool) *httptest.ResponseRecorder {
+		var r *http.Request
+		if chunked {
+			// the unknown length is read as chunked
+			r, _ = http.NewRequest("POST", path, io.MultiReader(strings.NewReader(body)))
+			r.ContentLength = -1
+		} else {
+			r, _ = http.NewRequest("POST", path, strings.NewReader(body))
+		}
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	if w := do("/upload/file", "name=a", false); w.Code != http.StatusOK || w.Body.String() != "a" {
+		t.Errorf("small body: %d %s", w.Code, w.Body.String())
+	}
+	if w := do("/upload/file", "name=astaxie", false); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large Content-Length: %d", w.Code)
+	}
+	if w := do("/upload/file", "name=astaxie", true); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large chunked body: %d", w.Code)
+	}
+	if w := do("/user", "name=astaxie", true); w.Code != http.StatusOK || w.Body.String() != "astaxie" {
+		t.Errorf("unlimited route: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRouterMaxResponseSize(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.SetMaxResponseSize("/api/*", 8, ResponseSizeAbort)
+	handler.SetMaxResponseSize("/logs/*", 8, ResponseSizeTruncate)
+	body := func(ctx *context.Context) {
+		ctx.Output.Body([]byte(ctx.Input.Query("body")))
+	}
+	stream := func(ctx *context.Context) {
+		for _, s := range strings.Split(ctx.Input.Query("body"), ",") {
+			ctx.ResponseWriter.Write([]byte(s))
+		}
+	}
+	handler.Get("/api/body", body)
+	handler.Get("/api/stream", stream)
+	handler.Get("/logs/body", body)
+	handler.Get("/logs/stream", stream)
+	handler.Get("/user", body)
+
+	do := func(url string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	if w := do("/api/body?body=astaxie"); w.Code != http.StatusOK || w.Body.String() != "astaxie" {
+		t.Errorf("small response: %d %s", w.Code, w.Body.String())
+	}
+	if w := do("/api/body?body=astaxie-beego"); w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "astaxie") {
+		t.Errorf("large response should be aborted: %d %s", w.Code, w.Body.String())
+	}
+	if w := do("/api/stream?body=astaxie,-,beego"); w.Body.String() != "astaxie-" {
+		t.Errorf("large stream should be cut off: %s", w.Body.String())
+	}
+	w := do("/logs/body?body=astaxie-beego")
+	if w.Code != http.StatusOK || w.Body.String() != "astaxie-" || w.Header().Get(ResponseTruncatedHeader) != "13" {
+		t.Errorf("large response should be truncated: %d %s %v", w.Code, w.Body.String(), w.Header())
+	}
+	if w := do("/logs/stream?body=astaxie,-beego"); w.Body.String() != "astaxie-" {
+		t.Errorf("large stream should be truncated: %s", w.Body.String())
+	}
+	if w := do("/user?body=astaxie-beego"); w.Body.String() != "astaxie-beego" {
+		t.Errorf("unlimited route: %s", w.Body.String())
+	}
+}
+
+func TestRouterTransformers(t *testing.T) {
+	type user struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+		Old   string `json:"old_name"`
+	}
+	handler := NewControllerRegister()
+	handler.Post("/user", func(ctx *context.Context) {
+		u := &user{}
+		if err := ctx.Input.BindRequest(u); err != nil {
+			ctx.Output.SetStatus(400)
+			ctx.Output.Body([]byte(err.Error()))
+			return
+		}
+		ctx.Output.JSON(*u, false, false)
+	},
+		WithRequestTransformer(func(ctx *context.Context, req interface{}) error {
+			if u := req.(*user); u.Name == "" {
+				u.Name = u.Old
+			}
+			return nil
+		}),
+		WithResponseTransformer(func(ctx *context.Context, data interface{}) (interface{}, error) {
+			u := data.(user)
+			if ctx.Input.Header("X-Role") != "admin" {
+				u.Email = ""
+			}
+			return u, nil
+		}))
+
+	do := func(role string) string {
+		r, _ := http.NewRequest("POST", "/user", strings.NewReader(`{"old_name":"astaxie","email":"a@b.c"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("X-Role", role)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+	if body := do(""); body != `{"name":"astaxie","email":"","old_name":"astaxie"}` {
+		t.Errorf("masked response: %s", body)
+	}
+	if body := do("admin"); body != `{"name":"astaxie","email":"a@b.c","old_name":"astaxie"}` {
+		t.Errorf("admin response: %s", body)
+	}
+}
+
+func TestURLForName(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.AddWithOptions("/user/:id", &TestController{}, WithName("user.show"))
+	handler.AddWithOptions("/post/:year:int/?:slug", &TestController{}, WithName("post.show"), WithMethods("get:List"))
+	handler.Get("/files/*.*", func(ctx *context.Context) {}, WithName("file"))
+	handler.Handler("/", http.NotFoundHandler(), WithName("home"))
+
+	cases := []struct {
+		name   string
+		values []interface{}
+		url    string
+	}{
+		{"user.show", []interface{}{":id", 5}, "/user/5"},
+		{"user.show", []interface{}{":id", 5, "tab", "posts"}, "/user/5?tab=posts"},
+		{"user.show", nil, ""},
+		{"post.show", []interface{}{":year", 2015, ":slug", "hello"}, "/post/2015/hello"},
+		{"post.show", []interface{}{":year", 2015}, "/post/2015"},
+		{"file", []interface{}{":path", "a/b", ":ext", "txt"}, "/files/a/b.txt"},
+		{"home", nil, "/"},
+		{"unknown", nil, ""},
+	}
+	for _, c := range cases {
+		if url := handler.URLForName(c.name, c.values...); url != c.url {
+			t.Errorf("URLForName(%q, %v) = %q, want %q", c.name, c.values, url, c.url)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("the duplicated route name should panic")
+		}
+	}()
+	handler.AddWithOptions("/member/:id", &TestController{}, WithName("user.show"))
+}
+
+type localeController struct {
+	Controller
+}
+
+func (c *localeController) Get() {
+	c.Ctx.WriteString(RouteLocale(c.Ctx) + " " + c.TplLocale + " " + c.Ctx.Input.Param(":slug"))
+}
+
+type urlNameController struct {
+	Controller
+}
+
+func (c *urlNameController) Get() {
+	c.Ctx.WriteString(c.URLForName("product", ":slug", "desk"))
+}
+
+func TestRouterTranslations(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.AddWithOptions("/products/:slug", &localeController{}, WithName("product"), WithSLO(toolbox.SLO{Availability: 0.99}),
+		WithTranslations(map[string]string{"en": "/en/products/:slug", "de": "/de/produkte/:slug"}))
+	handler.Add("/link", &urlNameController{})
+
+	for path, body := range map[string]string{
+		"/products/desk":     "  desk",
+		"/en/products/desk":  "en en desk",
+		"/de/produkte/tisch": "de de tisch",
+	} {
+		r, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Body.String() != body {
+			t.Errorf("%s: %q, want %q", path, w.Body.String(), body)
+		}
+	}
+	r, _ := http.NewRequest("GET", "/de/products/desk", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("the untranslated path should be 404, got %d", w.Code)
+	}
+
+	if url := handler.URLForLocale("product", "de", ":slug", "tisch"); url != "/de/produkte/tisch" {
+		t.Errorf("de url = %s", url)
+	}
+	if url := handler.URLForLocale("product", "fr", ":slug", "desk"); url != "/products/desk" {
+		t.Errorf("fallback url = %s", url)
+	}
+
+	r, _ = http.NewRequest("GET", "/link", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Body.String() != "/products/desk" {
+		t.Errorf("URLForName should use the register of the request, got %q", w.Body.String())
+	}
+
+	requests := make(map[string]uint64)
+	for _, status := range toolbox.SLOs.Status() {
+		requests[status.Name] = status.Requests
+	}
+	for _, pattern := range []string{"/products/:slug", "/en/products/:slug", "/de/produkte/:slug"} {
+		if requests[pattern] == 0 {
+			t.Errorf("the requests of %s should be observed, got %v", pattern, requests)
+		}
+	}
+}
+
+type variantController struct {
+	Controller
+}
+
+func (c *variantController) Get() {
+	c.Ctx.WriteString("v2 " + RouteVariant(c.Ctx))
+}
+
+func TestRouterVariant(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.AddWithOptions("/checkout", &TestController{}, WithVariant("v2", &variantController{}, func(ctx *context.Context) bool {
+		return ctx.Input.Header("X-Beta") == "1"
+	}))
+	handler.Get("/search", func(ctx *context.Context) {
+		ctx.WriteString("v1")
+	}, WithVariantFunc("v2", func(ctx *context.Context) {
+		ctx.WriteString("v2")
+	}, SplitPercent(100)))
+
+	do := func(path, beta string) string {
+		r, _ := http.NewRequest("GET", path, nil)
+		r.Header.Set("X-Beta", beta)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+	if body := do("/checkout", ""); body != "ok" {
+		t.Errorf("control: %q", body)
+	}
+	if body := do("/checkout", "1"); body != "v2 v2" {
+		t.Errorf("variant: %q", body)
+	}
+	if body := do("/search", ""); body != "v2" {
+		t.Errorf("function variant: %q", body)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("a controller variant of a function router should panic")
+		}
+	}()
+	handler.Get("/other", func(ctx *context.Context) {}, WithVariant("v2", &variantController{}, SplitPercent(50)))
+}
+
+type mapSessionStore map[interface{}]interface{}
+
+func (s mapSessionStore) Set(key, value interface{}) error   { s[key] = value; return nil }
+func (s mapSessionStore) Get(key interface{}) interface{}    { return s[key] }
+func (s mapSessionStore) Delete(key interface{}) error       { delete(s, key); return nil }
+func (s mapSessionStore) SessionID() string                  { return "sid" }
+func (s mapSessionStore) SessionRelease(http.ResponseWriter) {}
+func (s mapSessionStore) Flush() error                       { return nil }
+
+func TestSplitSticky(t *testing.T) {
+	calls := 0
+	split := SplitSticky("ab.checkout", func(ctx *context.Context) bool {
+		calls++
+		return calls == 1
+	})
+	ctx := &context.Context{Input: context.NewInput(nil)}
+	ctx.Input.CruSession = mapSessionStore{}
+	for i := 0; i < 3; i++ {
+		if !split(ctx) {
+			t.Fatal("the assignment should stick")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("split called %d times", calls)
+	}
+}
+
+func TestRouterTypedParams(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Get("/user/:id:int", func(ctx *context.Context) {
+		id, err := ctx.Input.ParamInt(":id")
+		if err != nil {
+			t.Error(err)
+		}
+		ctx.WriteString(strconv.Itoa(id + 1))
+	})
+	handler.Get("/file/:uid:uuid", func(ctx *context.Context) {
+		ctx.WriteString(ctx.Input.Param(":uid"))
+	})
+	handler.Get("/date/:d:datetime", func(ctx *context.Context) {
+		d, err := ctx.Input.ParamTime(":d")
+		if err != nil {
+			t.Error(err)
+		}
+		ctx.WriteString(d.Format("Jan 2"))
+	})
+
+	cases := []struct {
+		url  string
+		code int
+		body string
+	}{
+		{"/user/41", 200, "42"},
+		{"/user/abc", 404, ""},
+		{"/user/99999999999999999999", 404, ""},
+		{"/file/0B6F3B1C-5C1E-4D5E-9F1A-2B3C4D5E6F70", 200, "0B6F3B1C-5C1E-4D5E-9F1A-2B3C4D5E6F70"},
+		{"/file/not-a-uuid", 404, ""},
+		{"/date/2015-06-01", 200, "Jun 1"},
+		{"/date/2015-06-01T10:00:00+08:00", 200, "Jun 1"},
+		{"/date/2015-13-01", 404, ""},
+	}
+	for _, c := range cases {
+		r, _ := http.NewRequest("GET", c.url, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != c.code || (c.body != "" && w.Body.String() != c.body) {
+			t.Errorf("%s: got %d %q, want %d %q", c.url, w.Code, w.Body.String(), c.code, c.body)
+		}
+	}
+}
+
+func TestRouterOptionalAndCatchAll(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Get("/posts(/:page)", func(ctx *context.Context) {
+		ctx.WriteString("posts page " + ctx.Input.Param(":page"))
+	}, WithName("posts"))
+	handler.Get("/archive(/:year:int(/:mon:int))", func(ctx *context.Context) {
+		ctx.WriteString("archive " + ctx.Input.Param(":year") + " " + ctx.Input.Param(":mon"))
+	}, WithName("archive"))
+	handler.Get("/files/*filepath", func(ctx *context.Context) {
+		ctx.WriteString("file " + ctx.Input.Param(":filepath") + " " + ctx.Input.Param(":splat"))
+	}, WithName("files"))
+	handler.Get("/files/readme", func(ctx *context.Context) {
+		ctx.WriteString("readme")
+	})
+
+	cases := []struct {
+		url  string
+		code int
+		body string
+	}{
+		{"/posts", 200, "posts page "},
+		{"/posts/2", 200, "posts page 2"},
+		{"/posts/2/3", 404, ""},
+		{"/archive", 200, "archive  "},
+		{"/archive/2015", 200, "archive 2015 "},
+		{"/archive/2015/06", 200, "archive 2015 06"},
+		{"/archive/x", 404, ""},
+		{"/files/a/b.txt", 200, "file a/b.txt a/b.txt"},
+		{"/files/readme", 200, "readme"},
+	}
+	for _, c := range cases {
+		r, _ := http.NewRequest("GET", c.url, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != c.code || (c.code == 200 && w.Body.String() != c.body) {
+			t.Errorf("%s: got %d %q, want %d %q", c.url, w.Code, w.Body.String(), c.code, c.body)
+		}
+	}
+
+	urls := [][2]string{
+		{handler.URLForName("posts"), "/posts"},
+		{handler.URLForName("posts", ":page", 2), "/posts/2"},
+		{handler.URLForName("archive", ":year", 2015, ":mon", "06"), "/archive/2015/06"},
+		{handler.URLForName("archive", ":year", 2015), "/archive/2015"},
+		{handler.URLForName("files", ":filepath", "a/b.txt"), "/files/a/b.txt"},
+	}
+	for _, u := range urls {
+		if u[0] != u[1] {
+			t.Errorf("URLForName got %q, want %q", u[0], u[1])
+		}
+	}
+}
+
+func TestRouterHost(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Get("/", func(ctx *context.Context) {
+		ctx.WriteString("www")
+	})
+	handler.Get("/about", func(ctx *context.Context) {
+		ctx.WriteString("about")
+	})
+	handler.Host("api.example.com").Get("/", func(ctx *context.Context) {
+		ctx.WriteString("api")
+	})
+	tenant := handler.Host(":tenant.example.com")
+	tenant.Filter(BeforeRouter, func(ctx *context.Context) {
+		ctx.Output.Header("X-Tenant-Filter", "1")
+	})
+	tenant.Get("/", func(ctx *context.Context) {
+		ctx.WriteString("tenant " + ctx.Input.Param(":tenant"))
+	})
+
+	cases := []struct {
+		host, url, body string
+		filtered        bool
+	}{
+		{"www.example.org", "/", "www", false},
+		{"api.example.com", "/", "api", false},
+		{"API.example.com:8080", "/", "api", false},
+		{"acme.example.com", "/", "tenant acme", true},
+		{"acme.example.com", "/about", "about", true},
+		{"a.b.example.com", "/", "www", false},
+	}
+	for _, c := range cases {
+		r, _ := http.NewRequest("GET", c.url, nil)
+		r.Host = c.host
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Body.String() != c.body || (w.Header().Get("X-Tenant-Filter") != "") != c.filtered {
+			t.Errorf("%s%s: got %q %v", c.host, c.url, w.Body.String(), w.Header())
+		}
+	}
+}
+
+func TestRouterHostRoutes(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Host("api.example.com").Get("/user/:id", func(ctx *context.Context) {
+		ctx.WriteString("user " + ctx.Input.Param(":id"))
+	}, WithName("api.user"))
+
+	r, _ := http.NewRequest("POST", "/user/1", nil)
+	r.Host = "api.example.com"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, OPTIONS" {
+		t.Errorf("the host route should answer 405, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+	r.Host = "www.example.com"
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("the other hosts should get 404, got %d", w.Code)
+	}
+
+	if url := handler.URLForName("api.user", ":id", 5); url != "/user/5" {
+		t.Errorf("URLForName of the host route = %q", url)
+	}
+	doc := handler.OpenAPI(swagger.OpenAPIInfo{Title: "test", Version: "1.0"})
+	if doc.Paths["/user/{id}"]["get"] == nil {
+		t.Errorf("the host route should be documented: %v", doc.Paths)
+	}
+}
+
+type allowBaseController struct {
+	Controller
+}
+
+func (c *allowBaseController) Post() {
+	c.Ctx.WriteString("post")
+}
+
+type allowController struct {
+	allowBaseController
+}
+
+func (c *allowController) Get() {
+	c.Ctx.WriteString("get")
+}
+
+func TestRouterControllerAllow(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Add("/allow", &allowController{})
+
+	for _, test := range []struct {
+		method string
+		code   int
+		body   string
+	}{
+		{"GET", http.StatusOK, "get"},
+		{"POST", http.StatusOK, "post"},
+		{"DELETE", http.StatusMethodNotAllowed, ""},
+		{"OPTIONS", http.StatusOK, ""},
+	} {
+		r, _ := http.NewRequest(test.method, "/allow", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != test.code || (test.body != "" && w.Body.String() != test.body) {
+			t.Errorf("%s /allow got %d %q", test.method, w.Code, w.Body.String())
+		}
+		if test.code != http.StatusOK || test.method == "OPTIONS" {
+			if allow := w.Header().Get("Allow"); allow != "GET, OPTIONS, POST" {
+				t.Errorf("%s /allow got Allow %q", test.method, allow)
+			}
+		}
+	}
+}
+
+func TestRouterVersion(t *testing.T) {
+	defer func(v string) { APIDefaultVersion = v }(APIDefaultVersion)
+	APIDefaultVersion = "v1"
+
+	handler := NewControllerRegister()
+	handler.Get("/ping", func(ctx *context.Context) {
+		ctx.WriteString("pong " + APIVersion(ctx))
+	})
+	v1 := handler.Version("v1", "/v1")
+	v1.Get("/user/:id", func(ctx *context.Context) {
+		ctx.WriteString("v1 " + ctx.Input.Param(":id"))
+	})
+	v2 := handler.Version("v2", "/api/v2")
+	v2.Filter(BeforeRouter, func(ctx *context.Context) {
+		ctx.Output.Header("X-V2-Filter", "1")
+	})
+	v2.Get("/user/:id", func(ctx *context.Context) {
+		ctx.WriteString("v2 " + ctx.Input.Param(":id"))
+	})
+
+	cases := []struct {
+		url, accept, body string
+		filtered          bool
+	}{
+		{"/user/1", "", "v1 1", false},
+		{"/user/1", "application/vnd.myapp.v2+json", "v2 1", true},
+		{"/user/1", "application/vnd.myapp.v1+json;q=0.5, application/vnd.myapp.v2+json", "v2 1", true},
+		{"/user/1", "application/vnd.myapp.v9+json", "v1 1", false},
+		{"/v1/user/2", "application/vnd.myapp.v2+json", "v1 2", false},
+		{"/api/v2/user/3", "", "v2 3", true},
+		{"/api/v2x/user/3", "", "", false},
+		{"/ping", "application/vnd.myapp.v2+json", "pong v2", true},
+	}
+	for _, c := range cases {
+		r, _ := http.NewRequest("GET", c.url, nil)
+		if c.accept != "" {
+			r.Header.Set("Accept", c.accept)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if c.body == "" {
+			if w.Code != http.StatusNotFound {
+				t.Errorf("%s: got %d, want 404", c.url, w.Code)
+			}
+			continue
+		}
+		if w.Body.String() != c.body || (w.Header().Get("X-V2-Filter") != "") != c.filtered {
+			t.Errorf("%s %s: got %q %v", c.url, c.accept, w.Body.String(), w.Header())
+		}
+	}
+}
+
+func TestRouterCoalesce(t *testing.T) {
+	var runs int32
+	started := make(chan bool)
+	release := make(chan bool)
+	handler := NewControllerRegister()
+	handler.Get("/report", func(ctx *context.Context) {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			started <- true
+			<-release
+		}
+		ctx.Output.Header("Set-Cookie", "sid=leader")
+		ctx.WriteString("report " + ctx.Input.Query("q"))
+	}, WithCoalesce(nil))
+
+	get := func(url string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	var wg sync.WaitGroup
+	results := make(chan *httptest.ResponseRecorder, 5)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results <- get("/report?q=1")
+	}()
+	<-started
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- get("/report?q=1")
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	var cookies int
+	for w := range results {
+		if w.Code != 200 || w.Body.String() != "report 1" {
+			t.Errorf("got %d %q", w.Code, w.Body.String())
+		}
+		if w.Header().Get("Set-Cookie") != "" {
+			cookies++
+		}
+	}
+	if runs != 1 || cookies != 1 {
+		t.Errorf("the handler ran %d times and set %d cookies, want once", runs, cookies)
+	}
+	if w := get("/report?q=2"); w.Body.String() != "report 2" || runs != 2 {
+		t.Errorf("a new request should run the handler: %q %d", w.Body.String(), runs)
+	}
+
+	for _, h := range []string{"Cookie", "Authorization"} {
+		r, _ := http.NewRequest("GET", "/report?q=1", nil)
+		r.Header.Set(h, "user")
+		if key := DefaultCoalesceKey(&context.Context{Request: r}); key != "" {
+			t.Errorf("the request with a %s header shouldn't be coalesced: %q", h, key)
+		}
+	}
+}
+
+func TestRouterCoalesceMaxBytes(t *testing.T) {
+	defer func(max int64) { CoalesceMaxBytes = max }(CoalesceMaxBytes)
+	CoalesceMaxBytes = 4
+
+	var runs int32
+	started := make(chan bool)
+	release := make(chan bool)
+	handler := NewControllerRegister()
+	handler.Get("/report", func(ctx *context.Context) {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			started <- true
+			<-release
+		}
+		ctx.WriteString("large report")
+	}, WithCoalesce(nil))
+
+	var wg sync.WaitGroup
+	results := make(chan *httptest.ResponseRecorder, 3)
+	get := func() {
+		defer wg.Done()
+		r, _ := http.NewRequest("GET", "/report", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		results <- w
+	}
+	wg.Add(1)
+	go get()
+	<-started
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go get()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for w := range results {
+		if w.Code != 200 || w.Body.String() != "large report" {
+			t.Errorf("got %d %q", w.Code, w.Body.String())
+		}
+	}
+	if runs != 3 {
+		t.Errorf("the handler ran %d times, the waiting requests of a large response should run it", runs)
+	}
+}
+
+type countingController struct {
+	Controller
+	requests int
+	resets   *int
+}
+
+func (c *countingController) Get() {
+	c.requests++
+	c.Ctx.WriteString(strconv.Itoa(c.requests) + " " + c.Layout)
+	c.Layout = "dirty.tpl"
+}
+
+func (c *countingController) Reset() {
+	c.requests = 0
+	*c.resets++
+}
+
+func TestRouterResettableController(t *testing.T) {
+	resets := 0
+	ct := reflect.TypeOf(countingController{})
+	controllerPools.Store(ct, &sync.Pool{New: func() interface{} {
+		return &countingController{resets: &resets}
+	}})
+	defer controllerPools.Delete(ct)
+
+	handler := NewControllerRegister()
+	handler.Add("/count", &countingController{})
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/count", nil)
+		handler.ServeHTTP(w, r)
+		if w.Body.String() != "1 " {
+			t.Fatalf("the pooled controller keeps the previous request: %q", w.Body.String())
+		}
+	}
+	if resets != 3 {
+		t.Errorf("the controller is reset %d times, want 3", resets)
+	}
+}
+
+type initController struct {
+	Controller
+}
+
+func (c *initController) Init(ctx *context.Context, controllerName, actionName string, app interface{}) {
+	c.XSRFExpire = 60
+	c.ViewPaths = []string{"themes/dark"}
+	c.Controller.Init(ctx, controllerName, actionName, app)
+}
+
+func (c *initController) Get() {
+	c.Ctx.WriteString(strconv.Itoa(c.XSRFExpire) + " " + strings.Join(c.ViewPaths, ","))
+}
+
+func TestRouterControllerInitOverride(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Add("/init", &initController{})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/init", nil)
+	handler.ServeHTTP(w, r)
+	if w.Body.String() != "60 themes/dark" {
+		t.Errorf("the fields set before Controller.Init are lost: %q", w.Body.String())
+	}
+}
+
+type dispatchController struct {
+	Controller
+}
+
+func (c *dispatchController) List() {
+	c.Ctx.WriteString("reflect list")
+}
+
+type generatedDispatchController struct {
+	dispatchController
+}
+
+func (c *generatedDispatchController) DispatchMethod(name string) bool {
+	if name == "List" {
+		c.Ctx.WriteString("generated list")
+		return true
+	}
+	return false
+}
+
+func TestRouterMethodDispatch(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Add("/list", &dispatchController{}, "get:List")
+	handler.Add("/generated", &generatedDispatchController{}, "get:List")
+	route, _ := handler.routers["GET"].Match("/list")
+	if _, ok := route.(*controllerInfo).methodIndexes["List"]; !ok {
+		t.Fatal("the method index isn't stored on the router at registration")
+	}
+	for url, body := range map[string]string{"/list": "reflect list", "/generated": "generated list"} {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", url, nil)
+		handler.ServeHTTP(w, r)
+		if w.Body.String() != body {
+			t.Errorf("%s: got %q, want %q", url, w.Body.String(), body)
+		}
+	}
+}
+
+type registeredDispatchController struct {
+	dispatchController
+}
+
+func (c *registeredDispatchController) Show() {
+	c.Ctx.WriteString("reflect show")
+}
+
+func TestRouterRegisterDispatch(t *testing.T) {
+	RegisterDispatch(&registeredDispatchController{}, func(c ControllerInterface, name string) bool {
+		switch name {
+		case "List":
+			c.(*registeredDispatchController).Ctx.WriteString("registered list")
+		default:
+			return false
+		}
+		return true
+	})
+	handler := NewControllerRegister()
+	handler.Add("/list", &registeredDispatchController{}, "get:List")
+	handler.Add("/show", &registeredDispatchController{}, "get:Show")
+	for url, body := range map[string]string{"/list": "registered list", "/show": "reflect show"} {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", url, nil)
+		handler.ServeHTTP(w, r)
+		if w.Body.String() != body {
+			t.Errorf("%s: got %q, want %q", url, w.Body.String(), body)
+		}
+	}
+}
+
+type benchDispatchController struct {
+	Controller
+}
+
+func (c *benchDispatchController) List() {
+	c.Ctx.Output.SetStatus(200)
+}
+
+type benchRegisteredController struct {
+	benchDispatchController
+}
+
+func init() {
+	RegisterDispatch(&benchRegisteredController{}, func(c ControllerInterface, name string) bool {
+		switch name {
+		case "List":
+			c.(*benchRegisteredController).List()
+		default:
+			return false
+		}
+		return true
+	})
+}
+
+func BenchmarkDispatchReflect(b *testing.B) {
+	benchmarkDispatch(b, &benchDispatchController{})
+}
+
+func BenchmarkDispatchRegistered(b *testing.B) {
+	benchmarkDispatch(b, &benchRegisteredController{})
+}
+
+func benchmarkDispatch(b *testing.B, c ControllerInterface) {
+	vc := reflect.ValueOf(c)
+	ctx := &context.Context{Input: context.NewInput(nil), Output: context.NewOutput()}
+	ctx.Output.Context = ctx
+	c.Init(ctx, "", "List", c)
+	route := &controllerInfo{controllerType: vc.Elem().Type(), methods: map[string]string{"get": "List"}}
+	route.cacheMethods()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dispatchMethod(vc, c, "List", route.methodIndexes)
+	}
+}
+
+func TestRouterWaitWorkers(t *testing.T) {
+	var done int32
+	mux := NewControllerRegister()
+	mux.Get("/stop", func(ctx *context.Context) {
+		ctx.Go(func(gocontext.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			atomic.StoreInt32(&done, 1)
+			return nil
+		})
+		panic(ErrAbort)
+	})
+	mux.Get("/panic", func(ctx *context.Context) {
+		ctx.Go(func(gocontext.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			atomic.StoreInt32(&done, 2)
+			return nil
+		})
+		panic("crash")
+	})
+
+	rw, r := testRequest("GET", "/stop")
+	mux.ServeHTTP(rw, r)
+	if atomic.LoadInt32(&done) != 1 {
+		t.Error("the workers should finish before the stopped request returns")
+	}
+	rw, r = testRequest("GET", "/panic")
+	mux.ServeHTTP(rw, r)
+	if atomic.LoadInt32(&done) != 2 {
+		t.Error("the workers should finish before the crashed request returns")
+	}
+}
+
+func TestRouterPooledContextWorkers(t *testing.T) {
+	var mismatch int32
+	mux := NewControllerRegister()
+	mux.Get("/user/:id", func(ctx *context.Context) {
+		id := ctx.Input.Param(":id")
+		ctx.Go(func(gocontext.Context) error {
+			time.Sleep(5 * time.Millisecond)
+			if ctx.Input.Param(":id") != id {
+				atomic.AddInt32(&mismatch, 1)
+			}
+			return nil
+		})
+		panic(ErrAbort)
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rw, r := testRequest("GET", "/user/"+strconv.Itoa(i))
+			mux.ServeHTTP(rw, r)
+		}(i)
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&mismatch); n != 0 {
+		t.Errorf("%d workers saw the context reused by another request", n)
+	}
+}
+
+func TestRouterTimeoutHijack(t *testing.T) {
+	mux := NewControllerRegister()
+	mux.SetTimeout("/ws", time.Second)
+	mux.Get("/ws", func(ctx *context.Context) {
+		conn, rw, err := ctx.ResponseWriter.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\nhello")
+		rw.Flush()
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: test\r\n\r\n"))
+	b, _ := io.ReadAll(bufio.NewReader(conn))
+	if !strings.HasSuffix(string(b), "\r\n\r\nhello") {
+		t.Errorf("the connection should be hijacked under a timeout, got %q", b)
+	}
+
+	tw := &timeoutWriter{w: httptest.NewRecorder(), h: make(http.Header), ctx: gocontext.Background(), timedOut: true}
+	if _, _, err := tw.Hijack(); err != http.ErrHandlerTimeout {
+		t.Errorf("the connection shouldn't be hijacked after the timeout: %v", err)
+	}
+}
+
+func TestRouterTimeoutInFlight(t *testing.T) {
+	release := make(chan bool)
+	mux := NewControllerRegister()
+	mux.SetTimeout("/busy", 10*time.Millisecond)
+	mux.Get("/busy", func(ctx *context.Context) {
+		<-release
+	})
+	base := Readiness().InFlight
+	rw, r := testRequest("GET", "/busy")
+	mux.ServeHTTP(rw, r)
+	if rw.Code != 503 {
+		t.Fatalf("got %d, want 503", rw.Code)
+	}
+	if n := Readiness().InFlight; n != base+1 {
+		t.Errorf("the handler still running after the timeout should be in flight: %d, want %d", n, base+1)
+	}
+	close(release)
+	for i := 0; i < 100 && Readiness().InFlight != base; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if n := Readiness().InFlight; n != base {
+		t.Errorf("in flight %d after the handler returned, want %d", n, base)
+	}
+}
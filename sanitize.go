@@ -0,0 +1,327 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"html"
+	"html/template"
+	"strings"
+)
+
+// HTMLPolicy is an allowlist of the html elements, attributes and url schemes kept by Sanitize.
+// The other tags are dropped with the comments and the content of script, style and the like,
+// the text is escaped, and the unclosed or stray tags are fixed, so the output can't break the page around it.
+type HTMLPolicy struct {
+	elements map[string]map[string]bool // the allowed attributes keyed by the allowed element
+	global   map[string]bool            // the attributes allowed on all the elements
+	schemes  map[string]bool
+	noFollow bool
+}
+
+// NewHTMLPolicy returns an empty policy, which keeps only the text.
+func NewHTMLPolicy() *HTMLPolicy {
+	return &HTMLPolicy{
+		elements: make(map[string]map[string]bool),
+		global:   make(map[string]bool),
+		schemes:  make(map[string]bool),
+	}
+}
+
+// UGCPolicy returns the policy for the user generated content: the formatting, list, table, link and image elements,
+// the http, https and mailto urls, and rel="nofollow" on the links.
+func UGCPolicy() *HTMLPolicy {
+	return NewHTMLPolicy().
+		AllowElements("p", "br", "hr", "b", "i", "u", "s", "em", "strong", "small", "sub", "sup", "del", "ins", "strike",
+			"code", "kbd", "pre", "abbr", "span", "blockquote", "q",
+			"h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "li", "dl", "dt", "dd",
+			"table", "thead", "tbody", "tfoot", "tr", "th", "td").
+		AllowAttrs("a", "href", "title").
+		AllowAttrs("img", "src", "alt", "title", "width", "height").
+		AllowAttrs("abbr", "title").
+		AllowAttrs("blockquote", "cite").
+		AllowAttrs("q", "cite").
+		AllowAttrs("th", "colspan", "rowspan").
+		AllowAttrs("td", "colspan", "rowspan").
+		AllowURLSchemes("http", "https", "mailto").
+		RequireNoFollow(true)
+}
+
+// DefaultHTMLPolicy is the policy of SanitizeHTML and the sanitize template function.
+var DefaultHTMLPolicy = UGCPolicy()
+
+// AllowElements allows the elements without attributes.
+func (p *HTMLPolicy) AllowElements(names ...string) *HTMLPolicy {
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if p.elements[name] == nil {
+			p.elements[name] = make(map[string]bool)
+		}
+	}
+	return p
+}
+
+// AllowAttrs allows the element with the attributes, the attributes are allowed on all the elements if element is empty.
+// The url attributes, e.g. href and src, are kept only if their scheme is allowed by AllowURLSchemes or they are relative.
+func (p *HTMLPolicy) AllowAttrs(element string, attrs ...string) *HTMLPolicy {
+	allowed := p.global
+	if element != "" {
+		p.AllowElements(element)
+		allowed = p.elements[strings.ToLower(element)]
+	}
+	for _, attr := range attrs {
+		allowed[strings.ToLower(attr)] = true
+	}
+	return p
+}
+
+// AllowURLSchemes allows the url schemes of the url attributes.
+func (p *HTMLPolicy) AllowURLSchemes(schemes ...string) *HTMLPolicy {
+	for _, s := range schemes {
+		p.schemes[strings.ToLower(s)] = true
+	}
+	return p
+}
+
+// RequireNoFollow sets whether rel="nofollow" is added to the links, so the spam links don't get the page rank.
+func (p *HTMLPolicy) RequireNoFollow(on bool) *HTMLPolicy {
+	p.noFollow = on
+	return p
+}
+
+// htmlVoidElements have no end tag.
+var htmlVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// htmlRawElements have their content dropped with them unless they are allowed.
+var htmlRawElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "noscript": true, "noembed": true,
+	"noframes": true, "template": true, "textarea": true, "title": true, "xmp": true, "svg": true, "math": true,
+}
+
+// htmlURLAttrs hold urls.
+var htmlURLAttrs = map[string]bool{
+	"href": true, "src": true, "cite": true, "action": true, "formaction": true, "background": true,
+	"longdesc": true, "poster": true, "xlink:href": true,
+}
+
+// Sanitize returns the html s with only the elements and attributes allowed by the policy.
+func (p *HTMLPolicy) Sanitize(s string) string {
+	var (
+		buf   strings.Builder
+		stack []string // the open elements
+	)
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			buf.WriteString(html.EscapeString(html.UnescapeString(s)))
+			break
+		}
+		buf.WriteString(html.EscapeString(html.UnescapeString(s[:i])))
+		s = s[i:]
+
+		switch {
+		case strings.HasPrefix(s, "<!--"):
+			s = skipPast(s[4:], "-->")
+			continue
+		case strings.HasPrefix(s, "</") && len(s) > 2 && isASCIILetter(s[2]):
+			name, _, rest, ok := parseHTMLTag(s[2:])
+			if !ok {
+				return p.closeAll(&buf, stack)
+			}
+			s = rest
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j] == name {
+					p.closeAll(&buf, stack[j:])
+					stack = stack[:j]
+					break
+				}
+			}
+			continue
+		case len(s) > 1 && isASCIILetter(s[1]):
+			name, attrs, rest, ok := parseHTMLTag(s[1:])
+			if !ok {
+				return p.closeAll(&buf, stack)
+			}
+			s = rest
+			allowed, found := p.elements[name]
+			if !found {
+				if htmlRawElements[name] {
+					s = skipPast(s, "</"+name)
+					s = skipPast(s, ">")
+				}
+				continue
+			}
+			p.writeTag(&buf, name, attrs, allowed)
+			if !htmlVoidElements[name] {
+				stack = append(stack, name)
+			}
+			continue
+		case strings.HasPrefix(s, "<!") || strings.HasPrefix(s, "<?") || strings.HasPrefix(s, "</"):
+			// doctype, cdata, processing instruction or a bogus end tag
+			s = skipPast(s, ">")
+			continue
+		}
+		buf.WriteString("&lt;")
+		s = s[1:]
+	}
+	return p.closeAll(&buf, stack)
+}
+
+// closeAll writes the end tags of the open elements and returns the output.
+func (p *HTMLPolicy) closeAll(buf *strings.Builder, stack []string) string {
+	for i := len(stack) - 1; i >= 0; i-- {
+		buf.WriteString("</" + stack[i] + ">")
+	}
+	return buf.String()
+}
+
+func (p *HTMLPolicy) writeTag(buf *strings.Builder, name string, attrs [][2]string, allowed map[string]bool) {
+	buf.WriteString("<" + name)
+	seen := make(map[string]bool, len(attrs))
+	hasLink := false
+	for _, a := range attrs {
+		key, val := a[0], a[1]
+		if seen[key] || !(allowed[key] || p.global[key]) || (key == "rel" && p.noFollow) {
+			continue
+		}
+		if htmlURLAttrs[key] {
+			if !p.allowURL(val) {
+				continue
+			}
+			hasLink = hasLink || key == "href"
+		}
+		seen[key] = true
+		buf.WriteString(" " + key + `="` + html.EscapeString(val) + `"`)
+	}
+	if hasLink && p.noFollow {
+		buf.WriteString(` rel="nofollow"`)
+	}
+	buf.WriteByte('>')
+}
+
+// allowURL returns whether the url is relative or its scheme is allowed.
+func (p *HTMLPolicy) allowURL(u string) bool {
+	// the browsers ignore the whitespaces and control characters in the scheme, e.g. "java\tscript:"
+	u = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, u)
+	i := strings.IndexAny(u, ":/?#")
+	if i < 0 || u[i] != ':' {
+		return true
+	}
+	return p.schemes[strings.ToLower(u[:i])]
+}
+
+// parseHTMLTag parses the name and the attributes of the tag s starting after "<" or "</",
+// the attribute values are unescaped. ok is false if the tag isn't closed by ">".
+func parseHTMLTag(s string) (name string, attrs [][2]string, rest string, ok bool) {
+	i := 0
+	for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	name = strings.ToLower(s[:i])
+	for {
+		for i < len(s) && (isHTMLSpace(s[i]) || s[i] == '/') {
+			i++
+		}
+		if i >= len(s) {
+			return name, attrs, "", false
+		}
+		if s[i] == '>' {
+			return name, attrs, s[i+1:], true
+		}
+		start := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' && s[i] != '/' && s[i] != '=' {
+			i++
+		}
+		key := strings.ToLower(s[start:i])
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		val := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isHTMLSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				end := strings.IndexByte(s[i+1:], s[i])
+				if end < 0 {
+					return name, attrs, "", false
+				}
+				val = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				val = s[start:i]
+			}
+		}
+		attrs = append(attrs, [2]string{key, html.UnescapeString(val)})
+	}
+}
+
+// skipPast returns s after the first sep, matched ASCII case insensitively, or empty if there's none.
+func skipPast(s, sep string) string {
+	for i := 0; i+len(sep) <= len(s); i++ {
+		if asciiEqualFold(s[i:i+len(sep)], sep) {
+			return s[i+len(sep):]
+		}
+	}
+	return ""
+}
+
+func asciiEqualFold(a, b string) bool {
+	for i := 0; i < len(a); i++ {
+		c, d := a[i], b[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if 'A' <= d && d <= 'Z' {
+			d += 'a' - 'A'
+		}
+		if c != d {
+			return false
+		}
+	}
+	return true
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// SanitizeHTML returns the user generated html s sanitized by DefaultHTMLPolicy,
+// use it instead of str2html to output the html of the users.
+// usage:
+//
+//	this.Data["Body"] = beego.SanitizeHTML(post.Body)
+//
+//	// in the template
+//	{{sanitize .Post.Body}}
+func SanitizeHTML(s string) template.HTML {
+	return template.HTML(DefaultHTMLPolicy.Sanitize(s))
+}
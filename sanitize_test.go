@@ -0,0 +1,65 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`hello <b>world</b>`, `hello <b>world</b>`},
+		{`<p onclick="alert(1)" class=x>text</p>`, `<p>text</p>`},
+		{`<script>alert("x")</script>ok`, `ok`},
+		{`<SCRIPT src=//evil></Script >ok`, `ok`},
+		{`<style>body{}</style><!-- note -->ok`, `ok`},
+		{`<a href="https://beego.me" rel="dofollow" title='t"x'>go</a>`, `<a href="https://beego.me" title="t&#34;x" rel="nofollow">go</a>`},
+		{`<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="jav&#x09;ascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href=/posts/1?a=1&amp;b=2>x</a>`, `<a href="/posts/1?a=1&amp;b=2" rel="nofollow">x</a>`},
+		{`<img src="data:image/png;base64,AA" alt=pic><img src=/a.png>`, `<img alt="pic"><img src="/a.png">`},
+		{`<b><i>unclosed`, `<b><i>unclosed</i></b>`},
+		{`<p><b>x</p></div></b>y`, `<p><b>x</b></p>y`},
+		{`1 < 2 && 3 > 2 &amp; &lt;b&gt;`, `1 &lt; 2 &amp;&amp; 3 &gt; 2 &amp; &lt;b&gt;`},
+		{`<div><iframe src=x></iframe>text</div>`, `text`},
+		{`<b title="x`, ``},
+		{`<!DOCTYPE html><?xml x?>plain`, `plain`},
+	}
+	for _, tt := range tests {
+		if got := SanitizeHTML(tt.in); got != template.HTML(tt.want) {
+			t.Errorf("SanitizeHTML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	p := NewHTMLPolicy().AllowAttrs("", "class").AllowAttrs("span", "data-id")
+	if got, want := p.Sanitize(`<span class=c data-id=1 id=2>x</span><b class=c>y</b>`), `<span class="c" data-id="1">x</span>y`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSanitizeTemplateFunc(t *testing.T) {
+	tpl := template.Must(template.New("t").Funcs(beegoTplFuncMap).Parse(`{{sanitize .}}`))
+	var buf strings.Builder
+	if err := tpl.Execute(&buf, `<em>hi</em><script>x</script>`); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "<em>hi</em>" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dynamodb for session provider
+//
+// depend on github.com/aws/aws-sdk-go
+//
+// go install github.com/aws/aws-sdk-go/...
+//
+// needs a table with the string hash key session_key,
+// enable the Time To Live of the table on the attribute session_expiry,
+// so DynamoDB removes the expired sessions by itself:
+//
+//	aws dynamodb create-table --table-name session \
+//		--attribute-definitions AttributeName=session_key,AttributeType=S \
+//		--key-schema AttributeName=session_key,KeyType=HASH \
+//		--provisioned-throughput ReadCapacityUnits=5,WriteCapacityUnits=5
+//
+//	aws dynamodb update-time-to-live --table-name session \
+//		--time-to-live-specification Enabled=true,AttributeName=session_expiry
+//
+// Usage:
+//
+//	import (
+//		_ "github.com/astaxie/beego/session/dynamodb"
+//		"github.com/astaxie/beego/session"
+//	)
+//
+//	func init() {
+//		globalSessions, _ = session.NewManager("dynamodb", `{"cookieName":"gosessionid","gclifetime":3600,"ProviderConfig":"{\"region\":\"us-east-1\",\"table\":\"session\"}"}`)
+//		go globalSessions.GC()
+//	}
+//
+// the credentials are loaded by the aws sdk, e.g. from the environment or the instance role.
+//
+// more docs: http://beego.me/docs/module/session.md
+package dynamodb
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/session"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awssession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+var dynamodbpder = &Provider{}
+
+// SessionStore dynamodb session store
+type SessionStore struct {
+	p      *Provider
+	sid    string
+	lock   sync.RWMutex
+	values map[interface{}]interface{}
+}
+
+// Set value in dynamodb session.
+// it is temp value in map.
+func (st *SessionStore) Set(key, value interface{}) error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.values[key] = value
+	return nil
+}
+
+// Get value from dynamodb session
+func (st *SessionStore) Get(key interface{}) interface{} {
+	st.lock.RLock()
+	defer st.lock.RUnlock()
+	if v, ok := st.values[key]; ok {
+		return v
+	}
+	return nil
+}
+
+// Delete value in dynamodb session
+func (st *SessionStore) Delete(key interface{}) error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	delete(st.values, key)
+	return nil
+}
+
+// Flush clear all values in dynamodb session
+func (st *SessionStore) Flush() error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.values = make(map[interface{}]interface{})
+	return nil
+}
+
+// SessionID get session id of this dynamodb session store
+func (st *SessionStore) SessionID() string {
+	return st.sid
+}
+
+// SessionRelease save dynamodb session values to the table and extend the expiry.
+// must call this method to save values to the table, the errors are logged.
+func (st *SessionStore) SessionRelease(w http.ResponseWriter) {
+	st.lock.RLock()
+	b, err := session.EncodeGob(st.values)
+	st.lock.RUnlock()
+	if err != nil {
+		log.Println("session: dynamodb encode", st.sid, err)
+		return
+	}
+	if err := st.p.put(st.sid, b); err != nil {
+		log.Println("session: dynamodb save", st.sid, err)
+	}
+}
+
+// dynamoDBAPI is the part of the DynamoDB client used by the provider.
+type dynamoDBAPI interface {
+	GetItem(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	PutItem(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	DeleteItem(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+	DescribeTable(*dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error)
+}
+
+// Provider dynamodb session provider
+type Provider struct {
+	maxlifetime int64
+	table       string
+	client      dynamoDBAPI
+}
+
+type providerConfig struct {
+	Region   string `json:"region"`
+	Table    string `json:"table"`
+	Endpoint string `json:"endpoint"` // e.g. the url of DynamoDB local
+}
+
+// SessionInit init dynamodb session.
+// savepath is a json string with the region, table and optional endpoint.
+func (p *Provider) SessionInit(maxlifetime int64, savePath string) error {
+	cf := &providerConfig{Table: "session"}
+	if err := json.Unmarshal([]byte(savePath), cf); err != nil {
+		return err
+	}
+	if cf.Region == "" {
+		return errors.New("session: dynamodb region is empty")
+	}
+	config := aws.NewConfig().WithRegion(cf.Region)
+	if cf.Endpoint != "" {
+		config = config.WithEndpoint(cf.Endpoint)
+	}
+	sess, err := awssession.NewSession(config)
+	if err != nil {
+		return err
+	}
+	p.maxlifetime = maxlifetime
+	p.table = cf.Table
+	p.client = dynamodb.New(sess)
+	return nil
+}
+
+func (p *Provider) key(sid string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{"session_key": {S: aws.String(sid)}}
+}
+
+// get returns the data of the session, ok is false if the session doesn't exist or is expired.
+// DynamoDB removes the expired items some time after the expiry, so it is checked here.
+func (p *Provider) get(sid string) (data []byte, ok bool, err error) {
+	out, err := p.client.GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String(p.table),
+		Key:            p.key(sid),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil || out.Item == nil {
+		return nil, false, err
+	}
+	if v, has := out.Item["session_expiry"]; has && v.N != nil {
+		expiry, _ := strconv.ParseInt(*v.N, 10, 64)
+		if expiry < time.Now().Unix() {
+			return nil, false, nil
+		}
+	}
+	if v, has := out.Item["session_data"]; has {
+		data = v.B
+	}
+	return data, true, nil
+}
+
+func (p *Provider) put(sid string, data []byte) error {
+	item := p.key(sid)
+	item["session_expiry"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(time.Now().Unix()+p.maxlifetime, 10))}
+	if len(data) > 0 {
+		item["session_data"] = &dynamodb.AttributeValue{B: data}
+	}
+	_, err := p.client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(p.table),
+		Item:      item,
+	})
+	return err
+}
+
+func (p *Provider) newStore(sid string, data []byte) (session.Store, error) {
+	var kv map[interface{}]interface{}
+	if len(data) == 0 {
+		kv = make(map[interface{}]interface{})
+	} else {
+		var err error
+		kv, err = session.DecodeGob(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &SessionStore{p: p, sid: sid, values: kv}, nil
+}
+
+// SessionRead get dynamodb session by sid
+func (p *Provider) SessionRead(sid string) (session.Store, error) {
+	data, ok, err := p.get(sid)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err = p.put(sid, nil); err != nil {
+			return nil, err
+		}
+	}
+	return p.newStore(sid, data)
+}
+
+// SessionExist check dynamodb session exist
+func (p *Provider) SessionExist(sid string) bool {
+	_, ok, _ := p.get(sid)
+	return ok
+}
+
+// SessionRegenerate generate new sid for dynamodb session
+func (p *Provider) SessionRegenerate(oldsid, sid string) (session.Store, error) {
+	data, _, err := p.get(oldsid)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.put(sid, data); err != nil {
+		return nil, err
+	}
+	p.SessionDestroy(oldsid)
+	return p.newStore(sid, data)
+}
+
+// SessionDestroy delete dynamodb session by sid
+func (p *Provider) SessionDestroy(sid string) error {
+	_, err := p.client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(p.table),
+		Key:       p.key(sid),
+	})
+	return err
+}
+
+// SessionGC Implement method, no used.
+// the expired sessions are removed by the Time To Live of the table.
+func (p *Provider) SessionGC() {
+	return
+}
+
+// SessionAll count values in dynamodb session.
+// DynamoDB updates the item count about every six hours, so it is approximate.
+func (p *Provider) SessionAll() int {
+	out, err := p.client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(p.table)})
+	if err != nil || out.Table == nil || out.Table.ItemCount == nil {
+		return 0
+	}
+	return int(*out.Table.ItemCount)
+}
+
+func init() {
+	session.Register("dynamodb", dynamodbpder)
+}
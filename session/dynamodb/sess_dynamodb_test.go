@@ -0,0 +1,136 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dynamodb
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// fakeDynamoDB keeps the items of a table in memory.
+type fakeDynamoDB struct {
+	items  map[string]map[string]*dynamodb.AttributeValue
+	putErr error
+}
+
+func newFakeProvider() (*Provider, *fakeDynamoDB) {
+	db := &fakeDynamoDB{items: make(map[string]map[string]*dynamodb.AttributeValue)}
+	return &Provider{maxlifetime: 3600, table: "session", client: db}, db
+}
+
+func (f *fakeDynamoDB) GetItem(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: f.items[*in.Key["session_key"].S]}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	if f.putErr != nil {
+		return nil, f.putErr
+	}
+	f.items[*in.Item["session_key"].S] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItem(in *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	delete(f.items, *in.Key["session_key"].S)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DescribeTable(in *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	n := int64(len(f.items))
+	return &dynamodb.DescribeTableOutput{Table: &dynamodb.TableDescription{ItemCount: &n}}, nil
+}
+
+func TestDynamoDBSession(t *testing.T) {
+	p, db := newFakeProvider()
+	if p.SessionExist("sid1") {
+		t.Fatal("the session shouldn't exist yet")
+	}
+	st, err := p.SessionRead("sid1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.SessionExist("sid1") {
+		t.Error("SessionRead should create the session")
+	}
+	st.Set("username", "astaxie")
+	st.SessionRelease(nil)
+
+	item := db.items["sid1"]
+	expiry, _ := strconv.ParseInt(*item["session_expiry"].N, 10, 64)
+	if now := time.Now().Unix(); expiry < now+3500 || expiry > now+3600 {
+		t.Errorf("the expiry %d should be maxlifetime from now", expiry)
+	}
+	if st, err = p.SessionRead("sid1"); err != nil || st.Get("username") != "astaxie" {
+		t.Errorf("the saved value is lost: %v %v", st.Get("username"), err)
+	}
+
+	st, err = p.SessionRegenerate("sid1", "sid2")
+	if err != nil || st.SessionID() != "sid2" || st.Get("username") != "astaxie" {
+		t.Errorf("SessionRegenerate should keep the values: %v %v", st.Get("username"), err)
+	}
+	if p.SessionExist("sid1") || !p.SessionExist("sid2") {
+		t.Error("SessionRegenerate should move the session to the new sid")
+	}
+	if n := p.SessionAll(); n != 1 {
+		t.Errorf("SessionAll = %d, want 1", n)
+	}
+
+	if err := p.SessionDestroy("sid2"); err != nil || p.SessionExist("sid2") {
+		t.Errorf("SessionDestroy should delete the session: %v", err)
+	}
+}
+
+func TestDynamoDBSessionExpired(t *testing.T) {
+	p, db := newFakeProvider()
+	db.items["old"] = map[string]*dynamodb.AttributeValue{
+		"session_key":    {S: aws.String("old")},
+		"session_expiry": {N: aws.String(strconv.FormatInt(time.Now().Unix()-1, 10))},
+		"session_data":   {B: []byte("stale")},
+	}
+	if p.SessionExist("old") {
+		t.Error("the expired session shouldn't exist before DynamoDB removes it")
+	}
+	st, err := p.SessionRead("old")
+	if err != nil || st.Get("username") != nil {
+		t.Errorf("the expired session should be read empty: %v", err)
+	}
+}
+
+func TestDynamoDBSessionReleaseError(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	p, db := newFakeProvider()
+	st, err := p.SessionRead("sid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.putErr = errors.New("ProvisionedThroughputExceededException")
+	st.Set("username", "astaxie")
+	st.SessionRelease(nil)
+	if !strings.Contains(buf.String(), "ProvisionedThroughputExceededException") {
+		t.Errorf("the failed save should be logged: %q", buf.String())
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+// maxSessionIDLength is the max length of a session id accepted from the request cookie.
+const maxSessionIDLength = 256
+
+// IDProvider generates the session ids instead of the default generator.
+// The ids must be unpredictable and only contain letters, digits, '-' and '_'.
+type IDProvider interface {
+	SessionID(r *http.Request) (string, error)
+}
+
+// IDProviderFunc is an adapter to use a function as IDProvider.
+type IDProviderFunc func(r *http.Request) (string, error)
+
+// SessionID calls f(r).
+func (f IDProviderFunc) SessionID(r *http.Request) (string, error) {
+	return f(r)
+}
+
+var errSessionIDRand = errors.New("Could not successfully read from the system CSPRNG.")
+
+// SetIDProvider sets a custom session id provider, nil restores the default generator.
+func (manager *Manager) SetIDProvider(p IDProvider) {
+	manager.idProvider = p
+}
+
+func (manager *Manager) sessionID(r *http.Request) (string, error) {
+	if manager.idProvider != nil {
+		sid, err := manager.idProvider.SessionID(r)
+		if err != nil {
+			return "", err
+		}
+		if !validSessionID(sid) {
+			return "", errors.New("session: invalid session id from IDProvider")
+		}
+		return sid, nil
+	}
+	if manager.config.SessionIDAlphabet != "" {
+		return randomString(manager.config.SessionIDAlphabet, int(manager.config.SessionIDLength))
+	}
+	b := make([]byte, manager.config.SessionIDLength)
+	n, err := rand.Read(b)
+	if n != len(b) || err != nil {
+		return "", errSessionIDRand
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// randomString returns n characters chosen uniformly from alphabet with crypto/rand.
+func randomString(alphabet string, n int) (string, error) {
+	// bytes above max are rejected, so every character has the same probability.
+	max := 256 - 256%len(alphabet)
+	s := make([]byte, 0, n)
+	b := make([]byte, n)
+	for len(s) < n {
+		if _, err := rand.Read(b); err != nil {
+			return "", errSessionIDRand
+		}
+		for _, c := range b {
+			if int(c) < max {
+				s = append(s, alphabet[int(c)%len(alphabet)])
+				if len(s) == n {
+					break
+				}
+			}
+		}
+	}
+	return string(s), nil
+}
+
+// validSessionID reports whether sid is safe to pass to the providers,
+// e.g. the file provider uses the id in the file path.
+func validSessionID(sid string) bool {
+	if sid == "" || len(sid) > maxSessionIDLength {
+		return false
+	}
+	for i := 0; i < len(sid); i++ {
+		c := sid[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
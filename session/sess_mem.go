@@ -16,12 +16,14 @@ package session
 
 import (
 	"container/list"
+	"errors"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 )
 
-var mempder = &MemProvider{list: list.New(), sessions: make(map[string]*list.Element)}
+var mempder = &MemProvider{list: list.New(), sessions: make(map[string]*list.Element), users: make(map[string]map[string]bool)}
 
 // MemSessionStore memory session store.
 // it saved sessions in a map in memory.
@@ -30,6 +32,8 @@ type MemSessionStore struct {
 	timeAccessed time.Time                   //last access time
 	value        map[interface{}]interface{} //session store
 	lock         sync.RWMutex
+	user         string    //user key bound by SessionBindUser
+	timeBound    time.Time //time of SessionBindUser
 }
 
 // Set value to memory session
@@ -77,9 +81,10 @@ func (st *MemSessionStore) SessionRelease(w http.ResponseWriter) {
 
 // MemProvider Implement the provider interface
 type MemProvider struct {
-	lock        sync.RWMutex             // locker
-	sessions    map[string]*list.Element // map in memory
-	list        *list.List               // for gc
+	lock        sync.RWMutex               // locker
+	sessions    map[string]*list.Element   // map in memory
+	list        *list.List                 // for gc
+	users       map[string]map[string]bool // session ids of the users
 	maxlifetime int64
 	savePath    string
 }
@@ -128,6 +133,10 @@ func (pder *MemProvider) SessionRegenerate(oldsid, sid string) (Store, error) {
 		element.Value.(*MemSessionStore).sid = sid
 		pder.sessions[sid] = element
 		delete(pder.sessions, oldsid)
+		if user := element.Value.(*MemSessionStore).user; user != "" {
+			delete(pder.users[user], oldsid)
+			pder.users[user][sid] = true
+		}
 		pder.lock.Unlock()
 		return element.Value.(*MemSessionStore), nil
 	}
@@ -145,8 +154,7 @@ func (pder *MemProvider) SessionDestroy(sid string) error {
 	pder.lock.Lock()
 	defer pder.lock.Unlock()
 	if element, ok := pder.sessions[sid]; ok {
-		delete(pder.sessions, sid)
-		pder.list.Remove(element)
+		pder.remove(element)
 		return nil
 	}
 	return nil
@@ -163,8 +171,7 @@ func (pder *MemProvider) SessionGC() {
 		if (element.Value.(*MemSessionStore).timeAccessed.Unix() + pder.maxlifetime) < time.Now().Unix() {
 			pder.lock.RUnlock()
 			pder.lock.Lock()
-			pder.list.Remove(element)
-			delete(pder.sessions, element.Value.(*MemSessionStore).sid)
+			pder.remove(element)
 			pder.lock.Unlock()
 			pder.lock.RLock()
 		} else {
@@ -174,6 +181,103 @@ func (pder *MemProvider) SessionGC() {
 	pder.lock.RUnlock()
 }
 
+// SessionGCBatch clean at most n expired session stores in memory session
+func (pder *MemProvider) SessionGCBatch(n int) int {
+	pder.lock.Lock()
+	defer pder.lock.Unlock()
+	removed := 0
+	for removed < n {
+		element := pder.list.Back()
+		if element == nil {
+			break
+		}
+		if (element.Value.(*MemSessionStore).timeAccessed.Unix() + pder.maxlifetime) >= time.Now().Unix() {
+			break
+		}
+		pder.remove(element)
+		removed++
+	}
+	return removed
+}
+
+// remove deletes the session store of element, the caller must hold the write lock.
+func (pder *MemProvider) remove(element *list.Element) {
+	st := element.Value.(*MemSessionStore)
+	pder.list.Remove(element)
+	delete(pder.sessions, st.sid)
+	if st.user != "" {
+		delete(pder.users[st.user], st.sid)
+		if len(pder.users[st.user]) == 0 {
+			delete(pder.users, st.user)
+		}
+	}
+}
+
+// SessionBindUser binds the memory session store to the user
+func (pder *MemProvider) SessionBindUser(sid, user string) error {
+	pder.lock.Lock()
+	defer pder.lock.Unlock()
+	element, ok := pder.sessions[sid]
+	if !ok {
+		return errors.New("session: session " + sid + " doesn't exist")
+	}
+	st := element.Value.(*MemSessionStore)
+	if st.user != "" {
+		delete(pder.users[st.user], sid)
+	}
+	if pder.users == nil {
+		pder.users = make(map[string]map[string]bool)
+	}
+	if pder.users[user] == nil {
+		pder.users[user] = make(map[string]bool)
+	}
+	pder.users[user][sid] = true
+	st.user = user
+	st.timeBound = time.Now()
+	return nil
+}
+
+// SessionUserIDs get the session ids of the user, the oldest bound session first
+func (pder *MemProvider) SessionUserIDs(user string) []string {
+	pder.lock.RLock()
+	defer pder.lock.RUnlock()
+	stores := make([]*MemSessionStore, 0, len(pder.users[user]))
+	for sid := range pder.users[user] {
+		stores = append(stores, pder.sessions[sid].Value.(*MemSessionStore))
+	}
+	sort.Sort(storesByBound(stores))
+	sids := make([]string, len(stores))
+	for i, st := range stores {
+		sids[i] = st.sid
+	}
+	return sids
+}
+
+// SessionInfo get the metadata of the memory session store
+func (pder *MemProvider) SessionInfo(sid string) (*SessionInfo, bool) {
+	pder.lock.RLock()
+	defer pder.lock.RUnlock()
+	element, ok := pder.sessions[sid]
+	if !ok {
+		return nil, false
+	}
+	st := element.Value.(*MemSessionStore)
+	st.lock.RLock()
+	defer st.lock.RUnlock()
+	return &SessionInfo{
+		User:         st.user,
+		Bound:        st.timeBound,
+		LastAccessed: st.timeAccessed,
+		Values:       len(st.value),
+	}, true
+}
+
+type storesByBound []*MemSessionStore
+
+func (s storesByBound) Len() int           { return len(s) }
+func (s storesByBound) Less(i, j int) bool { return s[i].timeBound.Before(s[j].timeBound) }
+func (s storesByBound) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
 // SessionAll get count number of memory session
 func (pder *MemProvider) SessionAll() int {
 	return pder.list.Len()
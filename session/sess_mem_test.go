@@ -15,6 +15,8 @@
 package session
 
 import (
+	"container/list"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -50,3 +52,147 @@ func TestMem(t *testing.T) {
 		}
 	}
 }
+
+func TestMemGCBatch(t *testing.T) {
+	pder := &MemProvider{list: list.New(), sessions: make(map[string]*list.Element)}
+	pder.SessionInit(-1, "")
+	for _, sid := range []string{"a", "b", "c"} {
+		pder.SessionRead(sid)
+	}
+	if n := pder.SessionGCBatch(2); n != 2 {
+		t.Fatal("gc batch should remove 2 sessions, removed", n)
+	}
+	if n := pder.SessionGCBatch(2); n != 1 {
+		t.Fatal("gc batch should remove the last session, removed", n)
+	}
+	if pder.SessionAll() != 0 {
+		t.Fatal("all the sessions should be expired")
+	}
+}
+
+func TestManagerGCStats(t *testing.T) {
+	for _, config := range []string{
+		`{"cookieName":"gosessionid","gclifetime":3600,"maxLifetime":-1}`,
+		`{"cookieName":"gosessionid","gclifetime":3600,"maxLifetime":-1,"gcBatchSize":2}`,
+	} {
+		manager, err := NewManager("memory", config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the memory provider is shared by the tests
+		manager.provider.SessionGC()
+		for i := 0; i < 3; i++ {
+			manager.provider.SessionRead(fmt.Sprintf("gcstats%d", i))
+		}
+		manager.GC()
+		if stats := manager.GCStats(); stats.Runs != 1 || stats.Expired != 3 {
+			t.Errorf("%s: gc stats got %+v, want 3 expired", config, stats)
+		}
+	}
+}
+
+func TestNewManagerSessionID(t *testing.T) {
+	for config, valid := range map[string]bool{
+		`{"cookieName":"gosessionid","sessionIDAlphabet":"abc-_"}`:                        true,
+		`{"cookieName":"gosessionid","sessionIDAlphabet":"abc/."}`:                        false,
+		`{"cookieName":"gosessionid","sessionIDAlphabet":"abc;="}`:                        false,
+		`{"cookieName":"gosessionid","sessionIDLength":128}`:                              true,
+		`{"cookieName":"gosessionid","sessionIDLength":129}`:                              false,
+		`{"cookieName":"gosessionid","sessionIDLength":256,"sessionIDAlphabet":"abcdef"}`: true,
+		`{"cookieName":"gosessionid","sessionIDLength":257,"sessionIDAlphabet":"abcdef"}`: false,
+	} {
+		if _, err := NewManager("memory", config); (err == nil) != valid {
+			t.Errorf("NewManager(%s) got %v, valid %t", config, err, valid)
+		}
+	}
+}
+
+func TestSessionUpgrade(t *testing.T) {
+	manager, _ := NewManager("memory", `{"cookieName":"gosessionid","gclifetime":10,"sessionIDLength":24,"sessionIDAlphabet":"abcdef"}`)
+	r, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	sess, _ := manager.SessionStart(w, r)
+	oldsid := sess.SessionID()
+	if len(oldsid) != 24 || strings.Trim(oldsid, "abcdef") != "" {
+		t.Fatal("session id should use the alphabet:", oldsid)
+	}
+	sess.Set("username", "astaxie")
+
+	w = httptest.NewRecorder()
+	sess, err := manager.SessionUpgrade(w, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.SessionID() == oldsid || manager.provider.SessionExist(oldsid) {
+		t.Fatal("old session id should be invalid after upgrade")
+	}
+	if sess.Get("username") != "astaxie" {
+		t.Fatal("session data should be kept after upgrade")
+	}
+	if c, _ := r.Cookie("gosessionid"); c == nil || c.Value != sess.SessionID() {
+		t.Fatal("request cookie should have the new session id")
+	}
+}
+
+func TestSessionIDProvider(t *testing.T) {
+	manager, _ := NewManager("memory", `{"cookieName":"gosessionid","gclifetime":10}`)
+	manager.SetIDProvider(IDProviderFunc(func(r *http.Request) (string, error) {
+		return "custom_id", nil
+	}))
+	r, _ := http.NewRequest("GET", "/", nil)
+	sess, _ := manager.SessionStart(httptest.NewRecorder(), r)
+	if sess.SessionID() != "custom_id" {
+		t.Fatal("custom id provider is not used:", sess.SessionID())
+	}
+
+	r, _ = http.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "gosessionid", Value: "../../etc"})
+	manager.SetIDProvider(nil)
+	sess, _ = manager.SessionStart(httptest.NewRecorder(), r)
+	if !validSessionID(sess.SessionID()) || sess.SessionID() == "../../etc" {
+		t.Fatal("invalid session id from cookie should be replaced")
+	}
+}
+
+func TestMemUserSessions(t *testing.T) {
+	manager, _ := NewManager("memory", `{"cookieName":"gosessionid","gclifetime":10,"maxSessionsPerUser":2}`)
+	var sids []string
+	for i := 0; i < 3; i++ {
+		r, _ := http.NewRequest("GET", "/", nil)
+		sess, _ := manager.SessionStart(httptest.NewRecorder(), r)
+		if err := manager.BindUser(sess.SessionID(), "astaxie"); err != nil {
+			t.Fatal(err)
+		}
+		sids = append(sids, sess.SessionID())
+	}
+	if manager.provider.SessionExist(sids[0]) {
+		t.Fatal("the oldest session should be revoked over the limit")
+	}
+	got, _ := manager.UserSessions("astaxie")
+	if len(got) != 2 || got[0] != sids[1] || got[1] != sids[2] {
+		t.Fatal("unexpected user sessions:", got)
+	}
+
+	infos, _ := manager.UserSessionInfos("astaxie")
+	if len(infos) != 2 || infos[0].User != "astaxie" || infos[0].Handle == sids[1] || infos[1].LastAccessed.IsZero() {
+		t.Fatal("unexpected session infos:", infos)
+	}
+	if ok, err := manager.RevokeUserSession("astaxie", infos[0].Handle); !ok || err != nil {
+		t.Fatal("revoke the session by handle:", ok, err)
+	}
+	if ok, _ := manager.RevokeUserSession("nobody", infos[1].Handle); ok {
+		t.Fatal("the session of another user shouldn't be revoked")
+	}
+	got, _ = manager.UserSessions("astaxie")
+	if len(got) != 1 || got[0] != sids[2] || manager.provider.SessionExist(sids[1]) {
+		t.Fatal("only the revoked session should be destroyed:", got)
+	}
+
+	if err := manager.RevokeUserSessions("astaxie", sids[2]); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = manager.UserSessions("astaxie")
+	if len(got) != 1 || got[0] != sids[2] || manager.provider.SessionExist(sids[1]) {
+		t.Fatal("only the excepted session should be kept:", got)
+	}
+}
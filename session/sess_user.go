@@ -0,0 +1,154 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// ErrUserIndexNotSupported is returned when the provider doesn't implement UserIndexProvider.
+var ErrUserIndexNotSupported = errors.New("session: provider doesn't support the user index")
+
+// UserIndexProvider is implemented by providers which index the sessions by user key,
+// it is required to list, revoke and limit the sessions of a user.
+type UserIndexProvider interface {
+	// SessionBindUser binds the session to the user, a session belongs to one user at most.
+	SessionBindUser(sid, user string) error
+	// SessionUserIDs returns the session ids of the user, the oldest bound session first.
+	SessionUserIDs(user string) []string
+}
+
+// SessionInfo is the non-sensitive metadata of a session, it doesn't carry the session id nor the values.
+// Handle identifies the session to RevokeUserSession without exposing the id.
+type SessionInfo struct {
+	Handle       string
+	User         string
+	Bound        time.Time // time of BindUser
+	LastAccessed time.Time
+	Values       int // number of the values stored in the session
+}
+
+// SessionInfoProvider is implemented by providers which report the metadata of the sessions.
+type SessionInfoProvider interface {
+	// SessionInfo returns the metadata of the session, Handle is filled by the Manager.
+	SessionInfo(sid string) (*SessionInfo, bool)
+}
+
+// sessionHandle derives the handle of the session id, the id can't be recovered from it.
+func sessionHandle(sid string) string {
+	h := sha256.Sum256([]byte(sid))
+	return hex.EncodeToString(h[:8])
+}
+
+func (manager *Manager) userIndex() (UserIndexProvider, error) {
+	if up, ok := manager.provider.(UserIndexProvider); ok {
+		return up, nil
+	}
+	return nil, ErrUserIndexNotSupported
+}
+
+// BindUser binds the session to the user, usually after login.
+// If maxSessionsPerUser is set, the oldest sessions of the user are revoked to keep the limit.
+func (manager *Manager) BindUser(sid, user string) error {
+	up, err := manager.userIndex()
+	if err != nil {
+		return err
+	}
+	if err := up.SessionBindUser(sid, user); err != nil {
+		return err
+	}
+	if max := manager.config.MaxSessionsPerUser; max > 0 {
+		sids := up.SessionUserIDs(user)
+		for i := 0; i < len(sids)-max; i++ {
+			if err := manager.provider.SessionDestroy(sids[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// UserSessions returns the session ids of the user, the oldest bound session first.
+func (manager *Manager) UserSessions(user string) ([]string, error) {
+	up, err := manager.userIndex()
+	if err != nil {
+		return nil, err
+	}
+	return up.SessionUserIDs(user), nil
+}
+
+// RevokeUserSessions destroys all the sessions of the user except the given session ids,
+// e.g. "sign out other devices" keeps the current session.
+func (manager *Manager) RevokeUserSessions(user string, except ...string) error {
+	sids, err := manager.UserSessions(user)
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]bool, len(except))
+	for _, sid := range except {
+		keep[sid] = true
+	}
+	for _, sid := range sids {
+		if keep[sid] {
+			continue
+		}
+		if err := manager.provider.SessionDestroy(sid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UserSessionInfos returns the metadata of the sessions of the user, the oldest bound session first.
+// Only Handle and User are set if the provider doesn't implement SessionInfoProvider.
+func (manager *Manager) UserSessionInfos(user string) ([]*SessionInfo, error) {
+	sids, err := manager.UserSessions(user)
+	if err != nil {
+		return nil, err
+	}
+	ip, _ := manager.provider.(SessionInfoProvider)
+	infos := make([]*SessionInfo, 0, len(sids))
+	for _, sid := range sids {
+		info := &SessionInfo{User: user}
+		if ip != nil {
+			i, ok := ip.SessionInfo(sid)
+			if !ok {
+				continue
+			}
+			info = i
+		}
+		info.Handle = sessionHandle(sid)
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// RevokeUserSession destroys the session of the user identified by the Handle of its SessionInfo,
+// it returns false if the user has no such session.
+func (manager *Manager) RevokeUserSession(user, handle string) (bool, error) {
+	sids, err := manager.UserSessions(user)
+	if err != nil {
+		return false, err
+	}
+	for _, sid := range sids {
+		if sessionHandle(sid) == handle {
+			return true, manager.provider.SessionDestroy(sid)
+		}
+	}
+	return false, nil
+}
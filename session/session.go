@@ -20,7 +20,7 @@
 // )
 //
 //	func init() {
-//      globalSessions, _ = session.NewManager("memory", `{"cookieName":"gosessionid", "enableSetCookie,omitempty": true, "gclifetime":3600, "maxLifetime": 3600, "secure": false, "sessionIDHashFunc": "sha1", "sessionIDHashKey": "", "cookieLifeTime": 3600, "providerConfig": ""}`)
+//      globalSessions, _ = session.NewManager("memory", `{"cookieName":"gosessionid", "enableSetCookie,omitempty": true, "gclifetime":3600, "maxLifetime": 3600, "secure": false, "sessionIDHashFunc": "sha1", "sessionIDHashKey": "", "cookieLifeTime": 3600, "providerConfig": "", "gcInterval": 60, "gcBatchSize": 1000, "gcBatchPause": 10}`)
 //		go globalSessions.GC()
 //	}
 //
@@ -28,12 +28,12 @@
 package session
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 )
 
@@ -59,6 +59,21 @@ type Provider interface {
 	SessionGC()
 }
 
+// BatchGCProvider is implemented by providers which can remove expired sessions in batches,
+// so a gc run doesn't lock a large store for a long time.
+type BatchGCProvider interface {
+	// SessionGCBatch removes at most n expired sessions and returns the number of removed sessions.
+	SessionGCBatch(n int) int
+}
+
+// GCStats contains the statistics of the session gc.
+type GCStats struct {
+	Runs         int64         // number of gc runs
+	Expired      int64         // number of sessions removed by gc, estimated from SessionAll without the batches
+	LastRun      time.Time     // start time of the last gc run
+	LastDuration time.Duration // duration of the last gc run
+}
+
 var provides = make(map[string]Provider)
 
 // Register makes a session provide available by the provided name.
@@ -84,12 +99,26 @@ type managerConfig struct {
 	ProviderConfig  string `json:"providerConfig"`
 	Domain          string `json:"domain"`
 	SessionIDLength int64  `json:"sessionIDLength"`
+	GcInterval      int64  `json:"gcInterval"`    // seconds between two gc runs, default is gclifetime
+	GcBatchSize     int    `json:"gcBatchSize"`   // sessions removed per batch by BatchGCProvider, 0 means no batch
+	GcBatchPause    int64  `json:"gcBatchPause"`  // milliseconds to sleep between two batches
+	GcMaxDuration   int64  `json:"gcMaxDuration"` // milliseconds a gc run may take, the rest is left for the next run
+
+	// SessionIDAlphabet makes ids of sessionIDLength characters from it, default is hex of sessionIDLength random bytes.
+	// It can only contain letters, digits, '-' and '_', the hex ids can be 128 bytes at most
+	SessionIDAlphabet string `json:"sessionIDAlphabet"`
+	// MaxSessionsPerUser limits the sessions bound to one user by BindUser, 0 means no limit
+	MaxSessionsPerUser int `json:"maxSessionsPerUser"`
 }
 
 // Manager contains Provider and its configuration.
 type Manager struct {
 	provider Provider
 	config   *managerConfig
+	gcLock   sync.RWMutex
+	gcStats  GCStats
+
+	idProvider IDProvider
 }
 
 // NewManager Create new Manager with provider name and json config string.
@@ -118,18 +147,37 @@ func NewManager(provideName, config string) (*Manager, error) {
 	if cf.Maxlifetime == 0 {
 		cf.Maxlifetime = cf.Gclifetime
 	}
+
+	if cf.SessionIDLength == 0 {
+		cf.SessionIDLength = 16
+	}
+
+	if len(cf.SessionIDAlphabet) > 256 {
+		return nil, errors.New("session: sessionIDAlphabet can't be longer than 256")
+	}
+	// the generated ids must pass validSessionID when they come back in the cookie
+	if cf.SessionIDAlphabet != "" && !validSessionID(cf.SessionIDAlphabet) {
+		return nil, errors.New("session: sessionIDAlphabet can only contain letters, digits, '-' and '_'")
+	}
+	if cf.SessionIDAlphabet == "" && cf.SessionIDLength*2 > maxSessionIDLength {
+		return nil, fmt.Errorf("session: sessionIDLength can't be longer than %d", maxSessionIDLength/2)
+	}
+	if cf.SessionIDAlphabet != "" && cf.SessionIDLength > maxSessionIDLength {
+		return nil, fmt.Errorf("session: sessionIDLength can't be longer than %d with sessionIDAlphabet", maxSessionIDLength)
+	}
+
 	err = provider.SessionInit(cf.Maxlifetime, cf.ProviderConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	if cf.SessionIDLength == 0 {
-		cf.SessionIDLength = 16
+	if cf.GcInterval == 0 {
+		cf.GcInterval = cf.Gclifetime
 	}
 
 	return &Manager{
-		provider,
-		cf,
+		provider: provider,
+		config:   cf,
 	}, nil
 }
 
@@ -164,7 +212,7 @@ func (manager *Manager) SessionStart(w http.ResponseWriter, r *http.Request) (se
 		if errs != nil {
 			return nil, errs
 		}
-		if manager.provider.SessionExist(sid) {
+		if validSessionID(sid) && manager.provider.SessionExist(sid) {
 			session, err = manager.provider.SessionRead(sid)
 		} else {
 			sid, err = manager.sessionID(r)
@@ -216,10 +264,54 @@ func (manager *Manager) GetSessionStore(sid string) (sessions Store, err error)
 }
 
 // GC Start session gc process.
-// it can do gc in times after gc lifetime.
+// it can do gc in times after gc interval, default is gc lifetime.
+// if the provider is a BatchGCProvider and gcBatchSize is set, expired sessions are removed in batches.
 func (manager *Manager) GC() {
-	manager.provider.SessionGC()
-	time.AfterFunc(time.Duration(manager.config.Gclifetime)*time.Second, func() { manager.GC() })
+	start := time.Now()
+	var expired int64
+	if bp, ok := manager.provider.(BatchGCProvider); ok && manager.config.GcBatchSize > 0 {
+		expired = manager.batchGC(bp, start)
+	} else {
+		// the sessions created or destroyed by the requests during the run skew it, so it's an estimate
+		before := manager.provider.SessionAll()
+		manager.provider.SessionGC()
+		if after := manager.provider.SessionAll(); after < before {
+			expired = int64(before - after)
+		}
+	}
+	manager.gcLock.Lock()
+	manager.gcStats.Runs++
+	manager.gcStats.Expired += expired
+	manager.gcStats.LastRun = start
+	manager.gcStats.LastDuration = time.Since(start)
+	manager.gcLock.Unlock()
+	time.AfterFunc(time.Duration(manager.config.GcInterval)*time.Second, func() { manager.GC() })
+}
+
+func (manager *Manager) batchGC(bp BatchGCProvider, start time.Time) int64 {
+	var expired int64
+	maxDuration := time.Duration(manager.config.GcMaxDuration) * time.Millisecond
+	for {
+		n := bp.SessionGCBatch(manager.config.GcBatchSize)
+		expired += int64(n)
+		if n < manager.config.GcBatchSize {
+			break
+		}
+		if maxDuration > 0 && time.Since(start) >= maxDuration {
+			break
+		}
+		if manager.config.GcBatchPause > 0 {
+			time.Sleep(time.Duration(manager.config.GcBatchPause) * time.Millisecond)
+		}
+	}
+	return expired
+}
+
+// GCStats returns the statistics of the session gc.
+func (manager *Manager) GCStats() GCStats {
+	manager.gcLock.RLock()
+	defer manager.gcLock.RUnlock()
+	return manager.gcStats
 }
 
 // SessionRegenerateID Regenerate a session id for this SessionStore who's id is saving in http request.
@@ -229,7 +321,7 @@ func (manager *Manager) SessionRegenerateID(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	cookie, err := r.Cookie(manager.config.CookieName)
-	if err != nil && cookie.Value == "" {
+	if err != nil || cookie.Value == "" {
 		//delete old cookie
 		session, _ = manager.provider.SessionRead(sid)
 		cookie = &http.Cookie{Name: manager.config.CookieName,
@@ -255,6 +347,55 @@ func (manager *Manager) SessionRegenerateID(w http.ResponseWriter, r *http.Reque
 	return
 }
 
+// SessionUpgrade regenerates the session id and keeps the session data,
+// it should be called when the privilege of the session changes, e.g. after login,
+// so an id known before the login (session fixation) is useless.
+func (manager *Manager) SessionUpgrade(w http.ResponseWriter, r *http.Request) (Store, error) {
+	var oldsid string
+	if cookie, err := r.Cookie(manager.config.CookieName); err == nil {
+		oldsid, _ = url.QueryUnescape(cookie.Value)
+	}
+	if oldsid != "" && !validSessionID(oldsid) {
+		oldsid = ""
+	}
+	sid, err := manager.sessionID(r)
+	if err != nil {
+		return nil, err
+	}
+	var session Store
+	if oldsid != "" && manager.provider.SessionExist(oldsid) {
+		session, err = manager.provider.SessionRegenerate(oldsid, sid)
+	} else {
+		session, err = manager.provider.SessionRead(sid)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cookie := &http.Cookie{
+		Name:     manager.config.CookieName,
+		Value:    url.QueryEscape(sid),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   manager.isSecure(r),
+		Domain:   manager.config.Domain,
+	}
+	if manager.config.CookieLifeTime > 0 {
+		cookie.MaxAge = manager.config.CookieLifeTime
+		cookie.Expires = time.Now().Add(time.Duration(manager.config.CookieLifeTime) * time.Second)
+	}
+	http.SetCookie(w, cookie)
+	// replace the old id in the request, so the rest of the request reads the new one.
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, c := range cookies {
+		if c.Name != manager.config.CookieName {
+			r.AddCookie(c)
+		}
+	}
+	r.AddCookie(cookie)
+	return session, nil
+}
+
 // GetActiveSession Get all active sessions count number.
 func (manager *Manager) GetActiveSession() int {
 	return manager.provider.SessionAll()
@@ -265,15 +406,6 @@ func (manager *Manager) SetSecure(secure bool) {
 	manager.config.Secure = secure
 }
 
-func (manager *Manager) sessionID(r *http.Request) (string, error) {
-	b := make([]byte, manager.config.SessionIDLength)
-	n, err := rand.Read(b)
-	if n != len(b) || err != nil {
-		return "", fmt.Errorf("Could not successfully read from the system CSPRNG.")
-	}
-	return hex.EncodeToString(b), nil
-}
-
 // Set cookie with https.
 func (manager *Manager) isSecure(req *http.Request) bool {
 	if !manager.config.Secure {
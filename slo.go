@@ -0,0 +1,51 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/toolbox"
+)
+
+// routeSLO is the SLO of a router, it's registered in toolbox.SLOs by the pattern on the first request,
+// when the namespace prefix is already added to the pattern.
+type routeSLO struct {
+	slo  toolbox.SLO
+	once sync.Once
+}
+
+// WithSLO declares the SLO of the router, the requests are observed by toolbox.SLOs under the router pattern,
+// which computes the burn rates and calls the functions added by toolbox.SLOs.OnAlert.
+// usage:
+//
+//	beego.RouterWithOptions("/order", &OrderController{}, beego.WithSLO(toolbox.SLO{
+//		Latency:      300 * time.Millisecond,
+//		Availability: 0.999,
+//	}))
+//	toolbox.SLOs.OnAlert(toolbox.SLOWebhook("https://hooks.example.com/slo"))
+func WithSLO(slo toolbox.SLO) RouterOption {
+	return func(o *routerOptions) {
+		o.slo = &routeSLO{slo: slo}
+	}
+}
+
+func (c *controllerInfo) observeSLO(status int, latency time.Duration) {
+	c.slo.once.Do(func() {
+		toolbox.SLOs.Register(c.pattern, c.slo.slo)
+	})
+	toolbox.SLOs.Observe(c.pattern, status, latency)
+}
@@ -0,0 +1,44 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/context"
+)
+
+// slowRequestReport returns the log of a request which took longer than WatchdogThreshold,
+// with the matched pattern and the params. The stack isn't taken again, the watchdog logs it
+// with the stacks of all the slow requests running at the time, by one dump.
+func slowRequestReport(ctx *context.Context, req *watchedRequest, latency time.Duration) string {
+	pattern := ctx.Input.RouterPattern
+	if pattern == "" {
+		pattern = "not matched"
+	}
+	params := make([]string, 0, ctx.Input.ParamsLen())
+	for i := 0; i < ctx.Input.ParamsLen(); i++ {
+		k, v := ctx.Input.ParamAt(i)
+		params = append(params, k+"="+v)
+	}
+	stack := "the request finished before the watchdog took its stack"
+	if watchdog.reported(req) {
+		stack = "its stack is logged by the watchdog"
+	}
+	return fmt.Sprintf("slow request: %s %s (%s) took %s, params [%s], %s",
+		ctx.Request.Method, ctx.Request.URL.Path, pattern, latency, strings.Join(params, " "), stack)
+}
@@ -15,6 +15,11 @@
 package beego
 
 import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net"
 	"net/http"
 	"os"
 	"path"
@@ -26,11 +31,147 @@ import (
 	"github.com/astaxie/beego/utils"
 )
 
-func serverStaticRouter(ctx *context.Context) {
+// StaticOptions holds the options of a static directory added by SetStaticPathWithOptions.
+type StaticOptions struct {
+	// Host limits the mapping to requests for this host, empty matches every host.
+	Host string
+	// IndexFiles are tried in order when a directory is requested.
+	IndexFiles []string
+	// CacheControl is sent as the Cache-Control header of every file served.
+	CacheControl string
+	// Filter runs before the file is served, it can stop serving by writing a response.
+	Filter FilterFunc
+	// FS is the file system the directory is in instead of the disk, e.g. an embed.FS.
+	FS fs.FS
+	// SPAFallback is the file in the directory served for the paths which don't match a file,
+	// e.g. "index.html" of a single-page application routing on the client.
+	// The paths with an extension are still 404, so the missing assets aren't answered with the page.
+	SPAFallback string
+}
+
+type staticRoute struct {
+	id     int
+	prefix string
+	dir    string
+	host   string
+	opts   StaticOptions
+}
+
+var (
+	staticRoutes  []*staticRoute
+	staticRouteID int
+)
+
+// matchStaticRoute returns the longest static route for the host and path.
+func matchStaticRoute(host, requestPath string) *staticRoute {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	var found *staticRoute
+	for _, r := range staticRoutes {
+		if r.host != "" && r.host != host {
+			continue
+		}
+		if !strings.HasPrefix(requestPath, r.prefix) {
+			continue
+		}
+		if len(requestPath) > len(r.prefix) && r.prefix != "" && requestPath[len(r.prefix)] != '/' {
+			continue
+		}
+		if found == nil || len(r.prefix) > len(found.prefix) || (len(r.prefix) == len(found.prefix) && found.host == "") {
+			found = r
+		}
+	}
+	return found
+}
+
+// serveStaticRoute serves the file of r for requestPath. When there is no file and routed reports
+// a router for the path, e.g. the api under a root SPA, nothing is written and the router serves it.
+func serveStaticRoute(ctx *context.Context, r *staticRoute, requestPath string, routed func(string) bool) {
+	if r.opts.Filter != nil {
+		r.opts.Filter(ctx)
+		if ctx.Written() {
+			return
+		}
+	}
+	fsys := staticFS(r)
+	file := path.Join(r.dir, requestPath[len(r.prefix):])
+	finfo, err := staticStat(fsys, file)
+	if err != nil {
+		if routed != nil && routed(requestPath) {
+			return
+		}
+		if serveStaticFallback(ctx, r, fsys, requestPath) {
+			return
+		}
+		if RunMode == "dev" {
+			Warn("Can't find the file:", file, err)
+		}
+		http.NotFound(ctx.ResponseWriter, ctx.Request)
+		return
+	}
+	if finfo.IsDir() {
+		index := ""
+		for _, name := range r.opts.IndexFiles {
+			if fi, err := staticStat(fsys, path.Join(file, name)); err == nil && !fi.IsDir() {
+				index = path.Join(file, name)
+				break
+			}
+		}
+		if index == "" && routed != nil && routed(requestPath) {
+			return
+		}
+		if index == "" && serveStaticFallback(ctx, r, fsys, requestPath) {
+			return
+		}
+		if index == "" && (!DirectoryIndex || fsys != nil) {
+			exception("403", ctx)
+			return
+		}
+		if ctx.Input.Request.URL.Path[len(ctx.Input.Request.URL.Path)-1] != '/' {
+			http.Redirect(ctx.ResponseWriter, ctx.Request, ctx.Input.Request.URL.Path+"/", 302)
+			return
+		}
+		if index != "" {
+			file = index
+			if finfo, err = staticStat(fsys, file); err != nil {
+				http.NotFound(ctx.ResponseWriter, ctx.Request)
+				return
+			}
+		}
+	}
+	if r.opts.CacheControl != "" {
+		ctx.Output.Header("Cache-Control", r.opts.CacheControl)
+	}
+	serveStaticFile(ctx, r, file, finfo)
+}
+
+// serveStaticFallback serves the SPAFallback file of r if the path has no extension.
+// The page isn't cached by the browsers, so a deploy takes effect at once.
+func serveStaticFallback(ctx *context.Context, r *staticRoute, fsys fs.FS, requestPath string) bool {
+	if r.opts.SPAFallback == "" || path.Ext(requestPath) != "" {
+		return false
+	}
+	file := path.Join(r.dir, r.opts.SPAFallback)
+	finfo, err := staticStat(fsys, file)
+	if err != nil || finfo.IsDir() {
+		return false
+	}
+	ctx.Output.Header("Cache-Control", "no-cache")
+	serveStaticFile(ctx, r, file, finfo)
+	return true
+}
+
+func serverStaticRouter(ctx *context.Context, routed func(string) bool) {
 	if ctx.Input.Method() != "GET" && ctx.Input.Method() != "HEAD" {
 		return
 	}
 	requestPath := filepath.Clean(ctx.Input.Request.URL.Path)
+	if r := matchStaticRoute(ctx.Input.Request.Host, requestPath); r != nil {
+		serveStaticRoute(ctx, r, requestPath, routed)
+		return
+	}
 	i := 0
 	for prefix, staticDir := range StaticDir {
 		if len(prefix) == 0 {
@@ -38,8 +179,8 @@ func serverStaticRouter(ctx *context.Context) {
 		}
 		if requestPath == "/favicon.ico" || requestPath == "/robots.txt" {
 			file := path.Join(staticDir, requestPath)
-			if utils.FileExists(file) {
-				http.ServeFile(ctx.ResponseWriter, ctx.Request, file)
+			if finfo, err := os.Stat(file); err == nil && !finfo.IsDir() {
+				serveStaticFile(ctx, nil, file, finfo)
 				return
 			}
 			i++
@@ -79,42 +220,189 @@ func serverStaticRouter(ctx *context.Context) {
 				}
 			}
 
-			//This block obtained from (https://github.com/smithfox/beego) - it should probably get merged into astaxie/beego after a pull request
-			isStaticFileToCompress := false
-			if StaticExtensionsToGzip != nil && len(StaticExtensionsToGzip) > 0 {
-				for _, statExtension := range StaticExtensionsToGzip {
-					if strings.HasSuffix(strings.ToLower(file), strings.ToLower(statExtension)) {
-						isStaticFileToCompress = true
-						break
-					}
-				}
-			}
-
-			if isStaticFileToCompress {
-				var contentEncoding string
-				if EnableGzip {
-					contentEncoding = getAcceptEncodingZip(ctx.Request)
-				}
+			serveStaticFile(ctx, nil, file, finfo)
+			return
+		}
+	}
+}
 
-				memzipfile, err := openMemZipFile(file, contentEncoding)
-				if err != nil {
-					return
-				}
+// staticCacheControl returns the StaticCacheControl policy of the longest prefix of the request path.
+func staticCacheControl(requestPath string) string {
+	found, cc := -1, ""
+	for prefix, v := range StaticCacheControl {
+		if !strings.HasPrefix(requestPath, prefix) || len(prefix) <= found {
+			continue
+		}
+		if prefix != "/" && len(requestPath) > len(prefix) && requestPath[len(prefix)] != '/' {
+			continue
+		}
+		found, cc = len(prefix), v
+	}
+	return cc
+}
 
-				if contentEncoding == "gzip" {
-					ctx.Output.Header("Content-Encoding", "gzip")
-				} else if contentEncoding == "deflate" {
-					ctx.Output.Header("Content-Encoding", "deflate")
-				} else {
-					ctx.Output.Header("Content-Length", strconv.FormatInt(finfo.Size(), 10))
-				}
+// staticETag returns the validator of the file made of its size and modification time,
+// the encoding is appended so the compressed and the identity responses don't share an ETag.
+func staticETag(finfo os.FileInfo, encoding string) string {
+	etag := fmt.Sprintf("%x-%x", finfo.Size(), finfo.ModTime().UnixNano())
+	if encoding != "" {
+		etag += "-" + encoding
+	}
+	return `"` + etag + `"`
+}
 
-				http.ServeContent(ctx.ResponseWriter, ctx.Request, file, finfo.ModTime(), memzipfile)
+// serveStaticFile serves the file, the files compressed ahead are preferred if EnableGzip is on,
+// or else the files of StaticExtensionsToGzip are compressed and cached in memory.
+// The ETag and Last-Modified validators are sent with every file,
+// http.ServeContent answers the conditional requests with 304 and the Range requests with 206.
+// The files of the route r are read from its fs.FS if it's set, r is nil for StaticDir.
+func serveStaticFile(ctx *context.Context, r *staticRoute, file string, finfo os.FileInfo) {
+	if ctx.ResponseWriter.Header().Get("Cache-Control") == "" {
+		if cc := staticCacheControl(filepath.ToSlash(filepath.Clean(ctx.Request.URL.Path))); cc != "" {
+			ctx.Output.Header("Cache-Control", cc)
+		}
+	}
+	if EnableGzip && !finfo.IsDir() && !isCompressedContentType(file) && servePrecompressed(ctx, r, file, finfo) {
+		return
+	}
 
-			} else {
-				http.ServeFile(ctx.ResponseWriter, ctx.Request, file)
+	//This block obtained from (https://github.com/smithfox/beego) - it should probably get merged into astaxie/beego after a pull request
+	isStaticFileToCompress := false
+	if StaticExtensionsToGzip != nil && len(StaticExtensionsToGzip) > 0 {
+		for _, statExtension := range StaticExtensionsToGzip {
+			if strings.HasSuffix(strings.ToLower(file), strings.ToLower(statExtension)) {
+				isStaticFileToCompress = true
+				break
 			}
+		}
+	}
+	if isStaticFileToCompress && isCompressedContentType(file) {
+		isStaticFileToCompress = false
+	}
+
+	if isStaticFileToCompress {
+		var contentEncoding string
+		if EnableGzip {
+			contentEncoding = getAcceptEncodingZip(ctx.Request)
+		}
+
+		memzipfile, err := openMemZipFile(staticFS(r), staticCacheKey(r, file), file, contentEncoding)
+		if err != nil {
 			return
 		}
+
+		ctx.Output.Header("Vary", "Accept-Encoding")
+		if finfo.ModTime().IsZero() {
+			key := contentETagKey{file: staticCacheKey(r, file), size: finfo.Size(), encoding: contentEncoding}
+			ctx.Output.Header("ETag", cachedContentETag(key, func() string {
+				return contentETag(bytes.NewReader(memzipfile.fi.content), contentEncoding)
+			}))
+		} else {
+			ctx.Output.Header("ETag", staticETag(finfo, contentEncoding))
+		}
+		if contentEncoding == "gzip" {
+			ctx.Output.Header("Content-Encoding", "gzip")
+		} else if contentEncoding == "deflate" {
+			ctx.Output.Header("Content-Encoding", "deflate")
+		} else {
+			ctx.Output.Header("Content-Length", strconv.FormatInt(finfo.Size(), 10))
+		}
+		if contentEncoding != "" {
+			// the ranges would be of the compressed bytes, send the whole file instead
+			ctx.Request.Header.Del("Range")
+		}
+
+		http.ServeContent(ctx.ResponseWriter, ctx.Request, file, finfo.ModTime(), memzipfile)
+
+	} else if !finfo.IsDir() {
+		serveFile(ctx, r, file, finfo)
+	} else if staticFS(r) != nil {
+		exception("403", ctx)
+	} else {
+		http.ServeFile(ctx.ResponseWriter, ctx.Request, file)
+	}
+}
+
+// precompressedFiles are the extensions of the files compressed ahead, in the order of preference.
+var precompressedFiles = []struct {
+	encoding, ext string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// servePrecompressed serves the file compressed ahead next to the file, e.g. app.js.br or app.js.gz,
+// if the client accepts its encoding and it's not older than the file.
+func servePrecompressed(ctx *context.Context, r *staticRoute, file string, finfo os.FileInfo) bool {
+	fsys := staticFS(r)
+	for _, p := range precompressedFiles {
+		if !acceptEncoding(ctx.Request, p.encoding) {
+			continue
+		}
+		cfinfo, err := staticStat(fsys, file+p.ext)
+		if err != nil || cfinfo.IsDir() || cfinfo.ModTime().Before(finfo.ModTime()) {
+			continue
+		}
+		f, err := staticOpen(fsys, file+p.ext)
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+		content, etag, err := staticContent(r, file+p.ext, f, cfinfo, p.encoding)
+		if err != nil {
+			continue
+		}
+		ctype := mime.TypeByExtension(filepath.Ext(file))
+		if ctype == "" {
+			ctype = "application/octet-stream"
+		}
+		ctx.Output.Header("Content-Type", ctype)
+		ctx.Output.Header("Content-Encoding", p.encoding)
+		ctx.Output.Header("Vary", "Accept-Encoding")
+		ctx.Output.Header("ETag", etag)
+		ctx.Request.Header.Del("Range")
+		http.ServeContent(ctx.ResponseWriter, ctx.Request, finfo.Name(), cfinfo.ModTime(), content)
+		return true
+	}
+	return false
+}
+
+// serveFile serves the file with the *os.File as body,
+// so the server can send it with sendfile through the io.ReaderFrom of the responseWriter.
+func serveFile(ctx *context.Context, r *staticRoute, file string, finfo os.FileInfo) {
+	f, err := staticOpen(staticFS(r), file)
+	if err != nil {
+		http.NotFound(ctx.ResponseWriter, ctx.Request)
+		return
+	}
+	defer f.Close()
+	content, etag, err := staticContent(r, file, f, finfo, "")
+	if err != nil {
+		http.NotFound(ctx.ResponseWriter, ctx.Request)
+		return
+	}
+	ctx.Output.Header("ETag", etag)
+	http.ServeContent(ctx.ResponseWriter, ctx.Request, finfo.Name(), finfo.ModTime(), content)
+}
+
+// compressedContentTypes are not compressed again, it only costs cpu.
+var compressedContentTypes = []string{
+	"image/", "video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-bzip2",
+	"application/x-7z-compressed", "application/x-rar-compressed", "application/x-xz",
+	"application/font-woff",
+}
+
+// isCompressedContentType reports whether the content type of the file is already compressed.
+func isCompressedContentType(file string) bool {
+	ctype := mime.TypeByExtension(filepath.Ext(file))
+	if ctype == "" || strings.HasPrefix(ctype, "image/svg") {
+		return false
+	}
+	for _, t := range compressedContentTypes {
+		if strings.HasPrefix(ctype, t) {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,465 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/astaxie/beego/context"
+)
+
+func setupStaticDir(t testing.TB, files map[string][]byte) func() {
+	dir, err := ioutil.TempDir("", "beego-static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldDir, oldGzip, oldExt, oldRoutes := StaticDir, EnableGzip, StaticExtensionsToGzip, staticRoutes
+	StaticDir = map[string]string{"/static": dir}
+	return func() {
+		StaticDir, EnableGzip, StaticExtensionsToGzip, staticRoutes = oldDir, oldGzip, oldExt, oldRoutes
+		os.RemoveAll(dir)
+	}
+}
+
+func TestStaticCompressedContentType(t *testing.T) {
+	defer setupStaticDir(t, map[string][]byte{
+		"app.js":   []byte("var a = 1;"),
+		"logo.png": []byte("\x89PNG"),
+	})()
+	EnableGzip = true
+	StaticExtensionsToGzip = []string{".js", ".png"}
+
+	mux := NewControllerRegister()
+	for file, gzipped := range map[string]bool{"/static/app.js": true, "/static/logo.png": false} {
+		rw, r := testRequest("GET", file)
+		r.Header.Set("Accept-Encoding", "gzip")
+		mux.ServeHTTP(rw, r)
+		if got := rw.Header().Get("Content-Encoding") == "gzip"; got != gzipped {
+			t.Errorf("TestStaticCompressedContentType %s gzipped should be %v", file, gzipped)
+		}
+	}
+}
+
+func TestStaticPathWithOptions(t *testing.T) {
+	defer setupStaticDir(t, map[string][]byte{
+		"a/index.html": []byte("site a"),
+		"b/home.html":  []byte("site b"),
+		"b/app.js":     []byte("var b = 1;"),
+	})()
+	dir := StaticDir["/static"]
+	SetStaticPathWithOptions("/assets", filepath.Join(dir, "a"), StaticOptions{
+		Host:       "a.example.com",
+		IndexFiles: []string{"index.html"},
+	})
+	SetStaticPathWithOptions("/assets", filepath.Join(dir, "b"), StaticOptions{
+		Host:         "B.example.com",
+		IndexFiles:   []string{"home.html"},
+		CacheControl: "public, max-age=60",
+		Filter: func(ctx *context.Context) {
+			if ctx.Input.Query("token") != "ok" {
+				ctx.Output.SetStatus(401)
+				ctx.Output.Body([]byte("unauthorized"))
+			}
+		},
+	})
+
+	mux := NewControllerRegister()
+	cases := []struct {
+		host, url string
+		code      int
+		body      string
+	}{
+		{"a.example.com", "/assets/", 200, "site a"},
+		{"a.example.com:8080", "/assets/", 200, "site a"},
+		{"b.example.com", "/assets/", 401, "unauthorized"},
+		{"b.example.com", "/assets/?token=ok", 200, "site b"},
+		{"b.example.com", "/assets/app.js?token=ok", 200, "var b = 1;"},
+		{"c.example.com", "/assets/", 404, ""},
+	}
+	for _, c := range cases {
+		rw, r := testRequest("GET", c.url)
+		r.Host = c.host
+		mux.ServeHTTP(rw, r)
+		if rw.Code != c.code || (c.body != "" && rw.Body.String() != c.body) {
+			t.Errorf("TestStaticPathWithOptions %s%s got %d %q", c.host, c.url, rw.Code, rw.Body.String())
+		}
+	}
+
+	rw, r := testRequest("GET", "/assets/app.js?token=ok")
+	r.Host = "b.example.com"
+	mux.ServeHTTP(rw, r)
+	if rw.Header().Get("Cache-Control") != "public, max-age=60" {
+		t.Error("TestStaticPathWithOptions Cache-Control header is not set")
+	}
+}
+
+func TestStaticConditionalAndRange(t *testing.T) {
+	defer setupStaticDir(t, map[string][]byte{
+		"app.js":       []byte("var a = 1;"),
+		"img/clip.mp4": []byte("0123456789"),
+	})()
+	EnableGzip = true
+	StaticExtensionsToGzip = []string{".js"}
+	oldCC := StaticCacheControl
+	defer func() { StaticCacheControl = oldCC }()
+	StaticCacheControl = map[string]string{
+		"/static":     "no-cache",
+		"/static/img": "public, max-age=86400",
+	}
+
+	mux := NewControllerRegister()
+	for _, file := range []string{"/static/app.js", "/static/img/clip.mp4"} {
+		rw, r := testRequest("GET", file)
+		mux.ServeHTTP(rw, r)
+		etag := rw.Header().Get("ETag")
+		if rw.Code != 200 || etag == "" || rw.Header().Get("Last-Modified") == "" {
+			t.Fatalf("TestStaticConditionalAndRange %s got %d, ETag %q", file, rw.Code, etag)
+		}
+
+		rw, r = testRequest("GET", file)
+		r.Header.Set("If-None-Match", etag)
+		mux.ServeHTTP(rw, r)
+		if rw.Code != 304 {
+			t.Errorf("TestStaticConditionalAndRange %s If-None-Match got %d", file, rw.Code)
+		}
+
+		rw, r = testRequest("GET", file)
+		r.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		mux.ServeHTTP(rw, r)
+		if rw.Code != 304 {
+			t.Errorf("TestStaticConditionalAndRange %s If-Modified-Since got %d", file, rw.Code)
+		}
+	}
+
+	rw, r := testRequest("GET", "/static/app.js")
+	r.Header.Set("Accept-Encoding", "gzip")
+	mux.ServeHTTP(rw, r)
+	if rw.Header().Get("ETag") == "" || !strings.HasSuffix(rw.Header().Get("ETag"), `-gzip"`) {
+		t.Errorf("TestStaticConditionalAndRange gzipped ETag got %q", rw.Header().Get("ETag"))
+	}
+	if rw.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("TestStaticConditionalAndRange /static Cache-Control got %q", rw.Header().Get("Cache-Control"))
+	}
+
+	rw, r = testRequest("GET", "/static/img/clip.mp4")
+	r.Header.Set("Range", "bytes=2-5")
+	mux.ServeHTTP(rw, r)
+	if rw.Code != 206 || rw.Body.String() != "2345" || rw.Header().Get("Content-Range") != "bytes 2-5/10" {
+		t.Errorf("TestStaticConditionalAndRange Range got %d %q", rw.Code, rw.Body.String())
+	}
+	if rw.Header().Get("Cache-Control") != "public, max-age=86400" {
+		t.Errorf("TestStaticConditionalAndRange /static/img Cache-Control got %q", rw.Header().Get("Cache-Control"))
+	}
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	defer setupStaticDir(t, map[string][]byte{
+		"app.css":    []byte("body{}"),
+		"app.css.gz": []byte("gzipped"),
+		"app.css.br": []byte("brotli"),
+		"old.js":     []byte("var a = 1;"),
+		"old.js.gz":  []byte("stale"),
+	})()
+	EnableGzip = true
+	StaticExtensionsToGzip = nil
+	now := time.Now()
+	for name, mtime := range map[string]time.Time{
+		"app.css": now, "app.css.gz": now, "app.css.br": now,
+		"old.js": now, "old.js.gz": now.Add(-time.Hour),
+	} {
+		os.Chtimes(filepath.Join(StaticDir["/static"], name), mtime, mtime)
+	}
+
+	mux := NewControllerRegister()
+	cases := []struct {
+		url, accept, encoding, body string
+	}{
+		{"/static/app.css", "gzip, deflate, br", "br", "brotli"},
+		{"/static/app.css", "gzip", "gzip", "gzipped"},
+		{"/static/app.css", "br;q=0, gzip;q=0.5", "gzip", "gzipped"},
+		{"/static/app.css", "", "", "body{}"},
+		{"/static/old.js", "gzip", "", "var a = 1;"},
+	}
+	for _, c := range cases {
+		rw, r := testRequest("GET", c.url)
+		r.Header.Set("Accept-Encoding", c.accept)
+		mux.ServeHTTP(rw, r)
+		if rw.Header().Get("Content-Encoding") != c.encoding || rw.Body.String() != c.body {
+			t.Errorf("TestStaticPrecompressed %s %q got %q %q", c.url, c.accept, rw.Header().Get("Content-Encoding"), rw.Body.String())
+		}
+		if c.encoding != "" && !strings.HasPrefix(rw.Header().Get("Content-Type"), "text/css") {
+			t.Errorf("TestStaticPrecompressed %s Content-Type got %q", c.url, rw.Header().Get("Content-Type"))
+		}
+	}
+}
+
+// countingFS counts the bytes read from its files.
+type countingFS struct {
+	fstest.MapFS
+	read int64
+}
+
+type countingFile struct {
+	fs.File
+	fsys *countingFS
+}
+
+func (f *countingFS) Open(name string) (fs.File, error) {
+	file, err := f.MapFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &countingFile{file, f}, nil
+}
+
+func (f *countingFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	f.fsys.read += int64(n)
+	return n, err
+}
+
+func (f *countingFile) Seek(offset int64, whence int) (int64, error) {
+	return f.File.(io.Seeker).Seek(offset, whence)
+}
+
+func TestStaticFSContentETag(t *testing.T) {
+	defer setupStaticDir(t, nil)()
+	EnableGzip = false
+	fsys := &countingFS{MapFS: fstest.MapFS{"notes.txt": {Data: bytes.Repeat([]byte("body{}"), 1000)}}}
+	SetStaticFS("/counted", fsys)
+
+	mux := NewControllerRegister()
+	var etags []string
+	var reads []int64
+	for i := 0; i < 2; i++ {
+		before := fsys.read
+		rw, r := testRequest("GET", "/counted/notes.txt")
+		mux.ServeHTTP(rw, r)
+		if rw.Code != 200 || rw.Body.Len() != 6000 {
+			t.Fatalf("got %d with %d bytes", rw.Code, rw.Body.Len())
+		}
+		etags = append(etags, rw.Header().Get("ETag"))
+		reads = append(reads, fsys.read-before)
+	}
+	if etags[0] == "" || etags[0] != etags[1] {
+		t.Errorf("the content ETag should be stable: %q", etags)
+	}
+	if reads[0] != 12000 || reads[1] != 6000 {
+		t.Errorf("the file should be hashed once and served without a copy, read %v bytes", reads)
+	}
+}
+
+func TestStaticFS(t *testing.T) {
+	defer setupStaticDir(t, nil)()
+	EnableGzip = true
+	StaticExtensionsToGzip = []string{".js"}
+	SetStaticFS("/embedded", fstest.MapFS{
+		"app.js":        {Data: []byte("var a = 1;")},
+		"app.css":       {Data: []byte("body{}")},
+		"app.css.br":    {Data: []byte("brotli")},
+		"docs/index.md": {Data: []byte("# docs")},
+	})
+	SetStaticPathWithOptions("/other", ".", StaticOptions{
+		FS:         fstest.MapFS{"app.js": {Data: []byte("var b = 2;")}, "index.html": {Data: []byte("home")}},
+		IndexFiles: []string{"index.html"},
+	})
+
+	mux := NewControllerRegister()
+	cases := []struct {
+		url, accept string
+		code        int
+		encoding    string
+		body        string
+	}{
+		{"/embedded/app.js", "", 200, "", "var a = 1;"},
+		{"/other/app.js", "", 200, "", "var b = 2;"},
+		{"/other/", "", 200, "", "home"},
+		{"/embedded/app.css", "br", 200, "br", "brotli"},
+		{"/embedded/docs/", "", 403, "", ""},
+		{"/embedded/missing.js", "", 404, "", ""},
+		{"/embedded/../staticfile.go", "", 404, "", ""},
+	}
+	for _, c := range cases {
+		rw, r := testRequest("GET", c.url)
+		r.Header.Set("Accept-Encoding", c.accept)
+		mux.ServeHTTP(rw, r)
+		if rw.Code != c.code || rw.Header().Get("Content-Encoding") != c.encoding || (c.body != "" && rw.Body.String() != c.body) {
+			t.Errorf("TestStaticFS %s got %d %q %q", c.url, rw.Code, rw.Header().Get("Content-Encoding"), rw.Body.String())
+		}
+	}
+
+	// the gzipped files of the file systems are cached apart
+	for _, file := range []string{"/embedded/app.js", "/other/app.js"} {
+		rw, r := testRequest("GET", file)
+		r.Header.Set("Accept-Encoding", "gzip")
+		mux.ServeHTTP(rw, r)
+		zr, err := gzip.NewReader(rw.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(zr)
+		if want := map[string]string{"/embedded/app.js": "var a = 1;", "/other/app.js": "var b = 2;"}[file]; string(body) != want {
+			t.Errorf("TestStaticFS gzipped %s got %q", file, body)
+		}
+	}
+
+	rw, r := testRequest("GET", "/embedded/app.js")
+	mux.ServeHTTP(rw, r)
+	etag := rw.Header().Get("ETag")
+	rw, r = testRequest("GET", "/embedded/app.js")
+	r.Header.Set("If-None-Match", etag)
+	mux.ServeHTTP(rw, r)
+	if etag == "" || rw.Code != 304 {
+		t.Errorf("TestStaticFS If-None-Match %q got %d", etag, rw.Code)
+	}
+
+	if a, err := AssetInfo("/embedded/app.css"); err != nil || !strings.HasPrefix(a.Integrity, "sha384-") {
+		t.Errorf("TestStaticFS AssetInfo got %v %v", a, err)
+	}
+}
+
+func TestStaticSPA(t *testing.T) {
+	defer setupStaticDir(t, map[string][]byte{
+		"index.html":     []byte("<app>"),
+		"js/app.js":      []byte("var a = 1;"),
+		"users/.gitkeep": []byte(""),
+	})()
+	SetStaticSPA("/app", StaticDir["/static"])
+
+	mux := NewControllerRegister()
+	cases := []struct {
+		url          string
+		code         int
+		body         string
+		cacheControl string
+	}{
+		{"/app/", 200, "<app>", ""},
+		{"/app/js/app.js", 200, "var a = 1;", ""},
+		{"/app/users/5", 200, "<app>", "no-cache"},
+		{"/app/users", 200, "<app>", "no-cache"},
+		{"/app/js/missing.js", 404, "", ""},
+	}
+	for _, c := range cases {
+		rw, r := testRequest("GET", c.url)
+		mux.ServeHTTP(rw, r)
+		if rw.Code != c.code || (c.body != "" && rw.Body.String() != c.body) || rw.Header().Get("Cache-Control") != c.cacheControl {
+			t.Errorf("TestStaticSPA %s got %d %q %q", c.url, rw.Code, rw.Body.String(), rw.Header().Get("Cache-Control"))
+		}
+	}
+}
+
+func TestStaticRootSPA(t *testing.T) {
+	defer setupStaticDir(t, map[string][]byte{
+		"index.html": []byte("<app>"),
+		"js/app.js":  []byte("var a = 1;"),
+	})()
+	SetStaticSPA("/", StaticDir["/static"])
+
+	mux := NewControllerRegister()
+	mux.Get("/api/users", func(ctx *context.Context) {
+		ctx.Output.Body([]byte("users"))
+	})
+	cases := []struct {
+		url  string
+		code int
+		body string
+	}{
+		{"/", 200, "<app>"},
+		{"/js/app.js", 200, "var a = 1;"},
+		{"/users/5", 200, "<app>"},
+		{"/api/users", 200, "users"},
+		{"/js/missing.js", 404, ""},
+	}
+	for _, c := range cases {
+		rw, r := testRequest("GET", c.url)
+		mux.ServeHTTP(rw, r)
+		if rw.Code != c.code || (c.body != "" && rw.Body.String() != c.body) {
+			t.Errorf("TestStaticRootSPA %s got %d %q", c.url, rw.Code, rw.Body.String())
+		}
+	}
+
+	SetStaticPathWithOptions("/", StaticDir["/static"], StaticOptions{})
+	rw, r := testRequest("GET", "/api/users")
+	mux.ServeHTTP(rw, r)
+	if rw.Code != 200 || rw.Body.String() != "users" {
+		t.Errorf("TestStaticRootSPA root path without fallback got %d %q", rw.Code, rw.Body.String())
+	}
+}
+
+func BenchmarkStaticLargeFile(b *testing.B) {
+	content := bytes.Repeat([]byte("beego"), 2<<20)
+	defer setupStaticDir(b, map[string][]byte{"large.bin": content})()
+	ts := httptest.NewServer(NewControllerRegister())
+	defer ts.Close()
+
+	b.SetBytes(int64(len(content)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := http.Get(ts.URL + "/static/large.bin")
+		if err != nil {
+			b.Fatal(err)
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+func TestMemFileCacheLRU(t *testing.T) {
+	defer func(max, fileMax int64) {
+		StaticCacheMaxBytes, StaticCacheFileMaxBytes = max, fileMax
+	}(StaticCacheMaxBytes, StaticCacheFileMaxBytes)
+	StaticCacheMaxBytes, StaticCacheFileMaxBytes = 10, 6
+
+	c := newMemFileCache()
+	now := time.Now()
+	newInfo := func(content string) *memFileInfo {
+		return &memFileInfo{modTime: now, content: []byte(content), contentSize: int64(len(content)), fileSize: int64(len(content))}
+	}
+	c.set("a", newInfo("aaaa"))
+	c.set("b", newInfo("bbbb"))
+	c.set("big", newInfo("bigbigbig"))
+	if _, ok := c.get("big", now, 9); ok {
+		t.Error("files over StaticCacheFileMaxBytes should not be cached")
+	}
+	c.get("a", now, 4)
+	c.set("c", newInfo("cccc"))
+	if _, ok := c.get("b", now, 4); ok {
+		t.Error("the least recently used file should be evicted")
+	}
+	if _, ok := c.get("a", now, 4); !ok || c.size != 8 {
+		t.Error("recently used file should be kept, size", c.size)
+	}
+	if _, ok := c.get("a", now.Add(time.Second), 4); ok {
+		t.Error("changed file should not be served from cache")
+	}
+}
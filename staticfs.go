@@ -0,0 +1,140 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// SetStaticFS maps the url to the root of fsys, e.g. the static files embedded into the binary,
+// so it runs without the files on the disk. Load the templates from the same files by SetTemplateFS.
+// usage:
+//
+//	//go:embed static views
+//	var files embed.FS
+//
+//	static, _ := fs.Sub(files, "static")
+//	beego.SetStaticFS("/static", static)
+//	beego.SetTemplateFS(files)
+func SetStaticFS(url string, fsys fs.FS) *App {
+	return SetStaticPathWithOptions(url, ".", StaticOptions{FS: fsys})
+}
+
+// staticFS returns the file system of the route, nil means the disk.
+func staticFS(r *staticRoute) fs.FS {
+	if r == nil {
+		return nil
+	}
+	return r.opts.FS
+}
+
+// staticFSPath converts the file path to the slash separated path of a fs.FS.
+func staticFSPath(name string) string {
+	name = strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+func staticStat(fsys fs.FS, name string) (os.FileInfo, error) {
+	if fsys == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(fsys, staticFSPath(name))
+}
+
+func staticOpen(fsys fs.FS, name string) (fs.File, error) {
+	if fsys == nil {
+		return os.Open(name)
+	}
+	return fsys.Open(staticFSPath(name))
+}
+
+// staticCacheKey returns the key of the file in staticFileCache, the files of each fs.FS are apart.
+func staticCacheKey(r *staticRoute, file string) string {
+	if staticFS(r) == nil {
+		return file
+	}
+	return fmt.Sprintf("fs%d:%s", r.id, file)
+}
+
+// staticContentETags caches the content ETags of the files without a modification time by file, size and encoding.
+var staticContentETags sync.Map
+
+type contentETagKey struct {
+	file     string // the staticCacheKey of the file
+	size     int64
+	encoding string
+}
+
+// staticContent returns the content of the file for http.ServeContent and its ETag.
+// The ETag of the files without a modification time, like the embedded files, is made of
+// the content, it's hashed once per file. The files which can't seek are read into memory.
+func staticContent(r *staticRoute, name string, f fs.File, finfo os.FileInfo, encoding string) (io.ReadSeeker, string, error) {
+	rs, seeker := f.(io.ReadSeeker)
+	if seeker && !finfo.ModTime().IsZero() {
+		return rs, staticETag(finfo, encoding), nil
+	}
+	key := contentETagKey{file: staticCacheKey(r, name), size: finfo.Size(), encoding: encoding}
+	if etag, ok := staticContentETags.Load(key); ok && seeker {
+		return rs, etag.(string), nil
+	}
+	if !seeker {
+		content, err := ioutil.ReadAll(f)
+		if err != nil {
+			return nil, "", err
+		}
+		etag := contentETag(bytes.NewReader(content), encoding)
+		staticContentETags.Store(key, etag)
+		return bytes.NewReader(content), etag, nil
+	}
+	etag := contentETag(rs, encoding)
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+	staticContentETags.Store(key, etag)
+	return rs, etag, nil
+}
+
+// cachedContentETag returns the content ETag of key from staticContentETags, it's computed by etag once.
+func cachedContentETag(key contentETagKey, etag func() string) string {
+	if v, ok := staticContentETags.Load(key); ok {
+		return v.(string)
+	}
+	v, _ := staticContentETags.LoadOrStore(key, etag())
+	return v.(string)
+}
+
+// contentETag returns the ETag made of the size and the hash of the content.
+func contentETag(content io.Reader, encoding string) string {
+	h := fnv.New64a()
+	n, _ := io.Copy(h, content)
+	etag := fmt.Sprintf("%x-%x", n, h.Sum64())
+	if encoding != "" {
+		etag += "-" + encoding
+	}
+	return `"` + etag + `"`
+}
@@ -18,7 +18,6 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -31,6 +30,8 @@ var (
 	beegoTplFuncMap = make(template.FuncMap)
 	// BeeTemplates caching map and supported template file extensions.
 	BeeTemplates = make(map[string]*template.Template)
+	// beeViewPathTemplates stores the templates of the view paths added by AddViewPath.
+	beeViewPathTemplates = make(map[string]map[string]*template.Template)
 	// BeeTemplateExt stores the template extention which will build
 	BeeTemplateExt = []string{"tpl", "html"}
 )
@@ -45,13 +46,18 @@ func init() {
 	beegoTplFuncMap["substr"] = Substr
 	beegoTplFuncMap["html2str"] = HTML2str
 	beegoTplFuncMap["str2html"] = Str2html
+	beegoTplFuncMap["sanitize"] = SanitizeHTML
 	beegoTplFuncMap["htmlquote"] = Htmlquote
 	beegoTplFuncMap["htmlunquote"] = Htmlunquote
 	beegoTplFuncMap["renderform"] = RenderForm
 	beegoTplFuncMap["assets_js"] = AssetsJs
 	beegoTplFuncMap["assets_css"] = AssetsCSS
+	beegoTplFuncMap["asset_url"] = AssetURL
+	beegoTplFuncMap["integrity"] = AssetIntegrityAttr
 	beegoTplFuncMap["config"] = Config
 	beegoTplFuncMap["map_get"] = MapGet
+	beegoTplFuncMap["consented"] = Consented
+	beegoTplFuncMap["consent_given"] = ConsentGiven
 
 	// go1.2 added template funcs
 	// Comparisons
@@ -63,6 +69,9 @@ func init() {
 	beegoTplFuncMap["ne"] = ne // !=
 
 	beegoTplFuncMap["urlfor"] = URLFor // !=
+	beegoTplFuncMap["urlforname"] = URLForName
+	beegoTplFuncMap["urlforlocale"] = URLForLocale
+	beegoTplFuncMap["tr"] = tr
 }
 
 // AddFuncMap let user to register a func in the template.
@@ -125,8 +134,26 @@ func AddTemplateExt(ext string) {
 
 // BuildTemplate will build all template files in a directory.
 // it makes beego can render any template file in view directory.
+// The templates of the engines added by AddTemplateEngine are parsed when all the files are built.
 func BuildTemplate(dir string, files ...string) error {
-	if _, err := os.Stat(dir); err != nil {
+	return buildTemplate(dir, false, files...)
+}
+
+func buildTemplate(dir string, strict bool, files ...string) error {
+	if err := buildTemplateTo(BeeTemplates, dir, strict, files...); err != nil {
+		return err
+	}
+	if len(files) > 0 {
+		return nil
+	}
+	return parseEngineTemplates(dir, strict)
+}
+
+// buildTemplateTo builds the template files into tpls, in strict mode it stops at the first template
+// which can't be parsed instead of logging and skipping it.
+func buildTemplateTo(tpls map[string]*template.Template, dir string, strict bool, files ...string) (err error) {
+	dir = tplPath(dir)
+	if _, err := tplStat(dir); err != nil {
 		if os.IsNotExist(err) {
 			return nil
 		}
@@ -136,21 +163,35 @@ func BuildTemplate(dir string, files ...string) error {
 		root:  dir,
 		files: make(map[string][]string),
 	}
-	err := filepath.Walk(dir, func(path string, f os.FileInfo, err error) error {
+	if templateFS != nil && dir == "." {
+		self.root = ""
+	}
+	err = tplWalk(dir, func(path string, f os.FileInfo, err error) error {
 		return self.visit(path, f, err)
 	})
 	if err != nil {
 		fmt.Printf("filepath.Walk() returned %v\n", err)
 		return err
 	}
+	if strict {
+		// getTplDeep panics on the missing templates
+		defer func() {
+			if e := recover(); e != nil {
+				err = fmt.Errorf("beego: build template: %v", e)
+			}
+		}()
+	}
 	for _, v := range self.files {
 		for _, file := range v {
 			if len(files) == 0 || utils.InSlice(file, files) {
 				t, err := getTemplate(self.root, file, v...)
 				if err != nil {
+					if strict {
+						return fmt.Errorf("beego: parse template %s: %v", file, err)
+					}
 					Trace("parse template err:", file, err)
 				} else {
-					BeeTemplates[file] = t
+					tpls[file] = t
 				}
 			}
 		}
@@ -165,10 +206,10 @@ func getTplDeep(root, file, parent string, t *template.Template) (*template.Temp
 	} else {
 		fileabspath = filepath.Join(root, file)
 	}
-	if e := utils.FileExists(fileabspath); !e {
+	if _, err := tplStat(fileabspath); err != nil {
 		panic("can't find template file:" + file)
 	}
-	data, err := ioutil.ReadFile(fileabspath)
+	data, err := tplReadFile(fileabspath)
 	if err != nil {
 		return nil, [][]string{}, err
 	}
@@ -178,6 +219,7 @@ func getTplDeep(root, file, parent string, t *template.Template) (*template.Temp
 	}
 	reg := regexp.MustCompile(TemplateLeft + "[ ]*template[ ]+\"([^\"]+)\"")
 	allsub := reg.FindAllStringSubmatch(string(data), -1)
+	included := false
 	for _, m := range allsub {
 		if len(m) == 2 {
 			tlook := t.Lookup(m[1])
@@ -191,6 +233,14 @@ func getTplDeep(root, file, parent string, t *template.Template) (*template.Temp
 			if err != nil {
 				return nil, [][]string{}, err
 			}
+			included = true
+		}
+	}
+	// parse the file again after the files it includes, so its defines override their blocks,
+	// e.g. a page including its layout fills the {{block}}s of the layout and of the layouts the layout includes
+	if included && regexp.MustCompile(TemplateLeft+"[ ]*(define|block)[ ]+\"").Match(data) {
+		if t, err = t.New(file).Parse(string(data)); err != nil {
+			return nil, [][]string{}, err
 		}
 	}
 	return t, allsub, nil
@@ -235,7 +285,7 @@ func _getTemplate(t0 *template.Template, root string, submods [][]string, others
 			//second check define
 			for _, otherfile := range others {
 				fileabspath := filepath.Join(root, otherfile)
-				data, err := ioutil.ReadFile(fileabspath)
+				data, err := tplReadFile(fileabspath)
 				if err != nil {
 					continue
 				}
@@ -260,6 +310,51 @@ func _getTemplate(t0 *template.Template, root string, submods [][]string, others
 	return
 }
 
+// AddViewPath builds the templates of another view directory, the controllers search it
+// before ViewsPath when it's in their ViewPaths, e.g. to override the templates by a theme:
+//
+//	beego.AddViewPath("themes/blue")
+//
+//	func (c *BaseController) Prepare() {
+//		c.ViewPaths = []string{"themes/blue"}
+//	}
+func AddViewPath(viewPath string) error {
+	if _, ok := beeViewPathTemplates[viewPath]; ok {
+		return nil
+	}
+	tpls := make(map[string]*template.Template)
+	beeViewPathTemplates[viewPath] = tpls
+	return buildTemplateTo(tpls, viewPath, TemplatePrecompile)
+}
+
+// lookupTemplate returns the template of name and its file name, the view paths are searched in order
+// then ViewsPath, the template of locale like "index.zh-CN.tpl" is preferred in each of them.
+func lookupTemplate(viewPaths []string, name, locale string) (*template.Template, string) {
+	names := []string{name}
+	if locale != "" {
+		names = []string{localizedTplName(name, locale), name}
+	}
+	for _, viewPath := range viewPaths {
+		for _, n := range names {
+			if t, ok := beeViewPathTemplates[viewPath][n]; ok {
+				return t, n
+			}
+		}
+	}
+	for _, n := range names {
+		if t, ok := BeeTemplates[n]; ok {
+			return t, n
+		}
+	}
+	return nil, ""
+}
+
+// localizedTplName inserts the locale before the extension, user/index.tpl -> user/index.zh-CN.tpl.
+func localizedTplName(name, locale string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + locale + ext
+}
+
 // SetViewsPath sets view directory path in beego application.
 func SetViewsPath(path string) *App {
 	ViewsPath = path
@@ -277,6 +372,44 @@ func SetStaticPath(url string, path string) *App {
 	return BeeApp
 }
 
+// SetStaticPathWithOptions sets static directory path with its own options in beego application.
+// the mapping can be bound to a host so one application serves the assets of several sites:
+//
+//	beego.SetStaticPathWithOptions("/assets", "sites/a/public", beego.StaticOptions{
+//		Host:         "a.example.com",
+//		IndexFiles:   []string{"index.html"},
+//		CacheControl: "public, max-age=86400",
+//	})
+func SetStaticPathWithOptions(url string, path string, opts StaticOptions) *App {
+	if !strings.HasPrefix(url, "/") {
+		url = "/" + url
+	}
+	url = strings.TrimRight(url, "/")
+	host := strings.ToLower(opts.Host)
+	for i, r := range staticRoutes {
+		if r.prefix == url && r.host == host {
+			staticRoutes = append(staticRoutes[:i], staticRoutes[i+1:]...)
+			break
+		}
+	}
+	staticRouteID++
+	staticRoutes = append(staticRoutes, &staticRoute{id: staticRouteID, prefix: url, dir: path, host: host, opts: opts})
+	return BeeApp
+}
+
+// SetStaticSPA maps the url to the single-page application in path,
+// its index.html is served for the client side routes under the url, e.g. /app/users/5.
+// The paths without a file which match a router are served by the router, so the SPA can be mapped to "/" next to the api.
+// usage:
+//
+//	beego.SetStaticSPA("/app", "frontend/dist")
+func SetStaticSPA(url string, path string) *App {
+	return SetStaticPathWithOptions(url, path, StaticOptions{
+		IndexFiles:  []string{"index.html"},
+		SPAFallback: "index.html",
+	})
+}
+
 // DelStaticPath removes the static folder setting in this url pattern in beego application.
 func DelStaticPath(url string) *App {
 	if !strings.HasPrefix(url, "/") {
@@ -0,0 +1,181 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// TemplateAuditFuncs are the template functions which bypass the html escaping,
+// AuditTemplates reports them when they are applied to a value which isn't a constant.
+var TemplateAuditFuncs = []string{"str2html", "htmlunquote"}
+
+// TemplateAuditIssue is a risky expression found by AuditTemplates.
+type TemplateAuditIssue struct {
+	Template string // the template file
+	Location string // file:line:col of the expression
+	Func     string // the unescaping function
+	Expr     string // the pipeline of the action
+}
+
+func (i TemplateAuditIssue) String() string {
+	return fmt.Sprintf("%s: %s unescapes a dynamic value in %s", i.Location, i.Func, i.Expr)
+}
+
+// AuditTemplates walks the templates built in BeeTemplates and reports the expressions
+// which output dynamic values without the html escaping, to help auditing the XSS risk.
+// usage:
+//	for _, issue := range beego.AuditTemplates() {
+//		fmt.Println(issue)
+//	}
+func AuditTemplates() []TemplateAuditIssue {
+	funcs := make(map[string]bool, len(TemplateAuditFuncs))
+	for _, f := range TemplateAuditFuncs {
+		funcs[f] = true
+	}
+	a := &templateAuditor{funcs: funcs, seen: make(map[string]bool)}
+	names := make([]string, 0, len(BeeTemplates))
+	for name := range BeeTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, t := range BeeTemplates[name].Templates() {
+			if t.Tree == nil || t.Tree.Root == nil {
+				continue
+			}
+			a.template, a.tree = t.Tree.ParseName, t.Tree
+			a.walk(t.Tree.Root)
+		}
+	}
+	sort.Slice(a.issues, func(i, j int) bool {
+		fi, li, ci := splitAuditLocation(a.issues[i].Location)
+		fj, lj, cj := splitAuditLocation(a.issues[j].Location)
+		if fi != fj {
+			return fi < fj
+		}
+		if li != lj {
+			return li < lj
+		}
+		return ci < cj
+	})
+	return a.issues
+}
+
+// splitAuditLocation splits the file:line:col location of an issue.
+func splitAuditLocation(location string) (file string, line, col int) {
+	file = location
+	if i := strings.LastIndex(file, ":"); i >= 0 {
+		col, _ = strconv.Atoi(file[i+1:])
+		file = file[:i]
+	}
+	if i := strings.LastIndex(file, ":"); i >= 0 {
+		line, _ = strconv.Atoi(file[i+1:])
+		file = file[:i]
+	}
+	return file, line, col
+}
+
+type templateAuditor struct {
+	funcs    map[string]bool
+	seen     map[string]bool
+	template string
+	tree     *parse.Tree
+	issues   []TemplateAuditIssue
+}
+
+func (a *templateAuditor) walk(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			a.walk(c)
+		}
+	case *parse.ActionNode:
+		a.pipe(n.Pipe)
+	case *parse.IfNode:
+		a.branch(&n.BranchNode)
+	case *parse.RangeNode:
+		a.branch(&n.BranchNode)
+	case *parse.WithNode:
+		a.branch(&n.BranchNode)
+	case *parse.TemplateNode:
+		a.pipe(n.Pipe)
+	}
+}
+
+func (a *templateAuditor) branch(n *parse.BranchNode) {
+	a.pipe(n.Pipe)
+	a.walk(n.List)
+	a.walk(n.ElseList)
+}
+
+func (a *templateAuditor) pipe(p *parse.PipeNode) {
+	if p == nil {
+		return
+	}
+	for i, cmd := range p.Cmds {
+		if len(cmd.Args) == 0 {
+			continue
+		}
+		for _, arg := range cmd.Args[1:] {
+			if sub, ok := arg.(*parse.PipeNode); ok {
+				a.pipe(sub)
+			}
+		}
+		id, ok := cmd.Args[0].(*parse.IdentifierNode)
+		if !ok || !a.funcs[id.Ident] {
+			continue
+		}
+		dynamic := i > 0 && !isConstantCommand(p.Cmds[i-1])
+		for _, arg := range cmd.Args[1:] {
+			if !isConstantNode(arg) {
+				dynamic = true
+			}
+		}
+		if !dynamic {
+			continue
+		}
+		location, _ := a.tree.ErrorContext(cmd)
+		if a.seen[location] {
+			continue
+		}
+		a.seen[location] = true
+		a.issues = append(a.issues, TemplateAuditIssue{
+			Template: a.template,
+			Location: location,
+			Func:     id.Ident,
+			Expr:     "{{" + p.String() + "}}",
+		})
+	}
+}
+
+func isConstantCommand(cmd *parse.CommandNode) bool {
+	return len(cmd.Args) == 1 && isConstantNode(cmd.Args[0])
+}
+
+func isConstantNode(n parse.Node) bool {
+	switch n.(type) {
+	case *parse.StringNode, *parse.NumberNode, *parse.BoolNode, *parse.NilNode:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// TemplateEngine renders the templates of the extensions it's registered for by AddTemplateEngine,
+// e.g. an adapter of pongo2, amber, ace or quicktemplate. The other templates are html/template.
+// The names are the slash separated paths relative to the views directory, as in TplNames.
+type TemplateEngine interface {
+	// Parse loads the template name from fsys, it's called for every template file of the extension
+	// when the templates are built.
+	Parse(fsys fs.FS, name string) error
+	// Execute renders the template name with the data of the controller into w.
+	Execute(w io.Writer, name string, data map[interface{}]interface{}) error
+	// Watch is called in the dev mode before the template name is rendered,
+	// the engine parses it again if it changed.
+	Watch(fsys fs.FS, name string) error
+}
+
+var (
+	templateEnginesLock sync.RWMutex
+	// templateEngines are the engines keyed by the extension with the dot
+	templateEngines = make(map[string]TemplateEngine)
+	// engineTemplates are the templates parsed by the engines and the views file system they are in
+	engineTemplates = make(map[string]fs.FS)
+)
+
+// AddTemplateEngine renders the templates with the extension by engine instead of html/template.
+// Controller.Render picks the engine by the extension of TplNames, Layout and LayoutSections.
+// usage:
+//
+//	beego.AddTemplateEngine("pongo", pongo2adapter.New())
+//	// in the controller
+//	c.TplNames = "user/index.pongo"
+func AddTemplateEngine(ext string, engine TemplateEngine) *App {
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	templateEnginesLock.Lock()
+	templateEngines[ext] = engine
+	templateEnginesLock.Unlock()
+	return BeeApp
+}
+
+// templateEngine returns the engine of the template name, nil for html/template.
+func templateEngine(name string) TemplateEngine {
+	templateEnginesLock.RLock()
+	defer templateEnginesLock.RUnlock()
+	if len(templateEngines) == 0 {
+		return nil
+	}
+	return templateEngines[path.Ext(name)]
+}
+
+// viewsFS returns the file system of the views in dir, on the disk or in the one of SetTemplateFS.
+func viewsFS(dir string) (fs.FS, error) {
+	if templateFS == nil {
+		return os.DirFS(dir), nil
+	}
+	return fs.Sub(templateFS, tplPath(dir))
+}
+
+// parseEngineTemplates parses the templates of the engines in dir, in strict mode it stops at the first error.
+func parseEngineTemplates(dir string, strict bool) error {
+	templateEnginesLock.RLock()
+	n := len(templateEngines)
+	templateEnginesLock.RUnlock()
+	if n == 0 {
+		return nil
+	}
+	if _, err := tplStat(dir); err != nil {
+		return nil
+	}
+	fsys, err := viewsFS(dir)
+	if err != nil {
+		return err
+	}
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		engine := templateEngine(name)
+		if engine == nil {
+			return nil
+		}
+		if err := parseEngineTemplate(engine, fsys, name); err != nil {
+			if strict {
+				return err
+			}
+			Warn("template engine parse", name, err)
+		}
+		return nil
+	})
+}
+
+// parseEngineTemplate parses the template name of fsys by engine and records it.
+func parseEngineTemplate(engine TemplateEngine, fsys fs.FS, name string) error {
+	if err := engine.Parse(fsys, name); err != nil {
+		return err
+	}
+	templateEnginesLock.Lock()
+	engineTemplates[name] = fsys
+	templateEnginesLock.Unlock()
+	return nil
+}
+
+// parseNewEngineTemplate parses the template name of ViewsPath if it exists.
+func parseNewEngineTemplate(engine TemplateEngine, name string) (fs.FS, bool) {
+	fsys, err := viewsFS(ViewsPath)
+	if err != nil {
+		return nil, false
+	}
+	if _, err := fs.Stat(fsys, name); err != nil {
+		return nil, false
+	}
+	if err := parseEngineTemplate(engine, fsys, name); err != nil {
+		Warn("template engine parse", name, err)
+		return nil, false
+	}
+	return fsys, true
+}
+
+// engineTemplateExists returns whether the template of an engine or its TplLocale version is parsed.
+func engineTemplateExists(name, locale string) bool {
+	templateEnginesLock.RLock()
+	defer templateEnginesLock.RUnlock()
+	if _, ok := engineTemplates[name]; ok {
+		return true
+	}
+	_, ok := engineTemplates[localizedTplName(name, locale)]
+	return ok && locale != ""
+}
+
+// executeEngineTemplate renders the template of an engine, the TplLocale version is preferred.
+func executeEngineTemplate(engine TemplateEngine, w io.Writer, name, locale string, data map[interface{}]interface{}) error {
+	names := []string{name}
+	if locale != "" {
+		names = []string{localizedTplName(name, locale), name}
+	}
+	for _, n := range names {
+		templateEnginesLock.RLock()
+		fsys, ok := engineTemplates[n]
+		templateEnginesLock.RUnlock()
+		if !ok && RunMode == "dev" {
+			// the template is added after the start
+			fsys, ok = parseNewEngineTemplate(engine, n)
+		}
+		if !ok {
+			continue
+		}
+		if RunMode == "dev" {
+			if err := engine.Watch(fsys, n); err != nil {
+				return err
+			}
+		}
+		return engine.Execute(w, n, data)
+	}
+	return errors.New("can't find templatefile in the path:" + name)
+}
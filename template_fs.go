@@ -0,0 +1,77 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// templateFS is the file system of the templates set by SetTemplateFS, nil means the disk.
+var templateFS fs.FS
+
+// SetTemplateFS makes beego load the templates from fsys instead of the disk,
+// e.g. the files embedded into the binary, ViewsPath is then the directory in fsys.
+// usage:
+//	//go:embed views
+//	var views embed.FS
+//
+//	beego.SetTemplateFS(views)
+func SetTemplateFS(fsys fs.FS) *App {
+	templateFS = fsys
+	return BeeApp
+}
+
+// tplPath converts the template path to the slash separated path of templateFS.
+func tplPath(name string) string {
+	if templateFS == nil {
+		return name
+	}
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+}
+
+func tplStat(name string) (os.FileInfo, error) {
+	if templateFS == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(templateFS, tplPath(name))
+}
+
+func tplReadFile(name string) ([]byte, error) {
+	if templateFS == nil {
+		return ioutil.ReadFile(name)
+	}
+	return fs.ReadFile(templateFS, tplPath(name))
+}
+
+func tplWalk(root string, fn filepath.WalkFunc) error {
+	if templateFS == nil {
+		return filepath.Walk(root, fn)
+	}
+	return fs.WalkDir(templateFS, tplPath(root), func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(name, nil, err)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return fn(name, nil, err)
+		}
+		return fn(name, info, nil)
+	})
+}
@@ -0,0 +1,84 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TemplateNameFunc returns the template name without the extension of the controller action,
+// it's used when the controller doesn't set TplNames or call UseTemplate.
+type TemplateNameFunc func(controllerName, actionName string) string
+
+// templateNamings are the conventions selected by the TemplateNaming config.
+var templateNamings = map[string]TemplateNameFunc{
+	"lower": LowerTemplateName,
+	"snake": SnakeTemplateName,
+}
+
+// templateNaming is the convention in use, set by TemplateNaming or SetTemplateNaming.
+var templateNaming TemplateNameFunc = LowerTemplateName
+
+// LowerTemplateName is the default convention, MainController.GetList renders "maincontroller/getlist".
+func LowerTemplateName(controllerName, actionName string) string {
+	return strings.ToLower(controllerName) + "/" + strings.ToLower(actionName)
+}
+
+// SnakeTemplateName trims the Controller suffix and uses the snake case,
+// UserProfileController.GetList renders "user_profile/get_list".
+func SnakeTemplateName(controllerName, actionName string) string {
+	return snakeString(strings.TrimSuffix(controllerName, "Controller")) + "/" + snakeString(actionName)
+}
+
+func snakeString(s string) string {
+	rs := []rune(s)
+	b := make([]rune, 0, len(rs)+4)
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			// start a new word before an upper case letter following a lower case one,
+			// or the last upper case letter of an acronym, e.g. "HTTPServer" is "http_server"
+			if i > 0 && (unicode.IsLower(rs[i-1]) || i+1 < len(rs) && unicode.IsUpper(rs[i-1]) && unicode.IsLower(rs[i+1])) {
+				b = append(b, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b = append(b, r)
+	}
+	return string(b)
+}
+
+// SetTemplateNaming sets the convention of the template names resolved from the controller and action.
+// usage:
+//
+//	beego.SetTemplateNaming(beego.SnakeTemplateName)
+//	beego.SetTemplateNaming(func(controller, action string) string {
+//		return "pages/" + strings.ToLower(action)
+//	})
+func SetTemplateNaming(fn TemplateNameFunc) *App {
+	if fn == nil {
+		fn = LowerTemplateName
+	}
+	templateNaming = fn
+	return BeeApp
+}
+
+// withTplExt appends "."+ext to the template name if it has no extension.
+func withTplExt(name, ext string) string {
+	if ext == "" || strings.Contains(name[strings.LastIndex(name, "/")+1:], ".") {
+		return name
+	}
+	return name + "." + ext
+}
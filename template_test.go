@@ -15,9 +15,21 @@
 package beego
 
 import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
+
+	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/i18n"
 )
 
 var header = `{{define "header"}}
@@ -134,3 +146,439 @@ func TestRelativeTemplate(t *testing.T) {
 	}
 	os.RemoveAll(dir)
 }
+
+func TestTemplateFS(t *testing.T) {
+	defer func(old map[string]*template.Template) {
+		SetTemplateFS(nil)
+		BeeTemplates = old
+	}(BeeTemplates)
+	BeeTemplates = make(map[string]*template.Template)
+
+	SetTemplateFS(fstest.MapFS{
+		"views/header.tpl":       {Data: []byte(header)},
+		"views/index.tpl":        {Data: []byte(index)},
+		"views/blocks/block.tpl": {Data: []byte(block)},
+	})
+	if err := buildTemplate("./views/", true); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := BeeTemplates["index.tpl"].ExecuteTemplate(&buf, "index.tpl", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Hello, blocks!") {
+		t.Fatal("embedded template is not rendered:", buf.String())
+	}
+
+	SetTemplateFS(fstest.MapFS{
+		"views/broken.tpl": {Data: []byte("{{if .}}")},
+	})
+	if err := buildTemplate("views", true); err == nil || !strings.Contains(err.Error(), "broken.tpl") {
+		t.Fatal("strict build should fail on the broken template, got", err)
+	}
+	if err := BuildTemplate("views"); err != nil {
+		t.Fatal("BuildTemplate should skip the broken template, got", err)
+	}
+}
+
+func TestAuditTemplates(t *testing.T) {
+	defer func(old map[string]*template.Template) {
+		SetTemplateFS(nil)
+		BeeTemplates = old
+	}(BeeTemplates)
+	BeeTemplates = make(map[string]*template.Template)
+
+	SetTemplateFS(fstest.MapFS{
+		"views/audit.tpl": {Data: []byte(`{{str2html "<b>static</b>"}}
+{{.Title}}
+{{if .Bio}}{{str2html .Bio}}{{end}}
+{{.Comment | htmlunquote}}
+
+
+
+
+
+
+{{str2html .Footer}}`)},
+	})
+	if err := buildTemplate("views", true); err != nil {
+		t.Fatal(err)
+	}
+	issues := AuditTemplates()
+	if len(issues) != 3 {
+		t.Fatalf("should find 3 issues but got %v", issues)
+	}
+	if issues[0].Func != "str2html" || !strings.Contains(issues[0].Location, "audit.tpl:3") || issues[0].Expr != "{{str2html .Bio}}" {
+		t.Error("unexpected issue:", issues[0])
+	}
+	if issues[1].Func != "htmlunquote" || !strings.Contains(issues[1].Location, "audit.tpl:4") {
+		t.Error("unexpected issue:", issues[1])
+	}
+	if !strings.Contains(issues[2].Location, "audit.tpl:11") {
+		t.Error("the issues should be sorted by line:", issues[2])
+	}
+}
+
+func TestViewPathsAndLocale(t *testing.T) {
+	defer func(old map[string]*template.Template) {
+		SetTemplateFS(nil)
+		BeeTemplates = old
+		delete(beeViewPathTemplates, "themes/blue")
+	}(BeeTemplates)
+	BeeTemplates = make(map[string]*template.Template)
+
+	SetTemplateFS(fstest.MapFS{
+		"views/index.tpl":             {Data: []byte("base index")},
+		"views/index.zh-CN.tpl":       {Data: []byte("base zh-CN index")},
+		"views/about.tpl":             {Data: []byte("base about")},
+		"themes/blue/index.tpl":       {Data: []byte("blue index")},
+		"themes/blue/about.fr-FR.tpl": {Data: []byte("blue fr-FR about")},
+	})
+	if err := BuildTemplate("views"); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddViewPath("themes/blue"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		viewPaths    []string
+		name, locale string
+		want         string
+	}{
+		{nil, "index.tpl", "", "base index"},
+		{nil, "index.tpl", "zh-CN", "base zh-CN index"},
+		{[]string{"themes/blue"}, "index.tpl", "", "blue index"},
+		{[]string{"themes/blue"}, "about.tpl", "", "base about"},
+		{[]string{"themes/blue"}, "about.tpl", "fr-FR", "blue fr-FR about"},
+	}
+	for _, c := range cases {
+		ctrl := &Controller{ViewPaths: c.viewPaths, TplLocale: c.locale}
+		var buf bytes.Buffer
+		if err := ctrl.executeTemplate(&buf, c.name); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != c.want {
+			t.Errorf("%v %s %s: got %q, want %q", c.viewPaths, c.name, c.locale, buf.String(), c.want)
+		}
+	}
+}
+
+func TestTemplateNames(t *testing.T) {
+	names := []struct{ controller, action, lower, snake string }{
+		{"MainController", "Get", "maincontroller/get", "main/get"},
+		{"UserProfileController", "GetList", "userprofilecontroller/getlist", "user_profile/get_list"},
+		{"HTTPServerController", "ServeJSON", "httpservercontroller/servejson", "http_server/serve_json"},
+	}
+	for _, n := range names {
+		if got := LowerTemplateName(n.controller, n.action); got != n.lower {
+			t.Errorf("LowerTemplateName(%s, %s) = %s, want %s", n.controller, n.action, got, n.lower)
+		}
+		if got := SnakeTemplateName(n.controller, n.action); got != n.snake {
+			t.Errorf("SnakeTemplateName(%s, %s) = %s, want %s", n.controller, n.action, got, n.snake)
+		}
+	}
+
+	defer SetTemplateNaming(nil)
+	c := &Controller{controllerName: "UserProfileController", actionName: "GetList", TplExt: "tpl"}
+	if got := c.templateName(); got != "userprofilecontroller/getlist.tpl" {
+		t.Errorf("default template name: %s", got)
+	}
+	SetTemplateNaming(SnakeTemplateName)
+	if got := c.templateName(); got != "user_profile/get_list.tpl" {
+		t.Errorf("snake template name: %s", got)
+	}
+	c.UseTemplate("custom/name")
+	if c.TplNames != "custom/name.tpl" {
+		t.Errorf("UseTemplate: %s", c.TplNames)
+	}
+	c.UseTemplate("custom/name.html")
+	if c.TplNames != "custom/name.html" {
+		t.Errorf("UseTemplate with extension: %s", c.TplNames)
+	}
+}
+
+func TestRenderSections(t *testing.T) {
+	defer func(old map[string]*template.Template) {
+		SetTemplateFS(nil)
+		BeeTemplates = old
+	}(BeeTemplates)
+	BeeTemplates = make(map[string]*template.Template)
+
+	SetTemplateFS(fstest.MapFS{
+		"views/blocks/sidebar.tpl": {Data: []byte("sidebar of {{.Name}}")},
+		"views/blocks/footer.tpl":  {Data: []byte("footer")},
+		"views/page.tpl":           {Data: []byte("{{.Sidebar}}|{{.Footer}}|{{.Empty}}")},
+	})
+	if err := BuildTemplate("views"); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Controller{TplExt: "tpl", Data: map[interface{}]interface{}{"Name": "astaxie"}}
+	err := c.RenderSections(map[string]string{
+		"Sidebar": "blocks/sidebar",
+		"Footer":  "blocks/footer.tpl",
+		"Empty":   "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.UseTemplate("page")
+	out, err := c.RenderString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "sidebar of astaxie|footer|" {
+		t.Errorf("got %q", out)
+	}
+}
+
+type renderTestController struct {
+	Controller
+}
+
+func (c *renderTestController) Get() {
+	c.TplNames = c.Ctx.Input.Query("tpl")
+	c.StreamRender = c.Ctx.Input.Query("stream") != ""
+	c.Layout = c.Ctx.Input.Query("layout")
+	c.Data["Rows"] = make([]int, 10)
+}
+
+func TestRenderBuffer(t *testing.T) {
+	defer func(old map[string]*template.Template, size int64) {
+		SetTemplateFS(nil)
+		BeeTemplates = old
+		RenderBufferSize = size
+	}(BeeTemplates, RenderBufferSize)
+	BeeTemplates = make(map[string]*template.Template)
+	RenderBufferSize = 16
+
+	SetTemplateFS(fstest.MapFS{
+		"views/ok.tpl":     {Data: []byte("ok")},
+		"views/big.tpl":    {Data: []byte("{{range .Rows}}row{{end}}")},
+		"views/broken.tpl": {Data: []byte("before {{index .Rows 100}} after")},
+		"views/layout.tpl": {Data: []byte("[{{.LayoutContent}}]")},
+	})
+	if err := BuildTemplate("views"); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewControllerRegister()
+	handler.Add("/page", &renderTestController{})
+	do := func(url string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := do("/page?tpl=ok.tpl"); w.Code != 200 || w.Body.String() != "ok" {
+		t.Errorf("ok page: %d %q", w.Code, w.Body.String())
+	}
+	if w := do("/page?tpl=broken.tpl"); w.Code != 500 || strings.Contains(w.Body.String(), "before") {
+		t.Errorf("broken page should get a clean 500: %d %q", w.Code, w.Body.String())
+	}
+	if w := do("/page?tpl=missing.tpl"); w.Code != 500 {
+		t.Errorf("missing template should get 500: %d", w.Code)
+	}
+	if w := do("/page?tpl=big.tpl"); w.Code != 500 {
+		t.Errorf("page over RenderBufferSize should get 500: %d", w.Code)
+	}
+	if w := do("/page?tpl=big.tpl&stream=1"); w.Code != 200 || w.Body.Len() != 30 {
+		t.Errorf("streamed page: %d %q", w.Code, w.Body.String())
+	}
+	if w := do("/page?tpl=big.tpl&layout=layout.tpl"); w.Code != 500 {
+		t.Errorf("layout content over RenderBufferSize should get 500: %d", w.Code)
+	}
+	if w := do("/page?tpl=big.tpl&layout=layout.tpl&stream=1"); w.Code != 200 || w.Body.Len() != 32 {
+		t.Errorf("streamed layout: %d %q", w.Code, w.Body.String())
+	}
+	if w := do("/page?tpl=broken.tpl&stream=1"); !strings.HasPrefix(w.Body.String(), "before") || strings.Contains(w.Body.String(), "after") {
+		t.Errorf("broken streamed page should be cut off: %q", w.Body.String())
+	}
+
+	c := &Controller{TplNames: "big.tpl", Data: map[interface{}]interface{}{"Rows": make([]int, 10)}}
+	if _, err := c.RenderBytes(); err != ErrRenderBufferFull {
+		t.Errorf("page over RenderBufferSize: %v", err)
+	}
+	RenderBufferSize = 0
+	if b, err := c.RenderBytes(); err != nil || len(b) != 30 {
+		t.Errorf("unlimited buffer: %v %d", err, len(b))
+	}
+}
+
+// replaceEngine replaces {{Name}} by the data of the key Name.
+type replaceEngine struct {
+	templates map[string]string
+	watched   int
+}
+
+func (e *replaceEngine) Parse(fsys fs.FS, name string) error {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	e.templates[name] = string(b)
+	return nil
+}
+
+func (e *replaceEngine) Execute(w io.Writer, name string, data map[interface{}]interface{}) error {
+	s := e.templates[name]
+	for k, v := range data {
+		s = strings.Replace(s, "{{"+fmt.Sprint(k)+"}}", fmt.Sprint(v), -1)
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+func (e *replaceEngine) Watch(fsys fs.FS, name string) error {
+	e.watched++
+	return nil
+}
+
+func TestTemplateEngine(t *testing.T) {
+	defer func(old map[string]*template.Template, mode string) {
+		SetTemplateFS(nil)
+		BeeTemplates, RunMode = old, mode
+		templateEngines = make(map[string]TemplateEngine)
+		engineTemplates = make(map[string]fs.FS)
+	}(BeeTemplates, RunMode)
+	BeeTemplates = make(map[string]*template.Template)
+	RunMode = "prod"
+
+	engine := &replaceEngine{templates: make(map[string]string)}
+	AddTemplateEngine("rep", engine)
+	SetTemplateFS(fstest.MapFS{
+		"views/layout.tpl":          {Data: []byte("<main>{{.LayoutContent}}</main>")},
+		"views/user/show.rep":       {Data: []byte("user {{Name}}")},
+		"views/user/show.zh-CN.rep": {Data: []byte("用户 {{Name}}")},
+	})
+	if err := BuildTemplate("views"); err != nil {
+		t.Fatal(err)
+	}
+	if len(engine.templates) != 2 || len(BeeTemplates) != 1 {
+		t.Fatalf("got the engine templates %v and %d html templates", engine.templates, len(BeeTemplates))
+	}
+
+	for locale, want := range map[string]string{"": "<main>user astaxie</main>", "zh-CN": "<main>用户 astaxie</main>"} {
+		c := &Controller{Data: map[interface{}]interface{}{"Name": "astaxie"}, TplLocale: locale}
+		c.Layout = "layout.tpl"
+		c.TplNames = "user/show.rep"
+		out, err := c.RenderString()
+		if err != nil || out != want {
+			t.Errorf("locale %q got %q %v, want %q", locale, out, err, want)
+		}
+	}
+
+	c := &Controller{Data: map[interface{}]interface{}{}, TplNames: "user/missing.rep"}
+	if _, err := c.RenderString(); err == nil {
+		t.Error("expected an error for the missing template")
+	}
+
+	RunMode = "dev"
+	c = &Controller{Data: map[interface{}]interface{}{"Name": "dev"}, TplNames: "user/show.rep"}
+	if out, err := c.RenderString(); err != nil || out != "user dev" || engine.watched != 1 {
+		t.Errorf("dev got %q %v, watched %d times", out, err, engine.watched)
+	}
+}
+
+func TestTemplateInheritance(t *testing.T) {
+	defer func(old map[string]*template.Template) {
+		SetTemplateFS(nil)
+		BeeTemplates = old
+	}(BeeTemplates)
+	BeeTemplates = make(map[string]*template.Template)
+
+	SetTemplateFS(fstest.MapFS{
+		"views/base.tpl":        {Data: []byte(`<title>{{block "title" .}}Site{{end}}</title><nav>{{block "nav" .}}home{{end}}</nav><main>{{block "content" .}}{{end}}</main>`)},
+		"views/admin/base.tpl":  {Data: []byte(`{{define "nav"}}admin{{end}}{{template "base.tpl" .}}`)},
+		"views/admin/users.tpl": {Data: []byte(`{{define "title"}}Users{{end}}{{define "content"}}{{.Count}} users{{end}}{{template "admin/base.tpl" .}}`)},
+	})
+	if err := BuildTemplate("views"); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"admin/users.tpl": "<title>Users</title><nav>admin</nav><main>3 users</main>",
+		"admin/base.tpl":  "<title>Site</title><nav>admin</nav><main></main>",
+		"base.tpl":        "<title>Site</title><nav>home</nav><main></main>",
+	} {
+		c := &Controller{Data: map[interface{}]interface{}{"Count": 3}, TplNames: name}
+		if out, err := c.RenderString(); err != nil || out != want {
+			t.Errorf("%s got %q %v, want %q", name, out, err, want)
+		}
+	}
+}
+
+func TestOrderedSections(t *testing.T) {
+	defer func(old map[string]*template.Template) {
+		SetTemplateFS(nil)
+		BeeTemplates = old
+	}(BeeTemplates)
+	BeeTemplates = make(map[string]*template.Template)
+
+	SetTemplateFS(fstest.MapFS{
+		"views/layout.tpl":       {Data: []byte("{{.Crumb}}|{{.Sidebar}}|{{.Ads}}|{{.Extra}}|{{.LayoutContent}}")},
+		"views/blocks/crumb.tpl": {Data: []byte("crumb")},
+		"views/blocks/side.tpl":  {Data: []byte("side after {{.Crumb}}")},
+		"views/blocks/ads.tpl":   {Data: []byte("ads")},
+		"views/page.tpl":         {Data: []byte("page with {{.Sidebar}}")},
+	})
+	if err := BuildTemplate("views"); err != nil {
+		t.Fatal(err)
+	}
+	c := &Controller{TplExt: "tpl", Data: map[interface{}]interface{}{}, Layout: "layout.tpl", TplNames: "page.tpl"}
+	c.AddSection("Crumb", "blocks/crumb")
+	c.AddSection("Sidebar", "blocks/side.tpl")
+	c.Sections = append(c.Sections,
+		LayoutSection{Name: "Ads", Tpl: "blocks/ads", If: func() bool { return false }},
+		LayoutSection{Name: "Extra", Tpl: "blocks/missing", Optional: true})
+	out, err := c.RenderString()
+	if want := "crumb|side after crumb|||page with side after crumb"; err != nil || out != want {
+		t.Errorf("got %q %v, want %q", out, err, want)
+	}
+
+	c.Sections = []LayoutSection{{Name: "Extra", Tpl: "blocks/missing"}}
+	if _, err := c.RenderString(); err == nil {
+		t.Error("expected an error for the missing section")
+	}
+}
+
+func TestTemplateTr(t *testing.T) {
+	defer func(old map[string]*template.Template) {
+		SetTemplateFS(nil)
+		SetI18n(nil)
+		BeeTemplates = old
+	}(BeeTemplates)
+	BeeTemplates = make(map[string]*template.Template)
+
+	b := i18n.NewBundle("en-US")
+	b.Add("en-US", map[string]string{"inbox.one": "%d message", "inbox.other": "%d messages"})
+	b.Add("fr", map[string]string{"inbox.one": "%d message", "inbox.other": "%d messages (fr)"})
+	SetI18n(b)
+	SetTemplateFS(fstest.MapFS{
+		"views/inbox.tpl": {Data: []byte(`{{tr .Lang "inbox" 2}}|{{tr .Lang "missing"}}`)},
+	})
+	if err := BuildTemplate("views"); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := http.NewRequest("GET", "/inbox", nil)
+	r.Header.Set("Accept-Language", "fr-CA, en;q=0.5")
+	ctx := &context.Context{Request: r, Input: context.NewInput(r), Output: context.NewOutput()}
+	c := &Controller{}
+	c.Init(ctx, "InboxController", "Get", nil)
+	c.TplNames = "inbox.tpl"
+	out, err := c.RenderString()
+	if want := "2 messages (fr)|missing"; err != nil || out != want {
+		t.Errorf("got %q %v, want %q", out, err, want)
+	}
+	if got := c.Tr("inbox", 1); got != "1 message" {
+		t.Errorf("Tr = %q", got)
+	}
+
+	// the locale of the user profile set in the action
+	ctx.SetLocale("en-US")
+	out, err = c.RenderString()
+	if want := "2 messages|missing"; err != nil || out != want {
+		t.Errorf("got %q %v after SetLocale, want %q", out, err, want)
+	}
+}
@@ -188,6 +188,7 @@ func Config(returnType, key string, defaultVal interface{}) (value interface{},
 }
 
 // Str2html Convert string to template.HTML type.
+// The string is output without escaping, use SanitizeHTML for the html of the users.
 func Str2html(raw string) template.HTML {
 	return template.HTML(raw)
 }
@@ -261,20 +262,38 @@ func URLFor(endpoint string, values ...interface{}) string {
 	return BeeApp.Handlers.URLFor(endpoint, values...)
 }
 
+// URLForName returns the url of the route named by WithName with params.
+// usage:
+//
+//	{{urlforname "user.show" ":id" .User.Id}}
+func URLForName(name string, values ...interface{}) string {
+	return BeeApp.Handlers.URLForName(name, values...)
+}
+
+// URLForLocale returns the url of the named route translated to locale with params.
+// usage:
+//
+//	{{urlforlocale "product" .Lang ":slug" .Product.Slug}}
+func URLForLocale(name, locale string, values ...interface{}) string {
+	return BeeApp.Handlers.URLForLocale(name, locale, values...)
+}
+
 // AssetsJs returns script tag with src string.
+// If AssetIntegrity is on, the src is fingerprinted and the integrity attribute is added.
 func AssetsJs(src string) template.HTML {
-	text := string(src)
+	src, attrs := assetAttrs(src)
 
-	text = "<script src=\"" + src + "\"></script>"
+	text := "<script src=\"" + src + "\"" + attrs + "></script>"
 
 	return template.HTML(text)
 }
 
 // AssetsCSS returns stylesheet link tag with src string.
+// If AssetIntegrity is on, the src is fingerprinted and the integrity attribute is added.
 func AssetsCSS(src string) template.HTML {
-	text := string(src)
+	src, attrs := assetAttrs(src)
 
-	text = "<link href=\"" + src + "\" rel=\"stylesheet\" />"
+	text := "<link href=\"" + src + "\" rel=\"stylesheet\"" + attrs + " />"
 
 	return template.HTML(text)
 }
@@ -15,9 +15,15 @@
 package beego
 
 import (
+	"crypto/sha512"
+	"encoding/base64"
 	"html/template"
+	"io/ioutil"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -321,3 +327,60 @@ func TestMapGet(t *testing.T) {
 		t.Errorf("Error happens %v", err)
 	}
 }
+
+func TestAssetIntegrity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beego-asset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	content := []byte("alert(1)")
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.js"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	StaticDir["/sri"] = dir
+	defer delete(StaticDir, "/sri")
+
+	sum := sha512.Sum384(content)
+	want := "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+	a, err := AssetInfo("/sri/app.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Integrity != want {
+		t.Errorf("integrity = %s, want %s", a.Integrity, want)
+	}
+	if !strings.HasPrefix(a.URL, "/sri/app.js?v=") {
+		t.Errorf("url = %s", a.URL)
+	}
+	if attr := AssetIntegrityAttr("/sri/app.js"); string(attr) != `integrity="`+want+`" crossorigin="anonymous"` {
+		t.Errorf("attr = %s", attr)
+	}
+	if _, err := AssetInfo("/sri/missing.js"); err == nil {
+		t.Error("missing asset should fail")
+	}
+	if AssetURL("/nowhere/app.js") != "/nowhere/app.js" {
+		t.Error("unknown asset should keep its url")
+	}
+
+	if html := AssetsJs("/sri/app.js"); html != `<script src="/sri/app.js"></script>` {
+		t.Errorf("AssetsJs = %s", html)
+	}
+	AssetIntegrity = true
+	defer func() { AssetIntegrity = false }()
+	if html := AssetsJs("/sri/app.js"); html != template.HTML(`<script src="`+a.URL+`" integrity="`+want+`" crossorigin="anonymous"></script>`) {
+		t.Errorf("AssetsJs = %s", html)
+	}
+
+	// the hashes follow the file in dev mode
+	content = []byte("alert(2)")
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.js"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	mode := RunMode
+	RunMode = "dev"
+	defer func() { RunMode = mode }()
+	if b, _ := AssetInfo("/sri/app.js"); b == nil || b.Integrity == want {
+		t.Error("the integrity should change with the file")
+	}
+}
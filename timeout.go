@@ -0,0 +1,255 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"bufio"
+	gocontext "context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	beecontext "github.com/astaxie/beego/context"
+)
+
+// timeoutRouter stores the request timeout of the routers matched by pattern.
+type timeoutRouter struct {
+	tree    *Tree
+	pattern string
+	timeout time.Duration
+}
+
+// SetTimeout sets the timeout of the requests matched by pattern, it overrides RequestTimeout.
+// When the handler doesn't finish in time the request context is canceled and 503 is sent,
+// if the handler has already written the response it's cut off instead.
+// A zero timeout disables the timeout for the pattern, e.g. for the streaming routes.
+// usage:
+//	SetTimeout("/report/*", 30 * time.Second)
+//	SetTimeout("/events", 0)
+func (p *ControllerRegister) SetTimeout(pattern string, timeout time.Duration) {
+	tr := &timeoutRouter{
+		tree:    NewTree(),
+		pattern: pattern,
+		timeout: timeout,
+	}
+	if !RouterCaseSensitive {
+		pattern = strings.ToLower(pattern)
+	}
+	tr.tree.AddRouter(pattern, true)
+	p.timeouts = append(p.timeouts, tr)
+}
+
+// timeoutFor returns the timeout of the request path, the latest matched pattern wins.
+func (p *ControllerRegister) timeoutFor(urlPath string) time.Duration {
+	if !RouterCaseSensitive {
+		urlPath = strings.ToLower(urlPath)
+	}
+	for i := len(p.timeouts) - 1; i >= 0; i-- {
+		if ok, _ := p.timeouts[i].tree.Match(urlPath); ok != nil {
+			return p.timeouts[i].timeout
+		}
+	}
+	return time.Duration(RequestTimeout) * time.Second
+}
+
+// serveTimeout runs the request in a new goroutine and answers 503 if it doesn't finish in time.
+func (p *ControllerRegister) serveTimeout(rw http.ResponseWriter, r *http.Request, timeout time.Duration) {
+	c, cancel := gocontext.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	r = r.WithContext(c)
+
+	tw := &timeoutWriter{w: rw, h: make(http.Header), ctx: c}
+	done := make(chan struct{})
+	panicChan := make(chan interface{}, 1)
+	go func() {
+		defer readiness.end()
+		defer func() {
+			if err := recover(); err != nil {
+				panicChan <- err
+			}
+		}()
+		p.serve(tw, r)
+		close(done)
+	}()
+
+	select {
+	case err := <-panicChan:
+		panic(err)
+	case <-done:
+	case <-c.Done():
+	}
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if c.Err() != gocontext.DeadlineExceeded {
+		return
+	}
+	// the handler may finish right at the deadline
+	select {
+	case <-done:
+		if tw.wroteHeader && !tw.timedOut {
+			return
+		}
+	default:
+	}
+	tw.timedOut = true
+	if tw.hijacked {
+		return
+	}
+	if tw.wroteHeader {
+		Warn("the request timed out after the response is partially written:", r.URL.Path)
+		return
+	}
+	ctx := &beecontext.Context{
+		ResponseWriter: rw,
+		Request:        r,
+		Input:          beecontext.NewInput(r),
+		Output:         beecontext.NewOutput(),
+	}
+	ctx.Output.Context = ctx
+	exception("503", ctx)
+}
+
+// timeoutWriter drops the writes of the handler once the request timed out.
+// The headers are kept apart until they are written, so the error page doesn't race with the handler.
+// It forwards the Hijacker, Pusher, ReaderFrom and CloseNotifier of the underlying writer,
+// the connection can't be hijacked once the request timed out.
+type timeoutWriter struct {
+	w           http.ResponseWriter
+	h           http.Header
+	ctx         gocontext.Context
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+	hijacked    bool
+}
+
+// expired marks the writer timed out once the deadline is exceeded, the caller holds mu.
+func (tw *timeoutWriter) expired() bool {
+	if !tw.timedOut && tw.ctx.Err() == gocontext.DeadlineExceeded {
+		tw.timedOut = true
+	}
+	return tw.timedOut
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeader(http.StatusOK)
+	}
+	return tw.w.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired() || tw.wroteHeader {
+		return
+	}
+	tw.writeHeader(code)
+}
+
+func (tw *timeoutWriter) writeHeader(code int) {
+	dst := tw.w.Header()
+	for k, v := range tw.h {
+		dst[k] = v
+	}
+	tw.wroteHeader = true
+	tw.w.WriteHeader(code)
+}
+
+// Flush implements http.Flusher.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired() {
+		return
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		if !tw.wroteHeader {
+			tw.writeHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, e.g. for the WebSocket upgrade.
+// The request context is still canceled at the deadline, disable the timeout of the WebSocket routes by SetTimeout.
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired() {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	hj, ok := tw.w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("webserver doesn't support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		tw.hijacked = true
+		tw.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Push implements http.Pusher.
+func (tw *timeoutWriter) Push(target string, opts *http.PushOptions) error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired() {
+		return http.ErrHandlerTimeout
+	}
+	if p, ok := tw.w.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// ReadFrom implements io.ReaderFrom, so the server can use sendfile when serving files.
+func (tw *timeoutWriter) ReadFrom(r io.Reader) (int64, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeader(http.StatusOK)
+	}
+	if rf, ok := tw.w.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(struct{ io.Writer }{tw.w}, r)
+}
+
+// CloseNotify implements http.CloseNotifier.
+// Deprecated: use Request.Context().Done() instead.
+func (tw *timeoutWriter) CloseNotify() <-chan bool {
+	if cn, ok := tw.w.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return make(chan bool)
+}
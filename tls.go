@@ -0,0 +1,138 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// buildTLSConfig returns the tls.Config of the https server.
+// It starts from TLSConfig and adds the client certificate authentication,
+// cipher suites and the ALPN protocols from the config.
+func buildTLSConfig() (*tls.Config, error) {
+	config := &tls.Config{}
+	if TLSConfig != nil {
+		config = TLSConfig.Clone()
+	}
+	if HTTPSClientCAFile != "" {
+		pem, err := ioutil.ReadFile(HTTPSClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificate found in " + HTTPSClientCAFile)
+		}
+		config.ClientCAs = pool
+		if config.ClientAuth == tls.NoClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	if len(HTTPSCipherSuites) > 0 {
+		suites := make(map[string]uint16)
+		for _, s := range tls.CipherSuites() {
+			suites[s.Name] = s.ID
+		}
+		config.CipherSuites = nil
+		for _, name := range HTTPSCipherSuites {
+			id, ok := suites[strings.TrimSpace(name)]
+			if !ok {
+				return nil, errors.New("unknown or insecure cipher suite " + name)
+			}
+			config.CipherSuites = append(config.CipherSuites, id)
+		}
+	}
+	protos := make([]string, 0, len(config.NextProtos)+2)
+	if EnableHTTP2 {
+		protos = append(protos, "h2")
+	}
+	for _, p := range config.NextProtos {
+		if p != "h2" && p != "http/1.1" {
+			protos = append(protos, p)
+		}
+	}
+	config.NextProtos = append(protos, "http/1.1")
+	return config, nil
+}
+
+// setupTLS sets the tls.Config of the server, HTTP/2 is disabled if EnableHTTP2 is false.
+func (app *App) setupTLS() error {
+	config, err := buildTLSConfig()
+	if err != nil {
+		return err
+	}
+	app.Server.TLSConfig = config
+	if !EnableHTTP2 {
+		// a non-nil TLSNextProto disables the automatic HTTP/2
+		app.Server.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	}
+	return nil
+}
+
+// AutoTLSManager provides the certificates for EnableAutoTLS, autocert.Manager implements it.
+// HTTPHandler answers the ACME HTTP-01 challenges and passes the other requests to fallback.
+type AutoTLSManager interface {
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+	HTTPHandler(fallback http.Handler) http.Handler
+}
+
+// NewAutoTLSManager creates the AutoTLSManager with the hosts, cache directory and email of the config.
+// It's set by importing a provider:
+//	import _ "github.com/astaxie/beego/plugins/autotls"
+var NewAutoTLSManager func(hosts []string, cacheDir, email string) (AutoTLSManager, error)
+
+// setupAutoTLS gets the certificates from the AutoTLSManager and returns the handler
+// which answers the ACME challenges before the router.
+func (app *App) setupAutoTLS(handler http.Handler) (http.Handler, error) {
+	if NewAutoTLSManager == nil {
+		return nil, errors.New("no AutoTLSManager, forgotten import github.com/astaxie/beego/plugins/autotls?")
+	}
+	if len(AutoTLSHosts) == 0 {
+		return nil, errors.New("AutoTLSHosts is empty")
+	}
+	m, err := NewAutoTLSManager(AutoTLSHosts, AutoTLSCacheDir, AutoTLSEmail)
+	if err != nil {
+		return nil, err
+	}
+	app.Server.TLSConfig.GetCertificate = m.GetCertificate
+	// the TLS-ALPN-01 challenge
+	app.Server.TLSConfig.NextProtos = append(app.Server.TLSConfig.NextProtos, "acme-tls/1")
+	return m.HTTPHandler(handler), nil
+}
+
+// http3Port returns the UDP port of the HTTP/3 server, HTTP3Port or HTTPSPort.
+func http3Port() int {
+	if HTTP3Port != 0 {
+		return HTTP3Port
+	}
+	return HTTPSPort
+}
+
+// altSvcHandler advertises the HTTP/3 server on port in the Alt-Svc header of the responses.
+func altSvcHandler(handler http.Handler, port int) http.Handler {
+	altSvc := fmt.Sprintf(`h3=":%d"; ma=86400`, port)
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor < 3 {
+			rw.Header().Set("Alt-Svc", altSvc)
+		}
+		handler.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolbox
+
+import (
+	"sort"
+	"sync"
+)
+
+// AllocStat is the allocation statistics of the requests of a method and route pattern.
+type AllocStat struct {
+	Method   string `json:"method"`
+	Pattern  string `json:"pattern"`
+	Requests uint64 `json:"requests"`
+	Bytes    uint64 `json:"bytes"`     // the bytes allocated by all the requests
+	Objects  uint64 `json:"objects"`   // the objects allocated by all the requests
+	MaxBytes uint64 `json:"max_bytes"` // the bytes allocated by the heaviest request
+}
+
+// AvgBytes returns the average bytes allocated per request.
+func (s AllocStat) AvgBytes() uint64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.Bytes / s.Requests
+}
+
+// AvgObjects returns the average objects allocated per request.
+func (s AllocStat) AvgObjects() uint64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.Objects / s.Requests
+}
+
+// AllocStatistics collects the allocations of the requests per method and route pattern.
+type AllocStatistics struct {
+	lock  sync.Mutex
+	stats map[routeKey]*AllocStat
+}
+
+// NewAllocStatistics returns an empty AllocStatistics.
+func NewAllocStatistics() *AllocStatistics {
+	return &AllocStatistics{stats: make(map[routeKey]*AllocStat)}
+}
+
+// Observe records the bytes and the objects allocated while serving a request.
+func (a *AllocStatistics) Observe(method, pattern string, bytes, objects uint64) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	k := routeKey{method, pattern}
+	s, ok := a.stats[k]
+	if !ok {
+		s = &AllocStat{Method: method, Pattern: pattern}
+		a.stats[k] = s
+	}
+	s.Requests++
+	s.Bytes += bytes
+	s.Objects += objects
+	if bytes > s.MaxBytes {
+		s.MaxBytes = bytes
+	}
+}
+
+// Snapshot returns the statistics, the heaviest routes by the average bytes first.
+func (a *AllocStatistics) Snapshot() []AllocStat {
+	a.lock.Lock()
+	stats := make([]AllocStat, 0, len(a.stats))
+	for _, s := range a.stats {
+		stats = append(stats, *s)
+	}
+	a.lock.Unlock()
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].AvgBytes() != stats[j].AvgBytes() {
+			return stats[i].AvgBytes() > stats[j].AvgBytes()
+		}
+		return stats[i].Pattern < stats[j].Pattern
+	})
+	return stats
+}
+
+// Reset clears the statistics.
+func (a *AllocStatistics) Reset() {
+	a.lock.Lock()
+	a.stats = make(map[routeKey]*AllocStat)
+	a.lock.Unlock()
+}
+
+// Allocs is the global allocation statistics, it's exposed on /allocs of the admin module.
+var Allocs = NewAllocStatistics()
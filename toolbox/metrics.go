@@ -0,0 +1,177 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolbox
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// LatencyBuckets are the upper bounds in seconds of the request latency histogram.
+	LatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// SizeBuckets are the upper bounds in bytes of the response size histogram.
+	SizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+type routeKey struct {
+	method  string
+	pattern string
+}
+
+type routeMetrics struct {
+	status  map[int]uint64
+	latency *histogram
+	size    *histogram
+}
+
+// RequestMetrics collects the request counters, latency and response size histograms
+// per method and matched route pattern, and the number of the requests in flight.
+// It's written in the Prometheus text exposition format by WritePrometheus.
+type RequestMetrics struct {
+	lock     sync.Mutex
+	inFlight int64
+	routes   map[routeKey]*routeMetrics
+}
+
+// NewRequestMetrics returns an empty RequestMetrics.
+func NewRequestMetrics() *RequestMetrics {
+	return &RequestMetrics{routes: make(map[routeKey]*routeMetrics)}
+}
+
+// Begin counts a request in flight, call End when it's done.
+func (m *RequestMetrics) Begin() {
+	atomic.AddInt64(&m.inFlight, 1)
+}
+
+// End marks a request started by Begin finished.
+func (m *RequestMetrics) End() {
+	atomic.AddInt64(&m.inFlight, -1)
+}
+
+// Observe records a finished request, pattern is the matched route pattern, not the raw path,
+// so the number of the series stays bounded.
+func (m *RequestMetrics) Observe(method, pattern string, status int, latency time.Duration, size int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	k := routeKey{method, pattern}
+	r, ok := m.routes[k]
+	if !ok {
+		r = &routeMetrics{
+			status:  make(map[int]uint64),
+			latency: newHistogram(LatencyBuckets),
+			size:    newHistogram(SizeBuckets),
+		}
+		m.routes[k] = r
+	}
+	r.status[status]++
+	r.latency.observe(latency.Seconds())
+	r.size.observe(float64(size))
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format.
+func (m *RequestMetrics) WritePrometheus(w io.Writer) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	keys := make([]routeKey, 0, len(m.routes))
+	for k := range m.routes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].pattern != keys[j].pattern {
+			return keys[i].pattern < keys[j].pattern
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# HELP beego_http_requests_in_flight Number of the requests being served.")
+	fmt.Fprintln(bw, "# TYPE beego_http_requests_in_flight gauge")
+	fmt.Fprintf(bw, "beego_http_requests_in_flight %d\n", atomic.LoadInt64(&m.inFlight))
+
+	fmt.Fprintln(bw, "# HELP beego_http_requests_total Number of the requests by method, route pattern and status.")
+	fmt.Fprintln(bw, "# TYPE beego_http_requests_total counter")
+	for _, k := range keys {
+		r := m.routes[k]
+		codes := make([]int, 0, len(r.status))
+		for code := range r.status {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(bw, "beego_http_requests_total{%s,status=\"%d\"} %d\n", k.labels(), code, r.status[code])
+		}
+	}
+
+	fmt.Fprintln(bw, "# HELP beego_http_request_duration_seconds Latency of the requests by method and route pattern.")
+	fmt.Fprintln(bw, "# TYPE beego_http_request_duration_seconds histogram")
+	for _, k := range keys {
+		writeHistogram(bw, "beego_http_request_duration_seconds", k.labels(), m.routes[k].latency)
+	}
+
+	fmt.Fprintln(bw, "# HELP beego_http_response_size_bytes Size of the responses by method and route pattern.")
+	fmt.Fprintln(bw, "# TYPE beego_http_response_size_bytes histogram")
+	for _, k := range keys {
+		writeHistogram(bw, "beego_http_response_size_bytes", k.labels(), m.routes[k].size)
+	}
+	return bw.Flush()
+}
+
+func writeHistogram(w io.Writer, name, labels string, h *histogram) {
+	for i, b := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(b, 'g', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (k routeKey) labels() string {
+	return `method="` + labelEscaper.Replace(k.method) + `",pattern="` + labelEscaper.Replace(k.pattern) + `"`
+}
+
+// Metrics is the global request metrics, it's exposed on /metrics of the admin module.
+var Metrics = NewRequestMetrics()
@@ -0,0 +1,213 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SLO is the service level objective of a route.
+// A request is bad if it's answered with 5xx or it's slower than Latency.
+type SLO struct {
+	// Latency is the latency objective, e.g. the p99 latency for LatencyTarget 0.99, 0 disables it.
+	Latency time.Duration
+	// LatencyTarget is the fraction of the requests expected faster than Latency, default is 0.99.
+	LatencyTarget float64
+	// Availability is the fraction of the requests expected without 5xx, e.g. 0.999, 0 disables it.
+	Availability float64
+	// Window is the period the burn rates are computed on, default is one hour.
+	Window time.Duration
+	// BurnRate is the burn rate alerting, 1 spends exactly the error budget in the window, default is 1.
+	BurnRate float64
+	// MinRequests is the number of the requests in the window before alerting, default is 10.
+	MinRequests uint64
+}
+
+// SLOStatus is the state of a SLO in its window.
+type SLOStatus struct {
+	Name     string `json:"name"`
+	Requests uint64 `json:"requests"`
+	Errors   uint64 `json:"errors"`
+	Slow     uint64 `json:"slow"`
+	// ErrorBurnRate and LatencyBurnRate are how fast the error budgets are spent,
+	// the budget of the window is exhausted at 1.
+	ErrorBurnRate   float64   `json:"error_burn_rate"`
+	LatencyBurnRate float64   `json:"latency_burn_rate"`
+	Alerting        bool      `json:"alerting"`
+	Since           time.Time `json:"since,omitempty"` // when Alerting changed
+}
+
+// SLOAlertFunc is called when a SLO starts or stops alerting, status.Alerting tells which.
+type SLOAlertFunc func(status SLOStatus)
+
+const sloBuckets = 60
+
+type sloBucket struct {
+	epoch    int64
+	requests uint64
+	errors   uint64
+	slow     uint64
+}
+
+type sloState struct {
+	slo      SLO
+	buckets  [sloBuckets]sloBucket
+	alerting bool
+	since    time.Time
+}
+
+// SLOTracker computes the burn rates of the SLOs and calls the alert functions.
+type SLOTracker struct {
+	lock   sync.Mutex
+	slos   map[string]*sloState
+	alerts []SLOAlertFunc
+}
+
+// NewSLOTracker returns an empty SLOTracker.
+func NewSLOTracker() *SLOTracker {
+	return &SLOTracker{slos: make(map[string]*sloState)}
+}
+
+// Register adds the SLO by name, it replaces the SLO of the same name.
+func (t *SLOTracker) Register(name string, slo SLO) {
+	if slo.LatencyTarget <= 0 || slo.LatencyTarget >= 1 {
+		slo.LatencyTarget = 0.99
+	}
+	if slo.Window <= 0 {
+		slo.Window = time.Hour
+	} else if slo.Window < time.Second {
+		slo.Window = time.Second
+	}
+	if slo.BurnRate <= 0 {
+		slo.BurnRate = 1
+	}
+	if slo.MinRequests == 0 {
+		slo.MinRequests = 10
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.slos[name] = &sloState{slo: slo}
+}
+
+// OnAlert adds a function called when a SLO starts or stops alerting, it runs in a new goroutine.
+func (t *SLOTracker) OnAlert(fn SLOAlertFunc) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.alerts = append(t.alerts, fn)
+}
+
+// Observe records a finished request of the SLO, it does nothing if the name isn't registered.
+func (t *SLOTracker) Observe(name string, status int, latency time.Duration) {
+	t.observe(name, status, latency, time.Now())
+}
+
+func (t *SLOTracker) observe(name string, status int, latency time.Duration, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	s, ok := t.slos[name]
+	if !ok {
+		return
+	}
+	epoch := now.UnixNano() / int64(s.slo.Window/sloBuckets)
+	b := &s.buckets[epoch%sloBuckets]
+	if b.epoch != epoch {
+		*b = sloBucket{epoch: epoch}
+	}
+	b.requests++
+	if status >= 500 {
+		b.errors++
+	}
+	if s.slo.Latency > 0 && latency > s.slo.Latency {
+		b.slow++
+	}
+
+	st := s.status(name, epoch)
+	alerting := st.Requests >= s.slo.MinRequests &&
+		(st.ErrorBurnRate >= s.slo.BurnRate || st.LatencyBurnRate >= s.slo.BurnRate)
+	if alerting == s.alerting {
+		return
+	}
+	s.alerting, s.since = alerting, now
+	st.Alerting, st.Since = alerting, now
+	for _, fn := range t.alerts {
+		go fn(st)
+	}
+}
+
+// status sums the buckets of the window ending at epoch.
+func (s *sloState) status(name string, epoch int64) SLOStatus {
+	st := SLOStatus{Name: name, Alerting: s.alerting, Since: s.since}
+	for _, b := range s.buckets {
+		if b.epoch > epoch-sloBuckets && b.epoch <= epoch {
+			st.Requests += b.requests
+			st.Errors += b.errors
+			st.Slow += b.slow
+		}
+	}
+	if st.Requests == 0 {
+		return st
+	}
+	if s.slo.Availability > 0 && s.slo.Availability < 1 {
+		st.ErrorBurnRate = float64(st.Errors) / float64(st.Requests) / (1 - s.slo.Availability)
+	}
+	if s.slo.Latency > 0 {
+		st.LatencyBurnRate = float64(st.Slow) / float64(st.Requests) / (1 - s.slo.LatencyTarget)
+	}
+	return st
+}
+
+// Status returns the states of the SLOs sorted by name.
+func (t *SLOTracker) Status() []SLOStatus {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := time.Now()
+	list := make([]SLOStatus, 0, len(t.slos))
+	for name, s := range t.slos {
+		list = append(list, s.status(name, now.UnixNano()/int64(s.slo.Window/sloBuckets)))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// SLOWebhook returns a SLOAlertFunc which posts the SLOStatus as json to url.
+// usage:
+//
+//	toolbox.SLOs.OnAlert(toolbox.SLOWebhook("https://hooks.example.com/slo"))
+func SLOWebhook(url string) SLOAlertFunc {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return func(status SLOStatus) {
+		body, err := json.Marshal(status)
+		if err != nil {
+			return
+		}
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Println("slo webhook:", err)
+			return
+		}
+		resp.Body.Close()
+	}
+}
+
+// SLOs is the global SLO tracker, the SLOs of the routers are registered in it and it's exposed on /slo of the admin module.
+var SLOs = NewSLOTracker()
@@ -0,0 +1,64 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSLOTracker(t *testing.T) {
+	tracker := NewSLOTracker()
+	tracker.Register("/order", SLO{Latency: 100 * time.Millisecond, Availability: 0.9, Window: time.Minute})
+	alerts := make(chan SLOStatus, 2)
+	tracker.OnAlert(func(s SLOStatus) {
+		alerts <- s
+	})
+
+	now := time.Now()
+	for i := 0; i < 20; i++ {
+		tracker.observe("/order", 200, 10*time.Millisecond, now)
+	}
+	tracker.observe("/order", 500, 10*time.Millisecond, now)
+	tracker.observe("/unknown", 500, time.Second, now)
+	select {
+	case s := <-alerts:
+		t.Fatal("the budget isn't exhausted:", s)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	tracker.observe("/order", 503, 10*time.Millisecond, now)
+	tracker.observe("/order", 503, 10*time.Millisecond, now)
+	if s := <-alerts; !s.Alerting || s.Name != "/order" || s.Errors != 3 || s.ErrorBurnRate < 1 {
+		t.Fatalf("the error budget should alert: %+v", s)
+	}
+	st := tracker.Status()
+	if len(st) != 1 || !st[0].Alerting || st[0].Requests != 23 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+
+	// the buckets of the previous window are dropped
+	later := now.Add(2 * time.Minute)
+	tracker.observe("/order", 200, 200*time.Millisecond, later)
+	if s := <-alerts; s.Alerting || s.Requests != 1 {
+		t.Fatalf("the alert should be resolved in the new window: %+v", s)
+	}
+	for i := 0; i < 20; i++ {
+		tracker.observe("/order", 200, 10*time.Millisecond, later)
+	}
+	if s := <-alerts; !s.Alerting || s.Requests != 10 || s.Slow != 1 || s.LatencyBurnRate < 9.9 {
+		t.Fatalf("the latency budget should alert: %+v", s)
+	}
+}
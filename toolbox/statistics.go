@@ -16,30 +16,48 @@ package toolbox
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
 
+// UnmatchedPattern is the pattern the statistics of the requests which match no router are recorded under,
+// e.g. the static files and the not found pages.
+const UnmatchedPattern = "<unmatched>"
+
 // Statistics struct
 type Statistics struct {
-	RequestURL        string
+	RequestURL        string // the matched router pattern, e.g. /user/:id
 	RequestController string
 	RequestNum        int64
 	MinTime           time.Duration
 	MaxTime           time.Duration
 	TotalTime         time.Duration
+	SlowNum           int64 // the requests slower than URLMap.SlowThreshold
 }
 
 // URLMap contains several statistics struct to log different data
+// the statistics are keyed by the router pattern and the method, so the parameterized routers don't
+// create a key per url.
 type URLMap struct {
 	lock        sync.RWMutex
 	LengthLimit int //limit the urlmap's length if it's equal to 0 there's no limit
-	urlmap      map[string]map[string]*Statistics
+	// SlowThreshold counts the requests slower than it in SlowNum, 0 doesn't count them
+	SlowThreshold time.Duration
+	urlmap        map[string]map[string]*Statistics
 }
 
 // AddStatistics add statistics task.
-// it needs request method, request url, request controller and statistics time duration
+// it needs request method, the matched router pattern, request controller and statistics time duration,
+// an empty pattern is recorded as UnmatchedPattern.
 func (m *URLMap) AddStatistics(requestMethod, requestURL, requestController string, requesttime time.Duration) {
+	if requestURL == "" {
+		requestURL = UnmatchedPattern
+	}
+	var slow int64
+	if m.SlowThreshold > 0 && requesttime >= m.SlowThreshold {
+		slow = 1
+	}
 	m.lock.Lock()
 	defer m.lock.Unlock()
 	if method, ok := m.urlmap[requestURL]; ok {
@@ -52,6 +70,7 @@ func (m *URLMap) AddStatistics(requestMethod, requestURL, requestController stri
 				s.MinTime = requesttime
 			}
 			s.TotalTime += requesttime
+			s.SlowNum += slow
 		} else {
 			nb := &Statistics{
 				RequestURL:        requestURL,
@@ -60,6 +79,7 @@ func (m *URLMap) AddStatistics(requestMethod, requestURL, requestController stri
 				MinTime:           requesttime,
 				MaxTime:           requesttime,
 				TotalTime:         requesttime,
+				SlowNum:           slow,
 			}
 			m.urlmap[requestURL][requestMethod] = nb
 		}
@@ -76,6 +96,7 @@ func (m *URLMap) AddStatistics(requestMethod, requestURL, requestController stri
 			MinTime:           requesttime,
 			MaxTime:           requesttime,
 			TotalTime:         requesttime,
+			SlowNum:           slow,
 		}
 		methodmap[requestMethod] = nb
 		m.urlmap[requestURL] = methodmap
@@ -87,7 +108,7 @@ func (m *URLMap) GetMap() map[string]interface{} {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
 
-	var fields = []string{"requestUrl", "method", "times", "used", "max used", "min used", "avg used"}
+	var fields = []string{"pattern", "method", "times", "used", "max used", "min used", "avg used", "slow"}
 
 	var resultLists [][]string
 	content := make(map[string]interface{})
@@ -95,24 +116,77 @@ func (m *URLMap) GetMap() map[string]interface{} {
 
 	for k, v := range m.urlmap {
 		for kk, vv := range v {
-			result := []string{
-				fmt.Sprintf("% -50s", k),
-				fmt.Sprintf("% -10s", kk),
-				fmt.Sprintf("% -16d", vv.RequestNum),
-				fmt.Sprintf("% -16s", toS(vv.TotalTime)),
-				fmt.Sprintf("% -16s", toS(vv.MaxTime)),
-				fmt.Sprintf("% -16s", toS(vv.MinTime)),
-				fmt.Sprintf("% -16s", toS(time.Duration(int64(vv.TotalTime)/vv.RequestNum))),
-			}
-			resultLists = append(resultLists, result)
+			resultLists = append(resultLists, vv.row(k, kk))
+		}
+	}
+	content["Data"] = resultLists
+	return content
+}
+
+// GetAggregateMap puts the statistics of each pattern summed over the methods and the total of all patterns.
+func (m *URLMap) GetAggregateMap() map[string]interface{} {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	var fields = []string{"pattern", "methods", "times", "used", "max used", "min used", "avg used", "slow"}
+
+	patterns := make([]string, 0, len(m.urlmap))
+	for k := range m.urlmap {
+		patterns = append(patterns, k)
+	}
+	sort.Strings(patterns)
+
+	var resultLists [][]string
+	total := &Statistics{}
+	for _, k := range patterns {
+		s := &Statistics{}
+		methods := make([]string, 0, len(m.urlmap[k]))
+		for method, vv := range m.urlmap[k] {
+			methods = append(methods, method)
+			s.merge(vv)
+			total.merge(vv)
 		}
+		sort.Strings(methods)
+		resultLists = append(resultLists, s.row(k, fmt.Sprint(methods)))
+	}
+	if total.RequestNum > 0 {
+		resultLists = append(resultLists, total.row("total", ""))
 	}
+	content := make(map[string]interface{})
+	content["Fields"] = fields
 	content["Data"] = resultLists
 	return content
 }
 
+func (s *Statistics) merge(o *Statistics) {
+	if s.RequestNum == 0 || s.MinTime > o.MinTime {
+		s.MinTime = o.MinTime
+	}
+	if s.MaxTime < o.MaxTime {
+		s.MaxTime = o.MaxTime
+	}
+	s.RequestNum += o.RequestNum
+	s.TotalTime += o.TotalTime
+	s.SlowNum += o.SlowNum
+}
+
+func (s *Statistics) row(pattern, methods string) []string {
+	return []string{
+		fmt.Sprintf("% -50s", pattern),
+		fmt.Sprintf("% -10s", methods),
+		fmt.Sprintf("% -16d", s.RequestNum),
+		fmt.Sprintf("% -16s", toS(s.TotalTime)),
+		fmt.Sprintf("% -16s", toS(s.MaxTime)),
+		fmt.Sprintf("% -16s", toS(s.MinTime)),
+		fmt.Sprintf("% -16s", toS(time.Duration(int64(s.TotalTime)/s.RequestNum))),
+		fmt.Sprintf("% -16d", s.SlowNum),
+	}
+}
+
 // GetMapData return all mapdata
 func (m *URLMap) GetMapData() []map[string]interface{} {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 
 	var resultLists []map[string]interface{}
 
@@ -126,6 +200,7 @@ func (m *URLMap) GetMapData() []map[string]interface{} {
 				"max_time":    toS(vv.MaxTime),
 				"min_time":    toS(vv.MinTime),
 				"avg_time":    toS(time.Duration(int64(vv.TotalTime) / vv.RequestNum)),
+				"slow_times":  vv.SlowNum,
 			}
 			resultLists = append(resultLists, result)
 		}
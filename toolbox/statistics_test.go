@@ -15,7 +15,9 @@
 package toolbox
 
 import (
+	"bytes"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 )
@@ -38,3 +40,68 @@ func TestStatics(t *testing.T) {
 
 	t.Log(string(b))
 }
+
+func TestAggregateStatistics(t *testing.T) {
+	m := &URLMap{urlmap: make(map[string]map[string]*Statistics)}
+	m.AddStatistics("GET", "/user/:id", "UserController", 2*time.Millisecond)
+	m.AddStatistics("GET", "/user/:id", "UserController", 4*time.Millisecond)
+	m.AddStatistics("PUT", "/user/:id", "UserController", 6*time.Millisecond)
+	m.AddStatistics("GET", "", "", time.Millisecond)
+
+	data := m.GetAggregateMap()["Data"].([][]string)
+	if len(data) != 3 {
+		t.Fatalf("want 2 patterns and the total, got %v", data)
+	}
+	user, unmatched, total := data[0], data[1], data[2]
+	if strings.TrimSpace(unmatched[0]) != UnmatchedPattern {
+		t.Errorf("unmatched pattern: %v", unmatched)
+	}
+	if strings.TrimSpace(user[0]) != "/user/:id" || strings.TrimSpace(user[1]) != "[GET PUT]" || strings.TrimSpace(user[2]) != "3" {
+		t.Errorf("pattern row: %v", user)
+	}
+	if strings.TrimSpace(total[2]) != "4" || strings.TrimSpace(total[4]) != toS(6*time.Millisecond) || strings.TrimSpace(total[5]) != toS(time.Millisecond) {
+		t.Errorf("total row: %v", total)
+	}
+}
+
+func TestSlowStatistics(t *testing.T) {
+	m := &URLMap{urlmap: make(map[string]map[string]*Statistics), SlowThreshold: 100 * time.Millisecond}
+	m.AddStatistics("GET", "/report", "ReportController", 20*time.Millisecond)
+	m.AddStatistics("GET", "/report", "ReportController", 300*time.Millisecond)
+	m.AddStatistics("POST", "/report", "ReportController", time.Second)
+
+	if s := m.urlmap["/report"]["GET"]; s.RequestNum != 2 || s.SlowNum != 1 {
+		t.Errorf("GET statistics: %+v", s)
+	}
+	data := m.GetAggregateMap()["Data"].([][]string)
+	if slow := strings.TrimSpace(data[0][7]); slow != "2" {
+		t.Errorf("slow of the pattern: %s", slow)
+	}
+}
+
+func TestRequestMetrics(t *testing.T) {
+	m := NewRequestMetrics()
+	m.Begin()
+	m.Observe("GET", "/user/:id", 200, 20*time.Millisecond, 512)
+	m.Observe("GET", "/user/:id", 404, 2*time.Second, 50)
+
+	var buf bytes.Buffer
+	if err := m.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, line := range []string{
+		"beego_http_requests_in_flight 1",
+		`beego_http_requests_total{method="GET",pattern="/user/:id",status="200"} 1`,
+		`beego_http_requests_total{method="GET",pattern="/user/:id",status="404"} 1`,
+		`beego_http_request_duration_seconds_bucket{method="GET",pattern="/user/:id",le="0.025"} 1`,
+		`beego_http_request_duration_seconds_bucket{method="GET",pattern="/user/:id",le="+Inf"} 2`,
+		`beego_http_request_duration_seconds_count{method="GET",pattern="/user/:id"} 2`,
+		`beego_http_response_size_bytes_bucket{method="GET",pattern="/user/:id",le="100"} 1`,
+		`beego_http_response_size_bytes_sum{method="GET",pattern="/user/:id"} 562`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("metrics should contain %s, got:\n%s", line, out)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"github.com/astaxie/beego/context"
+)
+
+// RequestTransformer modifies the request struct bound by ctx.Input.BindRequest or Controller.ParseBody
+// before the handler gets it, such as filling the fields renamed since an old version of the API.
+type RequestTransformer func(ctx *context.Context, req interface{}) error
+
+// ResponseTransformer returns the object to serialize instead of data,
+// such as a copy with the fields masked by the role of the user. The error is answered by 500.
+type ResponseTransformer func(ctx *context.Context, data interface{}) (interface{}, error)
+
+// WithRequestTransformer adds the transformers of the request structs bound on the router, they run in order.
+// usage:
+//
+//	AddWithOptions("/v1/user", &UserController{}, WithRequestTransformer(func(ctx *context.Context, req interface{}) error {
+//		if u, ok := req.(*User); ok && u.Name == "" {
+//			u.Name = u.LegacyName
+//		}
+//		return nil
+//	}))
+func WithRequestTransformer(fns ...RequestTransformer) RouterOption {
+	return func(o *routerOptions) {
+		o.requestTransformers = append(o.requestTransformers, fns...)
+	}
+}
+
+// WithResponseTransformer adds the transformers of the objects served by ServeJSON, ServeXML, ServeYAML,
+// ServeFormat and the ctx.Output serializers on the router, they run in order.
+// usage:
+//
+//	AddWithOptions("/user", &UserController{}, WithResponseTransformer(func(ctx *context.Context, data interface{}) (interface{}, error) {
+//		if u, ok := data.(User); ok && !isAdmin(ctx) {
+//			u.Email = ""
+//			return u, nil
+//		}
+//		return data, nil
+//	}))
+func WithResponseTransformer(fns ...ResponseTransformer) RouterOption {
+	return func(o *routerOptions) {
+		o.responseTransformers = append(o.responseTransformers, fns...)
+	}
+}
+
+// setTransformers sets the transformers of the router on the context of the request.
+func (c *controllerInfo) setTransformers(ctx *context.Context) {
+	if reqs := c.requestTransformers; len(reqs) > 0 {
+		ctx.Input.Transform = func(dest interface{}) error {
+			for _, fn := range reqs {
+				if err := fn(ctx, dest); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if resps := c.responseTransformers; len(resps) > 0 {
+		ctx.Output.Transform = func(data interface{}) (interface{}, error) {
+			var err error
+			for _, fn := range resps {
+				if data, err = fn(ctx, data); err != nil {
+					return nil, err
+				}
+			}
+			return data, nil
+		}
+	}
+}
@@ -277,64 +277,124 @@ func (t *Tree) addseg(segments []string, route interface{}, wildcards []string,
 }
 
 // Match router to runObject & params
+// The path is walked in place without splitting it, so a static router is matched without allocation.
 func (t *Tree) Match(pattern string) (runObject interface{}, params map[string]string) {
+	var p treeParams
+	runObject = t.matchParams(pattern, &p)
+	return runObject, p.toMap()
+}
+
+// matchParams matches the router like Match, the params are appended to p instead of a new map.
+func (t *Tree) matchParams(pattern string, p *treeParams) interface{} {
 	if len(pattern) == 0 || pattern[0] != '/' {
-		return nil, nil
+		return nil
 	}
+	// same segments as splitPath: "/" has none, the trailing slash is ignored
+	if pattern == "/" {
+		pattern = ""
+	} else if pattern[len(pattern)-1] == '/' {
+		pattern = pattern[:len(pattern)-1]
+	}
+	var buf [8]string
+	return t.match(pattern, buf[:0], p)
+}
 
-	return t.match(splitPath(pattern), nil)
+// treeParams are the params of a match in the order of the pattern,
+// the router reuses them for the requests.
+type treeParams struct {
+	keys   []string
+	values []string
+}
+
+func (p *treeParams) set(key, val string) {
+	for i, k := range p.keys {
+		if k == key {
+			p.values[i] = val
+			return
+		}
+	}
+	p.keys = append(p.keys, key)
+	p.values = append(p.values, val)
 }
 
-func (t *Tree) match(segments []string, wildcardValues []string) (runObject interface{}, params map[string]string) {
+func (p *treeParams) truncate(n int) {
+	p.keys, p.values = p.keys[:n], p.values[:n]
+}
+
+func (p *treeParams) reset() {
+	p.truncate(0)
+}
+
+// toMap returns the params as a map, it's nil without params.
+func (p *treeParams) toMap() map[string]string {
+	if len(p.keys) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(p.keys))
+	for i, k := range p.keys {
+		m[k] = p.values[i]
+	}
+	return m
+}
+
+// nextSegment cuts the first segment of the path "/seg/rest", rest is empty after the last segment.
+func nextSegment(path string) (seg, rest string) {
+	if i := strings.IndexByte(path[1:], '/'); i >= 0 {
+		return path[1 : i+1], path[i+1:]
+	}
+	return path[1:], ""
+}
+
+// match searches the segments of path, it's empty or starts with "/".
+// p is left unchanged if nothing matches.
+func (t *Tree) match(path string, wildcardValues []string, p *treeParams) (runObject interface{}) {
 	// Handle leaf nodes:
-	if len(segments) == 0 {
+	if len(path) == 0 {
 		for _, l := range t.leaves {
-			if ok, pa := l.match(wildcardValues); ok {
-				return l.runObject, pa
+			if l.match(wildcardValues, p) {
+				return l.runObject
 			}
 		}
 		if t.wildcard != nil {
 			for _, l := range t.wildcard.leaves {
-				if ok, pa := l.match(wildcardValues); ok {
-					return l.runObject, pa
+				if l.match(wildcardValues, p) {
+					return l.runObject
 				}
 			}
 
 		}
-		return nil, nil
+		return nil
 	}
 
-	seg, segs := segments[0], segments[1:]
+	seg, rest := nextSegment(path)
 
 	subTree, ok := t.fixrouters[seg]
 	if ok {
-		runObject, params = subTree.match(segs, wildcardValues)
-	} else if len(segs) == 0 { //.json .xml
+		runObject = subTree.match(rest, wildcardValues, p)
+	} else if len(rest) == 0 { //.json .xml
 		if subindex := strings.LastIndex(seg, "."); subindex != -1 {
 			subTree, ok = t.fixrouters[seg[:subindex]]
 			if ok {
-				runObject, params = subTree.match(segs, wildcardValues)
+				runObject = subTree.match(rest, wildcardValues, p)
 				if runObject != nil {
-					if params == nil {
-						params = make(map[string]string)
-					}
-					params[":ext"] = seg[subindex+1:]
-					return runObject, params
+					p.set(":ext", seg[subindex+1:])
+					return runObject
 				}
 			}
 		}
 	}
 	if runObject == nil && t.wildcard != nil {
-		runObject, params = t.wildcard.match(segs, append(wildcardValues, seg))
+		runObject = t.wildcard.match(rest, append(wildcardValues, seg), p)
 	}
-	if runObject == nil {
+	if runObject == nil && len(t.leaves) > 0 {
+		values := append(wildcardValues, strings.Split(path[1:], "/")...)
 		for _, l := range t.leaves {
-			if ok, pa := l.match(append(wildcardValues, segments...)); ok {
-				return l.runObject, pa
+			if l.match(values, p) {
+				return l.runObject
 			}
 		}
 	}
-	return runObject, params
+	return runObject
 }
 
 type leafInfo struct {
@@ -347,47 +407,43 @@ type leafInfo struct {
 	runObject interface{}
 }
 
-func (leaf *leafInfo) match(wildcardValues []string) (ok bool, params map[string]string) {
+// match appends the params of the leaf to p, p is left unchanged if it doesn't match.
+func (leaf *leafInfo) match(wildcardValues []string, p *treeParams) bool {
+	n := len(p.keys)
 	if leaf.regexps == nil {
 		// has error
 		if len(wildcardValues) == 0 && len(leaf.wildcards) > 0 {
 			if utils.InSlice(":", leaf.wildcards) {
-				params = make(map[string]string)
-				j := 0
 				for _, v := range leaf.wildcards {
 					if v == ":" {
 						continue
 					}
-					params[v] = ""
-					j++
+					p.set(v, "")
 				}
-				return true, params
+				return true
 			}
-			return false, nil
+			return false
 		} else if len(wildcardValues) == 0 { // static path
-			return true, nil
+			return true
 		}
 		// match *
 		if len(leaf.wildcards) == 1 && leaf.wildcards[0] == ":splat" {
-			params = make(map[string]string)
-			params[":splat"] = path.Join(wildcardValues...)
-			return true, params
+			p.set(":splat", path.Join(wildcardValues...))
+			return true
 		}
 		// match *.*
 		if len(leaf.wildcards) == 3 && leaf.wildcards[0] == "." {
-			params = make(map[string]string)
 			lastone := wildcardValues[len(wildcardValues)-1]
 			strs := strings.SplitN(lastone, ".", 2)
 			if len(strs) == 2 {
-				params[":ext"] = strs[1]
+				p.set(":ext", strs[1])
 			} else {
-				params[":ext"] = ""
+				p.set(":ext", "")
 			}
-			params[":path"] = path.Join(wildcardValues[:len(wildcardValues)-1]...) + "/" + strs[0]
-			return true, params
+			p.set(":path", path.Join(wildcardValues[:len(wildcardValues)-1]...)+"/"+strs[0])
+			return true
 		}
 		// match :id
-		params = make(map[string]string)
 		j := 0
 		for _, v := range leaf.wildcards {
 			if v == ":" {
@@ -397,38 +453,39 @@ func (leaf *leafInfo) match(wildcardValues []string) (ok bool, params map[string
 				lastone := wildcardValues[len(wildcardValues)-1]
 				strs := strings.SplitN(lastone, ".", 2)
 				if len(strs) == 2 {
-					params[":ext"] = strs[1]
+					p.set(":ext", strs[1])
 				} else {
-					params[":ext"] = ""
+					p.set(":ext", "")
 				}
 				if len(wildcardValues[j:]) == 1 {
-					params[":path"] = strs[0]
+					p.set(":path", strs[0])
 				} else {
-					params[":path"] = path.Join(wildcardValues[j:]...) + "/" + strs[0]
+					p.set(":path", path.Join(wildcardValues[j:]...)+"/"+strs[0])
 				}
-				return true, params
+				return true
 			}
 			if len(wildcardValues) <= j {
-				return false, nil
+				p.truncate(n)
+				return false
 			}
-			params[v] = wildcardValues[j]
+			p.set(v, wildcardValues[j])
 			j++
 		}
-		if len(params) != len(wildcardValues) {
-			return false, nil
+		if len(p.keys)-n != len(wildcardValues) {
+			p.truncate(n)
+			return false
 		}
-		return true, params
+		return true
 	}
 
-	if !leaf.regexps.MatchString(path.Join(wildcardValues...)) {
-		return false, nil
-	}
-	params = make(map[string]string)
 	matches := leaf.regexps.FindStringSubmatch(path.Join(wildcardValues...))
+	if matches == nil {
+		return false
+	}
 	for i, match := range matches[1:] {
-		params[leaf.wildcards[i]] = match
+		p.set(leaf.wildcards[i], match)
 	}
-	return true, params
+	return true
 }
 
 // "/" -> []
@@ -454,6 +511,7 @@ func splitPath(key string) []string {
 // "?:id" -> true, [: :id], ""        : meaning can empty
 // ":id:int" -> true, [:id], ([0-9]+)
 // ":name:string" -> true, [:name], ([\w]+)
+// ":uid:uuid" -> true, [:uid], the regexp of the uuid in paramTypes
 // ":id([0-9]+)" -> true, [:id], ([0-9]+)
 // ":id([0-9]+)_:name" -> true, [:id :name], ([0-9]+)_(.+)
 // "cms_:id_:page.html" -> true, [:id :page], cms_(.+)_(.+).html
@@ -482,31 +540,17 @@ func splitSegment(key string) (bool, []string, string) {
 				continue
 			}
 			if start {
-				//:id:int and :name:string
+				//:id:int, :name:string and the other types of paramTypes
 				if v == ':' {
-					if len(key) >= i+4 {
-						if key[i+1:i+4] == "int" {
-							out = append(out, []rune("([0-9]+)")...)
-							params = append(params, ":"+string(param))
-							start = false
-							startexp = false
-							skipnum = 3
-							param = make([]rune, 0)
-							paramsNum++
-							continue
-						}
-					}
-					if len(key) >= i+7 {
-						if key[i+1:i+7] == "string" {
-							out = append(out, []rune(`([\w]+)`)...)
-							params = append(params, ":"+string(param))
-							paramsNum++
-							start = false
-							startexp = false
-							skipnum = 6
-							param = make([]rune, 0)
-							continue
-						}
+					if typ := paramTypePrefix(key[i+1:]); typ != "" {
+						out = append(out, []rune(paramTypes[typ].expr)...)
+						params = append(params, ":"+string(param))
+						paramsNum++
+						start = false
+						startexp = false
+						skipnum = len(typ)
+						param = make([]rune, 0)
+						continue
 					}
 				}
 				// params only support a-zA-Z0-9
@@ -33,6 +33,9 @@ func init() {
 	routers = append(routers, testinfo{"/topic/:id/?:auth:int", "/topic/1", map[string]string{":id": "1"}})
 	routers = append(routers, testinfo{"/topic/:id/?:auth:int", "/topic/1/123", map[string]string{":id": "1",":auth":"123"}})
 	routers = append(routers, testinfo{"/:id", "/123", map[string]string{":id": "123"}})
+	routers = append(routers, testinfo{"/file/:uid:uuid", "/file/0b6f3b1c-5c1e-4d5e-9f1a-2b3c4d5e6f70", map[string]string{":uid": "0b6f3b1c-5c1e-4d5e-9f1a-2b3c4d5e6f70"}})
+	routers = append(routers, testinfo{"/date/:d:datetime", "/date/2015-06-01", map[string]string{":d": "2015-06-01"}})
+	routers = append(routers, testinfo{"/date/:d:datetime/:id:int", "/date/2015-06-01T10:00:00Z/3", map[string]string{":d": "2015-06-01T10:00:00Z", ":id": "3"}})
 	routers = append(routers, testinfo{"/hello/?:id", "/hello", map[string]string{":id": ""}})
 	routers = append(routers, testinfo{"/", "/", nil})
 	routers = append(routers, testinfo{"/customer/login", "/customer/login", nil})
@@ -271,3 +274,63 @@ func TestSplitSegment(t *testing.T) {
 		t.Fatal(":id_cms.html should return true, [:id :page], cms_(.+)_(.+).html")
 	}
 }
+
+func TestTreeMatchStaticAllocs(t *testing.T) {
+	tr := NewTree()
+	tr.AddRouter("/api/v1/users/list", "users")
+	tr.AddRouter("/api/v1/users/:id", "user")
+	allocs := testing.AllocsPerRun(100, func() {
+		if obj, _ := tr.Match("/api/v1/users/list/"); obj != "users" {
+			t.Fatal("the static router isn't matched:", obj)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("matching a static router allocates %v times", allocs)
+	}
+}
+
+func benchmarkTree() *Tree {
+	tr := NewTree()
+	for _, r := range []string{"/", "/login", "/logout", "/api/v1/users", "/api/v1/users/:id", "/api/v1/users/:id/posts/:pid:int", "/static/*"} {
+		tr.AddRouter(r, r)
+	}
+	return tr
+}
+
+func BenchmarkTreeMatchStatic(b *testing.B) {
+	tr := benchmarkTree()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.Match("/api/v1/users")
+	}
+}
+
+func BenchmarkTreeMatchParams(b *testing.B) {
+	tr := benchmarkTree()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.Match("/api/v1/users/42/posts/7")
+	}
+}
+
+func BenchmarkTreeMatchSplat(b *testing.B) {
+	tr := benchmarkTree()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.Match("/static/js/app/main.js")
+	}
+}
+
+func BenchmarkTreeMatchParamsReused(b *testing.B) {
+	tr := benchmarkTree()
+	var p treeParams
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.reset()
+		tr.matchParams("/api/v1/users/42", &p)
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/validation"
+)
+
+// HTTPError is an error answered by the typed handlers with its status instead of 500.
+type HTTPError struct {
+	Status  int
+	Message string // the status text if it's empty
+}
+
+// NewHTTPError returns an HTTPError of the status.
+func NewHTTPError(status int, message string) *HTTPError {
+	return &HTTPError{Status: status, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.Status)
+	}
+	return e.Message
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil))
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Typed returns the FilterFunc of a typed handler, the signature is checked once here and it panics if it isn't one of
+//
+//	func(ctx *context.Context, req Request) (Response, error)
+//	func(ctx *context.Context, req Request) error
+//	func(ctx *context.Context) (Response, error)
+//	func(ctx *context.Context) error
+//
+// Request is a struct or a pointer to a struct, it's bound by ctx.Input.BindRequest and checked by the valid tags,
+// the bad requests are 400 and the invalid ones are 422 with the messages of the fields.
+// Response is served in the format of the Accept header by ctx.Output.Serve, 204 is sent if there's none or it's nil.
+// The errors are 500 unless they are an *HTTPError, orm.ErrNoRows (404), or a constraint violation (409).
+// usage:
+//
+//	beego.Post("/orders", beego.Typed(func(ctx *context.Context, req CreateOrderRequest) (*OrderResponse, error) {
+//		return orders.Create(ctx.Context(), req)
+//	}))
+func Typed(handler interface{}) FilterFunc {
+	f, _, _ := typedHandler(handler)
+	return f
+}
+
+// Handle registers the typed handler for the http method, the request and response types
+// are documented in the OpenAPI document unless opts set them. See Typed.
+// usage:
+//
+//	Handle("post", "/orders", createOrder)
+func (p *ControllerRegister) Handle(method, pattern string, handler interface{}, opts ...RouterOption) {
+	f, req, resp := typedHandler(handler)
+	var docs []RouterOption
+	if req != nil && method != "*" && !isBodyless(method) {
+		docs = append(docs, func(o *routerOptions) { o.routeDoc().request = req })
+	}
+	if resp != nil {
+		docs = append(docs, func(o *routerOptions) { o.routeDoc().response = resp })
+	}
+	p.AddMethod(method, pattern, f, append(docs, opts...)...)
+}
+
+// Handle registers the typed handler for the http method of BeeApp, see Typed.
+// usage:
+//
+//	beego.Handle("get", "/orders/:id", func(ctx *context.Context) (*Order, error) {
+//		return orders.Get(ctx.Input.Param(":id"))
+//	})
+func Handle(method, rootpath string, handler interface{}, opts ...RouterOption) *App {
+	BeeApp.Handlers.Handle(method, rootpath, handler, opts...)
+	return BeeApp
+}
+
+// isBodyless returns whether the requests of the method have no body to bind.
+func isBodyless(method string) bool {
+	switch method {
+	case "get", "GET", "head", "HEAD", "delete", "DELETE", "options", "OPTIONS":
+		return true
+	}
+	return false
+}
+
+// typedHandler checks the signature of handler and returns its FilterFunc, the request and the response types.
+func typedHandler(handler interface{}) (FilterFunc, reflect.Type, reflect.Type) {
+	fv := reflect.ValueOf(handler)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.IsVariadic() || ft.NumIn() < 1 || ft.NumIn() > 2 || ft.In(0) != contextType ||
+		ft.NumOut() < 1 || ft.NumOut() > 2 || ft.Out(ft.NumOut()-1) != errorType {
+		panic(fmt.Sprintf("beego: %s isn't a typed handler func(*context.Context[, Request]) ([Response, ]error)", ft))
+	}
+	var req, resp reflect.Type
+	if ft.NumIn() == 2 {
+		req = ft.In(1)
+		if st := req; st.Kind() != reflect.Struct && (st.Kind() != reflect.Ptr || st.Elem().Kind() != reflect.Struct) {
+			panic(fmt.Sprintf("beego: the request %s of the typed handler isn't a struct", req))
+		}
+	}
+	if ft.NumOut() == 2 {
+		resp = ft.Out(0)
+	}
+
+	f := func(ctx *context.Context) {
+		in := []reflect.Value{reflect.ValueOf(ctx)}
+		if req != nil {
+			v, ok := bindTyped(ctx, req)
+			if !ok {
+				return
+			}
+			in = append(in, v)
+		}
+		out := fv.Call(in)
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			typedError(ctx, err)
+			return
+		}
+		if ctx.Written() {
+			return
+		}
+		if len(out) == 1 || isNilValue(out[0]) {
+			if ctx.Output.Status == 0 {
+				ctx.Output.SetStatus(http.StatusNoContent)
+			}
+			ctx.Output.Body(nil)
+			return
+		}
+		if err := ctx.Output.Serve(out[0].Interface()); err != nil {
+			Error("serve the typed response:", err)
+		}
+	}
+	return f, req, resp
+}
+
+// bindTyped binds and validates the request of the type t, it answers the error if it can't.
+func bindTyped(ctx *context.Context, t reflect.Type) (reflect.Value, bool) {
+	ptr := t.Kind() == reflect.Ptr
+	if ptr {
+		t = t.Elem()
+	}
+	v := reflect.New(t)
+	if err := ctx.Input.BindRequest(v.Interface()); err != nil {
+		resourceError(ctx, http.StatusBadRequest, err.Error(), nil)
+		return v, false
+	}
+	valid := validation.Validation{}
+	ok, err := valid.Valid(v.Interface())
+	if err != nil {
+		resourceError(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return v, false
+	}
+	if !ok {
+		fields := make(map[string]string, len(valid.Errors))
+		for _, e := range valid.Errors {
+			fields[e.Field] = e.Message
+		}
+		resourceError(ctx, http.StatusUnprocessableEntity, "validation failed", fields)
+		return v, false
+	}
+	if ptr {
+		return v, true
+	}
+	return v.Elem(), true
+}
+
+// typedError answers the error of a typed handler, the detail of the server errors is shown in the dev mode only.
+func typedError(ctx *context.Context, err error) {
+	if ctx.Written() {
+		Error("the typed handler failed after writing the response:", err)
+		return
+	}
+	var he *HTTPError
+	switch {
+	case errors.As(err, &he):
+		resourceError(ctx, he.Status, he.Error(), nil)
+	case errors.Is(err, sql.ErrNoRows):
+		resourceError(ctx, http.StatusNotFound, "", nil)
+	case errors.As(err, new(constraintError)):
+		conflictError(ctx, err)
+	default:
+		Error("typed handler:", err)
+		detail := ""
+		if RunMode == "dev" {
+			detail = err.Error()
+		}
+		resourceError(ctx, http.StatusInternalServerError, detail, nil)
+	}
+}
+
+// isNilValue returns whether v is a nil pointer or interface, the nil slices and maps are served empty.
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// constraintError is a unique or foreign key violation, e.g. the *orm.ConstraintError,
+// the core doesn't import the orm.
+type constraintError interface {
+	error
+	ConstraintKind() error
+	ConstraintColumns() []string
+}
+
+// conflictError answers 409 for the constraint violation err with its kind and columns,
+// the message of the driver isn't sent, it may show the data and the schema.
+func conflictError(ctx *context.Context, err error) {
+	var ce constraintError
+	errors.As(err, &ce)
+	kind := ce.ConstraintKind().Error()
+	var fields map[string]string
+	if cols := ce.ConstraintColumns(); len(cols) > 0 {
+		fields = make(map[string]string, len(cols))
+		for _, col := range cols {
+			fields[col] = kind
+		}
+	}
+	resourceError(ctx, http.StatusConflict, kind, fields)
+}
+
+// resourceError writes the error as problem+json if EnableProblemJSON is on,
+// or else as {"error": "...", "fields": {...}}.
+func resourceError(ctx *context.Context, status int, detail string, fields map[string]string) {
+	if EnableProblemJSON {
+		WriteProblem(ctx, status, detail)
+		return
+	}
+	if detail == "" {
+		detail = http.StatusText(status)
+	}
+	body := map[string]interface{}{"error": detail}
+	if len(fields) > 0 {
+		body["fields"] = fields
+	}
+	ctx.Output.SetStatus(status)
+	ctx.Output.JSON(body, false, false)
+}
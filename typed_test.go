@@ -0,0 +1,108 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/astaxie/beego/context"
+	"github.com/astaxie/beego/orm"
+)
+
+type createOrderRequest struct {
+	Item     string `json:"item" valid:"Required"`
+	Quantity int    `json:"quantity" valid:"Range(1,10)"`
+}
+
+type orderResponse struct {
+	ID   int    `json:"id"`
+	Item string `json:"item"`
+}
+
+func TestTyped(t *testing.T) {
+	mux := NewControllerRegister()
+	mux.Handle("post", "/orders", func(ctx *context.Context, req createOrderRequest) (*orderResponse, error) {
+		if req.Item == "taken" {
+			return nil, &orm.ConstraintError{Kind: orm.ErrDuplicateKey, Columns: []string{"item"},
+				Err: errors.New("Duplicate entry 'taken' for key 'item'")}
+		}
+		ctx.Output.SetStatus(201)
+		return &orderResponse{ID: 1, Item: req.Item}, nil
+	})
+	mux.Get("/orders/:id", Typed(func(ctx *context.Context) (*orderResponse, error) {
+		switch ctx.Input.Param(":id") {
+		case "1":
+			return &orderResponse{ID: 1, Item: "book"}, nil
+		case "2":
+			return nil, NewHTTPError(403, "not yours")
+		case "3":
+			return nil, errors.New("disk full")
+		}
+		return nil, orm.ErrNoRows
+	}))
+	mux.Handle("delete", "/orders/:id", func(ctx *context.Context, req *struct{ Force bool }) error {
+		return nil
+	})
+
+	cases := []struct {
+		method, path, body string
+		code               int
+		contains           string
+	}{
+		{"POST", "/orders", `{"item":"book","quantity":2}`, 201, `"book"`},
+		{"POST", "/orders", `{"item":"book","quantity":20}`, 422, `"Quantity"`},
+		{"POST", "/orders", `{"item":`, 400, "body"},
+		{"POST", "/orders", `{"item":"taken","quantity":1}`, 409, `"item"`},
+		{"GET", "/orders/1", "", 200, `"book"`},
+		{"GET", "/orders/2", "", 403, "not yours"},
+		{"GET", "/orders/3", "", 500, ""},
+		{"GET", "/orders/4", "", 404, ""},
+		{"DELETE", "/orders/1?force=true", "", 204, ""},
+	}
+	for _, c := range cases {
+		r, _ := http.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.body != "" {
+			r.Header.Set("Content-Type", "application/json")
+		}
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, r)
+		if rw.Code != c.code || !strings.Contains(rw.Body.String(), c.contains) {
+			t.Errorf("%s %s got %d %q", c.method, c.path, rw.Code, rw.Body.String())
+		}
+		if strings.Contains(rw.Body.String(), "Duplicate entry") {
+			t.Errorf("%s %s leaks the driver error: %q", c.method, c.path, rw.Body.String())
+		}
+	}
+
+	for _, bad := range []interface{}{
+		func(ctx *context.Context) {},
+		func(ctx *context.Context, id int) error { return nil },
+		func(req createOrderRequest) error { return nil },
+		func(ctx *context.Context) (int, int) { return 0, 0 },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Typed(%T) expected to panic", bad)
+				}
+			}()
+			Typed(bad)
+		}()
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"math/rand"
+	"reflect"
+
+	"github.com/astaxie/beego/context"
+)
+
+const routeVariantDataKey = "Variant"
+
+// SplitFunc returns true if the request is served by the variant of the router, see WithVariant.
+type SplitFunc func(ctx *context.Context) bool
+
+// SplitPercent sends percent percents of the requests to the variant at random.
+func SplitPercent(percent float64) SplitFunc {
+	return func(ctx *context.Context) bool {
+		return rand.Float64()*100 < percent
+	}
+}
+
+// SplitSticky keeps the choice of split in the session under key, so a user stays on the same implementation.
+// It falls back to split if the session isn't on.
+func SplitSticky(key string, split SplitFunc) SplitFunc {
+	return func(ctx *context.Context) bool {
+		sess := ctx.Input.CruSession
+		if sess == nil {
+			return split(ctx)
+		}
+		if v, ok := sess.Get(key).(bool); ok {
+			return v
+		}
+		v := split(ctx)
+		sess.Set(key, v)
+		return v
+	}
+}
+
+// routeVariant is the alternative implementation of a router and its splitter.
+type routeVariant struct {
+	name       string
+	controller ControllerInterface
+	function   FilterFunc
+	split      SplitFunc
+	route      *controllerInfo
+}
+
+// WithVariant serves the requests chosen by split with the controller c instead of the one of the router,
+// e.g. to canary a rewritten controller. The name of the variant is returned by RouteVariant.
+// usage:
+//
+//	AddWithOptions("/checkout", &CheckoutController{},
+//		WithVariant("v2", &CheckoutV2Controller{}, SplitSticky("checkout.v2", SplitPercent(5))))
+func WithVariant(name string, c ControllerInterface, split SplitFunc) RouterOption {
+	return func(o *routerOptions) {
+		o.variant = &routeVariant{name: name, controller: c, split: split}
+	}
+}
+
+// WithVariantFunc same as WithVariant, but for the routers added by Get, Post, Any etc.
+func WithVariantFunc(name string, f FilterFunc, split SplitFunc) RouterOption {
+	return func(o *routerOptions) {
+		o.variant = &routeVariant{name: name, function: f, split: split}
+	}
+}
+
+// RouteVariant returns the name of the variant serving the request, it's empty for the router itself.
+func RouteVariant(ctx *context.Context) string {
+	v, _ := ctx.Input.GetData(routeVariantDataKey).(string)
+	return v
+}
+
+// setVariant builds the router of the variant from the route, it panics if the variant doesn't fit the router.
+func (c *controllerInfo) setVariant(v *routeVariant) {
+	if v == nil {
+		return
+	}
+	r := *c
+	switch {
+	case c.routerType == routerTypeBeego && v.controller != nil:
+		reflectVal := reflect.ValueOf(v.controller)
+		for _, m := range c.methods {
+			if !reflectVal.MethodByName(m).IsValid() {
+				panic("'" + m + "' method doesn't exist in the variant controller " + reflect.Indirect(reflectVal).Type().Name())
+			}
+		}
+		r.controllerType = reflect.Indirect(reflectVal).Type()
+		r.cacheMethods()
+	case c.routerType == routerTypeRESTFul && v.function != nil:
+		r.runFunction = v.function
+	default:
+		panic("the variant '" + v.name + "' doesn't fit the router " + c.pattern + ", use WithVariant for the controllers and WithVariantFunc for the functions")
+	}
+	r.variant = nil
+	// the coalesced requests must not share the response of the other implementation
+	r.coalesce = nil
+	v.route = &r
+	c.variant = v
+}
+
+// choose returns the router serving the request, the variant or c.
+func (c *controllerInfo) choose(ctx *context.Context) *controllerInfo {
+	if c.variant == nil || !c.variant.split(ctx) {
+		return c
+	}
+	ctx.Input.SetData(routeVariantDataKey, c.variant.name)
+	return c.variant.route
+}
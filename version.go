@@ -0,0 +1,179 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/astaxie/beego/context"
+)
+
+const apiVersionDataKey = "APIVersion"
+
+// versionRouter holds the routers of an API version added by ControllerRegister.Version.
+type versionRouter struct {
+	version  string
+	prefixes []string
+	handlers *ControllerRegister
+}
+
+// Version returns a RouterGroup whose routers only match the requests of the API version,
+// so the same pattern can be registered for several versions.
+// The version is selected by the vendor media type of the Accept header, e.g. application/vnd.myapp.v2+json,
+// or by the path prefixes, a request under a prefix is routed and filtered without the prefix.
+// The requests without a known version use APIDefaultVersion, the routers of the version are
+// searched before the routers without version.
+// usage:
+//
+//	v1 := beego.BeeApp.Handlers.Version("v1", "/v1")
+//	v1.Get("/user/:id", getUserV1)
+//	v2 := beego.BeeApp.Handlers.Version("v2", "/v2")
+//	v2.Get("/user/:id", getUserV2) // GET /v2/user/1 or GET /user/1 with Accept: application/vnd.myapp.v2+json
+func (p *ControllerRegister) Version(version string, prefixes ...string) *RouterGroup {
+	version = strings.ToLower(version)
+	var v *versionRouter
+	for _, vr := range p.versions {
+		if vr.version == version {
+			v = vr
+			break
+		}
+	}
+	if v == nil {
+		v = &versionRouter{version: version, handlers: NewControllerRegister()}
+		p.versions = append(p.versions, v)
+	}
+	for _, prefix := range prefixes {
+		prefix = "/" + strings.Trim(prefix, "/")
+		if !RouterCaseSensitive {
+			prefix = strings.ToLower(prefix)
+		}
+		v.prefixes = append(v.prefixes, prefix)
+	}
+	return &RouterGroup{prefix: "/", handlers: v.handlers, filters: p, cond: func(ctx *context.Context) bool {
+		return APIVersion(ctx) == version
+	}}
+}
+
+// Version returns a RouterGroup of BeeApp for the API version.
+// usage:
+//
+//	beego.Version("v2", "/v2").Router("/user", &controllers.UserV2Controller{})
+func Version(version string, prefixes ...string) *RouterGroup {
+	return BeeApp.Handlers.Version(version, prefixes...)
+}
+
+// APIVersion returns the API version selected for the request, it's empty if none is selected.
+func APIVersion(ctx *context.Context) string {
+	v, _ := ctx.Input.GetData(apiVersionDataKey).(string)
+	return v
+}
+
+// matchVersion returns the version routers of the request and the path without the version prefix.
+// byAccept reports whether the Accept header was looked at, so the response varies on it.
+func (p *ControllerRegister) matchVersion(accept, urlPath string) (v *versionRouter, path string, byAccept bool) {
+	if len(p.versions) == 0 {
+		return nil, urlPath, false
+	}
+	var matched string
+	for _, vr := range p.versions {
+		for _, prefix := range vr.prefixes {
+			if len(prefix) > len(matched) && hasPathPrefix(urlPath, prefix) {
+				v, matched = vr, prefix
+			}
+		}
+	}
+	if v != nil {
+		path = strings.TrimPrefix(urlPath, matched)
+		if path == "" {
+			path = "/"
+		}
+		return v, path, false
+	}
+	for _, version := range acceptVersions(accept) {
+		if v = p.version(version); v != nil {
+			return v, urlPath, true
+		}
+	}
+	return p.version(strings.ToLower(APIDefaultVersion)), urlPath, true
+}
+
+func (p *ControllerRegister) version(version string) *versionRouter {
+	if version == "" {
+		return nil
+	}
+	for _, vr := range p.versions {
+		if vr.version == version {
+			return vr
+		}
+	}
+	return nil
+}
+
+// hasPathPrefix reports whether prefix is a whole segments prefix of urlPath.
+func hasPathPrefix(urlPath, prefix string) bool {
+	if prefix == "/" {
+		return true
+	}
+	return strings.HasPrefix(urlPath, prefix) && (len(urlPath) == len(prefix) || urlPath[len(prefix)] == '/')
+}
+
+// acceptVersions returns the lower-cased versions of the vendor media types in the Accept header,
+// e.g. v2 of application/vnd.myapp.v2+json, ordered by their quality.
+func acceptVersions(accept string) []string {
+	if accept == "" {
+		return nil
+	}
+	type candidate struct {
+		version string
+		q       float64
+	}
+	var list []candidate
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mt := strings.ToLower(strings.TrimSpace(params[0]))
+		if !strings.HasPrefix(mt, "application/vnd.") {
+			continue
+		}
+		if i := strings.IndexByte(mt, '+'); i >= 0 {
+			mt = mt[:i]
+		}
+		i := strings.LastIndexByte(mt, '.')
+		if i < len("application/vnd.") {
+			continue
+		}
+		c := candidate{version: mt[i+1:], q: 1}
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && kv[0] == "q" {
+				if q, err := strconv.ParseFloat(kv[1], 64); err == nil {
+					c.q = q
+				}
+			}
+		}
+		if c.version != "" && c.q > 0 {
+			list = append(list, c)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].q > list[j].q
+	})
+	versions := make([]string, len(list))
+	for i, c := range list {
+		versions[i] = c.version
+	}
+	return versions
+}
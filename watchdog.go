@@ -0,0 +1,160 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// watchedRequest is a request in flight seen by the watchdog.
+type watchedRequest struct {
+	goroutine uint64
+	method    string
+	path      string
+	pattern   string
+	start     time.Time
+	reported  bool
+}
+
+// requestWatchdog logs the stacks of the requests running longer than WatchdogThreshold,
+// it helps to find the stuck handlers without a full goroutine dump.
+type requestWatchdog struct {
+	mu       sync.Mutex
+	requests map[*watchedRequest]struct{}
+	once     sync.Once
+}
+
+var watchdog = &requestWatchdog{requests: make(map[*watchedRequest]struct{})}
+
+// watch registers the request served by the current goroutine, call done when it finishes.
+func (wd *requestWatchdog) watch(method, path string) *watchedRequest {
+	wd.once.Do(func() {
+		go wd.run()
+	})
+	req := &watchedRequest{goroutine: goroutineID(), method: method, path: path, start: time.Now()}
+	wd.mu.Lock()
+	wd.requests[req] = struct{}{}
+	wd.mu.Unlock()
+	return req
+}
+
+// route sets the pattern matched by the request.
+func (wd *requestWatchdog) route(req *watchedRequest, pattern string) {
+	wd.mu.Lock()
+	req.pattern = pattern
+	wd.mu.Unlock()
+}
+
+// reported returns whether the stack of the request is logged.
+func (wd *requestWatchdog) reported(req *watchedRequest) bool {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	return req.reported
+}
+
+func (wd *requestWatchdog) done(req *watchedRequest) {
+	wd.mu.Lock()
+	delete(wd.requests, req)
+	wd.mu.Unlock()
+}
+
+// run checks the requests every half of the threshold.
+func (wd *requestWatchdog) run() {
+	for {
+		threshold := time.Duration(WatchdogThreshold) * time.Millisecond
+		if threshold <= 0 {
+			time.Sleep(time.Second)
+			continue
+		}
+		time.Sleep(threshold / 2)
+		for _, report := range wd.check(time.Now(), threshold) {
+			Warn(report)
+		}
+	}
+}
+
+// check returns the reports of the requests over threshold, each request is reported once.
+func (wd *requestWatchdog) check(now time.Time, threshold time.Duration) []string {
+	wd.mu.Lock()
+	var slow []watchedRequest
+	for req := range wd.requests {
+		if !req.reported && now.Sub(req.start) >= threshold {
+			req.reported = true
+			slow = append(slow, *req)
+		}
+	}
+	wd.mu.Unlock()
+	if len(slow) == 0 {
+		return nil
+	}
+
+	stacks := goroutineStacks()
+	reports := make([]string, 0, len(slow))
+	for _, req := range slow {
+		pattern := req.pattern
+		if pattern == "" {
+			pattern = "not matched yet"
+		}
+		stack, ok := stacks[req.goroutine]
+		if !ok {
+			stack = "the request just finished"
+		}
+		reports = append(reports, fmt.Sprintf("watchdog: %s %s (%s) is running for %s:\n%s",
+			req.method, req.path, pattern, now.Sub(req.start), stack))
+	}
+	return reports
+}
+
+// goroutineID returns the id of the current goroutine from the header of its stack, "goroutine 18 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// goroutineStacks returns the stacks of all the goroutines by id.
+func goroutineStacks() map[uint64]string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := make(map[uint64]string)
+	for _, s := range bytes.Split(buf, []byte("\n\n")) {
+		header := bytes.TrimPrefix(s, []byte("goroutine "))
+		i := bytes.IndexByte(header, ' ')
+		if i <= 0 {
+			continue
+		}
+		if id, err := strconv.ParseUint(string(header[:i]), 10, 64); err == nil {
+			stacks[id] = string(s)
+		}
+	}
+	return stacks
+}
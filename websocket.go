@@ -0,0 +1,139 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beego
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/astaxie/beego/context"
+)
+
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocketHandler handles the connection after the websocket handshake,
+// conn is closed when the handler returns.
+// The frames are read and written with a websocket library working on net.Conn.
+type WebSocketHandler func(ctx *context.Context, conn net.Conn)
+
+// WebSocketCheckOrigin decides whether the websocket request from the Origin is accepted.
+// The default only accepts requests without Origin or from the same host.
+var WebSocketCheckOrigin = func(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// WebSocket adds a websocket router for GET requests.
+// The BeforeRouter and BeforeExec filters run before the handshake, so they can authorize the request,
+// there is no AutoRender and gzip for the router.
+// usage:
+//	WebSocket("/ws/chat", func(ctx *context.Context, conn net.Conn) {
+//		// read and write the frames with conn
+//	})
+func (p *ControllerRegister) WebSocket(pattern string, h WebSocketHandler, opts ...RouterOption) {
+	p.AddMethod("get", pattern, func(ctx *context.Context) {
+		conn, err := upgradeWebSocket(ctx)
+		if err != nil {
+			Warn("websocket:", err)
+			ctx.Output.SetStatus(http.StatusBadRequest)
+			ctx.Output.Body([]byte(err.Error()))
+			return
+		}
+		defer conn.Close()
+		h(ctx, conn)
+	}, opts...)
+}
+
+func headerContainsToken(h http.Header, key, token string) bool {
+	for _, v := range h[key] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgradeWebSocket checks the handshake request, hijacks the connection and sends the handshake response.
+func upgradeWebSocket(ctx *context.Context) (net.Conn, error) {
+	r := ctx.Request
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return nil, errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-Websocket-Version") != "13" {
+		return nil, errors.New("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-Websocket-Key")
+	if key == "" {
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+	if !WebSocketCheckOrigin(r) {
+		return nil, errors.New("origin " + r.Header.Get("Origin") + " is not allowed")
+	}
+	hj, ok := ctx.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("webserver doesn't support hijacking")
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	h := sha1.New()
+	h.Write([]byte(key + webSocketGUID))
+	brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " +
+		base64.StdEncoding.EncodeToString(h.Sum(nil)) + "\r\n\r\n")
+	if err := brw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if brw.Reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: brw.Reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn reads the data already buffered by the http server first.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// WebSocket adds a websocket router to BeeApp.
+// usage:
+//    beego.WebSocket("/ws/chat", func(ctx *context.Context, conn net.Conn) {
+//          io.Copy(conn, conn)
+//    })
+func WebSocket(rootpath string, h WebSocketHandler, opts ...RouterOption) *App {
+	BeeApp.Handlers.WebSocket(rootpath, h, opts...)
+	return BeeApp
+}